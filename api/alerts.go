@@ -7,6 +7,8 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+
+	"github.com/shurcooL/graphql"
 )
 
 type HumioQuery struct {
@@ -64,6 +66,30 @@ func (a *Alerts) Update(viewName string, alert *Alert) (*Alert, error) {
 	return a.unmarshalToAlert(res)
 }
 
+// SetSilenced silences or unsilences an existing alert. Unlike Update, the
+// alert is identified by its ID, so no lookup by name is needed.
+func (a *Alerts) SetSilenced(viewName string, alert Alert, silenced bool) (*Alert, error) {
+	alert.Silenced = silenced
+
+	jsonStr, err := a.marshalToJSON(&alert)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("api/v1/repositories/%s/alerts/%s", viewName, alert.ID)
+
+	res, err := a.client.HTTPRequest(http.MethodPut, url, bytes.NewBuffer(jsonStr))
+	if err != nil {
+		return nil, fmt.Errorf("could not update alert in view %s with name %s, got: %w", viewName, alert.Name, err)
+	}
+
+	if res.StatusCode >= 400 {
+		res.Body.Close()
+		return nil, &StatusError{StatusCode: res.StatusCode, Message: fmt.Sprintf("could not update alert in view %s with name %s, got status code %d", viewName, alert.Name, res.StatusCode)}
+	}
+	return a.unmarshalToAlert(res)
+}
+
 func (a *Alerts) Add(viewName string, alert *Alert, updateExisting bool) (*Alert, error) {
 	nameAlreadyInUse, err := a.alertNameInUse(viewName, alert.Name)
 	if err != nil {
@@ -193,3 +219,36 @@ func (a *Alerts) alertNameInUse(viewName, alertName string) (bool, error) {
 	}
 	return false, nil
 }
+
+// AlertStatus is the state of an alert as seen by the alert job.
+type AlertStatus struct {
+	ID                 string
+	Name               string
+	Enabled            bool
+	ThrottleTimeMillis int64
+	// LastTriggered is the time the alert last triggered, in milliseconds
+	// since the epoch, or nil if it has never triggered.
+	LastTriggered *int64
+	// LastError is the error from the last time the alert ran, e.g. a
+	// failing notifier, or nil if it ran without errors.
+	LastError *string
+}
+
+// ListStatus returns the state of each alert in the view.
+func (a *Alerts) ListStatus(viewName string) ([]AlertStatus, error) {
+	var q struct {
+		SearchDomain struct {
+			Alerts []AlertStatus
+		} `graphql:"searchDomain(name: $viewName)"`
+	}
+
+	variables := map[string]interface{}{
+		"viewName": graphql.String(viewName),
+	}
+
+	if err := a.client.Query(&q, variables); err != nil {
+		return nil, err
+	}
+
+	return q.SearchDomain.Alerts, nil
+}
@@ -4,24 +4,74 @@ import (
 	"bytes"
 	"context"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/shurcooL/graphql"
 	"golang.org/x/oauth2"
 )
 
 type Client struct {
-	config Config
+	config           Config
+	limiter          *rateLimiter
+	queue            *requestQueue
+	endpointLimiters []endpointLimiter
+	basePath         string
+	priority         RequestPriority
 }
 
 type Config struct {
 	Address string
 	Token   string
+
+	// Timeouts of HTTP requests. Query jobs are created and polled, and
+	// results of synchronous queries are streamed, so QueryTimeout limits
+	// the time until the server starts responding rather than the duration
+	// of the query. A zero value means no timeout.
+	Timeout       time.Duration
+	QueryTimeout  time.Duration
+	IngestTimeout time.Duration
+
+	// MaxRequestsPerSecond limits how many requests are sent per second.
+	// A zero value means no limit. Requests rejected by the server with
+	// 429 Too Many Requests are retried regardless.
+	MaxRequestsPerSecond float64
+
+	// MaxConcurrentRequests limits how many requests are in flight at once.
+	// Further requests are queued, with interactive requests ahead of bulk
+	// requests, see WithPriority. A zero value means no limit.
+	MaxConcurrentRequests int
+
+	// EndpointRateLimits limits the requests per second to endpoints, by
+	// path prefix relative to Address, e.g. "graphql" or
+	// "api/v1/repositories/". The limit of the longest matching prefix
+	// applies, in addition to MaxRequestsPerSecond.
+	EndpointRateLimits map[string]float64
+
+	// RequestLogger, if set, is called after each HTTP request, e.g. to keep
+	// an audit log of the requests made. See also WithRequestLogger.
+	RequestLogger func(RequestLog)
+
+	// Context, if set, is the context of requests made without one, e.g. by
+	// Query, so they are aborted when it is cancelled or times out.
+	Context context.Context
 }
 
+const (
+	DefaultTimeout       = 30 * time.Second
+	DefaultQueryTimeout  = 2 * time.Minute
+	DefaultIngestTimeout = time.Minute
+)
+
 func DefaultConfig() Config {
 	config := Config{
-		Address: "",
-		Token:   "",
+		Address:       "",
+		Token:         "",
+		Timeout:       DefaultTimeout,
+		QueryTimeout:  DefaultQueryTimeout,
+		IngestTimeout: DefaultIngestTimeout,
 	}
 
 	return config
@@ -35,54 +85,189 @@ func (c *Client) Token() string {
 	return c.config.Token
 }
 
+// context returns the context of requests made without one.
+func (c *Client) context() context.Context {
+	if c.config.Context != nil {
+		return c.config.Context
+	}
+	return context.Background()
+}
+
+// Retries returns the number of requests the client has retried, because
+// the server responded with 429 Too Many Requests.
+func (c *Client) Retries() int64 {
+	return atomic.LoadInt64(&c.limiter.retries)
+}
+
 func NewClient(config Config) (*Client, error) {
+	var basePath string
+	if u, err := url.Parse(config.Address); err == nil {
+		basePath = strings.TrimPrefix(u.Path, "/")
+	}
+
 	return &Client{
-		config: config,
+		config:           config,
+		limiter:          &rateLimiter{maxPerSecond: config.MaxRequestsPerSecond},
+		queue:            &requestQueue{max: config.MaxConcurrentRequests},
+		endpointLimiters: newEndpointLimiters(config.EndpointRateLimits),
+		basePath:         basePath,
 	}, nil
 }
 
+// WithPriority returns a client making requests with the given priority. It
+// shares the request queue and rate limits with c, so e.g. a bulk sync does
+// not starve interactive requests made by the same process.
+func (c *Client) WithPriority(priority RequestPriority) *Client {
+	client := *c
+	client.priority = priority
+	return &client
+}
+
+// WithRequestLogger returns a client that also calls logger after each HTTP
+// request, e.g. to collect metrics of the requests made. Like WithPriority,
+// it shares the request queue and rate limits with c.
+func (c *Client) WithRequestLogger(logger func(RequestLog)) *Client {
+	client := *c
+	if previous := c.config.RequestLogger; previous != nil {
+		client.config.RequestLogger = func(r RequestLog) {
+			previous(r)
+			logger(r)
+		}
+	} else {
+		client.config.RequestLogger = logger
+	}
+	return &client
+}
+
+// transport returns base wrapped in the request queue, rate limiting and
+// request logging of the client.
+func (c *Client) transport(base http.RoundTripper) http.RoundTripper {
+	var transport http.RoundTripper = &rateLimitTransport{
+		limiter:  c.limiter,
+		queue:    c.queue,
+		priority: c.priority,
+		endpoint: c.endpointLimiter,
+		base:     base,
+	}
+	if c.config.RequestLogger != nil {
+		transport = &requestLogTransport{logger: c.config.RequestLogger, base: transport}
+	}
+	return transport
+}
+
 func (c *Client) newGraphQLClient() *graphql.Client {
 	src := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: c.config.Token},
 	)
 
-	httpClient := oauth2.NewClient(context.Background(), src)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: c.transport(http.DefaultTransport)})
+	httpClient := oauth2.NewClient(ctx, src)
+	httpClient.Timeout = c.config.Timeout
 	return graphql.NewClient(c.Address()+"graphql", httpClient)
 }
 
 func (c *Client) Query(query interface{}, variables map[string]interface{}) error {
+	return c.QueryContext(c.context(), query, variables)
+}
+
+// QueryContext is like Query, but the request is aborted if ctx is cancelled.
+func (c *Client) QueryContext(ctx context.Context, query interface{}, variables map[string]interface{}) error {
 	client := c.newGraphQLClient()
-	graphqlErr := client.Query(context.Background(), query, variables)
-	return graphqlErr
+	graphqlErr := client.Query(ctx, query, variables)
+	return wrapGraphQLError(graphqlErr)
 }
 
 func (c *Client) Mutate(mutation interface{}, variables map[string]interface{}) error {
+	return c.MutateContext(c.context(), mutation, variables)
+}
+
+// MutateContext is like Mutate, but the request is aborted if ctx is cancelled.
+func (c *Client) MutateContext(ctx context.Context, mutation interface{}, variables map[string]interface{}) error {
 	client := c.newGraphQLClient()
-	graphqlErr := client.Mutate(context.Background(), mutation, variables)
-	return graphqlErr
+	graphqlErr := client.Mutate(ctx, mutation, variables)
+	return wrapGraphQLError(graphqlErr)
 }
 
 func (c *Client) HTTPRequest(httpMethod string, path string, body *bytes.Buffer) (*http.Response, error) {
-	return c.HTTPRequestContext(context.Background(), httpMethod, path, body)
+	return c.HTTPRequestContext(c.context(), httpMethod, path, body)
 }
 
 func (c *Client) HTTPRequestContext(ctx context.Context, httpMethod string, path string, body *bytes.Buffer) (*http.Response, error) {
+	return c.HTTPRequestContextWithHeaders(ctx, httpMethod, path, body, nil)
+}
+
+// HTTPRequestContextWithHeaders is like HTTPRequestContext, but allows setting
+// additional request headers, e.g. Accept, or overriding the default ones.
+func (c *Client) HTTPRequestContextWithHeaders(ctx context.Context, httpMethod string, path string, body *bytes.Buffer, headers map[string]string) (*http.Response, error) {
+	return c.request(ctx, c.Address(), httpMethod, path, body, headers)
+}
+
+// NodeHTTPRequestContext is like HTTPRequestContext, but sends the request to
+// a node of the cluster, given by its URI, instead of the address of the
+// client. It is used for endpoints that only report on the node itself.
+func (c *Client) NodeHTTPRequestContext(ctx context.Context, nodeURI string, httpMethod string, path string) (*http.Response, error) {
+	if !strings.HasSuffix(nodeURI, "/") {
+		nodeURI += "/"
+	}
+	return c.request(ctx, nodeURI, httpMethod, path, nil, nil)
+}
+
+func (c *Client) request(ctx context.Context, address string, httpMethod string, path string, body *bytes.Buffer, headers map[string]string) (*http.Response, error) {
 	if body == nil {
 		body = bytes.NewBuffer([]byte(""))
 	}
 
-	url := c.Address() + path
+	url := address + path
 
 	req, reqErr := http.NewRequestWithContext(ctx, httpMethod, url, body)
+	if reqErr != nil {
+		return nil, reqErr
+	}
+
 	req.Header.Set("Authorization", "Bearer "+c.Token())
 	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
 
-	var client = &http.Client{}
+	upload := body.Len() > 0 && req.Header.Get("Content-Type") != "application/json"
+	return c.httpClient(path, upload).Do(req)
+}
 
-	if reqErr != nil {
-		return nil, reqErr
+// httpClient returns a client with the timeout for the kind of request made
+// to path. Uploads, e.g. of package archives, can take any time to send, so
+// only the time the server takes to respond is limited.
+func (c *Client) httpClient(path string, upload bool) *http.Client {
+	p := strings.SplitN(path, "?", 2)[0]
+
+	switch {
+	case strings.HasSuffix(p, "/ingest") || strings.HasSuffix(p, "/ingest-messages") || strings.HasPrefix(p, "api/v1/ingest/"):
+		return &http.Client{Timeout: c.config.IngestTimeout, Transport: c.transport(http.DefaultTransport)}
+	case strings.HasSuffix(p, "/query"):
+		// The result is streamed for as long as the query runs, so only the
+		// time until the response starts is limited.
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.ResponseHeaderTimeout = c.config.QueryTimeout
+		return &http.Client{Transport: c.transport(transport)}
+	case strings.Contains(p, "/queryjobs"):
+		return &http.Client{Timeout: c.config.QueryTimeout, Transport: c.transport(http.DefaultTransport)}
+	case upload:
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.ResponseHeaderTimeout = c.config.Timeout
+		return &http.Client{Transport: c.transport(transport)}
+	default:
+		return &http.Client{Timeout: c.config.Timeout, Transport: c.transport(http.DefaultTransport)}
+	}
+}
+
+// endpointLimiter returns the rate limiter of the endpoint of the request, if
+// it has a limit.
+func (c *Client) endpointLimiter(req *http.Request) *rateLimiter {
+	if len(c.endpointLimiters) == 0 {
+		return nil
 	}
-	return client.Do(req)
+	path := strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, "/"), c.basePath)
+	return endpointLimiterFor(c.endpointLimiters, path)
 }
 
 func optBoolArg(v *bool) *graphql.Boolean {
@@ -100,3 +285,19 @@ func optStringArg(v *string) *graphql.String {
 	}
 	return argPtr
 }
+
+func optFloatArg(v *float64) *graphql.Float {
+	var argPtr *graphql.Float
+	if v != nil {
+		argPtr = graphql.NewFloat(graphql.Float(*v))
+	}
+	return argPtr
+}
+
+func optIntArg(v *int) *graphql.Int {
+	var argPtr *graphql.Int
+	if v != nil {
+		argPtr = graphql.NewInt(graphql.Int(*v))
+	}
+	return argPtr
+}
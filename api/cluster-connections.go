@@ -0,0 +1,348 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shurcooL/graphql"
+)
+
+// The kinds of connections of a federated view.
+const (
+	// ClusterConnectionRemote is a connection to a view or repository on
+	// another cluster, searched with an API token of that cluster.
+	ClusterConnectionRemote = "remote"
+	// ClusterConnectionLocal is a connection to a view or repository on the
+	// cluster of the federated view.
+	ClusterConnectionLocal = "local"
+)
+
+// ClusterConnection is a connection of a federated view, a view searching
+// views or repositories on several clusters at once. The tags are added to
+// the events found through the connection, e.g. to tell the clusters apart.
+type ClusterConnection struct {
+	ID             string            `json:"id"`
+	Kind           string            `json:"kind"`
+	ClusterID      string            `json:"clusterId"`
+	PublicURL      string            `json:"publicUrl,omitempty"`
+	TargetViewName string            `json:"targetViewName,omitempty"`
+	Tags           map[string]string `json:"tags"`
+	QueryPrefix    string            `json:"queryPrefix"`
+}
+
+type ClusterConnections struct {
+	client *Client
+}
+
+func (c *Client) ClusterConnections() *ClusterConnections { return &ClusterConnections{client: c} }
+
+type clusterConnectionData struct {
+	Typename    string `graphql:"__typename"`
+	ID          string
+	ClusterID   string `graphql:"clusterId"`
+	Tags        []clusterConnectionTag
+	QueryPrefix string
+	Remote      struct {
+		PublicURL string `graphql:"publicUrl"`
+	} `graphql:"... on RemoteClusterConnection"`
+	Local struct {
+		TargetViewName string
+	} `graphql:"... on LocalClusterConnection"`
+}
+
+type clusterConnectionTag struct {
+	Key   string
+	Value string
+}
+
+func toClusterConnection(data clusterConnectionData) ClusterConnection {
+	connection := ClusterConnection{
+		ID:          data.ID,
+		ClusterID:   data.ClusterID,
+		Tags:        map[string]string{},
+		QueryPrefix: data.QueryPrefix,
+	}
+	for _, tag := range data.Tags {
+		connection.Tags[tag.Key] = tag.Value
+	}
+
+	if data.Typename == "LocalClusterConnection" {
+		connection.Kind = ClusterConnectionLocal
+		connection.TargetViewName = data.Local.TargetViewName
+	} else {
+		connection.Kind = ClusterConnectionRemote
+		connection.PublicURL = data.Remote.PublicURL
+	}
+
+	return connection
+}
+
+// ClusterConnectionInputTag is the GraphQL input type of a tag of a
+// connection of a federated view.
+type ClusterConnectionInputTag struct {
+	Key   graphql.String `json:"key"`
+	Value graphql.String `json:"value"`
+}
+
+func toClusterConnectionInputTags(tags map[string]string) []ClusterConnectionInputTag {
+	inputs := make([]ClusterConnectionInputTag, 0, len(tags))
+	for key, value := range tags {
+		inputs = append(inputs, ClusterConnectionInputTag{Key: graphql.String(key), Value: graphql.String(value)})
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].Key < inputs[j].Key })
+	return inputs
+}
+
+// CreateView creates a federated view without connections.
+func (c *ClusterConnections) CreateView(name, description string) error {
+	var m struct {
+		CreateMultiClusterSearchView struct {
+			Name string
+		} `graphql:"createMultiClusterSearchView(input: {name: $name, description: $description})"`
+	}
+
+	variables := map[string]interface{}{
+		"name":        graphql.String(name),
+		"description": graphql.String(description),
+	}
+
+	return c.client.Mutate(&m, variables)
+}
+
+// List returns the connections of a federated view. It returns an error if
+// the view is not federated.
+func (c *ClusterConnections) List(viewName string) ([]ClusterConnection, error) {
+	var q struct {
+		SearchDomain struct {
+			View struct {
+				IsFederated        bool
+				ClusterConnections []clusterConnectionData
+			} `graphql:"... on View"`
+		} `graphql:"searchDomain(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(viewName),
+	}
+
+	if err := c.client.Query(&q, variables); err != nil {
+		return nil, err
+	}
+	if !q.SearchDomain.View.IsFederated {
+		return nil, fmt.Errorf("%s is not a federated view", viewName)
+	}
+
+	connections := make([]ClusterConnection, len(q.SearchDomain.View.ClusterConnections))
+	for i, data := range q.SearchDomain.View.ClusterConnections {
+		connections[i] = toClusterConnection(data)
+	}
+
+	return connections, nil
+}
+
+// Get returns the connection of a federated view with the given ID.
+func (c *ClusterConnections) Get(viewName, id string) (*ClusterConnection, error) {
+	connections, err := c.List(viewName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, connection := range connections {
+		if connection.ID == id {
+			return &connection, nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not find a connection with id '%s' in view '%s'", id, viewName)
+}
+
+// CreateRemoteClusterConnectionInput is the GraphQL input type used to
+// connect a federated view to another cluster.
+type CreateRemoteClusterConnectionInput struct {
+	MultiClusterViewName graphql.String              `json:"multiClusterViewName"`
+	PublicURL            graphql.String              `json:"publicUrl"`
+	Token                graphql.String              `json:"token"`
+	Tags                 []ClusterConnectionInputTag `json:"tags"`
+	QueryPrefix          *graphql.String             `json:"queryPrefix,omitempty"`
+}
+
+// AddRemote connects a federated view to the cluster at publicURL. The
+// token is an API token of that cluster with access to the view or
+// repository to search.
+func (c *ClusterConnections) AddRemote(viewName, publicURL, token string, tags map[string]string, queryPrefix string) (*ClusterConnection, error) {
+	var m struct {
+		CreateRemoteClusterConnection clusterConnectionData `graphql:"createRemoteClusterConnection(input: $input)"`
+	}
+
+	input := CreateRemoteClusterConnectionInput{
+		MultiClusterViewName: graphql.String(viewName),
+		PublicURL:            graphql.String(publicURL),
+		Token:                graphql.String(token),
+		Tags:                 toClusterConnectionInputTags(tags),
+	}
+	if queryPrefix != "" {
+		input.QueryPrefix = graphql.NewString(graphql.String(queryPrefix))
+	}
+
+	variables := map[string]interface{}{
+		"input": input,
+	}
+
+	if err := c.client.Mutate(&m, variables); err != nil {
+		return nil, err
+	}
+
+	created := toClusterConnection(m.CreateRemoteClusterConnection)
+	return &created, nil
+}
+
+// CreateLocalClusterConnectionInput is the GraphQL input type used to
+// connect a federated view to a view or repository on the same cluster.
+type CreateLocalClusterConnectionInput struct {
+	MultiClusterViewName graphql.String              `json:"multiClusterViewName"`
+	TargetViewName       graphql.String              `json:"targetViewName"`
+	Tags                 []ClusterConnectionInputTag `json:"tags"`
+	QueryPrefix          *graphql.String             `json:"queryPrefix,omitempty"`
+}
+
+// AddLocal connects a federated view to a view or repository on the same
+// cluster.
+func (c *ClusterConnections) AddLocal(viewName, targetViewName string, tags map[string]string, queryPrefix string) (*ClusterConnection, error) {
+	var m struct {
+		CreateLocalClusterConnection clusterConnectionData `graphql:"createLocalClusterConnection(input: $input)"`
+	}
+
+	input := CreateLocalClusterConnectionInput{
+		MultiClusterViewName: graphql.String(viewName),
+		TargetViewName:       graphql.String(targetViewName),
+		Tags:                 toClusterConnectionInputTags(tags),
+	}
+	if queryPrefix != "" {
+		input.QueryPrefix = graphql.NewString(graphql.String(queryPrefix))
+	}
+
+	variables := map[string]interface{}{
+		"input": input,
+	}
+
+	if err := c.client.Mutate(&m, variables); err != nil {
+		return nil, err
+	}
+
+	created := toClusterConnection(m.CreateLocalClusterConnection)
+	return &created, nil
+}
+
+// ClusterConnectionChangeSet holds the settings to change on a connection
+// of a federated view. Fields that are nil are left unchanged. PublicURL and
+// Token only apply to remote connections, TargetViewName only to local ones.
+type ClusterConnectionChangeSet struct {
+	PublicURL      *string
+	Token          *string
+	TargetViewName *string
+	// Tags replace all the tags of the connection.
+	Tags        map[string]string
+	QueryPrefix *string
+}
+
+// UpdateRemoteClusterConnectionInput is the GraphQL input type used to
+// change a connection of a federated view to another cluster.
+type UpdateRemoteClusterConnectionInput struct {
+	MultiClusterViewName graphql.String              `json:"multiClusterViewName"`
+	ConnectionID         graphql.String              `json:"connectionId"`
+	PublicURL            *graphql.String             `json:"publicUrl,omitempty"`
+	Token                *graphql.String             `json:"token,omitempty"`
+	Tags                 []ClusterConnectionInputTag `json:"tags,omitempty"`
+	QueryPrefix          *graphql.String             `json:"queryPrefix,omitempty"`
+}
+
+// UpdateLocalClusterConnectionInput is the GraphQL input type used to
+// change a connection of a federated view to the same cluster.
+type UpdateLocalClusterConnectionInput struct {
+	MultiClusterViewName graphql.String              `json:"multiClusterViewName"`
+	ConnectionID         graphql.String              `json:"connectionId"`
+	TargetViewName       *graphql.String             `json:"targetViewName,omitempty"`
+	Tags                 []ClusterConnectionInputTag `json:"tags,omitempty"`
+	QueryPrefix          *graphql.String             `json:"queryPrefix,omitempty"`
+}
+
+// Update changes a connection of a federated view, e.g. to rotate the API
+// token of a remote connection.
+func (c *ClusterConnections) Update(viewName, id string, changeset ClusterConnectionChangeSet) (*ClusterConnection, error) {
+	existing, err := c.Get(viewName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []ClusterConnectionInputTag
+	if changeset.Tags != nil {
+		tags = toClusterConnectionInputTags(changeset.Tags)
+	}
+
+	if existing.Kind == ClusterConnectionLocal {
+		if changeset.PublicURL != nil || changeset.Token != nil {
+			return nil, fmt.Errorf("the connection %s is local, the URL and token only apply to remote connections", id)
+		}
+
+		var m struct {
+			UpdateLocalClusterConnection clusterConnectionData `graphql:"updateLocalClusterConnection(input: $input)"`
+		}
+
+		variables := map[string]interface{}{
+			"input": UpdateLocalClusterConnectionInput{
+				MultiClusterViewName: graphql.String(viewName),
+				ConnectionID:         graphql.String(id),
+				TargetViewName:       optStringArg(changeset.TargetViewName),
+				Tags:                 tags,
+				QueryPrefix:          optStringArg(changeset.QueryPrefix),
+			},
+		}
+
+		if err := c.client.Mutate(&m, variables); err != nil {
+			return nil, err
+		}
+
+		updated := toClusterConnection(m.UpdateLocalClusterConnection)
+		return &updated, nil
+	}
+
+	if changeset.TargetViewName != nil {
+		return nil, fmt.Errorf("the connection %s is remote, the target view only applies to local connections", id)
+	}
+
+	var m struct {
+		UpdateRemoteClusterConnection clusterConnectionData `graphql:"updateRemoteClusterConnection(input: $input)"`
+	}
+
+	variables := map[string]interface{}{
+		"input": UpdateRemoteClusterConnectionInput{
+			MultiClusterViewName: graphql.String(viewName),
+			ConnectionID:         graphql.String(id),
+			PublicURL:            optStringArg(changeset.PublicURL),
+			Token:                optStringArg(changeset.Token),
+			Tags:                 tags,
+			QueryPrefix:          optStringArg(changeset.QueryPrefix),
+		},
+	}
+
+	if err := c.client.Mutate(&m, variables); err != nil {
+		return nil, err
+	}
+
+	updated := toClusterConnection(m.UpdateRemoteClusterConnection)
+	return &updated, nil
+}
+
+// Delete removes a connection of a federated view.
+func (c *ClusterConnections) Delete(viewName, id string) error {
+	var m struct {
+		DeleteClusterConnection bool `graphql:"deleteClusterConnection(input: {multiClusterViewName: $viewName, connectionId: $connectionId})"`
+	}
+
+	variables := map[string]interface{}{
+		"viewName":     graphql.String(viewName),
+		"connectionId": graphql.String(id),
+	}
+
+	return c.client.Mutate(&m, variables)
+}
@@ -1,7 +1,13 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 
 	"github.com/shurcooL/graphql"
 )
@@ -219,3 +225,115 @@ func (n *ClusterNodes) Unregister(nodeID int64, force bool) error {
 
 	return graphqlErr
 }
+
+// SetEvicted marks a node as being evicted, or not. Segments are moved away
+// from an evicted node when data is redistributed, and no new segments are
+// placed on it.
+func (n *ClusterNodes) SetEvicted(nodeID int, evicted bool) error {
+	var m struct {
+		SetIsBeingEvicted struct {
+			// We have to make a selection, so just take __typename
+			Typename graphql.String `graphql:"__typename"`
+		} `graphql:"setIsBeingEvicted(vhost: $id, isBeingEvicted: $evicted)"`
+	}
+
+	variables := map[string]interface{}{
+		"id":      graphql.Int(nodeID),
+		"evicted": graphql.Boolean(evicted),
+	}
+
+	return n.client.Mutate(&m, variables)
+}
+
+// ThreadDump returns a thread dump of the node. It is requested from the URI
+// of the node, so the node must be reachable from the client.
+func (n *ClusterNodes) ThreadDump(ctx context.Context, node ClusterNode) ([]byte, error) {
+	res, err := n.client.NodeHTTPRequestContext(ctx, node.Uri, http.MethodGet, "api/v1/threaddump")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: res.StatusCode, Message: fmt.Sprintf("could not get thread dump of node %d, got status code %d", node.Id, res.StatusCode)}
+	}
+
+	return ioutil.ReadAll(res.Body)
+}
+
+type ClusterNodeVersion struct {
+	Id           int
+	Name         string
+	HumioVersion string
+	StartedAt    string
+	IsAvailable  bool
+}
+
+// ListVersions returns the Humio version and start time of every node in the cluster.
+func (n *ClusterNodes) ListVersions() ([]ClusterNodeVersion, error) {
+	var q struct {
+		Cluster struct {
+			Nodes []ClusterNodeVersion
+		}
+	}
+
+	graphqlErr := n.client.Query(&q, nil)
+
+	return q.Cluster.Nodes, graphqlErr
+}
+
+// MissingSegment is a segment that no node in the cluster holds a copy of.
+type MissingSegment struct {
+	RepositoryID string `json:"dataspaceId"`
+	DatasourceID string `json:"datasourceId"`
+	SegmentID    string `json:"segmentId"`
+	Start        int64  `json:"startTime"`
+	End          int64  `json:"endTime"`
+}
+
+// MissingSegments returns the segments that are missing from the cluster.
+func (c *Clusters) MissingSegments() ([]MissingSegment, error) {
+	resp, err := c.client.HTTPRequest(http.MethodGet, "api/v1/missing-segments", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("server responded with status code %d", resp.StatusCode)}
+	}
+
+	// The segments are returned as one JSON object per line.
+	var segments []MissingSegment
+	d := json.NewDecoder(resp.Body)
+	for {
+		var segment MissingSegment
+		err := d.Decode(&segment)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not parse response: %w", err)
+		}
+		segments = append(segments, segment)
+	}
+
+	return segments, nil
+}
+
+// DeleteMissingSegment removes a missing segment from the metadata of the
+// cluster, so it is no longer reported as missing. The data of the segment
+// is lost.
+func (c *Clusters) DeleteMissingSegment(segment MissingSegment) error {
+	path := fmt.Sprintf("api/v1/dataspaces/%s/datasources/%s/segments/%s", url.PathEscape(segment.RepositoryID), url.PathEscape(segment.DatasourceID), url.PathEscape(segment.SegmentID))
+	resp, err := c.client.HTTPRequest(http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("could not delete segment %s, server responded with status code %d", segment.SegmentID, resp.StatusCode)}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"github.com/shurcooL/graphql"
+)
+
+type Dashboards struct {
+	client *Client
+}
+
+func (c *Client) Dashboards() *Dashboards { return &Dashboards{client: c} }
+
+type Dashboard struct {
+	ID           string
+	Name         string
+	TemplateYaml string `graphql:"templateYaml"`
+}
+
+// List returns the dashboards of a view, including their definitions as
+// templates, which can be used to create copies of them.
+func (d *Dashboards) List(viewName string) ([]Dashboard, error) {
+	var q struct {
+		SearchDomain struct {
+			Dashboards []Dashboard
+		} `graphql:"searchDomain(name: $viewName)"`
+	}
+
+	variables := map[string]interface{}{
+		"viewName": graphql.String(viewName),
+	}
+
+	err := d.client.Query(&q, variables)
+
+	return q.SearchDomain.Dashboards, err
+}
+
+// CreateFromTemplate creates a dashboard in a view from a template, as
+// returned in Dashboard.TemplateYaml.
+func (d *Dashboards) CreateFromTemplate(viewName string, template string) error {
+	var m struct {
+		CreateDashboardFromTemplate struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"createDashboardFromTemplateV2(input: {viewName: $viewName, template: $template})"`
+	}
+
+	variables := map[string]interface{}{
+		"viewName": graphql.String(viewName),
+		"template": graphql.String(template),
+	}
+
+	return d.client.Mutate(&m, variables)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// StatusError is returned when the server responds with an unexpected HTTP status code.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// The GraphQL client only reports unexpected status codes in the error message.
+var graphQLStatusPattern = regexp.MustCompile(`^non-200 OK status code: (\d{3})`)
+
+// wrapGraphQLError converts an error from the GraphQL client into a
+// StatusError or GraphQLError, so callers can tell them apart.
+func wrapGraphQLError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if m := graphQLStatusPattern.FindStringSubmatch(err.Error()); m != nil {
+		statusCode, _ := strconv.Atoi(m[1])
+		return &StatusError{StatusCode: statusCode, Message: err.Error()}
+	}
+
+	// Errors returned by the server in the response are of an unexported type.
+	if t := reflect.TypeOf(err); t.PkgPath() == "github.com/shurcooL/graphql" && t.Name() == "errors" {
+		return GraphQLError{Message: err.Error()}
+	}
+
+	return err
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/shurcooL/graphql"
+)
+
+// FdrFeed is a feed ingesting data from CrowdStrike Falcon Data Replicator
+// (FDR) into a repository, by polling an SQS queue for new objects in S3.
+type FdrFeed struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	ParserID     string `json:"parserId"`
+	ClientID     string `json:"clientId"`
+	SqsURL       string `json:"sqsUrl"`
+	S3Identifier string `json:"s3Identifier"`
+	Enabled      bool   `json:"enabled"`
+}
+
+type FdrFeeds struct {
+	client *Client
+}
+
+func (c *Client) FdrFeeds() *FdrFeeds { return &FdrFeeds{client: c} }
+
+type fdrFeedData struct {
+	ID           string
+	Name         string
+	Description  *string
+	ParserID     string `graphql:"parserId"`
+	ClientID     string `graphql:"clientId"`
+	SqsURL       string `graphql:"sqsUrl"`
+	S3Identifier string `graphql:"s3Identifier"`
+	Enabled      bool
+}
+
+func toFdrFeed(data fdrFeedData) FdrFeed {
+	var description string
+	if data.Description != nil {
+		description = *data.Description
+	}
+
+	return FdrFeed{
+		ID:           data.ID,
+		Name:         data.Name,
+		Description:  description,
+		ParserID:     data.ParserID,
+		ClientID:     data.ClientID,
+		SqsURL:       data.SqsURL,
+		S3Identifier: data.S3Identifier,
+		Enabled:      data.Enabled,
+	}
+}
+
+func (f *FdrFeeds) List(repositoryName string) ([]FdrFeed, error) {
+	var q struct {
+		Repository struct {
+			FdrFeeds []fdrFeedData
+		} `graphql:"repository(name: $repositoryName)"`
+	}
+
+	variables := map[string]interface{}{
+		"repositoryName": graphql.String(repositoryName),
+	}
+
+	if err := f.client.Query(&q, variables); err != nil {
+		return nil, err
+	}
+
+	feeds := make([]FdrFeed, len(q.Repository.FdrFeeds))
+	for i, data := range q.Repository.FdrFeeds {
+		feeds[i] = toFdrFeed(data)
+	}
+
+	return feeds, nil
+}
+
+func (f *FdrFeeds) Get(repositoryName, feedName string) (*FdrFeed, error) {
+	feeds, err := f.List(repositoryName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, feed := range feeds {
+		if feed.Name == feedName {
+			return &feed, nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not find an FDR feed with name '%s' in repo '%s'", feedName, repositoryName)
+}
+
+// FdrFeedCreate holds the settings of a new FDR feed.
+type FdrFeedCreate struct {
+	Name         string
+	Description  string
+	ParserID     string
+	ClientID     string
+	ClientSecret string
+	SqsURL       string
+	S3Identifier string
+	Enabled      bool
+}
+
+func (f *FdrFeeds) Create(repositoryName string, feed FdrFeedCreate) (*FdrFeed, error) {
+	var mutation struct {
+		CreateFdrFeed fdrFeedData `graphql:"createFdrFeed(input: $input)"`
+	}
+
+	input := CreateFdrFeed{
+		RepositoryName: graphql.String(repositoryName),
+		Name:           graphql.String(feed.Name),
+		ParserID:       graphql.String(feed.ParserID),
+		ClientID:       graphql.String(feed.ClientID),
+		ClientSecret:   graphql.String(feed.ClientSecret),
+		SqsURL:         graphql.String(feed.SqsURL),
+		S3Identifier:   graphql.String(feed.S3Identifier),
+		Enabled:        graphql.Boolean(feed.Enabled),
+	}
+	if feed.Description != "" {
+		input.Description = graphql.NewString(graphql.String(feed.Description))
+	}
+
+	variables := map[string]interface{}{
+		"input": input,
+	}
+
+	if err := f.client.Mutate(&mutation, variables); err != nil {
+		return nil, err
+	}
+
+	created := toFdrFeed(mutation.CreateFdrFeed)
+	return &created, nil
+}
+
+// FdrFeedChangeSet holds the settings to change on an existing FDR feed.
+// Fields that are nil are left unchanged.
+type FdrFeedChangeSet struct {
+	Name         *string
+	Description  *string
+	ParserID     *string
+	ClientID     *string
+	ClientSecret *string
+	SqsURL       *string
+	S3Identifier *string
+	Enabled      *bool
+}
+
+func (f *FdrFeeds) Update(repositoryName, feedName string, changeset FdrFeedChangeSet) (*FdrFeed, error) {
+	existing, err := f.Get(repositoryName, feedName)
+	if err != nil {
+		return nil, err
+	}
+
+	var mutation struct {
+		UpdateFdrFeed fdrFeedData `graphql:"updateFdrFeed(input: $input)"`
+	}
+
+	input := UpdateFdrFeed{
+		RepositoryName: graphql.String(repositoryName),
+		ID:             graphql.String(existing.ID),
+		Name:           optStringArg(changeset.Name),
+		ParserID:       optStringArg(changeset.ParserID),
+		ClientID:       optStringArg(changeset.ClientID),
+		ClientSecret:   optStringArg(changeset.ClientSecret),
+		SqsURL:         optStringArg(changeset.SqsURL),
+		S3Identifier:   optStringArg(changeset.S3Identifier),
+		Enabled:        optBoolArg(changeset.Enabled),
+	}
+	if changeset.Description != nil {
+		input.Description = &UpdateDescription{Value: optStringArg(changeset.Description)}
+	}
+
+	variables := map[string]interface{}{
+		"input": input,
+	}
+
+	if err := f.client.Mutate(&mutation, variables); err != nil {
+		return nil, err
+	}
+
+	updated := toFdrFeed(mutation.UpdateFdrFeed)
+	return &updated, nil
+}
+
+func (f *FdrFeeds) Delete(repositoryName, feedName string) error {
+	existing, err := f.Get(repositoryName, feedName)
+	if err != nil {
+		return err
+	}
+
+	var mutation struct {
+		DeleteFdrFeed bool `graphql:"deleteFdrFeed(input: {repositoryName: $repositoryName, id: $id})"`
+	}
+
+	variables := map[string]interface{}{
+		"repositoryName": graphql.String(repositoryName),
+		"id":             graphql.String(existing.ID),
+	}
+
+	return f.client.Mutate(&mutation, variables)
+}
+
+// CreateFdrFeed is the GraphQL input type of the createFdrFeed mutation.
+type CreateFdrFeed struct {
+	RepositoryName graphql.String  `json:"repositoryName"`
+	Name           graphql.String  `json:"name"`
+	Description    *graphql.String `json:"description,omitempty"`
+	ParserID       graphql.String  `json:"parserId"`
+	ClientID       graphql.String  `json:"clientId"`
+	ClientSecret   graphql.String  `json:"clientSecret"`
+	SqsURL         graphql.String  `json:"sqsUrl"`
+	S3Identifier   graphql.String  `json:"s3Identifier"`
+	Enabled        graphql.Boolean `json:"enabled"`
+}
+
+// UpdateFdrFeed is the GraphQL input type of the updateFdrFeed mutation.
+type UpdateFdrFeed struct {
+	RepositoryName graphql.String     `json:"repositoryName"`
+	ID             graphql.String     `json:"id"`
+	Name           *graphql.String    `json:"name,omitempty"`
+	Description    *UpdateDescription `json:"description,omitempty"`
+	ParserID       *graphql.String    `json:"parserId,omitempty"`
+	ClientID       *graphql.String    `json:"clientId,omitempty"`
+	ClientSecret   *graphql.String    `json:"clientSecret,omitempty"`
+	SqsURL         *graphql.String    `json:"sqsUrl,omitempty"`
+	S3Identifier   *graphql.String    `json:"s3Identifier,omitempty"`
+	Enabled        *graphql.Boolean   `json:"enabled,omitempty"`
+}
+
+// UpdateDescription is the GraphQL input type used to change a description.
+type UpdateDescription struct {
+	Value *graphql.String `json:"value"`
+}
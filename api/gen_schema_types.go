@@ -0,0 +1,327 @@
+//go:build ignore
+// +build ignore
+
+// This program generates schema_types.go, the Go types of the GraphQL enums,
+// input objects and custom scalars of the Humio schema. The GraphQL client
+// derives the type of a query variable from the name of its Go type, so
+// arguments of these types must be passed using the generated types.
+//
+// By default the schema is fetched from the Humio cluster at $HUMIO_ADDRESS,
+// using $HUMIO_TOKEN, so regenerating the types for a new Humio version is:
+//
+//   $ HUMIO_ADDRESS=http://localhost:8080/ HUMIO_TOKEN=... go generate ./api
+//
+// Alternatively the types can be generated from a schema saved by
+// 'humioctl api introspect':
+//
+//   $ humioctl api introspect -o schema.json
+//   $ go run api/gen_schema_types.go -schema schema.json -out api/schema_types.go
+//
+// Types that are already declared by hand in the api package are skipped.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/humio/cli/api"
+)
+
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+type inputValue struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Type        typeRef `json:"type"`
+}
+
+type enumValue struct {
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	IsDeprecated      bool   `json:"isDeprecated"`
+	DeprecationReason string `json:"deprecationReason"`
+}
+
+type fullType struct {
+	Kind        string       `json:"kind"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	InputFields []inputValue `json:"inputFields"`
+	EnumValues  []enumValue  `json:"enumValues"`
+}
+
+type schemaFile struct {
+	Data struct {
+		Schema struct {
+			Types []fullType `json:"types"`
+		} `json:"__schema"`
+	} `json:"data"`
+}
+
+// builtinScalars maps the GraphQL built-in scalars to the types of the
+// GraphQL client.
+var builtinScalars = map[string]string{
+	"String":  "graphql.String",
+	"Int":     "graphql.Int",
+	"Float":   "graphql.Float",
+	"Boolean": "graphql.Boolean",
+	"ID":      "graphql.ID",
+}
+
+// customScalars maps custom scalars that are not strings to Go types.
+var customScalars = map[string]string{
+	"Long": "int64",
+}
+
+func main() {
+	schemaPath := flag.String("schema", "", "The introspection result to generate types from. By default the schema is fetched from $HUMIO_ADDRESS.")
+	outPath := flag.String("out", "schema_types.go", "The file to write the types to.")
+	flag.Parse()
+
+	content, source, err := loadSchema(*schemaPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var schema schemaFile
+	if err := json.Unmarshal(content, &schema); err != nil {
+		log.Fatalf("error parsing the schema from %s: %v", source, err)
+	}
+	if len(schema.Data.Schema.Types) == 0 {
+		log.Fatalf("the schema from %s contains no types, it must be the output of 'humioctl api introspect'", source)
+	}
+
+	declared, err := declaredTypes(filepath.Dir(*outPath), filepath.Base(*outPath))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := &generator{}
+	types := schema.Data.Schema.Types
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") || builtinScalars[t.Name] != "" {
+			continue
+		}
+		if t.Kind != "ENUM" && t.Kind != "INPUT_OBJECT" && t.Kind != "SCALAR" {
+			continue
+		}
+		if declared[t.Name] {
+			fmt.Fprintf(os.Stderr, "skipping %s, it is already declared in the package\n", t.Name)
+			continue
+		}
+
+		switch t.Kind {
+		case "ENUM":
+			g.enum(t)
+		case "INPUT_OBJECT":
+			g.inputObject(t)
+		case "SCALAR":
+			g.scalar(t)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by gen_schema_types.go. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package api\n\n")
+	if g.usesGraphQL {
+		fmt.Fprintf(&out, "import \"github.com/shurcooL/graphql\"\n\n")
+	}
+	out.Write(g.buf.Bytes())
+
+	code, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatalf("error formatting generated code: %v", err)
+	}
+
+	if err := ioutil.WriteFile(*outPath, code, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// loadSchema reads the introspection result from path or, if path is empty,
+// introspects the cluster at $HUMIO_ADDRESS. It also returns where the schema
+// was loaded from, for error messages.
+func loadSchema(path string) ([]byte, string, error) {
+	if path != "" {
+		content, err := ioutil.ReadFile(path)
+		return content, path, err
+	}
+
+	address := os.Getenv("HUMIO_ADDRESS")
+	if address == "" {
+		return nil, "", fmt.Errorf("set HUMIO_ADDRESS and HUMIO_TOKEN to introspect a Humio cluster, or use -schema to read a saved schema")
+	}
+
+	client, err := api.NewClient(api.Config{Address: address, Token: os.Getenv("HUMIO_TOKEN")})
+	if err != nil {
+		return nil, "", err
+	}
+
+	content, err := client.Introspect()
+	if err != nil {
+		return nil, "", fmt.Errorf("error introspecting %s: %v", address, err)
+	}
+	return content, address, nil
+}
+
+// declaredTypes returns the names of the types declared in the package in
+// dir, ignoring the generated file.
+func declaredTypes(dir, generatedFile string) (map[string]bool, error) {
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		if name == generatedFile {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+				for _, spec := range gen.Specs {
+					declared[spec.(*ast.TypeSpec).Name.Name] = true
+				}
+			}
+		}
+	}
+
+	return declared, nil
+}
+
+type generator struct {
+	buf         bytes.Buffer
+	usesGraphQL bool
+}
+
+func (g *generator) comment(name, kind, description string) {
+	fmt.Fprintf(&g.buf, "// %s is the GraphQL %s %s.\n", name, kind, name)
+	if description == "" {
+		return
+	}
+	fmt.Fprintf(&g.buf, "//\n")
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		fmt.Fprintf(&g.buf, "// %s\n", strings.TrimSpace(line))
+	}
+}
+
+func (g *generator) enum(t fullType) {
+	g.comment(t.Name, "enum", t.Description)
+	fmt.Fprintf(&g.buf, "type %s string\n\n", t.Name)
+
+	if len(t.EnumValues) == 0 {
+		return
+	}
+
+	fmt.Fprintf(&g.buf, "const (\n")
+	for _, v := range t.EnumValues {
+		if v.Description != "" {
+			fmt.Fprintf(&g.buf, "// %s\n", strings.Join(strings.Fields(v.Description), " "))
+			if v.IsDeprecated {
+				fmt.Fprintf(&g.buf, "//\n")
+			}
+		}
+		if v.IsDeprecated {
+			fmt.Fprintf(&g.buf, "// Deprecated: %s\n", strings.Join(strings.Fields(v.DeprecationReason), " "))
+		}
+		fmt.Fprintf(&g.buf, "%s%s %s = %q\n", t.Name, goName(v.Name), t.Name, v.Name)
+	}
+	fmt.Fprintf(&g.buf, ")\n\n")
+}
+
+func (g *generator) inputObject(t fullType) {
+	g.comment(t.Name, "input object", t.Description)
+	fmt.Fprintf(&g.buf, "type %s struct {\n", t.Name)
+	for _, f := range t.InputFields {
+		if f.Description != "" {
+			fmt.Fprintf(&g.buf, "// %s\n", strings.Join(strings.Fields(f.Description), " "))
+		}
+
+		tag := f.Name
+		if f.Type.Kind != "NON_NULL" {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&g.buf, "%s %s `json:\"%s\"`\n", goName(f.Name), g.goType(f.Type, true), tag)
+	}
+	fmt.Fprintf(&g.buf, "}\n\n")
+}
+
+func (g *generator) scalar(t fullType) {
+	g.comment(t.Name, "scalar", t.Description)
+
+	goType := customScalars[t.Name]
+	if goType == "" {
+		goType = "string"
+	}
+	fmt.Fprintf(&g.buf, "type %s %s\n\n", t.Name, goType)
+}
+
+// goType returns the Go type of a GraphQL type. Nullable named types are
+// pointers, so they can be left out.
+func (g *generator) goType(t typeRef, nullable bool) string {
+	switch t.Kind {
+	case "NON_NULL":
+		return g.goType(*t.OfType, false)
+	case "LIST":
+		return "[]" + g.goType(*t.OfType, true)
+	}
+
+	name := t.Name
+	if scalar, ok := builtinScalars[name]; ok {
+		g.usesGraphQL = true
+		name = scalar
+	}
+
+	if nullable {
+		return "*" + name
+	}
+	return name
+}
+
+// goName turns a GraphQL name, e.g. "repositoryName" or "READ_ONLY", into an
+// exported Go name, e.g. "RepositoryName" or "ReadOnly".
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' }) {
+		if strings.ToUpper(part) == part {
+			part = strings.ToLower(part)
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	s := b.String()
+	if strings.HasSuffix(s, "Id") {
+		s = strings.TrimSuffix(s, "Id") + "ID"
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+package api
+
+//go:generate go run gen_schema_types.go -out schema_types.go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+type GraphQLError struct {
+	Message string `json:"message"`
+}
+
+func (e GraphQLError) Error() string {
+	return e.Message
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+// RawGraphQL executes an arbitrary GraphQL query or mutation and returns the raw
+// JSON response. An error is returned if the request failed or if the response
+// contains GraphQL errors, in which case the response is returned as well.
+func (c *Client) RawGraphQL(query string, variables map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTPRequest(http.MethodPost, "graphql", &buf)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return body, &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("server responded with status code %d", resp.StatusCode)}
+	}
+
+	var result graphQLResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return body, fmt.Errorf("could not parse response: %v", err)
+	}
+
+	if len(result.Errors) > 0 {
+		return body, result.Errors[0]
+	}
+
+	return body, nil
+}
+
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives { name description locations args { ...InputValue } }
+  }
+}
+fragment FullType on __Type {
+  kind name description
+  fields(includeDeprecated: true) {
+    name description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason }
+  possibleTypes { ...TypeRef }
+}
+fragment InputValue on __InputValue {
+  name description
+  type { ...TypeRef }
+  defaultValue
+}
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}`
+
+// Introspect fetches the GraphQL schema of the server using the standard introspection query.
+func (c *Client) Introspect() ([]byte, error) {
+	return c.RawGraphQL(introspectionQuery, nil)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/shurcooL/graphql"
+)
+
+type Groups struct {
+	client *Client
+}
+
+// Group is a Humio group. Members of the identity provider group named by
+// LookupName, e.g. a SAML or LDAP group, become members of the group when
+// they log in.
+type Group struct {
+	ID          string
+	DisplayName string
+	LookupName  string
+	UserCount   int
+}
+
+func (c *Client) Groups() *Groups { return &Groups{client: c} }
+
+// List returns all groups.
+func (g *Groups) List() ([]Group, error) {
+	var groups []Group
+
+	_, err := fetchPages(0, func(pageNumber, pageSize int) (int, int, error) {
+		var q struct {
+			GroupsPage struct {
+				PageInfo struct {
+					TotalNumberOfRows int
+				}
+				Page []Group
+			} `graphql:"groupsPage(pageNumber: $pageNumber, pageSize: $pageSize)"`
+		}
+
+		variables := map[string]interface{}{
+			"pageNumber": graphql.Int(pageNumber),
+			"pageSize":   graphql.Int(pageSize),
+		}
+
+		if err := g.client.Query(&q, variables); err != nil {
+			return 0, 0, err
+		}
+
+		groups = append(groups, q.GroupsPage.Page...)
+		return len(q.GroupsPage.Page), q.GroupsPage.PageInfo.TotalNumberOfRows, nil
+	})
+
+	return groups, err
+}
+
+// Add creates a group. If lookupName is not empty, members of the identity
+// provider group with that name become members of the group.
+func (g *Groups) Add(displayName, lookupName string) (Group, error) {
+	var m struct {
+		AddGroup struct {
+			Group Group
+		} `graphql:"addGroup(displayName: $displayName, lookupName: $lookupName)"`
+	}
+
+	variables := map[string]interface{}{
+		"displayName": graphql.String(displayName),
+		"lookupName":  optStringArg(nonEmpty(lookupName)),
+	}
+
+	err := g.client.Mutate(&m, variables)
+
+	return m.AddGroup.Group, err
+}
+
+// SetLookupName maps the identity provider group named lookupName to the
+// group. An empty lookupName removes the mapping.
+func (g *Groups) SetLookupName(id, lookupName string) error {
+	var m struct {
+		UpdateGroup struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"updateGroup(input: {groupId: $groupId, lookupName: $lookupName})"`
+	}
+
+	variables := map[string]interface{}{
+		"groupId":    graphql.String(id),
+		"lookupName": optStringArg(nonEmpty(lookupName)),
+	}
+
+	if err := g.client.Mutate(&m, variables); err != nil {
+		return fmt.Errorf("could not update group %s: %w", id, err)
+	}
+	return nil
+}
+
+// Members returns the usernames of the members of the group.
+func (g *Groups) Members(id string) ([]string, error) {
+	var q struct {
+		Group struct {
+			Users []struct {
+				Username string
+			}
+		} `graphql:"group(groupId: $groupId)"`
+	}
+
+	variables := map[string]interface{}{
+		"groupId": graphql.String(id),
+	}
+
+	if err := g.client.Query(&q, variables); err != nil {
+		return nil, err
+	}
+
+	usernames := make([]string, len(q.Group.Users))
+	for i, u := range q.Group.Users {
+		usernames[i] = u.Username
+	}
+	return usernames, nil
+}
+
+// AddMembers adds the users to the group.
+func (g *Groups) AddMembers(id string, usernames []string) error {
+	var m struct {
+		AddUsersToGroup struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"addUsersToGroup(input: {groupId: $groupId, users: $users})"`
+	}
+
+	variables := map[string]interface{}{
+		"groupId": graphql.String(id),
+		"users":   toGraphQLStrings(usernames),
+	}
+
+	if err := g.client.Mutate(&m, variables); err != nil {
+		return fmt.Errorf("could not add users to group %s: %w", id, err)
+	}
+	return nil
+}
+
+// RemoveMembers removes the users from the group.
+func (g *Groups) RemoveMembers(id string, usernames []string) error {
+	var m struct {
+		RemoveUsersFromGroup struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"removeUsersFromGroup(input: {groupId: $groupId, users: $users})"`
+	}
+
+	variables := map[string]interface{}{
+		"groupId": graphql.String(id),
+		"users":   toGraphQLStrings(usernames),
+	}
+
+	if err := g.client.Mutate(&m, variables); err != nil {
+		return fmt.Errorf("could not remove users from group %s: %w", id, err)
+	}
+	return nil
+}
+
+func toGraphQLStrings(vs []string) []graphql.String {
+	result := make([]graphql.String, len(vs))
+	for i, v := range vs {
+		result[i] = graphql.String(v)
+	}
+	return result
+}
+
+// nonEmpty returns nil for the empty string, so it is sent as null.
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
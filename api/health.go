@@ -56,7 +56,7 @@ func (c *Client) Health() (Health, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return Health{}, fmt.Errorf("server responded with status code %d", resp.StatusCode)
+		return Health{}, &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("server responded with status code %d", resp.StatusCode)}
 	}
 
 	var rawJson bytes.Buffer
@@ -137,3 +137,30 @@ func (i *IngestTokens) Remove(repositoryName string, tokenName string) error {
 
 	return i.client.Mutate(&mutation, variables)
 }
+
+// Rotate replaces an ingest token with a new one named newName, with the same
+// assigned parser. The API has no way of regenerating or renaming a token, so
+// the new token is added before the old one is removed, and the old one is
+// kept if the new one cannot be added. If the old token cannot be removed, the
+// new token is returned along with the error.
+func (i *IngestTokens) Rotate(repositoryName string, tokenName string, newName string) (*IngestToken, error) {
+	if newName == tokenName {
+		return nil, fmt.Errorf("rotation is unsupported: ingest tokens cannot be regenerated in place, the new token must have another name than '%s'", tokenName)
+	}
+
+	existing, err := i.Get(repositoryName, tokenName)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := i.Add(repositoryName, newName, existing.AssignedParser)
+	if err != nil {
+		return nil, fmt.Errorf("could not add the new token, the old token is kept: %w", err)
+	}
+
+	if err := i.Remove(repositoryName, tokenName); err != nil {
+		return token, fmt.Errorf("the new token '%s' was added, but the old token could not be removed: %w", newName, err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/shurcooL/graphql"
+)
+
+type IPFilters struct {
+	client *Client
+}
+
+// IPFilter restricts where tokens can be used from. Filter holds one rule
+// per line, e.g. "allow 10.0.0.0/8" or "deny all".
+type IPFilter struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Filter string `graphql:"ipFilter" json:"ipFilter"`
+}
+
+type IPFilterInput struct {
+	Name     graphql.String `json:"name"`
+	IPFilter graphql.String `json:"ipFilter"`
+}
+
+type IPFilterUpdateInput struct {
+	ID       graphql.String  `json:"id"`
+	Name     *graphql.String `json:"name,omitempty"`
+	IPFilter *graphql.String `json:"ipFilter,omitempty"`
+}
+
+type IPFilterIdInput struct {
+	ID graphql.String `json:"id"`
+}
+
+func (c *Client) IPFilters() *IPFilters { return &IPFilters{client: c} }
+
+func (f *IPFilters) List() ([]IPFilter, error) {
+	var q struct {
+		IPFilters []IPFilter `graphql:"ipFilters"`
+	}
+
+	err := f.client.Query(&q, nil)
+
+	return q.IPFilters, err
+}
+
+// Get returns the IP filter with the given name or ID.
+func (f *IPFilters) Get(nameOrID string) (IPFilter, error) {
+	filters, err := f.List()
+	if err != nil {
+		return IPFilter{}, err
+	}
+
+	for _, filter := range filters {
+		if filter.Name == nameOrID || filter.ID == nameOrID {
+			return filter, nil
+		}
+	}
+
+	return IPFilter{}, fmt.Errorf("IP filter %s not found", nameOrID)
+}
+
+func (f *IPFilters) Create(name, filter string) (IPFilter, error) {
+	var m struct {
+		CreateIPFilter IPFilter `graphql:"createIPFilter(input: $input)"`
+	}
+
+	variables := map[string]interface{}{
+		"input": IPFilterInput{Name: graphql.String(name), IPFilter: graphql.String(filter)},
+	}
+
+	err := f.client.Mutate(&m, variables)
+
+	return m.CreateIPFilter, err
+}
+
+// Update changes the name and rules of an IP filter. Nil values are left
+// unchanged.
+func (f *IPFilters) Update(id string, name, filter *string) (IPFilter, error) {
+	var m struct {
+		UpdateIPFilter IPFilter `graphql:"updateIPFilter(input: $input)"`
+	}
+
+	variables := map[string]interface{}{
+		"input": IPFilterUpdateInput{ID: graphql.String(id), Name: optStringArg(name), IPFilter: optStringArg(filter)},
+	}
+
+	err := f.client.Mutate(&m, variables)
+
+	return m.UpdateIPFilter, err
+}
+
+func (f *IPFilters) Delete(id string) error {
+	var m struct {
+		DeleteIPFilter bool `graphql:"deleteIPFilter(input: $input)"`
+	}
+
+	variables := map[string]interface{}{
+		"input": IPFilterIdInput{ID: graphql.String(id)},
+	}
+
+	return f.client.Mutate(&m, variables)
+}
@@ -134,6 +134,42 @@ func (n *Notifiers) Delete(viewName, notifierName string) error {
 	return nil
 }
 
+// NotifierTestResult is the outcome of sending a test notification.
+type NotifierTestResult struct {
+	Success    bool
+	StatusCode int
+	Message    string
+}
+
+// Test makes the server send a test notification through the notifier, and
+// returns whether it could be delivered.
+func (n *Notifiers) Test(viewName, notifierName string) (*NotifierTestResult, error) {
+	notifierID, err := n.convertNotifierNameToID(viewName, notifierName)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("api/v1/repositories/%s/alertnotifiers/%s/test", viewName, notifierID)
+
+	res, err := n.client.HTTPRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotifierTestResult{
+		Success:    res.StatusCode >= 200 && res.StatusCode < 300,
+		StatusCode: res.StatusCode,
+		Message:    string(bytes.TrimSpace(body)),
+	}, nil
+}
+
 func (n *Notifiers) marshalToJSON(notifier *Notifier) ([]byte, error) {
 	jsonStr, err := json.Marshal(notifier)
 	if err != nil {
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"github.com/shurcooL/graphql"
+)
+
+type Organizations struct {
+	client *Client
+}
+
+func (c *Client) Organizations() *Organizations { return &Organizations{client: c} }
+
+// OrganizationSettings are the organization-wide defaults of new
+// repositories and users, and the limits of the organization. A value of 0
+// means there is no default or limit.
+type OrganizationSettings struct {
+	Name                               string
+	DefaultTimeBasedRetentionDays      float64
+	DefaultIngestSizeBasedRetentionGB  float64
+	DefaultStorageSizeBasedRetentionGB float64
+	MaxAutoShardCount                  int
+	DefaultUserRole                    string
+	IngestLimitGBPerDay                float64
+	MaxRepositories                    int
+}
+
+// OrganizationSettingsChangeSet holds the settings to change. Settings that
+// are nil are left as they are.
+type OrganizationSettingsChangeSet struct {
+	DefaultTimeBasedRetentionDays      *float64
+	DefaultIngestSizeBasedRetentionGB  *float64
+	DefaultStorageSizeBasedRetentionGB *float64
+	MaxAutoShardCount                  *int
+	DefaultUserRole                    *string
+	IngestLimitGBPerDay                *float64
+	MaxRepositories                    *int
+}
+
+type organizationSettingsData struct {
+	DefaultTimeBasedRetention        *float64
+	DefaultIngestSizeBasedRetention  *float64
+	DefaultStorageSizeBasedRetention *float64
+	MaxAutoShardCount                *int
+	DefaultUserRole                  *struct {
+		Name string
+	}
+	IngestLimitGBPerDay *float64 `graphql:"ingestLimitGBPerDay"`
+	MaxRepositories     *int
+}
+
+func toOrganizationSettings(name string, data organizationSettingsData) OrganizationSettings {
+	settings := OrganizationSettings{Name: name}
+	if data.DefaultTimeBasedRetention != nil {
+		settings.DefaultTimeBasedRetentionDays = *data.DefaultTimeBasedRetention
+	}
+	if data.DefaultIngestSizeBasedRetention != nil {
+		settings.DefaultIngestSizeBasedRetentionGB = *data.DefaultIngestSizeBasedRetention
+	}
+	if data.DefaultStorageSizeBasedRetention != nil {
+		settings.DefaultStorageSizeBasedRetentionGB = *data.DefaultStorageSizeBasedRetention
+	}
+	if data.MaxAutoShardCount != nil {
+		settings.MaxAutoShardCount = *data.MaxAutoShardCount
+	}
+	if data.DefaultUserRole != nil {
+		settings.DefaultUserRole = data.DefaultUserRole.Name
+	}
+	if data.IngestLimitGBPerDay != nil {
+		settings.IngestLimitGBPerDay = *data.IngestLimitGBPerDay
+	}
+	if data.MaxRepositories != nil {
+		settings.MaxRepositories = *data.MaxRepositories
+	}
+	return settings
+}
+
+// Settings returns the settings of the organization the API token belongs
+// to.
+func (o *Organizations) Settings() (OrganizationSettings, error) {
+	var q struct {
+		CurrentOrganization struct {
+			Name     string
+			Settings organizationSettingsData
+		}
+	}
+
+	if err := o.client.Query(&q, nil); err != nil {
+		return OrganizationSettings{}, err
+	}
+
+	return toOrganizationSettings(q.CurrentOrganization.Name, q.CurrentOrganization.Settings), nil
+}
+
+// UpdateOrganizationSettingsInput is the GraphQL input type used to change
+// the settings of an organization.
+type UpdateOrganizationSettingsInput struct {
+	DefaultTimeBasedRetention        *graphql.Float  `json:"defaultTimeBasedRetention,omitempty"`
+	DefaultIngestSizeBasedRetention  *graphql.Float  `json:"defaultIngestSizeBasedRetention,omitempty"`
+	DefaultStorageSizeBasedRetention *graphql.Float  `json:"defaultStorageSizeBasedRetention,omitempty"`
+	MaxAutoShardCount                *graphql.Int    `json:"maxAutoShardCount,omitempty"`
+	DefaultUserRoleName              *graphql.String `json:"defaultUserRoleName,omitempty"`
+	IngestLimitGBPerDay              *graphql.Float  `json:"ingestLimitGBPerDay,omitempty"`
+	MaxRepositories                  *graphql.Int    `json:"maxRepositories,omitempty"`
+}
+
+// UpdateSettings changes the settings of the organization the API token
+// belongs to, and returns the settings after the change.
+func (o *Organizations) UpdateSettings(changeset OrganizationSettingsChangeSet) (OrganizationSettings, error) {
+	var m struct {
+		UpdateOrganizationSettings struct {
+			Name     string
+			Settings organizationSettingsData
+		} `graphql:"updateOrganizationSettings(input: $input)"`
+	}
+
+	variables := map[string]interface{}{
+		"input": UpdateOrganizationSettingsInput{
+			DefaultTimeBasedRetention:        optFloatArg(changeset.DefaultTimeBasedRetentionDays),
+			DefaultIngestSizeBasedRetention:  optFloatArg(changeset.DefaultIngestSizeBasedRetentionGB),
+			DefaultStorageSizeBasedRetention: optFloatArg(changeset.DefaultStorageSizeBasedRetentionGB),
+			MaxAutoShardCount:                optIntArg(changeset.MaxAutoShardCount),
+			DefaultUserRoleName:              optStringArg(changeset.DefaultUserRole),
+			IngestLimitGBPerDay:              optFloatArg(changeset.IngestLimitGBPerDay),
+			MaxRepositories:                  optIntArg(changeset.MaxRepositories),
+		},
+	}
+
+	if err := o.client.Mutate(&m, variables); err != nil {
+		return OrganizationSettings{}, err
+	}
+
+	return toOrganizationSettings(m.UpdateOrganizationSettings.Name, m.UpdateOrganizationSettings.Settings), nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/shurcooL/graphql"
+)
+
+type Packages struct {
+	client *Client
+}
+
+type InstalledPackage struct {
+	ID     string `graphql:"id"`
+	Source string
+}
+
+func (c *Client) Packages() *Packages { return &Packages{client: c} }
+
+// InstallArchive installs a package from the content of a zip file into a view.
+func (p *Packages) InstallArchive(viewName string, archive []byte, overwrite bool) error {
+	path := fmt.Sprintf("api/v1/packages/install?view=%s&overwrite=%t", url.QueryEscape(viewName), overwrite)
+	headers := map[string]string{"Content-Type": "application/zip"}
+
+	resp, err := p.client.HTTPRequestContextWithHeaders(p.client.context(), http.MethodPost, path, bytes.NewBuffer(archive), headers)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("could not install package, got status code %d: %s", resp.StatusCode, string(body))}
+	}
+
+	return nil
+}
+
+// InstallFromMarketplace installs a package from the Humio package marketplace
+// into a view. The package is specified as <scope>/<name>[@<version>].
+func (p *Packages) InstallFromMarketplace(viewName string, packageID string) error {
+	var m struct {
+		InstallPackageFromRegistry struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"installPackageFromRegistryV2(InstallPackageFromRegistryInput: {viewName: $viewName, packageId: $packageId})"`
+	}
+
+	variables := map[string]interface{}{
+		"viewName":  graphql.String(viewName),
+		"packageId": VersionedPackageSpecifier(packageID),
+	}
+
+	return p.client.Mutate(&m, variables)
+}
+
+func (p *Packages) List(viewName string) ([]InstalledPackage, error) {
+	var q struct {
+		SearchDomain struct {
+			InstalledPackages []InstalledPackage
+		} `graphql:"searchDomain(name: $viewName)"`
+	}
+
+	variables := map[string]interface{}{
+		"viewName": graphql.String(viewName),
+	}
+
+	err := p.client.Query(&q, variables)
+
+	return q.SearchDomain.InstalledPackages, err
+}
+
+// Uninstall removes a package from a view. The package is specified as <scope>/<name>.
+func (p *Packages) Uninstall(viewName string, packageID string) error {
+	var m struct {
+		UninstallPackage struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"uninstallPackage(packageId: $packageId, viewName: $viewName)"`
+	}
+
+	variables := map[string]interface{}{
+		"viewName":  graphql.String(viewName),
+		"packageId": UnversionedPackageSpecifier(packageID),
+	}
+
+	return p.client.Mutate(&m, variables)
+}
+
+// VersionedPackageSpecifier is a package id including the version, e.g. humio/nginx@1.0.0.
+type VersionedPackageSpecifier string
+
+// UnversionedPackageSpecifier is a package id without the version, e.g. humio/nginx.
+type UnversionedPackageSpecifier string
@@ -0,0 +1,28 @@
+package api
+
+// DefaultPageSize is the number of items requested per page when paginating list queries.
+const DefaultPageSize = 100
+
+// fetchPages calls fetch for consecutive pages, starting from page 1, until
+// at least limit items have been fetched or there are no more pages. A limit
+// of 0 fetches all pages. fetch returns the number of items on the page and
+// the total number of items, and fetchPages returns the total.
+func fetchPages(limit int, fetch func(pageNumber, pageSize int) (int, int, error)) (int, error) {
+	fetched, total := 0, 0
+
+	for pageNumber := 1; limit == 0 || fetched < limit; pageNumber++ {
+		n, t, err := fetch(pageNumber, DefaultPageSize)
+		if err != nil {
+			return total, err
+		}
+
+		fetched += n
+		total = t
+
+		if n < DefaultPageSize || fetched >= total {
+			break
+		}
+	}
+
+	return total, nil
+}
@@ -26,6 +26,7 @@ type Parsers struct {
 func (c *Client) Parsers() *Parsers { return &Parsers{client: c} }
 
 type ParserListItem struct {
+	ID        string
 	Name      string
 	IsBuiltIn bool
 }
@@ -93,17 +94,29 @@ func (p *Parsers) Add(reposistoryName string, parser *Parser, force bool) error
 }
 
 func testCasesToStrings(parser *Parser) []graphql.String {
+	testData := parser.TestData()
 
+	result := make([]graphql.String, len(testData))
+	for i, item := range testData {
+		result[i] = graphql.String(item)
+	}
+
+	return result
+}
+
+// TestData returns the inputs of the test cases followed by the lines of the
+// example, which is the test data stored with the parser in Humio.
+func (parser *Parser) TestData() []string {
 	lines := strings.Split(parser.Example, "\n")
 
-	result := make([]graphql.String, 0)
+	result := make([]string, 0)
 	for _, item := range parser.Tests {
-		result = append(result, graphql.String(item.Input))
+		result = append(result, item.Input)
 	}
 
 	for i, item := range lines {
 		if i != len(lines)-1 {
-			result = append(result, graphql.String(item))
+			result = append(result, item)
 		}
 	}
 
@@ -157,3 +170,50 @@ func toTestCase(line string) ParserTestCase {
 		Output: map[string]string{},
 	}
 }
+
+// Test runs the parser script on the inputs of the parser's test cases and
+// returns the fields of the resulting events, one per test case.
+func (p *Parsers) Test(reposistoryName string, parser *Parser) ([]map[string]string, error) {
+	var mutation struct {
+		TestParser struct {
+			Events []struct {
+				Fields []struct {
+					FieldName string
+					Value     string
+				}
+			}
+		} `graphql:"testParser(input: { repositoryName: $repositoryName, parserName: $name, sourceCode: $sourceCode, tagFields: $tagFields, testData: $testData })"`
+	}
+
+	tagFieldsGQL := make([]graphql.String, len(parser.TagFields))
+	for i, field := range parser.TagFields {
+		tagFieldsGQL[i] = graphql.String(field)
+	}
+
+	testData := make([]graphql.String, len(parser.Tests))
+	for i, test := range parser.Tests {
+		testData[i] = graphql.String(test.Input)
+	}
+
+	variables := map[string]interface{}{
+		"repositoryName": graphql.String(reposistoryName),
+		"name":           graphql.String(parser.Name),
+		"sourceCode":     graphql.String(parser.Script),
+		"tagFields":      tagFieldsGQL,
+		"testData":       testData,
+	}
+
+	if err := p.client.Mutate(&mutation, variables); err != nil {
+		return nil, err
+	}
+
+	events := make([]map[string]string, len(mutation.TestParser.Events))
+	for i, event := range mutation.TestParser.Events {
+		events[i] = map[string]string{}
+		for _, field := range event.Fields {
+			events[i][field.FieldName] = field.Value
+		}
+	}
+
+	return events, nil
+}
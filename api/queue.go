@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RequestPriority decides which requests waiting in the request queue of a
+// Client are sent first.
+type RequestPriority int
+
+const (
+	// PriorityInteractive is the priority of requests made on behalf of a
+	// user waiting for the result. It is the default.
+	PriorityInteractive RequestPriority = iota
+	// PriorityBulk is the priority of requests made by commands changing or
+	// exporting many things at once, e.g. syncs. They are only sent when no
+	// interactive requests are waiting.
+	PriorityBulk
+)
+
+// requestQueue limits the number of requests in flight. When the limit is
+// reached, requests wait in the order they were made, with interactive
+// requests ahead of bulk requests.
+type requestQueue struct {
+	// max is the number of requests that can be in flight, or 0 for no
+	// limit.
+	max int
+
+	mu      sync.Mutex
+	active  int
+	waiting [PriorityBulk + 1][]chan struct{}
+}
+
+// acquire blocks until the request may be sent, or ctx is cancelled. Each
+// successful call must be followed by a call to release.
+func (q *requestQueue) acquire(ctx context.Context, priority RequestPriority) error {
+	if q.max <= 0 {
+		return nil
+	}
+
+	q.mu.Lock()
+	if q.active < q.max && q.waitingCount() == 0 {
+		q.active++
+		q.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	q.waiting[priority] = append(q.waiting[priority], ready)
+	q.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for i, c := range q.waiting[priority] {
+			if c == ready {
+				q.waiting[priority] = append(q.waiting[priority][:i], q.waiting[priority][i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// The slot was handed over while giving up, so pass it on.
+		q.releaseLocked()
+		return ctx.Err()
+	}
+}
+
+func (q *requestQueue) release() {
+	if q.max <= 0 {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.releaseLocked()
+}
+
+// releaseLocked hands the slot of a finished request to the next waiting
+// request, if any.
+func (q *requestQueue) releaseLocked() {
+	for priority := range q.waiting {
+		if len(q.waiting[priority]) > 0 {
+			next := q.waiting[priority][0]
+			q.waiting[priority] = q.waiting[priority][1:]
+			close(next)
+			return
+		}
+	}
+	q.active--
+}
+
+func (q *requestQueue) waitingCount() int {
+	n := 0
+	for _, w := range q.waiting {
+		n += len(w)
+	}
+	return n
+}
+
+// endpointLimiter is the rate limiter of the requests to the paths starting
+// with prefix.
+type endpointLimiter struct {
+	prefix  string
+	limiter *rateLimiter
+}
+
+// newEndpointLimiters returns the limiters of the endpoint rate limits,
+// longest prefix first, so the most specific one applies.
+func newEndpointLimiters(limits map[string]float64) []endpointLimiter {
+	var limiters []endpointLimiter
+	for prefix, maxPerSecond := range limits {
+		if maxPerSecond <= 0 {
+			continue
+		}
+		limiters = append(limiters, endpointLimiter{
+			prefix:  strings.TrimPrefix(prefix, "/"),
+			limiter: &rateLimiter{maxPerSecond: maxPerSecond},
+		})
+	}
+	sort.Slice(limiters, func(i, j int) bool {
+		return len(limiters[i].prefix) > len(limiters[j].prefix)
+	})
+	return limiters
+}
+
+// endpointLimiterFor returns the limiter of the endpoint rate limit that
+// applies to path, which is relative to the address of the client.
+func endpointLimiterFor(limiters []endpointLimiter, path string) *rateLimiter {
+	for _, l := range limiters {
+		if strings.HasPrefix(path, l.prefix) {
+			return l.limiter
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxRateLimitRetries is how many times a request is retried when the
+	// server responds with 429 Too Many Requests.
+	maxRateLimitRetries = 5
+
+	// maxRateLimitBackoff is the longest time waited before a retry when the
+	// server does not say how long to wait.
+	maxRateLimitBackoff = 30 * time.Second
+)
+
+// rateLimiter spaces out requests to at most maxPerSecond, and pauses all
+// requests when the server has asked the client to back off. It is shared
+// by all requests made by a Client, so e.g. ingest slows down as a whole.
+type rateLimiter struct {
+	maxPerSecond float64
+
+	// retries counts the requests retried after 429 Too Many Requests.
+	retries int64
+
+	mu          sync.Mutex
+	next        time.Time
+	pausedUntil time.Time
+}
+
+// wait blocks until the request may be sent, or ctx is cancelled.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := now
+	if l.pausedUntil.After(at) {
+		at = l.pausedUntil
+	}
+	if l.maxPerSecond > 0 {
+		if l.next.After(at) {
+			at = l.next
+		}
+		l.next = at.Add(time.Duration(float64(time.Second) / l.maxPerSecond))
+	}
+	l.mu.Unlock()
+
+	return sleepContext(ctx, at.Sub(now))
+}
+
+// pause makes requests wait for d before being sent.
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimitTransport queues requests, applies the rate limiters to them and
+// retries requests that were rejected with 429 Too Many Requests.
+type rateLimitTransport struct {
+	limiter  *rateLimiter
+	queue    *requestQueue
+	priority RequestPriority
+	// endpoint returns the rate limiter of the endpoint of a request, or
+	// nil if it has none.
+	endpoint func(*http.Request) *rateLimiter
+	base     http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The request holds its place in the queue until the response headers
+	// are received, including while waiting to be retried.
+	if err := t.queue.acquire(req.Context(), t.priority); err != nil {
+		return nil, err
+	}
+	defer t.queue.release()
+
+	endpointLimiter := t.endpoint(req)
+
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.wait(req.Context()); err != nil {
+			return nil, err
+		}
+		if endpointLimiter != nil {
+			if err := endpointLimiter.wait(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt == maxRateLimitRetries {
+			return resp, err
+		}
+
+		// The body has been consumed, so the request can only be retried
+		// if it can be recreated.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		t.limiter.pause(retryAfter(resp, attempt))
+		atomic.AddInt64(&t.limiter.retries, 1)
+		countRetry(req.Context())
+		resp.Body.Close()
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryAfter returns how long to wait before retrying, as given by the
+// Retry-After header, or an exponential backoff if the header is missing.
+func retryAfter(resp *http.Response, attempt int) time.Duration {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+	}
+
+	backoff := time.Second << uint(attempt)
+	if backoff > maxRateLimitBackoff {
+		backoff = maxRateLimitBackoff
+	}
+	return backoff
+}
@@ -34,25 +34,61 @@ func (r *Repositories) Get(name string) (Repository, error) {
 
 	if graphqlErr != nil {
 		// The graphql error message is vague if the repo already exists, so add a hint.
-		return q.Repository, fmt.Errorf("%+v. Does the repo already exist?", graphqlErr)
+		return q.Repository, fmt.Errorf("%w. Does the repo already exist?", graphqlErr)
 	}
 
 	return q.Repository, nil
 }
 
 type RepoListItem struct {
-	Name      string
-	SpaceUsed int64 `graphql:"compressedByteSize"`
+	Name          string
+	Description   string
+	SpaceUsed     int64   `graphql:"compressedByteSize"`
+	IngestedBytes int64   `graphql:"uncompressedByteSize"`
+	RetentionDays float64 `graphql:"timeBasedRetention"`
 }
 
 func (r *Repositories) List() ([]RepoListItem, error) {
-	var q struct {
-		Repositories []RepoListItem `graphql:"repositories"`
-	}
+	repos, _, err := r.ListLimit(0)
+	return repos, err
+}
 
-	graphqlErr := r.client.Query(&q, nil)
+// ListLimit returns at most limit repositories, fetching as many pages as
+// needed, and the total number of repositories. A limit of 0 returns all
+// repositories.
+func (r *Repositories) ListLimit(limit int) ([]RepoListItem, int, error) {
+	var repos []RepoListItem
+
+	total, err := fetchPages(limit, func(pageNumber, pageSize int) (int, int, error) {
+		var q struct {
+			SearchDomainsPage struct {
+				TotalResults int
+				Results      []struct {
+					Repository RepoListItem `graphql:"... on Repository"`
+				}
+			} `graphql:"searchDomainsPage(searchDomainType: Repository, pageNumber: $pageNumber, pageSize: $pageSize)"`
+		}
 
-	return q.Repositories, graphqlErr
+		variables := map[string]interface{}{
+			"pageNumber": graphql.Int(pageNumber),
+			"pageSize":   graphql.Int(pageSize),
+		}
+
+		if err := r.client.Query(&q, variables); err != nil {
+			return 0, 0, err
+		}
+
+		for _, result := range q.SearchDomainsPage.Results {
+			repos = append(repos, result.Repository)
+		}
+		return len(q.SearchDomainsPage.Results), q.SearchDomainsPage.TotalResults, nil
+	})
+
+	if limit > 0 && len(repos) > limit {
+		repos = repos[:limit]
+	}
+
+	return repos, total, err
 }
 
 func (r *Repositories) Create(name string) error {
@@ -70,7 +106,7 @@ func (r *Repositories) Create(name string) error {
 
 	if graphqlErr != nil {
 		// The graphql error message is vague if the repo already exists, so add a hint.
-		return fmt.Errorf("%+v. Does the repo already exist?", graphqlErr)
+		return fmt.Errorf("%w. Does the repo already exist?", graphqlErr)
 	}
 
 	return nil
@@ -224,3 +260,515 @@ func (r *Repositories) UpdateDescription(name, description string) error {
 
 	return nil
 }
+
+type RepositoryStats struct {
+	CompressedByteSize   int64
+	UncompressedByteSize int64
+	SegmentCount         int
+}
+
+// Stats returns the current storage usage of a repository.
+func (r *Repositories) Stats(name string) (RepositoryStats, error) {
+	var q struct {
+		Repository struct {
+			CompressedByteSize   int64
+			UncompressedByteSize int64
+			Datasources          []struct {
+				Segments []struct {
+					ID string `graphql:"id"`
+				}
+			}
+		} `graphql:"repository(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	graphqlErr := r.client.Query(&q, variables)
+
+	stats := RepositoryStats{
+		CompressedByteSize:   q.Repository.CompressedByteSize,
+		UncompressedByteSize: q.Repository.UncompressedByteSize,
+	}
+	for _, ds := range q.Repository.Datasources {
+		stats.SegmentCount += len(ds.Segments)
+	}
+
+	return stats, graphqlErr
+}
+
+// IngestStatistics is the amount of data ingested into a repository during a
+// day, as accounted by the server.
+type IngestStatistics struct {
+	// Start is the start of the day in milliseconds since the epoch.
+	Start       int64
+	IngestBytes int64
+	Events      int64
+}
+
+// IngestStatistics returns the daily ingest statistics of a repository for
+// the last days, oldest first. Unlike searching the repository, these include
+// data that has since been deleted by retention.
+func (r *Repositories) IngestStatistics(name string, days int) ([]IngestStatistics, error) {
+	var q struct {
+		Repository struct {
+			IngestStatistics []IngestStatistics `graphql:"ingestStatistics(days: $days)"`
+		} `graphql:"repository(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+		"days": graphql.Int(days),
+	}
+
+	if err := r.client.Query(&q, variables); err != nil {
+		return nil, err
+	}
+
+	return q.Repository.IngestStatistics, nil
+}
+
+// Segment is a segment file of a datasource in a repository.
+type Segment struct {
+	ID          string
+	Datasource  string
+	Start       int64
+	End         int64
+	CurrentSize int64
+	// Hosts are the IDs of the nodes that hold a copy of the segment.
+	Hosts []int
+}
+
+// Segments returns the segments of all datasources of a repository.
+func (r *Repositories) Segments(name string) ([]Segment, error) {
+	var q struct {
+		Repository struct {
+			Datasources []struct {
+				ID       string `graphql:"id"`
+				Segments []struct {
+					ID          string `graphql:"id"`
+					Start       int64
+					End         int64
+					CurrentSize int64
+					OwnerHosts  []int
+				}
+			}
+		} `graphql:"repository(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	if err := r.client.Query(&q, variables); err != nil {
+		return nil, err
+	}
+
+	var segments []Segment
+	for _, ds := range q.Repository.Datasources {
+		for _, s := range ds.Segments {
+			segments = append(segments, Segment{
+				ID:          s.ID,
+				Datasource:  ds.ID,
+				Start:       s.Start,
+				End:         s.End,
+				CurrentSize: s.CurrentSize,
+				Hosts:       s.OwnerHosts,
+			})
+		}
+	}
+
+	return segments, nil
+}
+
+// Datasource is a datasource of a repository, i.e. the events with the same
+// tags.
+type Datasource struct {
+	ID   string
+	Tags map[string]string
+}
+
+// Datasources returns the datasources of a repository.
+func (r *Repositories) Datasources(name string) ([]Datasource, error) {
+	var q struct {
+		Repository struct {
+			Datasources []struct {
+				ID   string `graphql:"id"`
+				Tags []struct {
+					Key   string
+					Value string
+				}
+			}
+		} `graphql:"repository(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	if err := r.client.Query(&q, variables); err != nil {
+		return nil, err
+	}
+
+	datasources := make([]Datasource, 0, len(q.Repository.Datasources))
+	for _, ds := range q.Repository.Datasources {
+		tags := make(map[string]string, len(ds.Tags))
+		for _, t := range ds.Tags {
+			tags[t.Key] = t.Value
+		}
+		datasources = append(datasources, Datasource{ID: ds.ID, Tags: tags})
+	}
+
+	return datasources, nil
+}
+
+// S3ArchivingFormat is the format of the files archived to S3, which is also
+// used for Google Cloud Storage and Azure Blob Storage.
+type S3ArchivingFormat string
+
+const (
+	S3ArchivingFormatRaw    S3ArchivingFormat = "RAW"
+	S3ArchivingFormatNDJSON S3ArchivingFormat = "NDJSON"
+)
+
+type S3ArchivingConfiguration struct {
+	Bucket   string
+	Region   string
+	Disabled bool
+	Format   S3ArchivingFormat
+}
+
+// S3ArchivingConfiguration returns the S3 archiving configuration of a
+// repository, or nil if archiving has not been configured.
+func (r *Repositories) S3ArchivingConfiguration(name string) (*S3ArchivingConfiguration, error) {
+	var q struct {
+		Repository struct {
+			S3ArchivingConfiguration *S3ArchivingConfiguration
+		} `graphql:"repository(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	graphqlErr := r.client.Query(&q, variables)
+
+	return q.Repository.S3ArchivingConfiguration, graphqlErr
+}
+
+// ConfigureS3Archiving sets the bucket that the data of a repository is
+// archived to. Archiving is enabled by configuring it.
+func (r *Repositories) ConfigureS3Archiving(name, bucket, region string, format S3ArchivingFormat) error {
+	var m struct {
+		S3ConfigureArchiving struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"s3ConfigureArchiving(repositoryName: $name, bucket: $bucket, region: $region, format: $format)"`
+	}
+
+	variables := map[string]interface{}{
+		"name":   graphql.String(name),
+		"bucket": graphql.String(bucket),
+		"region": graphql.String(region),
+		"format": format,
+	}
+
+	return r.client.Mutate(&m, variables)
+}
+
+// EnableS3Archiving resumes archiving of a repository that has been
+// configured before.
+func (r *Repositories) EnableS3Archiving(name string) error {
+	var m struct {
+		S3EnableArchiving struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"s3EnableArchiving(repositoryName: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	return r.client.Mutate(&m, variables)
+}
+
+// DisableS3Archiving stops archiving of a repository, keeping its
+// configuration.
+func (r *Repositories) DisableS3Archiving(name string) error {
+	var m struct {
+		S3DisableArchiving struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"s3DisableArchiving(repositoryName: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	return r.client.Mutate(&m, variables)
+}
+
+// GCSArchivingConfiguration is the configuration of archiving to a Google
+// Cloud Storage bucket. The files have the same formats as with S3.
+type GCSArchivingConfiguration struct {
+	Bucket   string
+	Disabled bool
+	Format   S3ArchivingFormat
+}
+
+// GCSArchivingConfiguration returns the Google Cloud Storage archiving
+// configuration of a repository, or nil if it has not been configured.
+func (r *Repositories) GCSArchivingConfiguration(name string) (*GCSArchivingConfiguration, error) {
+	var q struct {
+		Repository struct {
+			GCSArchivingConfiguration *GCSArchivingConfiguration `graphql:"gcsArchivingConfiguration"`
+		} `graphql:"repository(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	graphqlErr := r.client.Query(&q, variables)
+
+	return q.Repository.GCSArchivingConfiguration, graphqlErr
+}
+
+// ConfigureGCSArchiving sets the Google Cloud Storage bucket that the data
+// of a repository is archived to. serviceAccountKey is the JSON key of the
+// service account to write to the bucket with. If it is empty, the default
+// credentials of the Humio cluster are used.
+func (r *Repositories) ConfigureGCSArchiving(name, bucket, serviceAccountKey string, format S3ArchivingFormat) error {
+	var m struct {
+		GCSConfigureArchiving struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"gcsConfigureArchiving(repositoryName: $name, bucket: $bucket, format: $format, serviceAccountKey: $serviceAccountKey)"`
+	}
+
+	variables := map[string]interface{}{
+		"name":              graphql.String(name),
+		"bucket":            graphql.String(bucket),
+		"format":            format,
+		"serviceAccountKey": optStringArg(nonEmpty(serviceAccountKey)),
+	}
+
+	return r.client.Mutate(&m, variables)
+}
+
+// EnableGCSArchiving resumes archiving of a repository to Google Cloud
+// Storage.
+func (r *Repositories) EnableGCSArchiving(name string) error {
+	var m struct {
+		GCSEnableArchiving struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"gcsEnableArchiving(repositoryName: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	return r.client.Mutate(&m, variables)
+}
+
+// DisableGCSArchiving stops archiving of a repository to Google Cloud
+// Storage, keeping its configuration.
+func (r *Repositories) DisableGCSArchiving(name string) error {
+	var m struct {
+		GCSDisableArchiving struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"gcsDisableArchiving(repositoryName: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	return r.client.Mutate(&m, variables)
+}
+
+// AzureArchivingConfiguration is the configuration of archiving to an Azure
+// Blob Storage container. The files have the same formats as with S3.
+type AzureArchivingConfiguration struct {
+	StorageAccountName string
+	ContainerName      string
+	// AuthenticationType is how Humio authenticates to the storage account,
+	// e.g. with an account key, a SAS token or the managed identity of the
+	// cluster.
+	AuthenticationType string
+	Disabled           bool
+	Format             S3ArchivingFormat
+}
+
+// AzureArchivingCredentials are the credentials Humio uses to write to the
+// storage account. At most one of them may be set. If none is set, the
+// managed identity of the Humio cluster is used.
+type AzureArchivingCredentials struct {
+	AccountKey string
+	SASToken   string
+}
+
+// AzureArchivingConfiguration returns the Azure Blob Storage archiving
+// configuration of a repository, or nil if it has not been configured.
+func (r *Repositories) AzureArchivingConfiguration(name string) (*AzureArchivingConfiguration, error) {
+	var q struct {
+		Repository struct {
+			AzureArchivingConfiguration *AzureArchivingConfiguration
+		} `graphql:"repository(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	graphqlErr := r.client.Query(&q, variables)
+
+	return q.Repository.AzureArchivingConfiguration, graphqlErr
+}
+
+// ConfigureAzureArchiving sets the Azure Blob Storage container that the
+// data of a repository is archived to.
+func (r *Repositories) ConfigureAzureArchiving(name, storageAccount, container string, credentials AzureArchivingCredentials, format S3ArchivingFormat) error {
+	var m struct {
+		AzureConfigureArchiving struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"azureConfigureArchiving(repositoryName: $name, storageAccountName: $storageAccountName, containerName: $containerName, format: $format, accountKey: $accountKey, sasToken: $sasToken)"`
+	}
+
+	variables := map[string]interface{}{
+		"name":               graphql.String(name),
+		"storageAccountName": graphql.String(storageAccount),
+		"containerName":      graphql.String(container),
+		"format":             format,
+		"accountKey":         optStringArg(nonEmpty(credentials.AccountKey)),
+		"sasToken":           optStringArg(nonEmpty(credentials.SASToken)),
+	}
+
+	return r.client.Mutate(&m, variables)
+}
+
+// EnableAzureArchiving resumes archiving of a repository to Azure Blob
+// Storage.
+func (r *Repositories) EnableAzureArchiving(name string) error {
+	var m struct {
+		AzureEnableArchiving struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"azureEnableArchiving(repositoryName: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	return r.client.Mutate(&m, variables)
+}
+
+// DisableAzureArchiving stops archiving of a repository to Azure Blob
+// Storage, keeping its configuration.
+func (r *Repositories) DisableAzureArchiving(name string) error {
+	var m struct {
+		AzureDisableArchiving struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"azureDisableArchiving(repositoryName: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	return r.client.Mutate(&m, variables)
+}
+
+// SetDefaultParser sets the parser used for data ingested into the
+// repository without a parser, e.g. with an ingest token that has none.
+func (r *Repositories) SetDefaultParser(name, parserName string) error {
+	var m struct {
+		SetDefaultParser struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"setDefaultParserForRepository(repositoryName: $name, parserName: $parserName)"`
+	}
+
+	variables := map[string]interface{}{
+		"name":       graphql.String(name),
+		"parserName": graphql.String(parserName),
+	}
+
+	return r.client.Mutate(&m, variables)
+}
+
+// DefaultParser returns the name of the default parser of the repository,
+// or an empty string if it has none.
+func (r *Repositories) DefaultParser(name string) (string, error) {
+	var q struct {
+		Repository struct {
+			DefaultParser *struct {
+				Name string
+			}
+		} `graphql:"repository(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	if err := r.client.Query(&q, variables); err != nil {
+		return "", err
+	}
+
+	if q.Repository.DefaultParser == nil {
+		return "", nil
+	}
+	return q.Repository.DefaultParser.Name, nil
+}
+
+// TagGrouping groups the values of a tag into a fixed number of buckets,
+// to limit the number of datasources created for tags with many values.
+type TagGrouping struct {
+	TagName string `yaml:"tagName" json:"tagName"`
+	Modulus int    `yaml:"modulus" json:"modulus"`
+}
+
+// TagGroupingRuleInput is the GraphQL input type of a tag grouping.
+type TagGroupingRuleInput struct {
+	TagName graphql.String `json:"tagName"`
+	Modulus graphql.Int    `json:"modulus"`
+}
+
+// TagGroupings returns the tag groupings of a repository.
+func (r *Repositories) TagGroupings(name string) ([]TagGrouping, error) {
+	var q struct {
+		Repository struct {
+			TagGroupings []TagGrouping
+		} `graphql:"repository(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	graphqlErr := r.client.Query(&q, variables)
+
+	return q.Repository.TagGroupings, graphqlErr
+}
+
+// SetTagGroupings replaces the tag groupings of a repository. An empty list
+// removes all tag groupings.
+func (r *Repositories) SetTagGroupings(name string, groupings []TagGrouping) error {
+	var m struct {
+		SetTagGroupings struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"setTagGroupings(repositoryName: $name, tagGroupings: $tagGroupings)"`
+	}
+
+	inputs := make([]TagGroupingRuleInput, len(groupings))
+	for i, g := range groupings {
+		inputs[i] = TagGroupingRuleInput{TagName: graphql.String(g.TagName), Modulus: graphql.Int(g.Modulus)}
+	}
+
+	variables := map[string]interface{}{
+		"name":         graphql.String(name),
+		"tagGroupings": inputs,
+	}
+
+	return r.client.Mutate(&m, variables)
+}
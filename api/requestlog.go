@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestLog describes an HTTP request made by the client, as passed to
+// Config.RequestLogger. It holds no credentials, query parameters or
+// request bodies.
+type RequestLog struct {
+	Method string
+	Path   string
+	// Operation is the type and first field of GraphQL requests, e.g.
+	// "mutation createParser", and empty for other requests.
+	Operation  string
+	StatusCode int
+	Duration   time.Duration
+	// Retries is the number of times the request was retried because the
+	// server responded with 429 Too Many Requests. Duration includes the
+	// time spent waiting to retry.
+	Retries int
+	Err     error
+}
+
+// retryCountKey is the context key of the number of times a request has been
+// retried, counted by rateLimitTransport for requestLogTransport.
+type retryCountKey struct{}
+
+// countRetry counts a retry of the request made with ctx, if it is logged.
+func countRetry(ctx context.Context) {
+	if retries, ok := ctx.Value(retryCountKey{}).(*int); ok {
+		*retries++
+	}
+}
+
+// requestLogTransport calls logger after each request made through base.
+type requestLogTransport struct {
+	logger func(RequestLog)
+	base   http.RoundTripper
+}
+
+func (t *requestLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	entry := RequestLog{Method: req.Method, Path: req.URL.Path}
+	if strings.HasSuffix(req.URL.Path, "/graphql") && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			var payload struct {
+				Query string `json:"query"`
+			}
+			if content, err := ioutil.ReadAll(body); err == nil && json.Unmarshal(content, &payload) == nil {
+				entry.Operation = graphQLOperation(payload.Query)
+			}
+			body.Close()
+		}
+	}
+
+	retries := new(int)
+	req = req.WithContext(context.WithValue(req.Context(), retryCountKey{}, retries))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	entry.Duration = time.Since(start)
+	entry.Retries = *retries
+	entry.Err = err
+	if resp != nil {
+		entry.StatusCode = resp.StatusCode
+	}
+	t.logger(entry)
+
+	return resp, err
+}
+
+// graphQLOperation returns the type and first field of a GraphQL document,
+// e.g. "mutation createParser".
+func graphQLOperation(query string) string {
+	query = strings.TrimSpace(query)
+
+	operation := "query"
+	for _, t := range []string{"mutation", "subscription"} {
+		if strings.HasPrefix(query, t) {
+			operation = t
+		}
+	}
+
+	i := strings.Index(query, "{")
+	if i < 0 {
+		return operation
+	}
+	field := strings.TrimSpace(query[i+1:])
+	end := strings.IndexFunc(field, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	if end >= 0 {
+		field = field[:end]
+	}
+
+	return strings.TrimSpace(operation + " " + field)
+}
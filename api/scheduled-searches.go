@@ -0,0 +1,38 @@
+package api
+
+import "github.com/shurcooL/graphql"
+
+type ScheduledSearches struct {
+	client *Client
+}
+
+// ScheduledSearchStatus is the state of a scheduled search.
+type ScheduledSearchStatus struct {
+	ID      string
+	Name    string
+	Enabled bool
+	// LastError is the error from the last time the search ran, or nil if
+	// it ran without errors.
+	LastError *string
+}
+
+func (c *Client) ScheduledSearches() *ScheduledSearches { return &ScheduledSearches{client: c} }
+
+// ListStatus returns the state of each scheduled search in the view.
+func (s *ScheduledSearches) ListStatus(viewName string) ([]ScheduledSearchStatus, error) {
+	var q struct {
+		SearchDomain struct {
+			ScheduledSearches []ScheduledSearchStatus
+		} `graphql:"searchDomain(name: $viewName)"`
+	}
+
+	variables := map[string]interface{}{
+		"viewName": graphql.String(viewName),
+	}
+
+	if err := s.client.Query(&q, variables); err != nil {
+		return nil, err
+	}
+
+	return q.SearchDomain.ScheduledSearches, nil
+}
@@ -5,9 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
+
+	"github.com/shurcooL/graphql"
 )
 
 type QueryJobs struct {
@@ -26,6 +30,27 @@ type Query struct {
 	ShowQueryEventDistribution bool              `json:"showQueryEventDistribution,omitempty"`
 }
 
+// MarshalJSON sends Start and End as numbers if they are absolute times in
+// milliseconds since the epoch, as Humio only accepts relative times as strings.
+func (q Query) MarshalJSON() ([]byte, error) {
+	type query Query
+	return json.Marshal(struct {
+		query
+		Start interface{} `json:"start,omitempty"`
+		End   interface{} `json:"end,omitempty"`
+	}{query(q), queryTime(q.Start), queryTime(q.End)})
+}
+
+func queryTime(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms
+	}
+	return s
+}
+
 type QueryResultMetadata struct {
 	EventCount       uint64                 `json:"eventCount"`
 	ExtraData        map[string]interface{} `json:"extraData"`
@@ -58,6 +83,11 @@ func (e QueryError) Error() string {
 }
 
 func (q QueryJobs) Create(repository string, query Query) (string, error) {
+	return q.CreateContext(context.Background(), repository, query)
+}
+
+// CreateContext is like Create, but the request is aborted if ctx is cancelled.
+func (q QueryJobs) CreateContext(ctx context.Context, repository string, query Query) (string, error) {
 	var buf bytes.Buffer
 	err := json.NewEncoder(&buf).Encode(query)
 
@@ -65,7 +95,7 @@ func (q QueryJobs) Create(repository string, query Query) (string, error) {
 		return "", err
 	}
 
-	resp, err := q.client.HTTPRequest(http.MethodPost, "api/v1/repositories/"+url.QueryEscape(repository)+"/queryjobs", &buf)
+	resp, err := q.client.HTTPRequestContext(ctx, http.MethodPost, "api/v1/repositories/"+url.QueryEscape(repository)+"/queryjobs", &buf)
 
 	if err != nil {
 		return "", err
@@ -80,7 +110,7 @@ func (q QueryJobs) Create(repository string, query Query) (string, error) {
 		return "", QueryError{string(body)}
 	case http.StatusOK:
 	default:
-		return "", fmt.Errorf("could not create query job, got status code %d", resp.StatusCode)
+		return "", &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("could not create query job, got status code %d", resp.StatusCode)}
 	}
 
 	var jsonResponse struct {
@@ -108,7 +138,7 @@ func (q *QueryJobs) PollContext(ctx context.Context, repository string, id strin
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return QueryResult{}, fmt.Errorf("error polling query job, got status code %d", resp.StatusCode)
+		return QueryResult{}, &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("error polling query job, got status code %d", resp.StatusCode)}
 	}
 
 	var result QueryResult
@@ -118,7 +148,95 @@ func (q *QueryJobs) PollContext(ctx context.Context, repository string, id strin
 	return result, err
 }
 
+// Delete stops a query job on the server. It is not given a context, and
+// does not use the context of the client, as it is usually called to clean
+// up after the context of the query was cancelled or timed out.
 func (q *QueryJobs) Delete(repository string, id string) error {
-	_, err := q.client.HTTPRequest(http.MethodDelete, "api/v1/repositories/"+url.QueryEscape(repository)+"/queryjobs/"+id, bytes.NewBuffer(nil))
+	_, err := q.client.HTTPRequestContext(context.Background(), http.MethodDelete, "api/v1/repositories/"+url.QueryEscape(repository)+"/queryjobs/"+id, bytes.NewBuffer(nil))
 	return err
 }
+
+// Cancel stops a query job running in a view, e.g. one found with ListRunning.
+func (q *QueryJobs) Cancel(view string, id string) error {
+	resp, err := q.client.HTTPRequest(http.MethodDelete, "api/v1/repositories/"+url.QueryEscape(view)+"/queryjobs/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("error cancelling query job: %s", body)}
+	}
+	return nil
+}
+
+// RunningQuery is a query job running on the cluster, as shown in the query
+// monitor of the UI.
+type RunningQuery struct {
+	ID             string
+	View           string
+	InitiatingUser *string
+	QueryString    string `graphql:"queryInput"`
+	IsLive         bool
+	TotalWork      Long
+	WorkDone       Long
+	// Cost is the cost of the query so far, in the units of the query quota.
+	Cost float64
+	// StartedAt is the time the query started, in milliseconds since the epoch.
+	StartedAt Long
+}
+
+// ListRunning returns the query jobs running on the cluster.
+func (q *QueryJobs) ListRunning() ([]RunningQuery, error) {
+	var query struct {
+		RunningQueries struct {
+			Queries []RunningQuery
+		} `graphql:"runningQueries(searchFilter: $filter, global: true)"`
+	}
+
+	variables := map[string]interface{}{
+		"filter": graphql.String(""),
+	}
+
+	if err := q.client.Query(&query, variables); err != nil {
+		return nil, err
+	}
+
+	return query.RunningQueries.Queries, nil
+}
+
+// Stream runs the query synchronously and returns a reader of the result as
+// newline-delimited JSON, one event per line. The result is streamed by the
+// server as it is produced, so it is suitable for very large result sets.
+// The caller must close the returned reader.
+func (q *QueryJobs) Stream(ctx context.Context, repository string, query Query) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{"Accept": "application/x-ndjson"}
+	resp, err := q.client.HTTPRequestContextWithHeaders(ctx, http.MethodPost, "api/v1/repositories/"+url.QueryEscape(repository)+"/query", &buf, headers)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusBadRequest:
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, QueryError{string(body)}
+	default:
+		resp.Body.Close()
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("could not run query, got status code %d", resp.StatusCode)}
+	}
+}
@@ -26,7 +26,7 @@ func (c *Client) Status() (*StatusResponse, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("error getting server status: %s", resp.Status)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("error getting server status: %s", resp.Status)}
 	}
 
 	jsonData, err := ioutil.ReadAll(resp.Body)
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"time"
+
+	"github.com/shurcooL/graphql"
+)
+
+type Tokens struct {
+	client *Client
+}
+
+// Long is the GraphQL scalar of 64-bit integers, e.g. timestamps in
+// milliseconds since the epoch.
+type Long int64
+
+// Permission is a permission on a view or repository, e.g. ReadAccess.
+type Permission string
+
+// ViewPermissionsToken is an API token with permissions on a set of views
+// or repositories, optionally restricted by an IP filter.
+type ViewPermissionsToken struct {
+	ID       string
+	Name     string
+	ExpireAt *int64
+	IPFilter *struct {
+		Name string
+	} `graphql:"ipFilterV2"`
+	Views []struct {
+		Name string
+	}
+	Permissions []string
+}
+
+type CreateViewPermissionsTokenInput struct {
+	Name        graphql.String   `json:"name"`
+	ExpireAt    *Long            `json:"expireAt,omitempty"`
+	IPFilterID  *graphql.String  `json:"ipFilterId,omitempty"`
+	ViewIDs     []graphql.String `json:"viewIds"`
+	Permissions []Permission     `json:"permissions"`
+}
+
+type TokenIdInput struct {
+	ID graphql.String `json:"id"`
+}
+
+func (c *Client) Tokens() *Tokens { return &Tokens{client: c} }
+
+// ListViewPermissionsTokens returns the tokens with permissions on views
+// and repositories.
+func (t *Tokens) ListViewPermissionsTokens() ([]ViewPermissionsToken, error) {
+	var q struct {
+		Tokens struct {
+			Results []struct {
+				Token ViewPermissionsToken `graphql:"... on ViewPermissionsToken"`
+			}
+		} `graphql:"tokens(typeFilter: [ViewPermissionToken])"`
+	}
+
+	err := t.client.Query(&q, nil)
+
+	tokens := make([]ViewPermissionsToken, len(q.Tokens.Results))
+	for i, r := range q.Tokens.Results {
+		tokens[i] = r.Token
+	}
+	return tokens, err
+}
+
+// CreateViewPermissionsToken creates a token with the permissions on the
+// views with the given IDs, and returns the secret token. The token can
+// only be used from the addresses allowed by the IP filter, unless
+// ipFilterID is empty, and never expires if expireAt is the zero time.
+func (t *Tokens) CreateViewPermissionsToken(name string, viewIDs []string, permissions []string, ipFilterID string, expireAt time.Time) (string, error) {
+	var m struct {
+		CreateViewPermissionsToken string `graphql:"createViewPermissionsToken(input: $input)"`
+	}
+
+	input := CreateViewPermissionsTokenInput{
+		Name:       graphql.String(name),
+		IPFilterID: optStringArg(nonEmpty(ipFilterID)),
+	}
+	for _, id := range viewIDs {
+		input.ViewIDs = append(input.ViewIDs, graphql.String(id))
+	}
+	for _, p := range permissions {
+		input.Permissions = append(input.Permissions, Permission(p))
+	}
+	if !expireAt.IsZero() {
+		ms := Long(expireAt.UnixNano() / int64(time.Millisecond))
+		input.ExpireAt = &ms
+	}
+
+	variables := map[string]interface{}{
+		"input": input,
+	}
+
+	err := t.client.Mutate(&m, variables)
+
+	return m.CreateViewPermissionsToken, err
+}
+
+func (t *Tokens) Delete(id string) error {
+	var m struct {
+		DeleteToken bool `graphql:"deleteToken(input: $input)"`
+	}
+
+	variables := map[string]interface{}{
+		"input": TokenIdInput{ID: graphql.String(id)},
+	}
+
+	return t.client.Mutate(&m, variables)
+}
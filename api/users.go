@@ -29,13 +29,43 @@ type UserChangeSet struct {
 func (c *Client) Users() *Users { return &Users{client: c} }
 
 func (u *Users) List() ([]User, error) {
-	var q struct {
-		Users []User `graphql:"accounts"`
-	}
+	users, _, err := u.ListLimit(0)
+	return users, err
+}
 
-	graphqlErr := u.client.Query(&q, nil)
+// ListLimit returns at most limit users, fetching as many pages as needed, and
+// the total number of users. A limit of 0 returns all users.
+func (u *Users) ListLimit(limit int) ([]User, int, error) {
+	var users []User
+
+	total, err := fetchPages(limit, func(pageNumber, pageSize int) (int, int, error) {
+		var q struct {
+			UsersPage struct {
+				PageInfo struct {
+					TotalNumberOfRows int
+				}
+				Page []User
+			} `graphql:"usersPage(pageNumber: $pageNumber, pageSize: $pageSize)"`
+		}
+
+		variables := map[string]interface{}{
+			"pageNumber": graphql.Int(pageNumber),
+			"pageSize":   graphql.Int(pageSize),
+		}
+
+		if err := u.client.Query(&q, variables); err != nil {
+			return 0, 0, err
+		}
+
+		users = append(users, q.UsersPage.Page...)
+		return len(q.UsersPage.Page), q.UsersPage.PageInfo.TotalNumberOfRows, nil
+	})
+
+	if limit > 0 && len(users) > limit {
+		users = users[:limit]
+	}
 
-	return q.Users, graphqlErr
+	return users, total, err
 }
 
 func (u *Users) Get(username string) (User, error) {
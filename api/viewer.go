@@ -29,3 +29,15 @@ func (c *Viewer) ApiToken() (string, error) {
 	graphqlErr := c.client.Query(&query, nil)
 	return query.Viewer.ApiToken, graphqlErr
 }
+
+// OrganizationName fetches the name of the organization the API token belongs to.
+func (c *Viewer) OrganizationName() (string, error) {
+	var query struct {
+		CurrentOrganization struct {
+			Name string
+		}
+	}
+
+	graphqlErr := c.client.Query(&query, nil)
+	return query.CurrentOrganization.Name, graphqlErr
+}
@@ -13,23 +13,24 @@ type Views struct {
 
 type RolePermission struct {
 	Role struct {
-		Name string
-	}
+		Name string `json:"name"`
+	} `json:"role"`
 	View struct {
-		Name string
-	}
-	QueryPrefix string
+		Name string `json:"name"`
+	} `json:"view"`
+	QueryPrefix string `json:"queryPrefix"`
 }
 
 type ViewConnection struct {
-	RepoName string
-	Filter   string
+	RepoName string `json:"repository"`
+	Filter   string `json:"filter"`
 }
 
 type ViewQueryData struct {
-	Name     string
-	Roles    []RolePermission
-	ViewInfo struct {
+	Name        string
+	Description string
+	Roles       []RolePermission
+	ViewInfo    struct {
 		Connections []struct {
 			Repository struct{ Name string }
 			Filter     string
@@ -38,9 +39,10 @@ type ViewQueryData struct {
 }
 
 type View struct {
-	Name        string
-	Roles       []RolePermission
-	Connections []ViewConnection
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Roles       []RolePermission `json:"roles"`
+	Connections []ViewConnection `json:"connections"`
 }
 
 func (c *Client) Views() *Views { return &Views{client: c} }
@@ -71,6 +73,7 @@ func (c *Views) Get(name string) (*View, error) {
 
 	view := View{
 		Name:        q.Result.Name,
+		Description: q.Result.Description,
 		Roles:       q.Result.Roles,
 		Connections: connections,
 	}
@@ -95,3 +98,249 @@ func (c *Views) List() ([]ViewListItem, error) {
 
 	return q.View, graphqlErr
 }
+
+// ID returns the ID of the view or repository with the given name.
+func (c *Views) ID(name string) (string, error) {
+	var q struct {
+		SearchDomain struct {
+			ID string
+		} `graphql:"searchDomain(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	err := c.client.Query(&q, variables)
+
+	return q.SearchDomain.ID, err
+}
+
+type ViewConnectionInput struct {
+	RepositoryName graphql.String `json:"repositoryName"`
+	Filter         graphql.String `json:"filter"`
+}
+
+func toViewConnectionInputs(connections []ViewConnection) []ViewConnectionInput {
+	inputs := make([]ViewConnectionInput, len(connections))
+	for i, c := range connections {
+		inputs[i] = ViewConnectionInput{
+			RepositoryName: graphql.String(c.RepoName),
+			Filter:         graphql.String(c.Filter),
+		}
+	}
+	return inputs
+}
+
+func (c *Views) Create(name, description string, connections []ViewConnection) error {
+	var m struct {
+		CreateView struct {
+			Name string
+		} `graphql:"createView(name: $name, description: $description, connections: $connections)"`
+	}
+
+	variables := map[string]interface{}{
+		"name":        graphql.String(name),
+		"description": graphql.String(description),
+		"connections": toViewConnectionInputs(connections),
+	}
+
+	return c.client.Mutate(&m, variables)
+}
+
+// UpdateConnections replaces the connections of a view with the given ones.
+func (c *Views) UpdateConnections(name string, connections []ViewConnection) error {
+	var m struct {
+		UpdateViewConnections struct {
+			Name string
+		} `graphql:"updateViewConnections(viewName: $name, connections: $connections)"`
+	}
+
+	variables := map[string]interface{}{
+		"name":        graphql.String(name),
+		"connections": toViewConnectionInputs(connections),
+	}
+
+	return c.client.Mutate(&m, variables)
+}
+
+func (c *Views) UpdateDescription(name, description string) error {
+	var m struct {
+		UpdateDescription struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"updateDescriptionForSearchDomain(name: $name, newDescription: $description)"`
+	}
+
+	variables := map[string]interface{}{
+		"name":        graphql.String(name),
+		"description": graphql.String(description),
+	}
+
+	return c.client.Mutate(&m, variables)
+}
+
+// GetDefaultQuery returns the query string of the default query of a view,
+// which is shown when the view is opened. It is empty if no default query is set.
+func (c *Views) GetDefaultQuery(name string) (string, error) {
+	var q struct {
+		SearchDomain struct {
+			DefaultQuery *struct {
+				Query struct {
+					QueryString string
+				}
+			}
+		} `graphql:"searchDomain(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	if err := c.client.Query(&q, variables); err != nil {
+		return "", err
+	}
+
+	if q.SearchDomain.DefaultQuery == nil {
+		return "", nil
+	}
+	return q.SearchDomain.DefaultQuery.Query.QueryString, nil
+}
+
+// SetDefaultQuery saves the query string as a saved query in the view and
+// makes it the default query of the view.
+func (c *Views) SetDefaultQuery(name, queryString string) error {
+	var create struct {
+		CreateSavedQuery struct {
+			SavedQuery struct {
+				ID string
+			}
+		} `graphql:"createSavedQuery(input: {name: $queryName, viewName: $name, queryString: $queryString})"`
+	}
+
+	variables := map[string]interface{}{
+		"name":        graphql.String(name),
+		"queryName":   graphql.String("Default query"),
+		"queryString": graphql.String(queryString),
+	}
+
+	if err := c.client.Mutate(&create, variables); err != nil {
+		return err
+	}
+
+	var m struct {
+		SetDefaultSavedQuery struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"setDefaultSavedQuery(input: {viewName: $name, savedQueryId: $savedQueryId})"`
+	}
+
+	variables = map[string]interface{}{
+		"name":         graphql.String(name),
+		"savedQueryId": graphql.String(create.CreateSavedQuery.SavedQuery.ID),
+	}
+
+	return c.client.Mutate(&m, variables)
+}
+
+// SetRolePermission gives members of the role access to the view. Only
+// events matching the query prefix are visible to them.
+func (c *Views) SetRolePermission(name, roleName, queryPrefix string) error {
+	var m struct {
+		UpdateRoleForView struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"updateRoleForView(viewName: $name, roleName: $roleName, queryPrefix: $queryPrefix)"`
+	}
+
+	variables := map[string]interface{}{
+		"name":        graphql.String(name),
+		"roleName":    graphql.String(roleName),
+		"queryPrefix": graphql.String(queryPrefix),
+	}
+
+	return c.client.Mutate(&m, variables)
+}
+
+// ViewRoleMembers is a role given on a view, with the users who are members
+// of it directly or through a group.
+type ViewRoleMembers struct {
+	Role struct {
+		ID          string
+		Name        string
+		Permissions []string `graphql:"viewPermissions"`
+		Users       []struct {
+			Username string
+		}
+		Groups []struct {
+			ID          string
+			DisplayName string
+			Users       []struct {
+				Username string
+			}
+		}
+	}
+	QueryPrefix string
+}
+
+// RoleMembers returns the roles given on the view or repository, with their
+// permissions and members.
+func (c *Views) RoleMembers(name string) ([]ViewRoleMembers, error) {
+	var q struct {
+		SearchDomain struct {
+			Roles []ViewRoleMembers
+		} `graphql:"searchDomain(name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"name": graphql.String(name),
+	}
+
+	err := c.client.Query(&q, variables)
+
+	return q.SearchDomain.Roles, err
+}
+
+// AssignRoleToGroup gives the members of the group the permissions of the
+// role on the view. The role must have been given on the view, see
+// SetRolePermission.
+func (c *Views) AssignRoleToGroup(name, roleID, groupID string) error {
+	viewID, err := c.ID(name)
+	if err != nil {
+		return err
+	}
+
+	var m struct {
+		AssignRoleToGroup struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"assignRoleToGroup(input: {viewId: $viewId, groupId: $groupId, roleId: $roleId})"`
+	}
+
+	variables := map[string]interface{}{
+		"viewId":  graphql.String(viewID),
+		"groupId": graphql.String(groupID),
+		"roleId":  graphql.String(roleID),
+	}
+
+	return c.client.Mutate(&m, variables)
+}
+
+// UnassignRoleFromGroup takes the permissions of the role on the view from
+// the members of the group.
+func (c *Views) UnassignRoleFromGroup(name, roleID, groupID string) error {
+	viewID, err := c.ID(name)
+	if err != nil {
+		return err
+	}
+
+	var m struct {
+		UnassignRoleFromGroup struct {
+			Type string `graphql:"__typename"`
+		} `graphql:"unassignRoleFromGroup(input: {viewId: $viewId, groupId: $groupId, roleId: $roleId})"`
+	}
+
+	variables := map[string]interface{}{
+		"viewId":  graphql.String(viewID),
+		"groupId": graphql.String(groupID),
+		"roleId":  graphql.String(roleID),
+	}
+
+	return c.client.Mutate(&m, variables)
+}
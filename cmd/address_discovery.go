@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/humio/cli/api"
+)
+
+// addressProbeTimeout limits how long each address is probed for, so the
+// setup wizard does not hang on addresses that do not respond.
+const addressProbeTimeout = 5 * time.Second
+
+// addressProbe is the result of probing an address for the status endpoint
+// of Humio.
+type addressProbe struct {
+	address string
+	// version is the version of Humio responding at the address, or empty
+	// if Humio did not respond.
+	version string
+	// problem describes why Humio did not respond.
+	problem string
+	// redirect is the address the server redirects to, if any.
+	redirect string
+}
+
+// normalizeAddress turns the address entered by a user into the form
+// expected in profiles, e.g. humio.example.com into
+// https://humio.example.com/.
+func normalizeAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if !strings.Contains(address, "://") {
+		address = "https://" + address
+	}
+
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("%q is not a valid address, e.g. https://humio.example.com/", address)
+	}
+
+	u.RawQuery = ""
+	u.Fragment = ""
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u.String(), nil
+}
+
+// probeAddress checks whether Humio responds at the address. Redirects are
+// not followed: requests other than GET, e.g. to the GraphQL API, would fail
+// if they were redirected, so the address must be the one the server
+// redirects to.
+func probeAddress(address string) addressProbe {
+	probe := addressProbe{address: address}
+
+	client := &http.Client{
+		Timeout: addressProbeTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(address + "api/v1/status")
+	if err != nil {
+		probe.problem = describeProbeError(err)
+		return probe
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		location, err := resp.Location()
+		if err != nil {
+			probe.problem = fmt.Sprintf("the server redirects with %s, but without a valid location", resp.Status)
+			break
+		}
+		probe.problem = fmt.Sprintf("the server redirects to %s", location)
+		if strings.HasSuffix(location.Path, "/api/v1/status") {
+			location.Path = strings.TrimSuffix(location.Path, "api/v1/status")
+			location.RawQuery = ""
+			probe.redirect = location.String()
+		}
+	case resp.StatusCode >= 400:
+		probe.problem = fmt.Sprintf("the server responded with %s, it may not be Humio, or Humio may be served under another path", resp.Status)
+	default:
+		var status api.StatusResponse
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil || status.Version == "" {
+			probe.problem = "the server responded, but not like Humio does"
+			break
+		}
+		probe.version = status.Version
+	}
+
+	return probe
+}
+
+// describeProbeError explains the common reasons for not being able to
+// connect to an address.
+func describeProbeError(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+
+	switch {
+	case strings.Contains(err.Error(), "server gave HTTP response to HTTPS client"),
+		strings.Contains(err.Error(), "first record does not look like a TLS handshake"):
+		return "the server does not use HTTPS"
+	case errors.As(err, &unknownAuthority):
+		return "the TLS certificate of the server is not trusted"
+	case errors.As(err, &hostname):
+		return fmt.Sprintf("the TLS certificate of the server is not valid for %s", hostname.Host)
+	case errors.As(err, &dnsErr):
+		return fmt.Sprintf("the host %s could not be found", dnsErr.Name)
+	case strings.Contains(err.Error(), "connection refused"):
+		return "the connection was refused, is the port correct?"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Sprintf("the server did not respond within %s", addressProbeTimeout)
+	default:
+		return err.Error()
+	}
+}
+
+// addressCandidates returns the variations of an address Humio is commonly
+// found at when it does not respond at the address itself: the other
+// scheme, the root of the server if the address has a path, e.g. a URL
+// copied from the UI, the path Humio is often served under behind a reverse
+// proxy, and Humio Cloud for addresses like cloud.us.
+func addressCandidates(address string) []string {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil
+	}
+
+	schemes := []string{u.Scheme, "https"}
+	if u.Scheme == "https" {
+		schemes[1] = "http"
+	}
+	paths := []string{u.Path}
+	for _, path := range []string{"/", "/humio/"} {
+		if path != u.Path {
+			paths = append(paths, path)
+		}
+	}
+
+	var candidates []string
+	for _, scheme := range schemes {
+		for _, path := range paths {
+			candidate := url.URL{Scheme: scheme, Host: u.Host, Path: path}
+			candidates = append(candidates, candidate.String())
+		}
+	}
+
+	if host := u.Hostname(); strings.HasPrefix(host, "cloud") && !strings.HasSuffix(host, ".humio.com") && u.Port() == "" {
+		candidates = append(candidates, "https://"+host+".humio.com/")
+	}
+
+	return candidates
+}
+
+// discoverAddress probes the address and returns the result, together with
+// a suggestion of a better address, if any: the address the server
+// redirects to or a variation of the address Humio responds at, if Humio
+// does not respond at the address, or the HTTPS variation of an HTTP
+// address, so the API token is not sent unencrypted.
+func discoverAddress(address string) (addressProbe, *addressProbe) {
+	probe := probeAddress(address)
+	if probe.version != "" {
+		if u, err := url.Parse(address); err == nil && u.Scheme == "http" && !isLocalHost(u.Hostname()) {
+			u.Scheme = "https"
+			if secure := probeAddress(u.String()); secure.version != "" {
+				return probe, &secure
+			}
+		}
+		return probe, nil
+	}
+
+	if probe.redirect != "" {
+		if redirected := probeAddress(probe.redirect); redirected.version != "" {
+			return probe, &redirected
+		}
+	}
+
+	var candidates []string
+	for _, candidate := range addressCandidates(address) {
+		if candidate != address && candidate != probe.redirect {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	// The candidates are probed at once, but the first one in the list that
+	// works is suggested.
+	probes := make([]addressProbe, len(candidates))
+	var wg sync.WaitGroup
+	for i, candidate := range candidates {
+		wg.Add(1)
+		go func(i int, candidate string) {
+			defer wg.Done()
+			probes[i] = probeAddress(candidate)
+		}(i, candidate)
+	}
+	wg.Wait()
+
+	for _, p := range probes {
+		if p.version != "" {
+			p := p
+			return probe, &p
+		}
+	}
+	return probe, nil
+}
+
+func isLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,393 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// agentRestartMinDelay is how long a failed source waits before it is
+	// restarted the first time. The delay doubles with each failure in a
+	// row, up to agentRestartMaxDelay.
+	agentRestartMinDelay = time.Second
+	agentRestartMaxDelay = time.Minute
+)
+
+// agentConfig is the configuration file of 'humioctl agent'.
+type agentConfig struct {
+	// Address and Token default to the ones of the profile.
+	Address string `yaml:"address"`
+	Token   string `yaml:"token"`
+	// Repo is the repository of the sources that do not have their own.
+	Repo          string        `yaml:"repo"`
+	Compress      *bool         `yaml:"compress"`
+	MetricsListen string        `yaml:"metrics-listen"`
+	Sources       []agentSource `yaml:"sources"`
+}
+
+// agentSource is a source of events of the agent, sent to a repository.
+type agentSource struct {
+	Name string `yaml:"name"`
+	// Type is tail, syslog or hec.
+	Type string `yaml:"type"`
+	Repo string `yaml:"repo"`
+	// IngestToken is the token to send the events with, instead of the
+	// token of the agent.
+	IngestToken string            `yaml:"ingest-token"`
+	Parser      string            `yaml:"parser"`
+	Tags        map[string]string `yaml:"tags"`
+	Fields      map[string]string `yaml:"fields"`
+
+	// Paths and CheckpointFile apply to tail sources.
+	Paths          []string `yaml:"paths"`
+	CheckpointFile string   `yaml:"checkpoint-file"`
+	// Listen applies to syslog and hec sources.
+	Listen string `yaml:"listen"`
+	// Protocol applies to syslog sources, and is udp or tcp.
+	Protocol string `yaml:"protocol"`
+	// ListenToken applies to hec sources.
+	ListenToken string `yaml:"listen-token"`
+}
+
+// loadAgentConfig reads and checks the configuration file of the agent.
+// Unknown keys are errors, so typos are not silently ignored.
+func loadAgentConfig(file string) (agentConfig, error) {
+	var config agentConfig
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return config, err
+	}
+	if err := yaml.UnmarshalStrict(content, &config); err != nil {
+		return config, fmt.Errorf("error parsing %s: %w", file, err)
+	}
+	if err := config.validate(); err != nil {
+		return config, fmt.Errorf("invalid configuration %s: %w", file, err)
+	}
+
+	return config, nil
+}
+
+// validate checks the sources and fills in their defaults.
+func (c *agentConfig) validate() error {
+	if len(c.Sources) == 0 {
+		return errors.New("no sources are configured")
+	}
+
+	names := map[string]bool{}
+	for i := range c.Sources {
+		s := &c.Sources[i]
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("%s-%d", s.Type, i+1)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("there is more than one source named %s", s.Name)
+		}
+		names[s.Name] = true
+
+		if s.Repo == "" {
+			s.Repo = c.Repo
+		}
+		if s.Repo == "" {
+			return fmt.Errorf("source %s: no repo is given for the source or the agent", s.Name)
+		}
+
+		var unused []string
+		switch s.Type {
+		case "tail":
+			if len(s.Paths) == 0 {
+				return fmt.Errorf("source %s: paths is required for tail sources", s.Name)
+			}
+			if s.CheckpointFile != "" && (len(s.Paths) != 1 || isGlobPattern(s.Paths[0])) {
+				return fmt.Errorf("source %s: checkpoint-file can only be used with a single path that is not a pattern", s.Name)
+			}
+			if s.Listen != "" {
+				unused = append(unused, "listen")
+			}
+			if s.Protocol != "" {
+				unused = append(unused, "protocol")
+			}
+			if s.ListenToken != "" {
+				unused = append(unused, "listen-token")
+			}
+		case "syslog", "hec":
+			if s.Listen == "" {
+				return fmt.Errorf("source %s: listen is required for %s sources", s.Name, s.Type)
+			}
+			if len(s.Paths) > 0 {
+				unused = append(unused, "paths")
+			}
+			if s.CheckpointFile != "" {
+				unused = append(unused, "checkpoint-file")
+			}
+			if s.Type == "syslog" {
+				if s.Protocol == "" {
+					s.Protocol = "udp"
+				}
+				if s.Protocol != "udp" && s.Protocol != "tcp" {
+					return fmt.Errorf("source %s: protocol must be udp or tcp", s.Name)
+				}
+				if s.ListenToken != "" {
+					unused = append(unused, "listen-token")
+				}
+			} else {
+				if s.Protocol != "" {
+					unused = append(unused, "protocol")
+				}
+				if s.ListenToken == "" && !isLoopbackAddress(s.Listen) {
+					return fmt.Errorf("source %s: listen-token is required unless listen is a loopback address, e.g. localhost:8088", s.Name)
+				}
+			}
+		default:
+			return fmt.Errorf("source %s: unsupported type %q, must be one of: tail, syslog, hec", s.Name, s.Type)
+		}
+		if len(unused) > 0 {
+			return fmt.Errorf("source %s: %s cannot be used with %s sources", s.Name, strings.Join(unused, ", "), s.Type)
+		}
+
+		if s.Parser == "" {
+			// Syslog messages are rarely sent anywhere else.
+			if s.Type == "syslog" {
+				s.Parser = "syslog"
+			} else {
+				s.Parser = "default"
+			}
+		}
+	}
+
+	return nil
+}
+
+func newAgentCmd() *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "agent --file <config>",
+		Short: "Run ingest pipelines defined in a configuration file.",
+		Long: `Runs the sources of events defined in a YAML configuration file at once
+and sends their events to Humio, e.g. as a single-binary shipper on edge
+hosts. A source that fails, e.g. because its port is in use, is restarted
+after a delay, which doubles with each failure up to a minute. The agent
+runs until it is interrupted, and then sends the events already read.
+
+The sources are of the types:
+
+  tail     Tails files, like 'humioctl ingest --tail'. The paths can be glob
+           patterns, which are checked for new files.
+  syslog   Receives syslog messages on UDP or TCP. Over TCP the messages must
+           be separated by newlines. The parser defaults to syslog.
+  hec      Emulates the Splunk HTTP Event Collector and Humio's ingest API,
+           like 'humioctl ingest --listen-hec'. listen-token is required
+           unless listen is a loopback address.
+
+Each source can have its own repository, ingest token, parser, tags and
+fields. The address and token default to the ones of the profile, and the
+requests are compressed unless compress is false. With metrics-listen,
+metrics of all the sources are served in the Prometheus text format, like
+'humioctl ingest --metrics-listen':
+
+  address: https://humio.example.com/
+  repo: edge
+  metrics-listen: localhost:9464
+  sources:
+    - name: nginx
+      type: tail
+      paths: ["/var/log/nginx/*.log"]
+      parser: accesslog
+      tags: {service: nginx}
+    - name: app
+      type: tail
+      paths: [/var/log/app/server.log]
+      checkpoint-file: /var/lib/humioctl/app.checkpoint
+      fields: {env: prod}
+    - name: syslog
+      type: syslog
+      listen: ":5514"
+      protocol: tcp
+    - name: hec
+      type: hec
+      listen: ":8088"
+      listen-token: secret
+      repo: apps
+      ingest-token: 7f3e...
+
+  $ humioctl agent --file agent.yaml`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := loadAgentConfig(file)
+			exitOnError(cmd, err, "Error loading the agent configuration")
+
+			address := config.Address
+			if address == "" {
+				address = configString("address")
+			}
+			token := config.Token
+			if token == "" {
+				token = configString("token")
+			}
+
+			client, err := newApiClientForLogin(address, token)
+			exitOnError(cmd, err, "Error creating HTTP client")
+
+			var metrics *ingestMetrics
+			if config.MetricsListen != "" {
+				metrics = newIngestMetrics()
+				client = client.WithRequestLogger(metrics.observe)
+			}
+
+			// All sources are counted together, and the progress is only
+			// summarized when the agent stops.
+			progress := newIngestProgress(client, 0, false)
+			progress.show = false
+
+			ctx := contextCancelledOnInterrupt(commandContext)
+
+			if metrics != nil {
+				metrics.progress = progress
+				exitOnError(cmd, metrics.serve(ctx, config.MetricsListen, false), "Error serving metrics")
+			}
+
+			runs := make([]func(context.Context) error, len(config.Sources))
+			for i, source := range config.Sources {
+				sourceClient := client
+				if source.IngestToken != "" {
+					sourceClient, err = newApiClientForLogin(address, source.IngestToken)
+					exitOnError(cmd, err, "Error creating HTTP client")
+					if metrics != nil {
+						sourceClient = sourceClient.WithRequestLogger(metrics.observe)
+					}
+				}
+
+				var compression *ingestCompression
+				if config.Compress == nil || *config.Compress {
+					compression = &ingestCompression{}
+				}
+
+				runs[i] = agentSourceRunner(source, sourceClient, compression, progress)
+			}
+
+			progress.start()
+
+			var wg sync.WaitGroup
+			for i, source := range config.Sources {
+				wg.Add(1)
+				go func(name string, run func(context.Context) error) {
+					defer wg.Done()
+					superviseAgentSource(ctx, name, run)
+				}(source.Name, runs[i])
+			}
+			log.Printf("Running %d sources, interrupt to stop", len(config.Sources))
+			wg.Wait()
+
+			progress.stop()
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "The YAML configuration file of the agent.")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+// agentSourceRunner returns a function running the source until ctx is
+// cancelled or the source fails.
+func agentSourceRunner(source agentSource, client *api.Client, compression *ingestCompression, progress *ingestProgress) func(context.Context) error {
+	newSend := func(fields map[string]string) func(batch []string) error {
+		return progress.count(func(batch []string) error {
+			return sendBatch(client, source.Repo, batch, fields, source.Tags, source.Parser, compression)
+		})
+	}
+
+	switch source.Type {
+	case "tail":
+		return func(ctx context.Context) error {
+			var checkpoint *ingestCheckpoint
+			if source.CheckpointFile != "" {
+				var err error
+				checkpoint, err = loadCheckpoint(source.CheckpointFile, source.Paths[0])
+				if err != nil {
+					return fmt.Errorf("error loading checkpoint: %v", err)
+				}
+			}
+
+			return tailFiles(ctx, source.Paths, true, func(file string) *ingestSender {
+				fields := map[string]string{"@source": file}
+				for k, v := range source.Fields {
+					fields[k] = v
+				}
+
+				return startSendingWithCheckpoint(newSend(fields), checkpoint)
+			}, checkpoint)
+		}
+	case "syslog":
+		return func(ctx context.Context) error {
+			fields := map[string]string{}
+			for k, v := range source.Fields {
+				fields[k] = v
+			}
+
+			sender := startSending(newSend(fields))
+			defer sender.stop()
+			return listenSyslog(ctx, source.Protocol, source.Listen, sender)
+		}
+	default:
+		listener := &hecListener{client: client, repo: source.Repo, fields: source.Fields, tags: source.Tags, parser: source.Parser, token: source.ListenToken, quiet: true, progress: progress}
+		return func(ctx context.Context) error {
+			return listener.listen(ctx, source.Listen)
+		}
+	}
+}
+
+// superviseAgentSource runs a source until ctx is cancelled, restarting it
+// when it fails. The delay before restarting is reset once the source has
+// run for longer than the longest delay.
+func superviseAgentSource(ctx context.Context, name string, run func(context.Context) error) {
+	delay := agentRestartMinDelay
+	for {
+		started := time.Now()
+		err := run(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = errors.New("stopped unexpectedly")
+		}
+
+		if time.Since(started) > agentRestartMaxDelay {
+			delay = agentRestartMinDelay
+		}
+		log.Printf("Source %s failed: %v, restarting in %s", name, err, delay)
+		if sleepUnlessDone(ctx, delay) != nil {
+			return
+		}
+
+		delay *= 2
+		if delay > agentRestartMaxDelay {
+			delay = agentRestartMaxDelay
+		}
+	}
+}
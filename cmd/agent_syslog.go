@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+)
+
+// syslogMaxMessageSize is the largest syslog message received, the largest
+// UDP datagram.
+const syslogMaxMessageSize = 64 * 1024
+
+// listenSyslog receives syslog messages on addr until ctx is cancelled and
+// queues them to be sent. Over UDP each datagram is a message, over TCP
+// messages are separated by newlines, i.e. non-transparent framing. Octet
+// counted messages are not supported.
+func listenSyslog(ctx context.Context, protocol, addr string, sender *ingestSender) error {
+	switch protocol {
+	case "udp":
+		return listenSyslogUDP(ctx, addr, sender)
+	case "tcp":
+		return listenSyslogTCP(ctx, addr, sender)
+	default:
+		return fmt.Errorf("unsupported syslog protocol %q, must be udp or tcp", protocol)
+	}
+}
+
+func listenSyslogUDP(ctx context.Context, addr string, sender *ingestSender) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stop := closeWhenDone(ctx, conn)
+	defer stop()
+
+	log.Printf("Listening for syslog messages on udp %s", conn.LocalAddr())
+
+	buf := make([]byte, syslogMaxMessageSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		// Some senders put several messages in a datagram, one per line.
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			line = strings.TrimRight(line, "\r\x00")
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if !sender.sendLine(ctx, line) {
+				return nil
+			}
+		}
+	}
+}
+
+func listenSyslogTCP(ctx context.Context, addr string, sender *ingestSender) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	// Connections are closed when the listener stops, whether it is stopped
+	// or fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := closeWhenDone(ctx, listener)
+	defer stop()
+
+	log.Printf("Listening for syslog messages on tcp %s", listener.Addr())
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			stopped := ctx.Err() != nil
+			// Close the connections before waiting for them.
+			cancel()
+			if stopped {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			stop := closeWhenDone(ctx, conn)
+			defer stop()
+
+			scanner := bufio.NewScanner(conn)
+			scanner.Buffer(nil, syslogMaxMessageSize)
+			for scanner.Scan() {
+				line := strings.TrimRight(scanner.Text(), "\r\x00")
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+				if !sender.sendLine(ctx, line) {
+					return
+				}
+			}
+			if err := scanner.Err(); err != nil && ctx.Err() == nil {
+				log.Printf("Closed syslog connection from %s: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// closeWhenDone closes c when ctx is cancelled, to interrupt reads from it.
+// The returned function stops waiting for ctx.
+func closeWhenDone(ctx context.Context, c interface{ Close() error }) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = c.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
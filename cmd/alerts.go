@@ -27,7 +27,16 @@ func newAlertsCmd() *cobra.Command {
 	cmd.AddCommand(newAlertsListCmd())
 	cmd.AddCommand(newAlertsInstallCmd())
 	cmd.AddCommand(newAlertsExportCmd())
+	cmd.AddCommand(newAlertsExportAllCmd())
+	cmd.AddCommand(newAlertsRunCmd())
 	cmd.AddCommand(newAlertsRemoveCmd())
+	cmd.AddCommand(newAlertsEnableCmd())
+	cmd.AddCommand(newAlertsDisableCmd())
+	cmd.AddCommand(newAlertsStatusCmd())
+	cmd.AddCommand(newAlertsWatchErrorsCmd())
+	cmd.AddCommand(newAlertsFromSigmaCmd())
+	cmd.AddCommand(newAlertsSilenceCmd())
+	cmd.AddCommand(newAlertsSilencesCmd())
 
 	return cmd
 }
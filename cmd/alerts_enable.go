@@ -0,0 +1,123 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newAlertsEnableCmd() *cobra.Command {
+	return newAlertsToggleCmd(true)
+}
+
+// newAlertsToggleCmd creates the enable or disable command. Both select alerts
+// the same way and only differ in whether the alerts are silenced.
+func newAlertsToggleCmd(enable bool) *cobra.Command {
+	var view string
+	var labels []string
+
+	verb, pastTense := "disable", "disabled"
+	if enable {
+		verb, pastTense = "enable", "enabled"
+	}
+
+	cmd := cobra.Command{
+		Use:   verb + " [flags] [<name>...]",
+		Short: fmt.Sprintf("Bulk %ss alerts in a view.", verb),
+		Long: fmt.Sprintf(`%ss all alerts in a view matching any of the given names or
+labels. Names may be glob patterns, e.g. 'backfill-*'.
+
+  $ humioctl alerts %s --repo ops 'backfill-*'
+  $ humioctl alerts %s --repo ops --label maintenance`, strings.Title(verb), verb, verb),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if view == "" {
+				return fmt.Errorf("the --repo flag is required")
+			}
+			if len(args) == 0 && len(labels) == 0 {
+				return fmt.Errorf("at least one name, pattern or --label must be given")
+			}
+			for _, pattern := range args {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+				}
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			alerts, err := client.Alerts().List(view)
+			exitOnError(cmd, err, "error fetching alerts")
+
+			var changed, unchanged, failed int
+			for _, alert := range alerts {
+				if !alertMatches(alert, args, labels) {
+					continue
+				}
+
+				if alert.Silenced != enable {
+					unchanged++
+					continue
+				}
+
+				if _, err := client.Alerts().SetSilenced(view, alert, !enable); err != nil {
+					cmd.PrintErrln(fmt.Sprintf("error updating alert %s: %s", alert.Name, err))
+					failed++
+					continue
+				}
+
+				cmd.Println(fmt.Sprintf("Alert %s %s", alert.Name, pastTense))
+				changed++
+			}
+
+			cmd.Println(fmt.Sprintf("%s: %d, Unchanged: %d, Failed: %d", strings.Title(pastTense), changed, unchanged, failed))
+
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&view, "repo", "r", "", "The repository or view containing the alerts.")
+	cmd.Flags().StringArrayVarP(&labels, "label", "l", nil, "Select alerts with this label. Can be given multiple times.")
+
+	return &cmd
+}
+
+// alertMatches returns true if the alert matches any of the name patterns or
+// has any of the labels.
+func alertMatches(alert api.Alert, patterns []string, labels []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, alert.Name); ok {
+			return true
+		}
+	}
+
+	for _, label := range labels {
+		for _, l := range alert.Labels {
+			if l == label {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -15,9 +15,7 @@
 package cmd
 
 import (
-	"fmt"
 	"io/ioutil"
-	"os"
 
 	"github.com/spf13/cobra"
 	yaml "gopkg.in/yaml.v2"
@@ -42,23 +40,14 @@ func newAlertsExportCmd() *cobra.Command {
 			client := NewApiClient(cmd)
 
 			alert, apiErr := client.Alerts().Get(view, alertName)
-			if apiErr != nil {
-				cmd.Println(fmt.Errorf("Error fetching alert: %s", apiErr))
-				os.Exit(1)
-			}
+			exitOnError(cmd, apiErr, "Error fetching alert")
 
 			yamlData, yamlErr := yaml.Marshal(&alert)
-			if yamlErr != nil {
-				cmd.Println(fmt.Errorf("Failed to serialize the alert: %s", yamlErr))
-				os.Exit(1)
-			}
+			exitOnError(cmd, yamlErr, "Failed to serialize the alert")
 			outFilePath := outputName + ".yaml"
 
 			writeErr := ioutil.WriteFile(outFilePath, yamlData, 0644)
-			if writeErr != nil {
-				cmd.Println(fmt.Errorf("Error saving the alert file: %s", writeErr))
-				os.Exit(1)
-			}
+			exitOnError(cmd, writeErr, "Error saving the alert file")
 		},
 	}
 
@@ -0,0 +1,65 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newAlertsExportAllCmd() *cobra.Command {
+	var outputDir string
+
+	cmd := cobra.Command{
+		Use:   "export-all [flags] <view>",
+		Short: "Export all alerts in <view> to a directory.",
+		Long: `Writes each alert in the view to a YAML file in the output directory, named
+after the alert. Existing files are overwritten, so exporting again updates
+the files in place, e.g. for backups kept in version control. The files can
+be installed with 'humioctl alerts install'.
+
+  $ humioctl alerts export-all ops -o ./alerts`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			view := args[0]
+
+			client := NewApiClient(cmd)
+
+			alerts, err := client.Alerts().List(view)
+			exitOnError(cmd, err, "Error fetching alerts")
+
+			assets := map[string][]byte{}
+			for i := range alerts {
+				yamlData, err := yaml.Marshal(&alerts[i])
+				exitOnError(cmd, err, "Failed to serialize the alert")
+				assets[alerts[i].Name] = yamlData
+			}
+
+			files, err := writeExportFiles(outputDir, assets)
+			exitOnError(cmd, err, "Error saving the alert files")
+
+			for _, file := range files {
+				cmd.Println(file)
+			}
+			cmd.Println(fmt.Sprintf("Exported %d alerts to %s", len(files), outputDir))
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output", "o", ".", "The directory to write the alert files to.")
+
+	return &cmd
+}
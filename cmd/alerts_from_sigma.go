@@ -0,0 +1,230 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newAlertsFromSigmaCmd() *cobra.Command {
+	var repo, queryPrefix, start, minLevel string
+	var notifierNames []string
+	var throttle time.Duration
+	var fields keyValueFlag
+	var force, dryRun bool
+
+	cmd := cobra.Command{
+		Use:   "from-sigma [flags] <path>...",
+		Short: "Create alerts from Sigma rules",
+		Long: `Converts Sigma detection rules to Humio queries and creates an alert for each
+rule in the repository or view given by --repo. Each path is a rule file or
+a directory, which is searched for .yml and .yaml files.
+
+  $ humioctl alerts from-sigma ./rules/ --repo secops --notifier soc-slack
+
+The alerts are named after the title of the rule, and labeled with "sigma",
+the level of the rule as "severity:<level>", and the tags of the rule. Use
+--min-level to only create alerts for rules of at least the given level.
+
+Field names in the rules are used as they are, unless renamed with --field,
+e.g. --field Image=process.executable. Use --query-prefix to restrict the
+alerts to the events the rules are written for, e.g. '#type=winlogbeat'.
+
+Matching is case insensitive, as required by Sigma. The modifiers contains,
+startswith, endswith, all, re and cidr are supported. Rules using other
+modifiers or aggregations are skipped with a warning.
+
+Use --dry-run to print the converted queries without creating alerts.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if repo == "" {
+				return fmt.Errorf("the --repo flag is required")
+			}
+			if minLevel != "" && sigmaLevelIndex(minLevel) == -1 {
+				return fmt.Errorf("invalid level %q, must be one of: %s", minLevel, strings.Join(sigmaLevels, ", "))
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			var files []string
+			for _, arg := range args {
+				found, err := findSigmaRuleFiles(arg)
+				exitOnError(cmd, err, "Error reading rules")
+				files = append(files, found...)
+			}
+
+			converter := sigmaConverter{fields: fields.values}
+
+			var alerts []api.Alert
+			var skipped int
+			for _, file := range files {
+				rules, err := readSigmaRules(file)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", file, err)
+					skipped++
+					continue
+				}
+
+				for _, rule := range rules {
+					if sigmaLevelIndex(rule.Level) < sigmaLevelIndex(minLevel) {
+						continue
+					}
+
+					query, err := converter.convert(rule)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Skipping %q in %s: %v\n", rule.Title, file, err)
+						skipped++
+						continue
+					}
+					if queryPrefix != "" {
+						query = queryPrefix + " | " + query
+					}
+
+					alerts = append(alerts, sigmaAlert(rule, query, start, throttle))
+				}
+			}
+
+			if dryRun {
+				for _, alert := range alerts {
+					cmd.Printf("%s [%s]\n  %s\n\n", alert.Name, strings.Join(alert.Labels, ", "), alert.Query.QueryString)
+				}
+				cmd.Printf("%d rules converted, %d skipped\n", len(alerts), skipped)
+				return
+			}
+
+			client := NewApiClient(cmd)
+
+			var notifierIDs []string
+			for _, name := range notifierNames {
+				notifier, err := client.Notifiers().Get(repo, name)
+				exitOnError(cmd, err, "Error fetching notifier")
+				notifierIDs = append(notifierIDs, notifier.ID)
+			}
+
+			var failed int
+			for _, alert := range alerts {
+				alert.Notifiers = notifierIDs
+				if _, err := client.Alerts().Add(repo, &alert, force); err != nil {
+					fmt.Fprintf(os.Stderr, "Error creating alert %q: %v\n", alert.Name, err)
+					failed++
+					continue
+				}
+				cmd.Printf("Created alert %q\n", alert.Name)
+			}
+
+			cmd.Printf("%d alerts created, %d rules skipped\n", len(alerts)-failed, skipped)
+			if failed > 0 {
+				exitOnError(cmd, fmt.Errorf("%d alerts could not be created", failed), "")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&repo, "repo", "r", "", "The repository or view to create the alerts in.")
+	cmd.Flags().Var(&fields, "field", "Rename a field used in the rules, e.g. Image=process.executable. Specify multiple times for multiple fields.")
+	cmd.Flags().StringVar(&queryPrefix, "query-prefix", "", "A filter to add to the beginning of each query, e.g. '#type=winlogbeat'.")
+	cmd.Flags().StringSliceVar(&notifierNames, "notifier", nil, "The name of a notifier to trigger from the alerts. Specify multiple times for multiple notifiers.")
+	cmd.Flags().StringVar(&minLevel, "min-level", "", "Only create alerts for rules of at least this level: "+strings.Join(sigmaLevels, ", ")+".")
+	cmd.Flags().StringVar(&start, "start", "1h", "The time window the alert queries search.")
+	cmd.Flags().DurationVar(&throttle, "throttle", time.Hour, "The minimum time between notifications of an alert.")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Update existing alerts with the same names.")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the converted queries without creating alerts.")
+
+	return &cmd
+}
+
+// findSigmaRuleFiles returns the path if it is a file, or the YAML files in
+// it and its subdirectories if it is a directory.
+func findSigmaRuleFiles(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var files []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(path); !info.IsDir() && (ext == ".yml" || ext == ".yaml") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// readSigmaRules reads the rules in a file, which can contain several YAML
+// documents.
+func readSigmaRules(file string) ([]sigmaRule, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []sigmaRule
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var rule sigmaRule
+		err := dec.Decode(&rule)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rule.Title == "" {
+			return nil, fmt.Errorf("not a Sigma rule, it has no title")
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func sigmaAlert(rule sigmaRule, query, start string, throttle time.Duration) api.Alert {
+	labels := []string{"sigma"}
+	if rule.Level != "" {
+		labels = append(labels, "severity:"+rule.Level)
+	}
+	labels = append(labels, rule.Tags...)
+
+	description := strings.TrimSpace(rule.Description)
+	if rule.ID != "" {
+		description = strings.TrimSpace(fmt.Sprintf("%s\n\nSigma rule %s", description, rule.ID))
+	}
+
+	return api.Alert{
+		Name:               rule.Title,
+		Description:        description,
+		Query:              api.HumioQuery{QueryString: query, Start: start, End: "now", IsLive: true},
+		ThrottleTimeMillis: int(throttle / time.Millisecond),
+		Labels:             labels,
+	}
+}
@@ -15,10 +15,8 @@
 package cmd
 
 import (
-	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
 
 	"github.com/humio/cli/api"
 	"github.com/spf13/cobra"
@@ -29,6 +27,7 @@ func newAlertsInstallCmd() *cobra.Command {
 	var content []byte
 	var readErr error
 	var force bool
+	var vars templateVarFlags
 	var filePath, url, name string
 
 	cmd := cobra.Command{
@@ -46,6 +45,12 @@ The install command allows you to install alerts from a URL or from a local file
 
 By default 'install' will not override existing alerts with the same name.
 Use the --force flag to update existing alerts with conflicting names.
+
+Use --var and --var-file to install the same alert in different environments.
+The file is then rendered as a Go template, e.g. with {{ .repo }} replaced
+by the value of --var repo=web-prod:
+
+  $ humioctl alerts install web --file=./alert.yaml --var-file=prod.yaml --var threshold=100
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Check that we got the right number of argument
@@ -56,15 +61,16 @@ Use the --force flag to update existing alerts with conflicting names.
 				} else if url != "" {
 					content, readErr = getURLAlert(url)
 				} else {
-					cmd.Println(fmt.Errorf("you must specify a path using --file or --url"))
-					os.Exit(1)
+					exitOnError(cmd, usageError("you must specify a path using --file or --url"), "")
 				}
 			} else if l := len(args); l != 2 {
-				cmd.Println(fmt.Errorf("This command takes one argument: <view>"))
-				os.Exit(1)
+				exitOnError(cmd, usageError("This command takes one argument: <view>"), "")
 			}
 			exitOnError(cmd, readErr, "Failed to load the alert")
 
+			content, readErr = vars.render("alert", content)
+			exitOnError(cmd, readErr, "Failed to render the alert")
+
 			viewName := args[0]
 			alert := api.Alert{}
 			alert.Name = name
@@ -84,6 +90,7 @@ Use the --force flag to update existing alerts with conflicting names.
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overrides any alert with the same name. This can be used for updating alert that are already installed. (See --name)")
 	cmd.Flags().StringVar(&filePath, "file", "", "The local file path to the alert to install.")
 	cmd.Flags().StringVar(&url, "url", "", "A URL to fetch the alert file from.")
+	vars.register(&cmd)
 	cmd.Flags().StringVarP(&name, "name", "n", "", "Install the alert under a specific name, ignoreing the `name` attribute in the alert file.")
 
 	return &cmd
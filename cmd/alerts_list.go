@@ -17,15 +17,31 @@ package cmd
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 func newAlertsListCmd() *cobra.Command {
+	var limit listLimitFlags
+	var output string
+	var showQuery bool
+
 	cmd := cobra.Command{
 		Use:   "list [flags] <view>",
 		Short: "List all alerts in a view.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Lists the alerts in a view with their notifiers. Use --output wide to also
+show the throttle time, labels, search window and query of each alert, e.g.
+to audit the alerts of a repository, and --show-query to print the full
+query of each alert after the table:
+
+  $ humioctl alerts list ops --output wide --show-query`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "wide" {
+				return fmt.Errorf("invalid value %q for --output, must be one of: table, wide", output)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 
 			view := args[0]
@@ -35,11 +51,19 @@ func newAlertsListCmd() *cobra.Command {
 			alerts, err := client.Alerts().List(view)
 
 			if err != nil {
-				return fmt.Errorf("Error fetching alerts: %s", err)
+				return fmt.Errorf("Error fetching alerts: %w", err)
 			}
 
-			var output []string
-			output = append(output, "Name | Enabled | Description | Notifiers")
+			// The alerts endpoint is not paginated, so the limit is applied here.
+			total := len(alerts)
+			if l := limit.value(); l > 0 && total > l {
+				alerts = alerts[:l]
+			}
+
+			rows := []string{"Name | Enabled | Description | Notifiers"}
+			if output == "wide" {
+				rows[0] += " | Throttle | Labels | Window | Query"
+			}
 			for i := 0; i < len(alerts); i++ {
 				alert := alerts[i]
 				var notifierNames []string
@@ -50,14 +74,38 @@ func newAlertsListCmd() *cobra.Command {
 					}
 					notifierNames = append(notifierNames, notifier.Name)
 				}
-				output = append(output, fmt.Sprintf("%v | %v | %v | %v", alert.Name, !alert.Silenced, alert.Description, strings.Join(notifierNames, ", ")))
+				row := fmt.Sprintf("%v | %v | %v | %v", alert.Name, !alert.Silenced, alert.Description, strings.Join(notifierNames, ", "))
+				if output == "wide" {
+					row += fmt.Sprintf(" | %s | %s | %s | %s",
+						time.Duration(alert.ThrottleTimeMillis)*time.Millisecond,
+						valueOrEmpty(strings.Join(alert.Labels, ", ")),
+						valueOrEmpty(alert.Query.Start),
+						strings.Replace(truncateString(strings.Join(strings.Fields(alert.Query.QueryString), " "), 60), "|", "/", -1))
+				}
+				rows = append(rows, row)
 			}
 
-			printTable(cmd, output)
+			printTable(cmd, rows)
+
+			if showQuery {
+				for _, alert := range alerts {
+					cmd.Println(alert.Name + ":")
+					for _, line := range strings.Split(strings.TrimSpace(alert.Query.QueryString), "\n") {
+						cmd.Println("  " + line)
+					}
+					cmd.Println()
+				}
+			}
+
+			limit.printTruncated(len(alerts), total, "alerts")
 
 			return nil
 		},
 	}
 
+	limit.register(&cmd, "alerts")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "The format of the list, either 'table' or 'wide', which adds the throttle time, labels, search window and query.")
+	cmd.Flags().BoolVar(&showQuery, "show-query", false, "Print the full query of each alert after the table.")
+
 	return &cmd
 }
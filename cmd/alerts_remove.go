@@ -15,9 +15,6 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 )
 
@@ -35,10 +32,7 @@ func newAlertsRemoveCmd() *cobra.Command {
 			client := NewApiClient(cmd)
 
 			err := client.Alerts().Delete(view, name)
-			if err != nil {
-				cmd.Println(fmt.Errorf("error removing ingest token: %s", err))
-				os.Exit(1)
-			}
+			exitOnError(cmd, err, "error removing alert")
 
 			cmd.Println("Alert removed")
 		},
@@ -0,0 +1,193 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newAlertsRunCmd() *cobra.Command {
+	var start, end, at, fmtStr string
+	var noProgress bool
+
+	cmd := cobra.Command{
+		Use:   "run [flags] <view> <alert>",
+		Short: "Run the query of an alert and print the result.",
+		Long: `Runs the query of the alert over its time window, or the window given by
+--start and --end, and prints the events or the aggregate result. An alert
+fires if its query returns any result, so this shows whether, and why, the
+alert would fire.
+
+Use --at to run the query over the window of the alert ending at a time in
+the past, e.g. to check that the alert would have fired for an incident:
+
+  $ humioctl alerts run ops 'High error rate' --at '2020-03-01 14:30'
+  $ humioctl alerts run ops 'High error rate' --start 7d`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if at != "" && (cmd.Flags().Changed("start") || cmd.Flags().Changed("end")) {
+				return fmt.Errorf("--at cannot be used together with --start or --end")
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			view, alertName := args[0], args[1]
+
+			client := NewApiClient(cmd)
+
+			alert, err := client.Alerts().Get(view, alertName)
+			exitOnError(cmd, err, "Error fetching alert")
+
+			if !cmd.Flags().Changed("start") {
+				start = alert.Query.Start
+			}
+			if !cmd.Flags().Changed("end") {
+				end = alert.Query.End
+			}
+
+			now := time.Now()
+			queryStart, queryEnd, err := parseQueryTimeRange(start, end, now)
+			if err != nil {
+				exitOnError(cmd, usageError(err.Error()), "")
+			}
+			if at != "" {
+				queryStart, queryEnd, err = alertWindowAt(queryStart, queryEnd, at, now)
+				if err != nil {
+					exitOnError(cmd, usageError(err.Error()), "")
+				}
+			}
+
+			ctx := contextCancelledOnInterrupt(commandContext)
+
+			result, err := runQueryJob(ctx, client, view, api.Query{
+				QueryString: alert.Query.QueryString,
+				Start:       queryStart,
+				End:         queryEnd,
+			}, noProgress)
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			if queryError, ok := err.(api.QueryError); ok {
+				exitOnError(cmd, queryError, "There is an error in the query of the alert")
+			}
+			exitOnError(cmd, err, "Error running the query of the alert")
+
+			if result.Metadata.IsAggregate {
+				newAggregatePrinter(cmd.OutOrStdout()).print(result)
+			} else {
+				newEventListPrinter(cmd.OutOrStdout(), fmtStr).print(result)
+			}
+
+			// The verdict is written to stderr, so it does not end up in the
+			// output if it is redirected.
+			if n := len(result.Events); n > 0 {
+				fmt.Fprintf(os.Stderr, "The alert would fire: the query returned results (%d).\n", n)
+			} else {
+				fmt.Fprintln(os.Stderr, "The alert would not fire: the query returned no results.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&start, "start", "s", "", "Query start time, in the formats of 'humioctl search'. Defaults to the start of the alert's query.")
+	cmd.Flags().StringVarP(&end, "end", "e", "", "Query end time, in the same formats as --start. Defaults to the end of the alert's query.")
+	cmd.Flags().StringVar(&at, "at", "", "Run the query over the window of the alert ending at this time, e.g. 2020-03-01T14:30:00Z or '3 days ago'.")
+	cmd.Flags().StringVarP(&fmtStr, "fmt", "f", "{@timestamp} {@rawstring}", "Format string if the result is an event list, see 'humioctl search --help'.")
+	cmd.Flags().BoolVar(&noProgress, "no-progress", false, "Do not show progress information.")
+
+	return &cmd
+}
+
+// alertWindowAt returns the time range of an alert's query moved to end at
+// the time at instead of now. Only relative start and end times are moved.
+func alertWindowAt(start, end, at string, now time.Time) (string, string, error) {
+	atTime, err := parseQueryTime(at, now)
+	if err != nil {
+		return "", "", err
+	}
+
+	var atMillis int64
+	if isDigits(atTime) {
+		atMillis, _ = strconv.ParseInt(atTime, 10, 64)
+	} else if d, ok := relativeTimeDuration(atTime); ok {
+		atMillis = now.Add(-d).UnixNano() / int64(time.Millisecond)
+	} else {
+		return "", "", fmt.Errorf("invalid time %q for --at", at)
+	}
+
+	move := func(t string) (string, error) {
+		if t == "" || t == "now" {
+			return fmt.Sprint(atMillis), nil
+		}
+		if d, ok := relativeTimeDuration(t); ok {
+			return fmt.Sprint(atMillis - int64(d/time.Millisecond)), nil
+		}
+		return "", fmt.Errorf("the alert's query has the absolute time %s, so it cannot be run --at another time", t)
+	}
+
+	if start, err = move(start); err != nil {
+		return "", "", err
+	}
+	if end, err = move(end); err != nil {
+		return "", "", err
+	}
+	return start, end, nil
+}
+
+// runQueryJob runs a query to completion, showing its progress on stderr
+// unless noProgress is true, and returns the result.
+func runQueryJob(ctx context.Context, client *api.Client, repository string, query api.Query, noProgress bool) (api.QueryResult, error) {
+	id, err := client.QueryJobs().CreateContext(ctx, repository, query)
+	if err != nil {
+		return api.QueryResult{}, err
+	}
+
+	defer func() {
+		// Also stops the query on the server if it was interrupted.
+		_ = client.QueryJobs().Delete(repository, id)
+	}()
+
+	poller := queryJobPoller{
+		queryJobs:  client.QueryJobs(),
+		repository: repository,
+		id:         id,
+	}
+
+	var progress *queryResultProgressBar
+	if !noProgress {
+		progress = newQueryResultProgressBar()
+	}
+
+	for {
+		result, err := poller.WaitAndPollContext(ctx)
+		if progress != nil {
+			if err == nil {
+				progress.Update(result)
+			}
+			if err != nil || result.Done {
+				progress.Finish()
+			}
+		}
+		if err != nil || result.Done {
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+// silenceLabelPrefix is the prefix of the label recording when a silenced
+// alert should be enabled again, e.g. "silenced-until=2020-06-01T12:00:00Z".
+const silenceLabelPrefix = "silenced-until="
+
+func newAlertsSilenceCmd() *cobra.Command {
+	var view string
+	var duration time.Duration
+
+	cmd := cobra.Command{
+		Use:   "silence [flags] <name>",
+		Short: "Silence an alert for a period of time.",
+		Long: `Disables the alert and records when it should be enabled again in the
+label 'silenced-until=<time>'. Alerts are not enabled automatically by Humio;
+run 'humioctl alerts silences expire' periodically, e.g. from cron, or keep
+'humioctl alerts silences expire --wait' running to enable them again.
+
+  $ humioctl alerts silence --repo ops disk-usage --for 2h`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if view == "" {
+				return fmt.Errorf("the --repo flag is required")
+			}
+			if duration <= 0 {
+				return fmt.Errorf("the --for flag is required and must be positive")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+
+			client := NewApiClient(cmd)
+
+			alert, err := client.Alerts().Get(view, name)
+			exitOnError(cmd, err, "error fetching alert")
+
+			until := time.Now().Add(duration).UTC().Truncate(time.Second)
+			alert.Labels = withSilenceExpiry(alert.Labels, until)
+
+			_, err = client.Alerts().SetSilenced(view, *alert, true)
+			exitOnError(cmd, err, "error silencing alert")
+
+			cmd.Println(fmt.Sprintf("Alert %s silenced until %s", name, until.Local().Format(time.RFC1123)))
+		},
+	}
+
+	cmd.Flags().StringVarP(&view, "repo", "r", "", "The repository or view containing the alert.")
+	cmd.Flags().DurationVar(&duration, "for", 0, "How long to silence the alert, e.g. 30m or 2h.")
+
+	return &cmd
+}
+
+// alertSilenceExpiry returns when the silence of the alert expires, if it
+// was silenced with 'alerts silence'.
+func alertSilenceExpiry(alert api.Alert) (time.Time, bool) {
+	for _, label := range alert.Labels {
+		if strings.HasPrefix(label, silenceLabelPrefix) {
+			t, err := time.Parse(time.RFC3339, strings.TrimPrefix(label, silenceLabelPrefix))
+			return t, err == nil
+		}
+	}
+	return time.Time{}, false
+}
+
+// withSilenceExpiry returns the labels with the silence expiry replaced by
+// until, or removed if until is the zero time.
+func withSilenceExpiry(labels []string, until time.Time) []string {
+	var result []string
+	for _, label := range labels {
+		if !strings.HasPrefix(label, silenceLabelPrefix) {
+			result = append(result, label)
+		}
+	}
+	if !until.IsZero() {
+		result = append(result, silenceLabelPrefix+until.Format(time.RFC3339))
+	}
+	return result
+}
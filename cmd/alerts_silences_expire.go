@@ -0,0 +1,111 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newAlertsSilencesExpireCmd() *cobra.Command {
+	var view string
+	var wait bool
+	var maxInterval time.Duration
+
+	cmd := cobra.Command{
+		Use:   "expire [flags]",
+		Short: "Enable alerts whose silence has expired.",
+		Long: `Enables the alerts silenced with 'humioctl alerts silence' whose silence has
+expired. Run it periodically, e.g. every few minutes from cron:
+
+  */5 * * * * humioctl alerts silences expire --repo ops
+
+With --wait the command keeps running until all silences have expired.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if view == "" {
+				return fmt.Errorf("the --repo flag is required")
+			}
+			return cobra.NoArgs(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+			ctx := contextCancelledOnInterrupt(commandContext)
+
+			for {
+				remaining, failed, err := expireSilences(cmd, client, view)
+				exitOnError(cmd, err, "error fetching alerts")
+
+				if failed > 0 {
+					os.Exit(1)
+				}
+				if !wait || len(remaining) == 0 {
+					return
+				}
+
+				// Silences may be added or changed while waiting, so the alerts
+				// are checked at least every --max-interval.
+				d := time.Until(remaining[0].until)
+				if d > maxInterval {
+					d = maxInterval
+				}
+				if sleepUnlessDone(ctx, d) != nil {
+					return
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&view, "repo", "r", "", "The repository or view containing the alerts.")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Keep running until all silences have expired.")
+	cmd.Flags().DurationVar(&maxInterval, "max-interval", time.Minute, "With --wait, the longest time between checking the alerts.")
+
+	return &cmd
+}
+
+// expireSilences enables the alerts whose silence has expired and returns
+// the silences that have not.
+func expireSilences(cmd *cobra.Command, client *api.Client, view string) ([]silencedAlert, int, error) {
+	alerts, err := client.Alerts().List(view)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var remaining []silencedAlert
+	var failed int
+	now := time.Now()
+
+	for _, s := range silencedAlerts(alerts) {
+		if s.until.After(now) {
+			remaining = append(remaining, s)
+			continue
+		}
+
+		alert := s.alert
+		alert.Labels = withSilenceExpiry(alert.Labels, time.Time{})
+		if _, err := client.Alerts().SetSilenced(view, alert, false); err != nil {
+			cmd.PrintErrln(fmt.Sprintf("error enabling alert %s: %s", alert.Name, err))
+			failed++
+			continue
+		}
+
+		cmd.Println(fmt.Sprintf("Alert %s enabled, silence expired at %s", alert.Name, s.until.Local().Format(time.RFC1123)))
+	}
+
+	return remaining, failed, nil
+}
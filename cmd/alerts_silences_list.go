@@ -0,0 +1,85 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newAlertsSilencesListCmd() *cobra.Command {
+	var view string
+
+	cmd := cobra.Command{
+		Use:   "list [flags]",
+		Short: "List silenced alerts and when they will be enabled again.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if view == "" {
+				return fmt.Errorf("the --repo flag is required")
+			}
+			return cobra.NoArgs(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			alerts, err := client.Alerts().List(view)
+			exitOnError(cmd, err, "error fetching alerts")
+
+			silenced := silencedAlerts(alerts)
+			if len(silenced) == 0 {
+				cmd.Println("No alerts in " + view + " are silenced")
+				return
+			}
+
+			now := time.Now()
+			rows := []string{"Name | Silenced Until | Remaining"}
+			for _, s := range silenced {
+				remaining := "expired"
+				if s.until.After(now) {
+					remaining = s.until.Sub(now).Truncate(time.Second).String()
+				}
+				rows = append(rows, fmt.Sprintf("%s | %s | %s", s.alert.Name, s.until.Local().Format(time.RFC1123), remaining))
+			}
+			printTable(cmd, rows)
+		},
+	}
+
+	cmd.Flags().StringVarP(&view, "repo", "r", "", "The repository or view containing the alerts.")
+
+	return &cmd
+}
+
+type silencedAlert struct {
+	alert api.Alert
+	until time.Time
+}
+
+// silencedAlerts returns the alerts silenced with 'alerts silence', ordered
+// by when they expire. Alerts that have been enabled again by other means
+// are included, so the label can be removed when they expire.
+func silencedAlerts(alerts []api.Alert) []silencedAlert {
+	var result []silencedAlert
+	for _, alert := range alerts {
+		if until, ok := alertSilenceExpiry(alert); ok {
+			result = append(result, silencedAlert{alert: alert, until: until})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].until.Before(result[j].until) })
+	return result
+}
@@ -0,0 +1,114 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newAlertsStatusCmd() *cobra.Command {
+	var errorsOnly bool
+
+	cmd := cobra.Command{
+		Use:   "status [flags] <view>",
+		Short: "Show when alerts last triggered and whether they are failing.",
+		Long: `Lists the alerts in a view with the time each alert last triggered, whether
+it is currently throttled, and the error from the last time it ran, e.g.
+if a notifier is failing. Alerts with errors are listed first.
+
+  $ humioctl alerts status ops --errors`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			view := args[0]
+
+			client := NewApiClient(cmd)
+
+			alerts, err := client.Alerts().ListStatus(view)
+			exitOnError(cmd, err, "error fetching alerts")
+
+			sort.SliceStable(alerts, func(i, j int) bool {
+				if iErr, jErr := alerts[i].LastError != nil, alerts[j].LastError != nil; iErr != jErr {
+					return iErr
+				}
+				return strings.ToLower(alerts[i].Name) < strings.ToLower(alerts[j].Name)
+			})
+
+			now := time.Now()
+			rows := []string{"Name | Enabled | Last Triggered | Throttled | Last Error"}
+			for _, alert := range alerts {
+				if errorsOnly && alert.LastError == nil {
+					continue
+				}
+
+				lastTriggered := "never"
+				if alert.LastTriggered != nil {
+					lastTriggered = formatTimeAgo(now, msToTime(*alert.LastTriggered))
+				}
+
+				var lastError string
+				if alert.LastError != nil {
+					// Keep the error on one line and out of the way of the column separator.
+					lastError = strings.NewReplacer("\n", " ", "|", "/").Replace(*alert.LastError)
+				}
+
+				rows = append(rows, fmt.Sprintf("%s | %s | %s | %s | %s",
+					alert.Name,
+					yesNo(alert.Enabled),
+					lastTriggered,
+					yesNo(alertThrottled(alert, now)),
+					valueOrEmpty(lastError)))
+			}
+
+			printTable(cmd, rows)
+		},
+	}
+
+	cmd.Flags().BoolVar(&errorsOnly, "errors", false, "Only list alerts with errors.")
+
+	return &cmd
+}
+
+// alertThrottled returns true if the alert has triggered within its throttle period.
+func alertThrottled(alert api.AlertStatus, now time.Time) bool {
+	if alert.LastTriggered == nil || alert.ThrottleTimeMillis <= 0 {
+		return false
+	}
+	return msToTime(*alert.LastTriggered + alert.ThrottleTimeMillis).After(now)
+}
+
+func msToTime(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// formatTimeAgo formats t as a time relative to now, e.g. "5m ago".
+func formatTimeAgo(now, t time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// watchedError is an error state of an alert or scheduled search.
+type watchedError struct {
+	Kind  string `json:"kind"`
+	View  string `json:"view"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+	Time  string `json:"time"`
+}
+
+func (e watchedError) String() string {
+	s := fmt.Sprintf("%s %-8s %s %s/%s", e.Time, strings.ToUpper(e.State), e.Kind, e.View, e.Name)
+	if e.Error != "" {
+		s += ": " + e.Error
+	}
+	return s
+}
+
+func newAlertsWatchErrorsCmd() *cobra.Command {
+	var interval time.Duration
+	var webhooks []string
+	var newOnly, noScheduledSearches bool
+
+	cmd := cobra.Command{
+		Use:   "watch-errors [flags] <view>...",
+		Short: "Watch alerts and scheduled searches for new errors.",
+		Long: `Polls the status of the alerts and scheduled searches in the views and prints
+a line whenever one of them starts failing, fails with a different error,
+or recovers. Errors that exist when the command starts are printed as well,
+unless --new-only is given.
+
+Use --webhook to also post each change as a JSON object to a URL, e.g. a
+Slack incoming webhook. The command runs until it is interrupted.
+
+  $ humioctl alerts watch-errors ops security --interval 2m \
+      --webhook https://hooks.slack.com/services/...`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if interval < time.Second {
+				return fmt.Errorf("the --interval must be at least 1s")
+			}
+			for _, target := range webhooks {
+				if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("invalid --webhook %q, must be an http(s) URL", target)
+				}
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+			ctx := contextCancelledOnInterrupt(commandContext)
+
+			// The failing alerts and scheduled searches, by kind, view and ID.
+			seen := map[string]watchedError{}
+			scheduledSearches := !noScheduledSearches
+			first := true
+
+			for {
+				current := map[string]watchedError{}
+				// Views that could not be fetched keep their errors until the next poll.
+				failed := map[string]bool{}
+
+				for _, view := range args {
+					alerts, err := client.Alerts().ListStatus(view)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "error fetching alerts in %s: %v\n", view, err)
+						failed[view] = true
+						continue
+					}
+					for _, alert := range alerts {
+						if alert.LastError != nil {
+							current["alert/"+view+"/"+alert.ID] = watchedError{Kind: "alert", View: view, Name: alert.Name, Error: *alert.LastError}
+						}
+					}
+
+					if !scheduledSearches {
+						continue
+					}
+					searches, err := client.ScheduledSearches().ListStatus(view)
+					if err != nil {
+						if first {
+							// Older servers have no scheduled searches, so don't keep asking.
+							fmt.Fprintf(os.Stderr, "error fetching scheduled searches, only watching alerts: %v\n", err)
+							scheduledSearches = false
+						} else {
+							fmt.Fprintf(os.Stderr, "error fetching scheduled searches in %s: %v\n", view, err)
+							failed[view] = true
+						}
+						continue
+					}
+					for _, search := range searches {
+						if search.LastError != nil {
+							current["scheduled-search/"+view+"/"+search.ID] = watchedError{Kind: "scheduled-search", View: view, Name: search.Name, Error: *search.LastError}
+						}
+					}
+				}
+
+				now := time.Now().Format(time.RFC3339)
+				var changes []watchedError
+				for key, e := range current {
+					if previous, ok := seen[key]; ok && previous.Error == e.Error {
+						continue
+					}
+					seen[key] = e
+					if first && newOnly {
+						continue
+					}
+					e.State, e.Time = "error", now
+					changes = append(changes, e)
+				}
+				for key, e := range seen {
+					if _, ok := current[key]; !ok && !failed[e.View] {
+						delete(seen, key)
+						e.State, e.Error, e.Time = "resolved", "", now
+						changes = append(changes, e)
+					}
+				}
+
+				sort.Slice(changes, func(i, j int) bool { return changes[i].String() < changes[j].String() })
+				for _, change := range changes {
+					cmd.Println(change.String())
+					for _, target := range webhooks {
+						payload := struct {
+							watchedError
+							Text string `json:"text"`
+						}{change, change.String()}
+						if err := postWebhook(target, payload); err != nil {
+							fmt.Fprintf(os.Stderr, "error posting to %s: %v\n", target, err)
+						}
+					}
+				}
+
+				first = false
+				if sleepUnlessDone(ctx, interval) != nil {
+					return
+				}
+			}
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", time.Minute, "How often to check the status.")
+	cmd.Flags().StringSliceVar(&webhooks, "webhook", nil, "A URL to post each change to as JSON. Can be given more than once.")
+	markSecretFlag(&cmd, "webhook")
+	cmd.Flags().BoolVar(&newOnly, "new-only", false, "Don't print the errors that exist when the command starts.")
+	cmd.Flags().BoolVar(&noScheduledSearches, "no-scheduled-searches", false, "Only watch alerts.")
+
+	return &cmd
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/humio/cli/api"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// registerAllProfilesFlag adds the --all-profiles flag to a read-only
+// command that supports running against every configured profile.
+func registerAllProfilesFlag(cmd *cobra.Command, allProfiles *bool) {
+	cmd.Flags().BoolVar(allProfiles, "all-profiles", false, "Run against every configured profile and show the results in one table labeled by profile.")
+}
+
+// printForAllProfiles calls fetch with a client for each configured profile
+// and prints the rows in one table, with the profile name as the first
+// column. The profiles are queried concurrently. If any profile fails, the
+// errors are printed after the table and the command exits with status 1.
+func printForAllProfiles(cmd *cobra.Command, header []string, fetch func(client *api.Client) ([][]string, error)) {
+	if profileFlag != "" || address != "" || token != "" || tokenFile != "" {
+		exitOnError(cmd, usageError("--all-profiles cannot be combined with --profile, --address, --token or --token-file"), "")
+	}
+
+	profiles := viper.GetStringMap("profiles")
+	if len(profiles) == 0 {
+		exitOnError(cmd, fmt.Errorf("no profiles are configured, add one with: humioctl profiles add <name>"), "")
+	}
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([][][]string, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, profile *login) {
+			defer wg.Done()
+
+			client, err := newApiClientForLogin(profile.address, profile.token)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			rows[i], errs[i] = fetch(client)
+		}(i, mapToLogin(profiles[name]))
+	}
+	wg.Wait()
+
+	w := tablewriter.NewWriter(cmd.OutOrStdout())
+	w.SetHeader(append([]string{"Profile"}, header...))
+	w.SetBorder(false)
+	w.SetAutoWrapText(false)
+	for i, name := range names {
+		for _, row := range rows[i] {
+			w.Append(append([]string{name}, row...))
+		}
+	}
+	w.Render()
+	cmd.Println()
+
+	var failed bool
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error from profile %s: %s\n", names[i], err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(exitCodeError)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newApiCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "api",
+		Short: "Make raw requests to the Humio API",
+		Long: `Make raw GraphQL or REST requests to the Humio API using the address and
+token of the current profile. The response is printed as JSON.
+
+This is useful for calling APIs that are not yet supported by other commands, e.g.
+
+  $ humioctl api graphql '{ viewer { username } }'
+
+  $ humioctl api get api/v1/status`,
+	}
+
+	cmd.AddCommand(newApiGraphQLCmd())
+	cmd.AddCommand(newApiRESTCmd("get", "GET"))
+	cmd.AddCommand(newApiRESTCmd("post", "POST"))
+	cmd.AddCommand(newApiRESTCmd("put", "PUT"))
+	cmd.AddCommand(newApiRESTCmd("delete", "DELETE"))
+	cmd.AddCommand(newApiSchemaCmd())
+	cmd.AddCommand(newApiIntrospectCmd())
+
+	return cmd
+}
+
+// printJSON pretty-prints data if it is valid JSON, otherwise it is printed as is.
+func printJSON(w io.Writer, data []byte) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		w.Write(data)
+		return
+	}
+	buf.WriteByte('\n')
+	w.Write(buf.Bytes())
+}
+
+// readArgOrStdin returns the argument at index i, or the content of stdin if
+// the argument is missing or "-".
+func readArgOrStdin(args []string, i int) (string, error) {
+	if len(args) > i && args[i] != "-" {
+		return args[i], nil
+	}
+
+	data, err := ioutil.ReadAll(os.Stdin)
+	return string(data), err
+}
@@ -0,0 +1,62 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+)
+
+func newApiGraphQLCmd() *cobra.Command {
+	var variablesFile string
+
+	cmd := &cobra.Command{
+		Use:   "graphql [flags] [<query>]",
+		Short: "Execute a GraphQL query or mutation",
+		Long: `Executes a GraphQL query or mutation and prints the JSON response.
+If <query> is not given or is "-" the query is read from stdin.
+
+Variables for the query can be given in a JSON file using --variables-file, e.g.
+
+  $ humioctl api graphql 'query($name: String!) { repository(name: $name) { name } }' --variables-file=vars.json`,
+		Args: cobra.RangeArgs(0, 1),
+		Run: func(cmd *cobra.Command, args []string) {
+			query, err := readArgOrStdin(args, 0)
+			exitOnError(cmd, err, "error reading query")
+
+			var variables map[string]interface{}
+			if variablesFile != "" {
+				data, err := ioutil.ReadFile(variablesFile)
+				exitOnError(cmd, err, "error reading variables file")
+				err = json.Unmarshal(data, &variables)
+				exitOnError(cmd, err, "error parsing variables file")
+			}
+
+			client := NewApiClient(cmd)
+
+			result, err := client.RawGraphQL(query, variables)
+			if result != nil {
+				printJSON(cmd.OutOrStdout(), result)
+			}
+			exitOnError(cmd, err, "error executing query")
+		},
+	}
+
+	cmd.Flags().StringVar(&variablesFile, "variables-file", "", "A JSON file with the variables for the query.")
+
+	return cmd
+}
@@ -0,0 +1,74 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+)
+
+func newApiIntrospectCmd() *cobra.Command {
+	var outputFile string
+
+	cmd := &cobra.Command{
+		Use:   "introspect [flags]",
+		Short: "Write the GraphQL schema of the Humio cluster to a file",
+		Long: `Fetches the GraphQL schema of the Humio cluster with an introspection query
+and writes it to a file, schema.json by default. The cached schema used by
+'api schema' is updated as well.
+
+The file can be loaded by GraphQL tooling, e.g. to compare the schemas of two
+Humio versions, or used to generate the GraphQL input and enum types of the api
+package:
+
+  $ humioctl api introspect -o schema.json
+  $ go run api/gen_schema_types.go -schema schema.json -out api/schema_types.go`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			schema, err := loadGraphQLSchema(client, true)
+			exitOnError(cmd, err, "error fetching schema")
+
+			var result struct {
+				Data struct {
+					Schema struct {
+						Types []json.RawMessage `json:"types"`
+					} `json:"__schema"`
+				} `json:"data"`
+			}
+			err = json.Unmarshal(schema, &result)
+			exitOnError(cmd, err, "error parsing schema")
+
+			var buf bytes.Buffer
+			err = json.Indent(&buf, schema, "", "  ")
+			exitOnError(cmd, err, "error parsing schema")
+			buf.WriteByte('\n')
+
+			err = ioutil.WriteFile(outputFile, buf.Bytes(), 0644)
+			exitOnError(cmd, err, "error writing schema")
+
+			cmd.Println(fmt.Sprintf("Wrote schema with %d types to %s", len(result.Data.Schema.Types), outputFile))
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "schema.json", "The file to write the schema to.")
+
+	return cmd
+}
@@ -0,0 +1,75 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newApiRESTCmd(name, method string) *cobra.Command {
+	var data, dataFile string
+
+	cmd := &cobra.Command{
+		Use:   name + " [flags] <path>",
+		Short: fmt.Sprintf("Make a %s request to a REST endpoint", method),
+		Long: fmt.Sprintf(`Makes a %s request to <path> relative to the address of the
+Humio cluster and prints the response, e.g.
+
+  $ humioctl api %s api/v1/status
+
+A request body can be given using --data or --data-file.`, method, name),
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := strings.TrimPrefix(args[0], "/")
+
+			var body *bytes.Buffer
+			switch {
+			case dataFile != "":
+				content, err := ioutil.ReadFile(dataFile)
+				exitOnError(cmd, err, "error reading data file")
+				body = bytes.NewBuffer(content)
+			case data != "":
+				body = bytes.NewBufferString(data)
+			}
+
+			client := NewApiClient(cmd)
+
+			resp, err := client.HTTPRequest(method, path, body)
+			exitOnError(cmd, err, "error making request")
+			defer resp.Body.Close()
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			exitOnError(cmd, err, "error reading response")
+
+			printJSON(cmd.OutOrStdout(), respBody)
+
+			if resp.StatusCode >= 400 {
+				exitOnError(cmd, &api.StatusError{StatusCode: resp.StatusCode, Message: "server responded with " + resp.Status}, "request failed")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&data, "data", "d", "", "The request body.")
+	markSecretFlag(cmd, "data")
+	cmd.Flags().StringVar(&dataFile, "data-file", "", "A file containing the request body.")
+
+	return cmd
+}
@@ -0,0 +1,98 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const schemaCacheMaxAge = 24 * time.Hour
+
+func newApiSchemaCmd() *cobra.Command {
+	var refresh bool
+
+	cmd := &cobra.Command{
+		Use:   "schema [flags]",
+		Short: "Print the GraphQL schema of the Humio cluster",
+		Long: `Prints the result of a GraphQL introspection query against the Humio cluster.
+
+The schema is cached in the config directory for 24 hours, since it only changes
+when Humio is upgraded. Use --refresh to fetch it again.`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			schema, err := loadGraphQLSchema(client, refresh)
+			exitOnError(cmd, err, "error fetching schema")
+
+			printJSON(cmd.OutOrStdout(), schema)
+		},
+	}
+
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Ignore the cached schema and fetch it from the server.")
+
+	return cmd
+}
+
+// loadGraphQLSchema returns the introspection result for the cluster, using
+// a cached copy if one exists that is not too old.
+func loadGraphQLSchema(client *api.Client, refresh bool) ([]byte, error) {
+	cacheFile := schemaCacheFile(client.Address())
+
+	if !refresh {
+		if info, err := os.Stat(cacheFile); err == nil && time.Since(info.ModTime()) < schemaCacheMaxAge {
+			if schema, err := ioutil.ReadFile(cacheFile); err == nil {
+				return schema, nil
+			}
+		}
+	}
+
+	schema, err := client.Introspect()
+	if err != nil {
+		return nil, err
+	}
+
+	// Failing to cache the schema is not a reason to fail the command.
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0700); err == nil {
+		_ = ioutil.WriteFile(cacheFile, schema, 0600)
+	}
+
+	return schema, nil
+}
+
+func schemaCacheFile(address string) string {
+	return cachePath("schema-" + cacheKey(address) + ".json")
+}
+
+// cachePath returns the path of a file in the cache directory, which is next
+// to the config file.
+func cachePath(name string) string {
+	return filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "cache", name)
+}
+
+var cacheKeyPattern = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// cacheKey makes s safe to use as part of a file name.
+func cacheKey(s string) string {
+	return cacheKeyPattern.ReplaceAllString(s, "_")
+}
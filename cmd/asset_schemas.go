@@ -0,0 +1,396 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/humio/cli/api"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// assetSchemas are the JSON Schemas of the YAML files assets are installed
+// from, by kind. They can be referenced with $schema in a file, so editors
+// can validate and complete it.
+var assetSchemas = map[string]string{
+	"parser": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://humio.com/schemas/humioctl/parser.json",
+  "title": "Humio parser",
+  "description": "A parser, as installed with 'humioctl parsers install'.",
+  "type": "object",
+  "required": ["name", "script"],
+  "additionalProperties": false,
+  "properties": {
+    "$schema": {"type": "string"},
+    "name": {"type": "string", "minLength": 1},
+    "script": {"type": "string", "minLength": 1},
+    "example": {"type": "string"},
+    "tagfields": {"type": "array", "items": {"type": "string", "minLength": 1}},
+    "tests": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["input"],
+        "additionalProperties": false,
+        "properties": {
+          "input": {"type": "string"},
+          "output": {"type": "object", "additionalProperties": {"type": ["string", "number", "boolean"]}}
+        }
+      }
+    }
+  }
+}`,
+	"alert": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://humio.com/schemas/humioctl/alert.json",
+  "title": "Humio alert",
+  "description": "An alert, as installed with 'humioctl alerts install'.",
+  "type": "object",
+  "required": ["name", "query"],
+  "additionalProperties": false,
+  "properties": {
+    "$schema": {"type": "string"},
+    "name": {"type": "string", "minLength": 1},
+    "description": {"type": "string"},
+    "query": {
+      "type": "object",
+      "required": ["queryString", "start"],
+      "additionalProperties": false,
+      "properties": {
+        "queryString": {"type": "string", "minLength": 1},
+        "start": {"type": "string", "pattern": "^[0-9]+ *[a-zA-Z]+$"},
+        "end": {"type": "string", "pattern": "^(now|[0-9]+ *[a-zA-Z]+)?$"},
+        "isLive": {"type": "boolean"}
+      }
+    },
+    "throttleTimeMillis": {"type": "integer", "minimum": 0},
+    "silenced": {"type": "boolean"},
+    "notifiers": {"type": "array", "items": {"type": "string", "minLength": 1}},
+    "labels": {"type": "array", "items": {"type": "string"}}
+  }
+}`,
+	"action": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://humio.com/schemas/humioctl/action.json",
+  "title": "Humio action",
+  "description": "An action, or notifier, as installed with 'humioctl notifiers install'.",
+  "type": "object",
+  "required": ["name", "entity", "properties"],
+  "additionalProperties": false,
+  "properties": {
+    "$schema": {"type": "string"},
+    "id": {"type": "string"},
+    "name": {"type": "string", "minLength": 1},
+    "entity": {"enum": ["` + strings.Join([]string{api.NotifierTypeEmail, api.NotifierTypeOpsGenie, api.NotifierTypePagerDuty, api.NotifierTypeSlack, api.NotifierTypeVictorOps, api.NotifierTypeWebHook}, `", "`) + `"]},
+    "properties": {"type": "object"}
+  }
+}`,
+	"dashboard": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://humio.com/schemas/humioctl/dashboard.json",
+  "title": "Humio dashboard",
+  "description": "A dashboard template, as exported by Humio.",
+  "type": "object",
+  "required": ["name"],
+  "properties": {
+    "$schema": {"type": "string"},
+    "name": {"type": "string", "minLength": 1},
+    "description": {"type": "string"},
+    "widgets": {"type": "object", "additionalProperties": {"type": "object"}},
+    "sections": {"type": "object", "additionalProperties": {"type": "object"}},
+    "parameters": {"type": "object", "additionalProperties": {"type": "object"}}
+  }
+}`,
+	"package": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://humio.com/schemas/humioctl/package.json",
+  "title": "Humio package manifest",
+  "description": "The manifest.yaml of a package, as created with 'humioctl packages create'.",
+  "type": "object",
+  "required": ["name", "version"],
+  "additionalProperties": false,
+  "properties": {
+    "$schema": {"type": "string"},
+    "name": {"type": "string", "pattern": "` + packageNamePattern.String() + `"},
+    "version": {"type": "string", "pattern": "` + strings.Replace(packageVersionPattern.String(), `\`, `\\`, -1) + `"},
+    "description": {"type": "string"},
+    "author": {"type": "string"}
+  }
+}`,
+}
+
+// assetKindDirs are the directories assets of each kind are kept in, e.g.
+// in a package or a git repository of assets.
+var assetKindDirs = map[string]string{
+	"parsers":    "parser",
+	"alerts":     "alert",
+	"actions":    "action",
+	"notifiers":  "action",
+	"dashboards": "dashboard",
+}
+
+func assetKinds() []string {
+	var kinds []string
+	for kind := range assetSchemas {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// jsonSchema is the subset of JSON Schema used by assetSchemas.
+type jsonSchema struct {
+	Type                 interface{}            `json:"type"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []interface{}          `json:"enum"`
+	Pattern              string                 `json:"pattern"`
+	MinLength            *int                   `json:"minLength"`
+	Minimum              *float64               `json:"minimum"`
+}
+
+func loadAssetSchema(kind string) (*jsonSchema, error) {
+	source, ok := assetSchemas[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown asset kind %q, must be one of: %s", kind, strings.Join(assetKinds(), ", "))
+	}
+
+	var schema jsonSchema
+	if err := json.Unmarshal([]byte(source), &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema of %s: %v", kind, err)
+	}
+	return &schema, nil
+}
+
+// validate returns all violations of the schema by value, each prefixed
+// with the path of the value in the document, e.g. query.start.
+func (s *jsonSchema) validate(path string, value interface{}) []string {
+	var violations []string
+	violation := func(format string, args ...interface{}) {
+		at := path
+		if at == "" {
+			at = "(document)"
+		}
+		violations = append(violations, at+": "+fmt.Sprintf(format, args...))
+	}
+
+	types := s.types()
+	if matchesType(types, "") {
+		// Unquoted scalars such as 1.0 or yes are read as strings when the
+		// asset is installed, even though YAML parses them as numbers or
+		// booleans.
+		switch value.(type) {
+		case bool, float64:
+			value = fmt.Sprint(value)
+		}
+	}
+
+	if len(types) > 0 && !matchesType(types, value) {
+		violation("must be %s, got %s", strings.Join(types, " or "), jsonTypeOf(value))
+		return violations
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if e == value {
+				found = true
+			}
+		}
+		if !found {
+			var allowed []string
+			for _, e := range s.Enum {
+				allowed = append(allowed, fmt.Sprint(e))
+			}
+			violation("must be one of %s, got %v", strings.Join(allowed, ", "), value)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.MinLength != nil && len(v) < *s.MinLength {
+			if *s.MinLength == 1 {
+				violation("must not be empty")
+			} else {
+				violation("must be at least %d characters", *s.MinLength)
+			}
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+				violation("must match %s, got %q", s.Pattern, v)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			violation("must be at least %v, got %v", *s.Minimum, v)
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				violations = append(violations, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				violation("missing required property %q", name)
+			}
+		}
+
+		var additional *jsonSchema
+		allowAdditional := true
+		if len(s.AdditionalProperties) > 0 {
+			if err := json.Unmarshal(s.AdditionalProperties, &allowAdditional); err != nil {
+				allowAdditional = true
+				_ = json.Unmarshal(s.AdditionalProperties, &additional)
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			propertyPath := name
+			if path != "" {
+				propertyPath = path + "." + name
+			}
+
+			if property, ok := s.Properties[name]; ok {
+				violations = append(violations, property.validate(propertyPath, v[name])...)
+			} else if additional != nil {
+				violations = append(violations, additional.validate(propertyPath, v[name])...)
+			} else if !allowAdditional {
+				violation("unknown property %q", name)
+			}
+		}
+	}
+
+	return violations
+}
+
+func (s *jsonSchema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, v := range t {
+			types = append(types, fmt.Sprint(v))
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesType(types []string, value interface{}) bool {
+	actual := jsonTypeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// parseYAMLDocument parses YAML into the values JSON would be parsed into,
+// so it can be validated against a JSON Schema.
+func parseYAMLDocument(content []byte) (interface{}, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	return yamlToJSONValue(doc), nil
+}
+
+func yamlToJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = yamlToJSONValue(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = yamlToJSONValue(e)
+		}
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	}
+	return value
+}
+
+// detectAssetKind returns the kind of asset in the file, from the $schema
+// it references, the directory it is in, or the properties it has.
+func detectAssetKind(path string, doc interface{}) (string, bool) {
+	m, _ := doc.(map[string]interface{})
+
+	if ref, ok := m["$schema"].(string); ok {
+		name := strings.TrimSuffix(filepath.Base(ref), filepath.Ext(ref))
+		if _, ok := assetSchemas[name]; ok {
+			return name, true
+		}
+	}
+
+	if filepath.Base(path) == packageManifestFile {
+		return "package", true
+	}
+
+	if kind, ok := assetKindDirs[filepath.Base(filepath.Dir(path))]; ok {
+		return kind, true
+	}
+
+	has := func(name string) bool {
+		_, ok := m[name]
+		return ok
+	}
+	switch {
+	case has("script"):
+		return "parser", true
+	case has("query") || has("throttleTimeMillis"):
+		return "alert", true
+	case has("entity"):
+		return "action", true
+	case has("widgets") || has("sections"):
+		return "dashboard", true
+	case has("version") && !has("script"):
+		return "package", true
+	}
+
+	return "", false
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// templateVarFlags are the --var and --var-file flags of commands that
+// install assets from files. With variables, the files are rendered as Go
+// templates before they are parsed, so the same file can be installed in
+// different environments, e.g. with {{ .repo }} or {{ .threshold }}.
+type templateVarFlags struct {
+	vars     keyValueFlag
+	varFiles []string
+}
+
+func (t *templateVarFlags) register(cmd *cobra.Command) {
+	cmd.Flags().Var(&t.vars, "var", "Set a template variable, e.g. repo=web-prod. Specify multiple times for multiple variables.")
+	cmd.Flags().StringSliceVar(&t.varFiles, "var-file", nil, "A YAML file of template variables. Variables set with --var take precedence.")
+}
+
+// render renders content as a template if any variables are given, and
+// otherwise returns it unchanged. Using a variable that is not set is an
+// error.
+func (t *templateVarFlags) render(name string, content []byte) ([]byte, error) {
+	if len(t.vars.values) == 0 && len(t.varFiles) == 0 {
+		return content, nil
+	}
+
+	vars := map[string]interface{}{}
+	for _, file := range t.varFiles {
+		fileContent, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var fileVars map[string]interface{}
+		if err := yaml.Unmarshal(fileContent, &fileVars); err != nil {
+			return nil, fmt.Errorf("invalid variable file %s: %v", file, err)
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+	for k, v := range t.vars.values {
+		vars[k] = v
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return nil, fmt.Errorf("error rendering template: %v", err)
+	}
+	return buf.Bytes(), nil
+}
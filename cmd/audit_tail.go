@@ -0,0 +1,184 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+const auditRepository = "humio-audit"
+
+// auditFilterFields maps the short names accepted by --filter to the fields
+// of audit log entries. Other names are used as field names as they are.
+var auditFilterFields = map[string]string{
+	"user": "actor.user.username",
+	"type": "type",
+	"ip":   "actor.ip",
+	"repo": "repository.name",
+}
+
+func newAuditTailCmd() *cobra.Command {
+	var (
+		since   string
+		limit   int
+		follow  bool
+		filters keyValueFlag
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tail [flags]",
+		Short: "Prints the latest entries of the audit log. [Root Only]",
+		Long: `Prints the latest entries of the audit log, oldest first.
+
+Entries are filtered using --filter key=value. The keys user, type, ip and
+repo are short for the corresponding fields of the audit log, other keys are
+used as field names. Values may contain * as a wildcard, e.g.
+
+  $ humioctl audit tail --since 1h --filter user=alice
+  $ humioctl audit tail --filter 'type=dataspace.*' --follow`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			start, err := parseQueryTime(since, time.Now())
+			if err != nil {
+				exitOnError(cmd, usageError(err.Error()), "")
+			}
+
+			client := NewApiClient(cmd)
+
+			ctx := contextCancelledOnInterrupt(commandContext)
+
+			queryString := auditQueryString(filters.values)
+			if !follow {
+				queryString += fmt.Sprintf(" | tail(%d)", limit)
+			}
+
+			err = func() error {
+				id, err := client.QueryJobs().CreateContext(ctx, auditRepository, api.Query{
+					QueryString: queryString,
+					Start:       start,
+					Live:        follow,
+				})
+				if err != nil {
+					return err
+				}
+
+				defer func(id string) {
+					_ = client.QueryJobs().Delete(auditRepository, id)
+				}(id)
+
+				poller := queryJobPoller{
+					queryJobs:  client.QueryJobs(),
+					repository: auditRepository,
+					id:         id,
+				}
+
+				printer := &eventListPrinter{
+					printedIds:     map[string]bool{},
+					w:              cmd.OutOrStdout(),
+					printEventFunc: printAuditEvent,
+				}
+
+				for {
+					result, err := poller.WaitAndPollContext(ctx)
+					if err != nil {
+						return err
+					}
+
+					if follow {
+						printer.print(result)
+					} else if result.Done {
+						printer.print(result)
+						return nil
+					}
+				}
+			}()
+
+			if errors.Is(err, context.Canceled) {
+				err = nil
+			}
+
+			exitOnError(cmd, err, "error reading the audit log")
+		},
+	}
+
+	cmd.Flags().StringVarP(&since, "since", "s", "1h", "How far back to look, e.g. 30m, 1h, 7d or 2020-01-01T00:00:00Z.")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 200, "The maximum number of entries to print.")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing new entries as they are logged, until interrupted.")
+	cmd.Flags().Var(&filters, "filter", "Only print entries where the field has the value, e.g. --filter user=alice. Specify multiple times for multiple filters.")
+
+	return cmd
+}
+
+func auditQueryString(filters map[string]string) string {
+	var keys []string
+	for k := range filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var terms []string
+	for _, k := range keys {
+		field, ok := auditFilterFields[k]
+		if !ok {
+			field = k
+		}
+		terms = append(terms, fmt.Sprintf("%s=%s", field, strconv.Quote(filters[k])))
+	}
+
+	if len(terms) == 0 {
+		return "*"
+	}
+	return strings.Join(terms, " | ")
+}
+
+// printAuditEvent prints an audit log entry on one line: the time, user and
+// type of the entry followed by the remaining fields as key=value pairs.
+func printAuditEvent(w io.Writer, e map[string]interface{}) {
+	timestamp := fmt.Sprint(e["@timestamp"])
+	if ts, ok := fieldPrinters["@timestamp"](e["@timestamp"]); ok {
+		timestamp = ts
+	}
+
+	user := valueOrEmpty(auditField(e, auditFilterFields["user"]))
+	entryType := valueOrEmpty(auditField(e, "type"))
+
+	var details []string
+	for k, v := range e {
+		if strings.HasPrefix(k, "@") || strings.HasPrefix(k, "#") || k == "type" || k == auditFilterFields["user"] {
+			continue
+		}
+		details = append(details, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(details)
+
+	fmt.Fprintf(w, "%s  %-16s %-32s %s\n", timestamp, user, entryType, strings.Join(details, " "))
+}
+
+func auditField(e map[string]interface{}, field string) string {
+	if v, ok := e[field]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
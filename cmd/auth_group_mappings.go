@@ -0,0 +1,47 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newAuthGroupMappingsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "group-mappings",
+		Short: "Map identity provider groups to Humio groups [Root Only]",
+		Long: `When users log in with SAML, LDAP or another identity provider, they become
+members of the Humio groups mapped to the groups they are members of in the
+identity provider, and get the roles given to those groups.
+
+  $ humioctl auth group-mappings add "CN=Ops,OU=Groups,DC=example,DC=com" ops`,
+	}
+
+	cmd.AddCommand(newAuthGroupMappingsListCmd())
+	cmd.AddCommand(newAuthGroupMappingsAddCmd())
+	cmd.AddCommand(newAuthGroupMappingsRemoveCmd())
+
+	return cmd
+}
+
+func findGroup(groups []api.Group, match func(api.Group) bool) (api.Group, bool) {
+	for _, g := range groups {
+		if match(g) {
+			return g, true
+		}
+	}
+	return api.Group{}, false
+}
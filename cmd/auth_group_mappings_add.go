@@ -0,0 +1,77 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newAuthGroupMappingsAddCmd() *cobra.Command {
+	var noCreate bool
+
+	cmd := &cobra.Command{
+		Use:   "add [flags] <idp-group> <humio-group>",
+		Short: "Map an identity provider group to a Humio group. [Root Only]",
+		Long: `Maps the identity provider group to the Humio group, creating the Humio
+group if it does not exist. A Humio group can only be mapped to one identity
+provider group, so an existing mapping of the Humio group is replaced.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			lookupName, displayName := args[0], args[1]
+
+			client := NewApiClient(cmd)
+
+			groups, err := client.Groups().List()
+			exitOnError(cmd, err, "error fetching groups")
+
+			if mapped, ok := findGroup(groups, func(g api.Group) bool { return g.LookupName == lookupName }); ok {
+				if mapped.DisplayName == displayName {
+					cmd.Println(fmt.Sprintf("%s is already mapped to %s", lookupName, displayName))
+					return
+				}
+				exitOnError(cmd, fmt.Errorf("%s is already mapped to %s, remove that mapping first", lookupName, mapped.DisplayName), "error adding group mapping")
+			}
+
+			group, ok := findGroup(groups, func(g api.Group) bool { return g.DisplayName == displayName })
+			if !ok {
+				if noCreate {
+					exitOnError(cmd, fmt.Errorf("group %s not found", displayName), "error adding group mapping")
+				}
+
+				_, err := client.Groups().Add(displayName, lookupName)
+				exitOnError(cmd, err, "error creating group")
+
+				cmd.Println(fmt.Sprintf("Created group %s mapped to %s", displayName, lookupName))
+				return
+			}
+
+			if group.LookupName != "" {
+				cmd.PrintErrln(fmt.Sprintf("Replacing the mapping of %s to %s", group.LookupName, displayName))
+			}
+
+			err = client.Groups().SetLookupName(group.ID, lookupName)
+			exitOnError(cmd, err, "error adding group mapping")
+
+			cmd.Println(fmt.Sprintf("Mapped %s to %s", lookupName, displayName))
+		},
+	}
+
+	cmd.Flags().BoolVar(&noCreate, "no-create", false, "Fail instead of creating the Humio group if it does not exist.")
+
+	return cmd
+}
@@ -0,0 +1,55 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+func newAuthGroupMappingsListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list [flags]",
+		Short: "List the identity provider groups mapped to Humio groups. [Root Only]",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			groups, err := client.Groups().List()
+			exitOnError(cmd, err, "error fetching groups")
+
+			sort.Slice(groups, func(i, j int) bool { return groups[i].LookupName < groups[j].LookupName })
+
+			rows := []string{"IdP Group | Humio Group | Users"}
+			for _, g := range groups {
+				if g.LookupName == "" {
+					continue
+				}
+				rows = append(rows, fmt.Sprintf("%s | %s | %d", g.LookupName, g.DisplayName, g.UserCount))
+			}
+
+			if len(rows) == 1 {
+				cmd.Println("No identity provider groups are mapped to Humio groups")
+				return
+			}
+
+			printTable(cmd, rows)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,53 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newAuthGroupMappingsRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove [flags] <idp-group>",
+		Short: "Remove the mapping of an identity provider group. [Root Only]",
+		Long: `Removes the mapping of the identity provider group to a Humio group. The
+Humio group is kept, but users no longer become members of it when they log
+in.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			lookupName := args[0]
+
+			client := NewApiClient(cmd)
+
+			groups, err := client.Groups().List()
+			exitOnError(cmd, err, "error fetching groups")
+
+			group, ok := findGroup(groups, func(g api.Group) bool { return g.LookupName == lookupName })
+			if !ok {
+				exitOnError(cmd, fmt.Errorf("%s is not mapped to a Humio group", lookupName), "error removing group mapping")
+			}
+
+			err = client.Groups().SetLookupName(group.ID, "")
+			exitOnError(cmd, err, "error removing group mapping")
+
+			cmd.Println(fmt.Sprintf("Removed the mapping of %s to %s", lookupName, group.DisplayName))
+		},
+	}
+
+	return cmd
+}
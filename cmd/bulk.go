@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"sync"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+// bulkFlags are the flags of commands making many requests at once, e.g.
+// syncs, imports and exports.
+type bulkFlags struct {
+	concurrency int
+}
+
+func (b *bulkFlags) register(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&b.concurrency, "concurrency", 4, "The number of requests to send in parallel, within the limits of --max-concurrent-requests, --max-rps and --endpoint-max-rps.")
+}
+
+// client returns an API client whose requests are queued behind interactive
+// requests, so other requests of the command are not held up.
+func (b *bulkFlags) client(cmd *cobra.Command) *api.Client {
+	return NewApiClient(cmd).WithPriority(api.PriorityBulk)
+}
+
+// run calls fn for each of n items, with at most --concurrency calls at a
+// time, and returns the error of each item.
+func (b *bulkFlags) run(n int, fn func(i int) error) []error {
+	concurrency := b.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	errs := make([]error, n)
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
@@ -0,0 +1,257 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// bundleFormatVersion is the version of the bundle format written by
+// 'bundle export'. It is increased when a bundle can no longer be read by
+// older versions of the CLI.
+const bundleFormatVersion = 1
+
+const (
+	bundleManifestFile  = "manifest.yaml"
+	bundleSignatureFile = "manifest.yaml.sig"
+)
+
+// The kinds of assets in a bundle, in the order they are imported. Alerts
+// come last, because they refer to notifiers.
+const (
+	bundleKindNotifier = "notifier"
+	bundleKindParser   = "parser"
+	bundleKindAlert    = "alert"
+)
+
+var bundleKinds = []string{bundleKindNotifier, bundleKindParser, bundleKindAlert}
+
+// bundleManifest describes the content of a bundle. It lists the checksum of
+// every asset file, so signing the manifest signs the whole bundle.
+type bundleManifest struct {
+	FormatVersion   int           `yaml:"formatVersion"`
+	CreatedAt       time.Time     `yaml:"createdAt"`
+	HumioctlVersion string        `yaml:"humioctlVersion"`
+	Source          bundleSource  `yaml:"source"`
+	Assets          []bundleAsset `yaml:"assets"`
+}
+
+type bundleSource struct {
+	Address       string `yaml:"address"`
+	ServerVersion string `yaml:"serverVersion"`
+	Repository    string `yaml:"repository"`
+}
+
+type bundleAsset struct {
+	Kind   string `yaml:"kind"`
+	Name   string `yaml:"name"`
+	File   string `yaml:"file"`
+	SHA256 string `yaml:"sha256"`
+}
+
+func newBundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Move the assets of a repository between clusters as a single file",
+		Long: `Exports the parsers, notifiers and alerts of a repository to a single signed
+archive, which can be carried to a cluster without network access to the
+first one, e.g. across an air gap, and imported there.
+
+  $ humioctl bundle keygen -o ops-bundle
+  $ humioctl bundle export --repo ops --sign-key ops-bundle.key ops.tar.gz
+  $ humioctl bundle import --verify-key ops-bundle.pub ops.tar.gz`,
+	}
+
+	cmd.AddCommand(newBundleExportCmd())
+	cmd.AddCommand(newBundleImportCmd())
+	cmd.AddCommand(newBundleKeygenCmd())
+
+	return cmd
+}
+
+// bundle is the content of a bundle file.
+type bundle struct {
+	manifest bundleManifest
+	// files are the asset files keyed by their name in the archive.
+	files map[string][]byte
+	// rawManifest and signature are the manifest as it was signed and its
+	// signature, if the bundle is signed.
+	rawManifest []byte
+	signature   []byte
+}
+
+// add adds an asset to the bundle, stored in a file named after it.
+func (b *bundle) add(kind string, name string, content []byte) {
+	fileName := exportFileNames([]string{name})[name]
+	file := path.Join(kind+"s", fileName)
+	for i := 2; b.files[file] != nil; i++ {
+		file = path.Join(kind+"s", fmt.Sprintf("%s-%d.yaml", strings.TrimSuffix(fileName, ".yaml"), i))
+	}
+
+	sum := sha256.Sum256(content)
+	b.files[file] = content
+	b.manifest.Assets = append(b.manifest.Assets, bundleAsset{Kind: kind, Name: name, File: file, SHA256: hex.EncodeToString(sum[:])})
+}
+
+// write signs the bundle with key, if it is not nil, and writes it to
+// filename as a gzipped tarball.
+func (b *bundle) write(filename string, key ed25519.PrivateKey) error {
+	manifest, err := yaml.Marshal(&b.manifest)
+	if err != nil {
+		return err
+	}
+
+	var archive supportBundle
+	archive.add(bundleManifestFile, manifest)
+	if key != nil {
+		signature := ed25519.Sign(key, manifest)
+		archive.add(bundleSignatureFile, []byte(base64.StdEncoding.EncodeToString(signature)+"\n"))
+	}
+	for _, asset := range b.manifest.Assets {
+		archive.add(asset.File, b.files[asset.File])
+	}
+
+	return archive.write(filename)
+}
+
+// readBundle reads a bundle file and checks that its files match the
+// checksums in the manifest. The signature is not checked.
+func readBundle(filename string) (*bundle, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("not a bundle file: %v", err)
+	}
+
+	b := &bundle{files: map[string][]byte{}}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("not a bundle file: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch header.Name {
+		case bundleManifestFile:
+			b.rawManifest = content
+		case bundleSignatureFile:
+			signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+			if err != nil {
+				return nil, fmt.Errorf("the signature of the bundle is malformed: %v", err)
+			}
+			b.signature = signature
+		default:
+			b.files[header.Name] = content
+		}
+	}
+
+	if b.rawManifest == nil {
+		return nil, fmt.Errorf("not a bundle file: %s is missing", bundleManifestFile)
+	}
+	if err := yaml.Unmarshal(b.rawManifest, &b.manifest); err != nil {
+		return nil, fmt.Errorf("the manifest of the bundle is malformed: %v", err)
+	}
+	if b.manifest.FormatVersion > bundleFormatVersion {
+		return nil, fmt.Errorf("the bundle has format version %d, but this version of humioctl only reads up to version %d, upgrade humioctl to import it", b.manifest.FormatVersion, bundleFormatVersion)
+	}
+
+	listed := map[string]bool{}
+	for _, asset := range b.manifest.Assets {
+		content, ok := b.files[asset.File]
+		if !ok {
+			return nil, fmt.Errorf("the bundle is incomplete: %s is missing", asset.File)
+		}
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != asset.SHA256 {
+			return nil, fmt.Errorf("the bundle is corrupt: the checksum of %s does not match the manifest", asset.File)
+		}
+		listed[asset.File] = true
+	}
+	for file := range b.files {
+		if !listed[file] {
+			return nil, fmt.Errorf("the bundle contains %s, which is not in the manifest", file)
+		}
+	}
+
+	return b, nil
+}
+
+// verify returns an error unless the bundle is signed with the private key
+// of key.
+func (b *bundle) verify(key ed25519.PublicKey) error {
+	if b.signature == nil {
+		return fmt.Errorf("the bundle is not signed")
+	}
+	if !ed25519.Verify(key, b.rawManifest, b.signature) {
+		return fmt.Errorf("the signature of the bundle does not match the key, the bundle may have been modified or signed with another key")
+	}
+	return nil
+}
+
+// readBundleKey reads a key written by 'bundle keygen' of the given size.
+func readBundleKey(filename string, size int) ([]byte, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(key) != size {
+		return nil, fmt.Errorf("%s is not a key written by 'humioctl bundle keygen'", filename)
+	}
+	return key, nil
+}
+
+// count returns the number of assets of the given kind in the bundle.
+func (b *bundle) count(kind string) int {
+	n := 0
+	for _, asset := range b.manifest.Assets {
+		if asset.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,150 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newBundleExportCmd() *cobra.Command {
+	var repo, signKeyFile string
+	var includeBuiltIn, includeSecrets bool
+
+	cmd := cobra.Command{
+		Use:   "export [flags] <file>",
+		Short: "Export the assets of a repository to a bundle file.",
+		Long: `Writes the parsers, notifiers and alerts of the repository to a gzipped
+tarball, with a manifest of the Humio and humioctl versions it was exported
+from and the checksum of each asset. Alerts refer to their notifiers by
+name, so they can be imported into a cluster where the notifiers have other
+IDs.
+
+Secrets of notifiers are replaced by placeholders, as by 'notifiers export',
+and given with --secret when the bundle is imported. Use --include-secrets
+to export the actual values.
+
+Use --sign-key with a private key from 'bundle keygen' to sign the bundle,
+so the import can check that it was not modified on the way.
+
+  $ humioctl bundle export --repo ops --sign-key ops-bundle.key ops.tar.gz`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if repo == "" {
+				return fmt.Errorf("--repo is required")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			output := args[0]
+
+			var key ed25519.PrivateKey
+			if signKeyFile != "" {
+				k, err := readBundleKey(signKeyFile, ed25519.PrivateKeySize)
+				exitOnError(cmd, err, "Error reading the signing key")
+				key = k
+			} else {
+				fmt.Fprintln(os.Stderr, "The bundle is not signed, use --sign-key to sign it.")
+			}
+
+			client := NewApiClient(cmd)
+
+			b := &bundle{
+				manifest: bundleManifest{
+					FormatVersion:   bundleFormatVersion,
+					CreatedAt:       time.Now().UTC().Truncate(time.Second),
+					HumioctlVersion: version,
+					Source:          bundleSource{Address: client.Address(), Repository: repo},
+				},
+				files: map[string][]byte{},
+			}
+
+			status, err := client.Status()
+			exitOnError(cmd, err, "Error fetching the server version")
+			b.manifest.Source.ServerVersion = status.Version
+
+			notifiers, err := client.Notifiers().List(repo)
+			exitOnError(cmd, err, "Error fetching notifiers")
+
+			notifierNames := map[string]string{}
+			for i := range notifiers {
+				notifier := notifiers[i]
+				notifierNames[notifier.ID] = notifier.Name
+				notifier.ID = ""
+				if !includeSecrets {
+					redactNotifierSecrets(&notifier)
+				}
+
+				yamlData, err := yaml.Marshal(&notifier)
+				exitOnError(cmd, err, "Failed to serialize the notifier")
+				b.add(bundleKindNotifier, notifier.Name, yamlData)
+			}
+
+			parsers, err := client.Parsers().List(repo)
+			exitOnError(cmd, err, "Error fetching parsers")
+
+			for _, item := range parsers {
+				if item.IsBuiltIn && !includeBuiltIn {
+					continue
+				}
+
+				parser, err := client.Parsers().Get(repo, item.Name)
+				exitOnError(cmd, err, fmt.Sprintf("Error fetching parser %s", item.Name))
+
+				yamlData, err := yaml.Marshal(&parser)
+				exitOnError(cmd, err, "Failed to serialize the parser")
+				b.add(bundleKindParser, item.Name, yamlData)
+			}
+
+			alerts, err := client.Alerts().List(repo)
+			exitOnError(cmd, err, "Error fetching alerts")
+
+			for i := range alerts {
+				alert := alerts[i]
+
+				var names []string
+				for _, id := range alert.Notifiers {
+					name, ok := notifierNames[id]
+					if !ok {
+						exitOnError(cmd, fmt.Errorf("the alert %s uses the notifier with ID %s, which is not in the repository", alert.Name, id), "Error exporting alerts")
+					}
+					names = append(names, name)
+				}
+				alert.Notifiers = names
+
+				yamlData, err := yaml.Marshal(&alert)
+				exitOnError(cmd, err, "Failed to serialize the alert")
+				b.add(bundleKindAlert, alert.Name, yamlData)
+			}
+
+			err = b.write(output, key)
+			exitOnError(cmd, err, "Error writing the bundle")
+
+			cmd.Printf("Exported %d notifiers, %d parsers and %d alerts from %s to %s\n",
+				b.count(bundleKindNotifier), b.count(bundleKindParser), b.count(bundleKindAlert), repo, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&repo, "repo", "r", "", "The repository to export the assets of.")
+	cmd.Flags().StringVar(&signKeyFile, "sign-key", "", "A private key file from 'bundle keygen' to sign the bundle with.")
+	cmd.Flags().BoolVar(&includeBuiltIn, "include-built-in", false, "Also export the built-in parsers.")
+	cmd.Flags().BoolVar(&includeSecrets, "include-secrets", false, "Export the secrets of notifiers instead of placeholders.")
+
+	return &cmd
+}
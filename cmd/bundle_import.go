@@ -0,0 +1,315 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newBundleImportCmd() *cobra.Command {
+	var repo, verifyKeyFile, onConflict string
+	var skipVerify, dryRun bool
+	var secrets notifierSecretFlags
+	var plan changePlanFlags
+
+	cmd := cobra.Command{
+		Use:   "import [flags] <file>",
+		Short: "Import the assets of a bundle file into a repository.",
+		Long: `Installs the notifiers, parsers and alerts of a bundle written by
+'bundle export' into the repository it was exported from, or the repository
+given by --repo.
+
+The checksums of the assets are always checked. The signature is checked
+with the public key given by --verify-key. Importing a bundle without
+checking its signature requires --skip-verify.
+
+An asset conflicts if the repository already has one of the same kind and
+name. By default nothing is imported if there are conflicts. Use
+--on-conflict=skip to keep the existing assets, or --on-conflict=overwrite to
+replace them with the ones in the bundle. Use --dry-run to see what would be
+done.
+
+To have the import approved before it is done, e.g. in a pipeline, write the
+plan as JSON with --plan-out and import the bundle in a later run with
+--apply-plan, which fails without importing anything if the assets to create
+or overwrite are no longer the ones in the plan file.
+
+Secrets of notifiers that were replaced by placeholders when the bundle was
+exported are given with --secret, or the environment variables
+HUMIO_SECRET_<NOTIFIER>_<NAME>, as for 'notifiers install'. The secrets are
+named after their notifier, so notifiers of the same type get their own
+values, e.g. --secret ops-slack.url=... --secret dev-slack.url=.... Nothing
+is imported if any of them are missing.
+
+A warning is printed if the server runs an older version of Humio than the
+bundle was exported from, as the assets may use features it does not have.
+
+  $ humioctl bundle import --verify-key ops-bundle.pub ops.tar.gz
+  $ humioctl bundle import --verify-key ops-bundle.pub --repo ops-staging --on-conflict=overwrite ops.tar.gz`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if onConflict != "fail" && onConflict != "skip" && onConflict != "overwrite" {
+				return fmt.Errorf("invalid value %q for --on-conflict, must be one of: fail, skip, overwrite", onConflict)
+			}
+			if verifyKeyFile == "" && !skipVerify {
+				return fmt.Errorf("--verify-key is required to check the signature of the bundle, use --skip-verify to import it without checking")
+			}
+			if err := plan.validate(); err != nil {
+				return err
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			b, err := readBundle(args[0])
+			exitOnError(cmd, err, "Error reading the bundle")
+
+			if verifyKeyFile != "" {
+				key, err := readBundleKey(verifyKeyFile, ed25519.PublicKeySize)
+				exitOnError(cmd, err, "Error reading the verification key")
+				exitOnError(cmd, b.verify(key), "Error verifying the bundle")
+			}
+
+			var missingSecrets []string
+			for _, asset := range b.manifest.Assets {
+				if asset.Kind != bundleKindNotifier {
+					continue
+				}
+				var notifier api.Notifier
+				if err := yaml.Unmarshal(b.files[asset.File], &notifier); err != nil {
+					exitOnError(cmd, err, fmt.Sprintf("Error reading notifier %s", asset.Name))
+				}
+				if err := secrets.resolve(&notifier); err != nil {
+					missingSecrets = append(missingSecrets, err.Error())
+				}
+			}
+			if len(missingSecrets) > 0 {
+				exitOnError(cmd, errors.New(strings.Join(missingSecrets, "\n")), "Nothing was imported")
+			}
+
+			source := b.manifest.Source
+			if repo == "" {
+				repo = source.Repository
+			}
+			cmd.Printf("Bundle of %s exported from %s (Humio %s) by humioctl %s at %s\n",
+				source.Repository, source.Address, valueOrEmpty(source.ServerVersion), b.manifest.HumioctlVersion, b.manifest.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+
+			client := NewApiClient(cmd)
+
+			status, err := client.Status()
+			exitOnError(cmd, err, "Error fetching the server version")
+			if compareVersions(status.Version, source.ServerVersion) < 0 {
+				fmt.Fprintf(os.Stderr, "Warning: the server runs Humio %s, which is older than Humio %s the bundle was exported from. Some assets may use features the server does not support.\n", status.Version, source.ServerVersion)
+			}
+
+			existing, err := existingBundleAssets(client, repo)
+			exitOnError(cmd, err, "Error fetching the assets of "+repo)
+
+			var conflicts []string
+			rows := []string{"Kind | Name | Action"}
+			actions := map[string]string{}
+			for _, kind := range bundleKinds {
+				for _, asset := range b.manifest.Assets {
+					if asset.Kind != kind {
+						continue
+					}
+
+					action := "create"
+					if existing[kind][asset.Name] {
+						action = onConflict
+						if onConflict == "fail" {
+							action = "conflict"
+							conflicts = append(conflicts, fmt.Sprintf("%s %s", kind, asset.Name))
+						}
+					}
+					actions[asset.File] = action
+					rows = append(rows, fmt.Sprintf("%s | %s | %s", kind, asset.Name, action))
+				}
+			}
+
+			if dryRun || plan.out != "" || len(conflicts) > 0 {
+				printTable(cmd, rows)
+			}
+			if len(conflicts) > 0 {
+				exitOnError(cmd, fmt.Errorf("%s already has: %s, use --on-conflict=skip or --on-conflict=overwrite", repo, strings.Join(conflicts, ", ")), "Nothing was imported")
+			}
+			if !plan.handle(cmd, bundleImportPlan(client, repo, b.manifest.Assets, actions)) || dryRun {
+				return
+			}
+
+			var imported, skipped, failed int
+			for _, kind := range bundleKinds {
+				var notifierIDs map[string]string
+				if kind == bundleKindAlert {
+					notifierIDs, err = notifierIDsByName(client, repo)
+					exitOnError(cmd, err, "Error fetching notifiers")
+				}
+
+				for _, asset := range b.manifest.Assets {
+					if asset.Kind != kind {
+						continue
+					}
+					if actions[asset.File] == "skip" {
+						skipped++
+						continue
+					}
+
+					overwrite := actions[asset.File] == "overwrite"
+					if err := importBundleAsset(client, repo, asset, b.files[asset.File], overwrite, notifierIDs, &secrets); err != nil {
+						cmd.Println(fmt.Sprintf("Error importing %s %s: %s", kind, asset.Name, err))
+						failed++
+						continue
+					}
+					imported++
+				}
+			}
+
+			cmd.Printf("Imported %d assets into %s, skipped %d\n", imported, repo, skipped)
+			if failed > 0 {
+				exitOnError(cmd, fmt.Errorf("%d of %d assets could not be imported", failed, len(b.manifest.Assets)), "")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&repo, "repo", "r", "", "The repository to import the assets into. Defaults to the repository the bundle was exported from.")
+	cmd.Flags().StringVar(&verifyKeyFile, "verify-key", "", "A public key file from 'bundle keygen' to check the signature of the bundle with.")
+	cmd.Flags().BoolVar(&skipVerify, "skip-verify", false, "Import the bundle without checking its signature.")
+	cmd.Flags().StringVar(&onConflict, "on-conflict", "fail", "What to do with assets that already exist: fail, skip or overwrite.")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be imported without changing anything.")
+	secrets.register(&cmd)
+	plan.register(&cmd)
+
+	return &cmd
+}
+
+// bundleImportPlan returns the plan of the import, as written by --plan-out.
+// Skipped assets are not part of it.
+func bundleImportPlan(client *api.Client, repo string, assets []bundleAsset, actions map[string]string) changePlan {
+	plan := changePlan{Command: "bundle import", Address: client.Address(), Target: repo}
+	for _, kind := range bundleKinds {
+		for _, asset := range assets {
+			if asset.Kind != kind {
+				continue
+			}
+			switch actions[asset.File] {
+			case "create":
+				plan.Changes = append(plan.Changes, plannedChange{Action: "create", Kind: kind, Name: asset.Name, Digest: asset.SHA256})
+			case "overwrite":
+				plan.Changes = append(plan.Changes, plannedChange{Action: "update", Kind: kind, Name: asset.Name, Digest: asset.SHA256})
+			}
+		}
+	}
+	return plan
+}
+
+// existingBundleAssets returns the names of the assets of each kind in the
+// repository.
+func existingBundleAssets(client *api.Client, repo string) (map[string]map[string]bool, error) {
+	existing := map[string]map[string]bool{}
+	for _, kind := range bundleKinds {
+		existing[kind] = map[string]bool{}
+	}
+
+	notifiers, err := client.Notifiers().List(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range notifiers {
+		existing[bundleKindNotifier][n.Name] = true
+	}
+
+	parsers, err := client.Parsers().List(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range parsers {
+		existing[bundleKindParser][p.Name] = true
+	}
+
+	alerts, err := client.Alerts().List(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range alerts {
+		existing[bundleKindAlert][a.Name] = true
+	}
+
+	return existing, nil
+}
+
+func notifierIDsByName(client *api.Client, repo string) (map[string]string, error) {
+	notifiers, err := client.Notifiers().List(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string]string{}
+	for _, n := range notifiers {
+		ids[n.Name] = n.ID
+	}
+	return ids, nil
+}
+
+// importBundleAsset installs an asset of a bundle in the repository. The
+// notifiers of alerts are given by name in the bundle, and are replaced by
+// their IDs in notifierIDs. The secrets of notifiers are filled in from
+// secrets.
+func importBundleAsset(client *api.Client, repo string, asset bundleAsset, content []byte, overwrite bool, notifierIDs map[string]string, secrets *notifierSecretFlags) error {
+	switch asset.Kind {
+	case bundleKindNotifier:
+		var notifier api.Notifier
+		if err := yaml.Unmarshal(content, &notifier); err != nil {
+			return err
+		}
+		notifier.ID = ""
+		if err := secrets.resolve(&notifier); err != nil {
+			return err
+		}
+		_, err := client.Notifiers().Add(repo, &notifier, overwrite)
+		return err
+	case bundleKindParser:
+		var parser api.Parser
+		if err := yaml.Unmarshal(content, &parser); err != nil {
+			return err
+		}
+		return client.Parsers().Add(repo, &parser, overwrite)
+	case bundleKindAlert:
+		var alert api.Alert
+		if err := yaml.Unmarshal(content, &alert); err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(alert.Notifiers))
+		for _, name := range alert.Notifiers {
+			id, ok := notifierIDs[name]
+			if !ok {
+				return fmt.Errorf("the notifier %s does not exist in %s", name, repo)
+			}
+			ids = append(ids, id)
+		}
+		alert.Notifiers = ids
+
+		_, err := client.Alerts().Add(repo, &alert, overwrite)
+		return err
+	default:
+		return fmt.Errorf("unknown kind of asset %q", asset.Kind)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newBundleKeygenCmd() *cobra.Command {
+	var output string
+	var force bool
+
+	cmd := cobra.Command{
+		Use:   "keygen [flags]",
+		Short: "Create a key pair for signing bundles.",
+		Long: `Creates an Ed25519 key pair for signing bundles. The private key is written to
+<output>.key and is used with 'bundle export --sign-key' on the connected
+side. The public key is written to <output>.pub and is used with
+'bundle import --verify-key' on the air-gapped side. Only the public key
+needs to be carried across the air gap.
+
+  $ humioctl bundle keygen -o ops-bundle`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			privateFile := output + ".key"
+			publicFile := output + ".pub"
+
+			if !force {
+				for _, file := range []string{privateFile, publicFile} {
+					if _, err := os.Stat(file); err == nil {
+						exitOnError(cmd, fmt.Errorf("%s already exists, use --force to overwrite it", file), "Error creating the key pair")
+					}
+				}
+			}
+
+			publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+			exitOnError(cmd, err, "Error creating the key pair")
+
+			err = ioutil.WriteFile(privateFile, []byte(base64.StdEncoding.EncodeToString(privateKey)+"\n"), 0600)
+			exitOnError(cmd, err, "Error saving the private key")
+			err = ioutil.WriteFile(publicFile, []byte(base64.StdEncoding.EncodeToString(publicKey)+"\n"), 0644)
+			exitOnError(cmd, err, "Error saving the public key")
+
+			cmd.Printf("Wrote the private key to %s and the public key to %s\n", privateFile, publicFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "humio-bundle", "The path of the key files, without the .key and .pub extensions.")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite existing key files.")
+
+	return &cmd
+}
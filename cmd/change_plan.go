@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// changePlanVersion is the version of the format of plan files.
+const changePlanVersion = 1
+
+// changePlan is a machine-readable description of the changes a sync or
+// import would make, written with --plan-out. It can be reviewed and
+// approved, e.g. in a pipeline, before the changes are made by running the
+// command again with --apply-plan.
+type changePlan struct {
+	Version int             `json:"version"`
+	Command string          `json:"command"`
+	Address string          `json:"address"`
+	Target  string          `json:"target,omitempty"`
+	Changes []plannedChange `json:"changes"`
+}
+
+// plannedChange is a change of a changePlan. Digest is a hash of the desired
+// state of the resource, so a plan is not applied if e.g. the file of a
+// parser was edited after the plan was approved.
+type plannedChange struct {
+	// Action is create, update or delete.
+	Action  string   `json:"action"`
+	Kind    string   `json:"kind"`
+	Name    string   `json:"name"`
+	Details []string `json:"details,omitempty"`
+	Digest  string   `json:"digest,omitempty"`
+}
+
+func (c plannedChange) String() string {
+	s := fmt.Sprintf("%s %s %s", c.Action, c.Kind, c.Name)
+	if len(c.Details) > 0 {
+		s += fmt.Sprintf(" (%s)", strings.Join(c.Details, ", "))
+	}
+	return s
+}
+
+// changeActions maps the symbols of the plans printed by sync commands to
+// the actions of plannedChange.
+var changeActions = map[string]string{
+	"+": "create",
+	"~": "update",
+	"-": "delete",
+}
+
+// changeDigest returns the digest of the desired state of a resource.
+func changeDigest(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// changePlanFlags are the --plan-out and --apply-plan flags of commands
+// applying a plan of changes.
+type changePlanFlags struct {
+	out   string
+	apply string
+}
+
+func (f *changePlanFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.out, "plan-out", "", "Write the plan as JSON to this file instead of applying it, e.g. to have it approved before applying it with --apply-plan.")
+	cmd.Flags().StringVar(&f.apply, "apply-plan", "", "Only apply the changes if they are the ones in this plan file, written by --plan-out.")
+}
+
+func (f *changePlanFlags) validate() error {
+	if f.out != "" && f.apply != "" {
+		return fmt.Errorf("--plan-out cannot be used together with --apply-plan")
+	}
+	return nil
+}
+
+// handle writes the plan to the --plan-out file, or checks that it is the
+// plan in the --apply-plan file. It returns true if the changes should be
+// applied.
+func (f *changePlanFlags) handle(cmd *cobra.Command, plan changePlan) bool {
+	plan.Version = changePlanVersion
+	if plan.Changes == nil {
+		plan.Changes = []plannedChange{}
+	}
+
+	switch {
+	case f.out != "":
+		data, err := json.MarshalIndent(plan, "", "  ")
+		exitOnError(cmd, err, "Error writing the plan")
+		exitOnError(cmd, ioutil.WriteFile(f.out, append(data, '\n'), 0644), "Error writing the plan")
+		cmd.Println(fmt.Sprintf("Wrote the plan to %s. Apply it with --apply-plan %s.", f.out, f.out))
+		return false
+	case f.apply != "":
+		exitOnError(cmd, f.check(plan), "Nothing was changed")
+	}
+	return true
+}
+
+// check returns an error describing the differences if plan is not the
+// plan in the --apply-plan file.
+func (f *changePlanFlags) check(plan changePlan) error {
+	data, err := ioutil.ReadFile(f.apply)
+	if err != nil {
+		return fmt.Errorf("error reading the plan: %w", err)
+	}
+
+	var approved changePlan
+	if err := json.Unmarshal(data, &approved); err != nil {
+		return fmt.Errorf("error parsing the plan %s: %w", f.apply, err)
+	}
+	if approved.Version != changePlanVersion {
+		return fmt.Errorf("the plan %s has version %d, expected %d", f.apply, approved.Version, changePlanVersion)
+	}
+	if approved.Command != plan.Command {
+		return fmt.Errorf("the plan %s is for '%s', not '%s'", f.apply, approved.Command, plan.Command)
+	}
+	if approved.Address != plan.Address || approved.Target != plan.Target {
+		return fmt.Errorf("the plan %s is for %s at %s, not %s at %s", f.apply, valueOrEmpty(approved.Target), approved.Address, valueOrEmpty(plan.Target), plan.Address)
+	}
+
+	// Changes of the same resource are told apart by their details, e.g.
+	// members added to and removed from a group.
+	identity := func(c plannedChange) string {
+		return c.String()
+	}
+	approvedDigests := map[string]string{}
+	for _, c := range approved.Changes {
+		approvedDigests[identity(c)] = c.Digest
+	}
+	planDigests := map[string]string{}
+	for _, c := range plan.Changes {
+		planDigests[identity(c)] = c.Digest
+	}
+
+	var differences []string
+	for _, c := range approved.Changes {
+		if _, ok := planDigests[identity(c)]; !ok {
+			differences = append(differences, "  only in the plan: "+c.String())
+		}
+	}
+	for _, c := range plan.Changes {
+		digest, ok := approvedDigests[identity(c)]
+		switch {
+		case !ok:
+			differences = append(differences, "  not in the plan: "+c.String())
+		case digest != c.Digest:
+			differences = append(differences, "  changed since the plan: "+c.String())
+		}
+	}
+	if len(differences) > 0 {
+		sort.Strings(differences)
+		return fmt.Errorf("the changes differ from the plan %s, make a new plan with --plan-out:\n%s", f.apply, strings.Join(differences, "\n"))
+	}
+
+	return nil
+}
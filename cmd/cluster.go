@@ -26,6 +26,12 @@ func newClusterCmd() *cobra.Command {
 
 	cmd.AddCommand(newClusterShowCmd())
 	cmd.AddCommand(newClusterNodesCmd())
+	cmd.AddCommand(newClusterUpgradeStatusCmd())
+	cmd.AddCommand(newClusterStorageCmd())
+	cmd.AddCommand(newClusterSegmentsCmd())
+	cmd.AddCommand(newClusterMissingSegmentsCmd())
+	cmd.AddCommand(newClusterLogsCmd())
+	cmd.AddCommand(newClusterReactionCmd())
 
 	return cmd
 }
@@ -0,0 +1,247 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+var clusterLogsParts = []string{"logs", "threaddump"}
+
+func newClusterLogsCmd() *cobra.Command {
+	var output, since string
+	var nodeIDs []int
+	var include []string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "logs [flags]",
+		Short: "Collect debug logs and thread dumps of the nodes into a tarball [Root Only]",
+		Long: `Collects the recent debug log and a thread dump of each node in the cluster
+into a gzipped tarball, e.g. to attach to a support case. The tarball also
+contains the cluster information from 'humioctl cluster nodes list'.
+
+The debug logs are read from the humio repository, and thread dumps are
+requested from each node directly, using the URI of the node, so the nodes
+must be reachable from where the command is run. Data that cannot be
+collected is listed in errors.txt in the tarball.
+
+  $ humioctl cluster logs --since 30m --node 1 --node 2 -o support.tar.gz`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			included := map[string]bool{}
+			for _, part := range include {
+				valid := false
+				for _, p := range clusterLogsParts {
+					valid = valid || part == p
+				}
+				if !valid {
+					exitOnError(cmd, usageError(fmt.Sprintf("invalid --include %q, must be one of: %s", part, strings.Join(clusterLogsParts, ", "))), "")
+				}
+				included[part] = true
+			}
+
+			start, err := parseQueryTime(since, time.Now())
+			if err != nil {
+				exitOnError(cmd, usageError(err.Error()), "")
+			}
+
+			if output == "" {
+				output = fmt.Sprintf("humio-debug-%s.tar.gz", time.Now().Format("20060102-150405"))
+			}
+
+			client := NewApiClient(cmd)
+			ctx := contextCancelledOnInterrupt(commandContext)
+
+			nodes, err := client.ClusterNodes().List()
+			exitOnError(cmd, err, "error fetching cluster nodes")
+
+			if len(nodeIDs) > 0 {
+				var selected []api.ClusterNode
+				for _, id := range nodeIDs {
+					node, ok := findClusterNode(nodes, id)
+					if !ok {
+						exitOnError(cmd, fmt.Errorf("node %d not found in cluster", id), "")
+					}
+					selected = append(selected, node)
+				}
+				nodes = selected
+			}
+
+			bundle := &supportBundle{}
+
+			clusterJSON, err := json.MarshalIndent(nodes, "", "  ")
+			exitOnError(cmd, err, "error encoding cluster information")
+			bundle.add("cluster.json", clusterJSON)
+
+			var collected int
+			for _, node := range nodes {
+				if ctx.Err() != nil {
+					break
+				}
+
+				fmt.Fprintf(os.Stderr, "Collecting from node %d (%s)\n", node.Id, node.Uri)
+				dir := fmt.Sprintf("node-%d/", node.Id)
+
+				if included["logs"] {
+					logs, err := fetchNodeDebugLog(ctx, client, node.Id, start, limit)
+					if err != nil {
+						bundle.addError(fmt.Errorf("debug log of node %d: %w", node.Id, err))
+					} else {
+						bundle.add(dir+"humio-debug.log", logs)
+						collected++
+					}
+				}
+
+				if included["threaddump"] {
+					dump, err := client.ClusterNodes().ThreadDump(ctx, node)
+					if err != nil {
+						bundle.addError(fmt.Errorf("thread dump of node %d: %w", node.Id, err))
+					} else {
+						bundle.add(dir+"threaddump.txt", dump)
+						collected++
+					}
+				}
+			}
+
+			exitOnError(cmd, bundle.write(output), "error writing tarball")
+
+			for _, err := range bundle.errors {
+				fmt.Fprintf(os.Stderr, "Could not collect the %s\n", err)
+			}
+			if collected == 0 && len(nodes) > 0 {
+				exitOnError(cmd, fmt.Errorf("no logs or thread dumps could be collected"), "")
+			}
+
+			cmd.Println(fmt.Sprintf("Wrote %s", output))
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "The file to write the tarball to. Defaults to humio-debug-<time>.tar.gz.")
+	cmd.Flags().IntSliceVar(&nodeIDs, "node", nil, "The ID of a node to collect from. Specify multiple times for multiple nodes. Defaults to all nodes.")
+	cmd.Flags().StringSliceVar(&include, "include", clusterLogsParts, "What to collect, one or more of: logs, threaddump.")
+	cmd.Flags().StringVar(&since, "since", "1h", "How far back to collect the debug log, e.g. 30m or 2020-01-01T12:00:00Z.")
+	cmd.Flags().IntVar(&limit, "limit", 10000, "The maximum number of debug log lines to collect per node.")
+
+	return cmd
+}
+
+func findClusterNode(nodes []api.ClusterNode, id int) (api.ClusterNode, bool) {
+	for _, node := range nodes {
+		if node.Id == id {
+			return node, true
+		}
+	}
+	return api.ClusterNode{}, false
+}
+
+// fetchNodeDebugLog returns the most recent lines of the debug log of the
+// node, which Humio ingests into the humio repository, oldest first.
+func fetchNodeDebugLog(ctx context.Context, client *api.Client, nodeID int, start string, limit int) ([]byte, error) {
+	body, err := client.QueryJobs().Stream(ctx, "humio", api.Query{
+		QueryString: fmt.Sprintf("#kind=logs #vhost=%d | tail(%d) | sort(@timestamp, order=asc, limit=%d)", nodeID, limit, limit),
+		Start:       start,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var buf bytes.Buffer
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, 10*1024*1024)
+	for scanner.Scan() {
+		var event struct {
+			RawString string `json:"@rawstring"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.RawString == "" {
+			continue
+		}
+		buf.WriteString(event.RawString)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), scanner.Err()
+}
+
+// supportBundle is a tarball of collected files, written when complete.
+type supportBundle struct {
+	files  []supportBundleFile
+	errors []error
+}
+
+type supportBundleFile struct {
+	name    string
+	content []byte
+}
+
+func (b *supportBundle) add(name string, content []byte) {
+	b.files = append(b.files, supportBundleFile{name: name, content: content})
+}
+
+func (b *supportBundle) addError(err error) {
+	b.errors = append(b.errors, err)
+}
+
+func (b *supportBundle) write(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	files := b.files
+	if len(b.errors) > 0 {
+		var errs bytes.Buffer
+		for _, err := range b.errors {
+			fmt.Fprintln(&errs, err)
+		}
+		files = append(files, supportBundleFile{name: "errors.txt", content: errs.Bytes()})
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		header := &tar.Header{Name: file.name, Mode: 0600, Size: int64(len(file.content)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
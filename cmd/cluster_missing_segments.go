@@ -0,0 +1,74 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+func newClusterMissingSegmentsCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "missing-segments",
+		Short: "List segments that no node in the cluster holds [Root Only]",
+		Long: `Lists the segments that are missing from the cluster, i.e. that no node holds
+a copy of, typically because nodes holding them were lost.
+
+The command can be used directly as a monitoring check. Like 'humioctl health
+--fail-on', the exit code follows the Nagios conventions:
+
+  0  No segments are missing
+  2  At least one segment is missing
+  3  The missing segments could not be determined`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			segments, err := client.Clusters().MissingSegments()
+			if err != nil {
+				cmd.Println(fmt.Errorf("error fetching missing segments: %s", err))
+				os.Exit(healthExitUnknown)
+			}
+
+			if len(segments) == 0 {
+				cmd.Println("No segments are missing")
+				return
+			}
+
+			sort.Slice(segments, func(i, j int) bool {
+				if segments[i].RepositoryID != segments[j].RepositoryID {
+					return segments[i].RepositoryID < segments[j].RepositoryID
+				}
+				return segments[i].Start < segments[j].Start
+			})
+
+			rows := []string{"Repository | Datasource | Segment | Start | End"}
+			for _, segment := range segments {
+				rows = append(rows, fmt.Sprintf("%s | %s | %s | %s | %s",
+					segment.RepositoryID, segment.DatasourceID, segment.SegmentID,
+					formatSegmentTime(segment.Start), formatSegmentTime(segment.End)))
+			}
+			printTable(cmd, rows)
+
+			cmd.Println(fmt.Sprintf("%d segments are missing", len(segments)))
+			os.Exit(healthExitDown)
+		},
+	}
+
+	return &cmd
+}
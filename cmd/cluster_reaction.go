@@ -0,0 +1,288 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// clusterIssue is a problem found in the cluster, with the remedy for it if
+// it can be fixed from the CLI.
+type clusterIssue struct {
+	problem string
+	hint    string
+	remedy  *clusterRemedy
+}
+
+type clusterRemedy struct {
+	description string
+	// dataLoss is true if applying the remedy deletes data, in which case it
+	// is not applied by --yes or --force without --allow-data-loss.
+	dataLoss bool
+	apply    func() error
+}
+
+func newClusterReactionCmd() *cobra.Command {
+	var dryRun, allowDataLoss bool
+	var confirm confirmFlags
+
+	cmd := cobra.Command{
+		Use:   "reaction [flags]",
+		Short: "Detect common cluster issues and fix them with guided remediation [Root Only]",
+		Long: `Checks the cluster for common issues and offers to fix each of them, asking
+for confirmation before changing anything. The issues detected are:
+
+  - Unavailable nodes that are assigned digest of ingest partitions, which
+    stops ingest to those partitions. The digest is moved to other nodes.
+  - Unavailable nodes that are assigned storage partitions. The storage is
+    moved to other nodes and data redistribution is started.
+  - Ingest partitions without any node, which cannot be fixed here.
+  - Under-replicated segments while all nodes are available. Data
+    redistribution is started.
+  - Missing segments, i.e. segments no node holds a copy of. If nodes are
+    unavailable they may hold them, so bring them back first. Otherwise the
+    segments can be removed from the cluster, losing their data.
+
+Use --dry-run to only list the issues, or --yes to fix them without asking,
+e.g. from a script. Removing missing segments also requires --allow-data-loss
+when using --yes. --force is like --yes, but is only accepted when the
+environment variable ` + allowForceEnvVar + `=true is set.
+
+The exit code is 1 if issues were found and not all of them were fixed.`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			exitOnError(cmd, confirm.checkForce(), "")
+
+			client := NewApiClient(cmd)
+
+			issues, err := detectClusterIssues(client)
+			exitOnError(cmd, err, "error checking the cluster")
+
+			if len(issues) == 0 {
+				cmd.Println("No issues found")
+				return
+			}
+
+			interactive := terminal.IsTerminal(int(os.Stdin.Fd()))
+			if !confirm.yes && !confirm.force && !dryRun && !interactive {
+				fmt.Fprintln(os.Stderr, "Not running interactively, only listing the issues. Use --yes to fix them.")
+				dryRun = true
+			}
+
+			cmd.Printf("Found %d issues:\n", len(issues))
+
+			unresolved := 0
+			for i, issue := range issues {
+				cmd.Println()
+				cmd.Printf("%d. %s\n", i+1, issue.problem)
+				if issue.hint != "" {
+					cmd.Printf("   %s\n", issue.hint)
+				}
+				if issue.remedy == nil {
+					unresolved++
+					continue
+				}
+
+				cmd.Printf("   Fix: %s\n", issue.remedy.description)
+				if issue.remedy.dataLoss {
+					cmd.Println("   Warning: This cannot be undone, the data is lost.")
+				}
+
+				switch {
+				case dryRun:
+					unresolved++
+					continue
+				case (confirm.yes || confirm.force) && issue.remedy.dataLoss && !allowDataLoss:
+					cmd.Println("   Skipped, use --allow-data-loss to apply it with --yes.")
+					unresolved++
+					continue
+				}
+
+				if !confirm.yes && !confirm.force {
+					cmd.Print("   ")
+				}
+				err := confirm.confirmApplying(cmd, "apply the fix")
+				if err == errNotConfirmed {
+					cmd.Println("   Skipped.")
+					unresolved++
+					continue
+				}
+				exitOnError(cmd, err, "error reading answer")
+
+				if err := issue.remedy.apply(); err != nil {
+					cmd.Printf("   Failed: %s\n", err)
+					unresolved++
+					continue
+				}
+				cmd.Println("   Done.")
+			}
+
+			if unresolved > 0 {
+				cmd.Println()
+				cmd.Printf("%d of %d issues were not fixed\n", unresolved, len(issues))
+				os.Exit(exitCodeError)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&confirm.yes, "yes", "y", false, "Apply the fixes without asking for confirmation.")
+	cmd.Flags().BoolVar(&confirm.force, "force", false, fmt.Sprintf("Apply the fixes without confirmation. Requires %s=true.", allowForceEnvVar))
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the issues and their fixes.")
+	cmd.Flags().BoolVar(&allowDataLoss, "allow-data-loss", false, "When used with --yes or --force: Also apply fixes that lose data, i.e. removing missing segments.")
+
+	return &cmd
+}
+
+// detectClusterIssues checks the cluster and its missing segments for the
+// issues 'cluster reaction' can help with.
+func detectClusterIssues(client *api.Client) ([]clusterIssue, error) {
+	cluster, err := client.Clusters().Get()
+	if err != nil {
+		return nil, err
+	}
+
+	missing, err := client.Clusters().MissingSegments()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching missing segments: %w", err)
+	}
+
+	unavailable := map[int]api.ClusterNode{}
+	for _, node := range cluster.Nodes {
+		if !node.IsAvailable {
+			unavailable[node.Id] = node
+		}
+	}
+
+	var issues []clusterIssue
+
+	var unassigned []int
+	digestByNode := map[int][]int{}
+	for _, p := range cluster.IngestPartitions {
+		if len(p.NodeIds) == 0 {
+			unassigned = append(unassigned, p.Id)
+		}
+		for _, id := range p.NodeIds {
+			if _, ok := unavailable[id]; ok {
+				digestByNode[id] = append(digestByNode[id], p.Id)
+			}
+		}
+	}
+	storageByNode := map[int][]int{}
+	for _, p := range cluster.StoragePartitions {
+		for _, id := range p.NodeIds {
+			if _, ok := unavailable[id]; ok {
+				storageByNode[id] = append(storageByNode[id], p.Id)
+			}
+		}
+	}
+
+	for _, id := range sortedNodeIDs(unavailable) {
+		node := unavailable[id]
+
+		if partitions := digestByNode[id]; len(partitions) > 0 {
+			issues = append(issues, clusterIssue{
+				problem: fmt.Sprintf("Node %d (%s) is unavailable, but digests ingest partitions %s.", node.Id, node.Name, joinInts(partitions)),
+				remedy: &clusterRemedy{
+					description: "Move the digest of its partitions to other nodes.",
+					apply: func() error {
+						return client.Clusters().ClusterMoveIngestRoutesAwayFromNode(node.Id)
+					},
+				},
+			})
+		}
+
+		if partitions := storageByNode[id]; len(partitions) > 0 {
+			issues = append(issues, clusterIssue{
+				problem: fmt.Sprintf("Node %d (%s) is unavailable, but is assigned storage partitions %s.", node.Id, node.Name, joinInts(partitions)),
+				remedy: &clusterRemedy{
+					description: "Move its storage partitions to other nodes and start data redistribution.",
+					apply: func() error {
+						if err := client.Clusters().ClusterMoveStorageRouteAwayFromNode(node.Id); err != nil {
+							return err
+						}
+						return client.Clusters().StartDataRedistribution()
+					},
+				},
+			})
+		}
+	}
+
+	if len(unassigned) > 0 {
+		issues = append(issues, clusterIssue{
+			problem: fmt.Sprintf("No node digests ingest partitions %s, so data sent to them is not digested.", joinInts(unassigned)),
+			hint:    "Assign the partitions to nodes in the cluster management page of the Humio UI.",
+		})
+	}
+
+	if cluster.UnderReplicatedSegmentSize > 0 && len(unavailable) == 0 {
+		issues = append(issues, clusterIssue{
+			problem: fmt.Sprintf("%s of segments are under-replicated, even though all nodes are available.", ByteCountDecimal(int64(cluster.UnderReplicatedSegmentSize))),
+			remedy: &clusterRemedy{
+				description: "Start data redistribution.",
+				apply:       client.Clusters().StartDataRedistribution,
+			},
+		})
+	}
+
+	if len(missing) > 0 {
+		issue := clusterIssue{
+			problem: fmt.Sprintf("%d segments are missing, i.e. no node holds a copy of them. See 'humioctl cluster missing-segments'.", len(missing)),
+		}
+		if len(unavailable) > 0 {
+			issue.hint = fmt.Sprintf("The unavailable nodes (%s) may hold the segments. Bring them back online, or fix the issues above and run this command again.", joinInts(sortedNodeIDs(unavailable)))
+		} else {
+			issue.remedy = &clusterRemedy{
+				description: "Remove the missing segments from the cluster, so they are no longer reported.",
+				dataLoss:    true,
+				apply: func() error {
+					for _, segment := range missing {
+						if err := client.Clusters().DeleteMissingSegment(segment); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			}
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+func sortedNodeIDs(nodes map[int]api.ClusterNode) []int {
+	ids := make([]int, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func joinInts(values []int) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, ", ")
+}
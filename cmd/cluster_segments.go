@@ -0,0 +1,160 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+type datasourceSegmentStats struct {
+	name            string
+	segments        int
+	size            int64
+	underReplicated int
+	oldest, newest  int64
+}
+
+func newClusterSegmentsCmd() *cobra.Command {
+	var repoName string
+	var listUnderReplicated bool
+
+	cmd := cobra.Command{
+		Use:   "segments [flags]",
+		Short: "Show the segments of a repository and their replication [Root Only]",
+		Long: `Shows the number and size of the segments of each datasource in a repository,
+and how many of them have fewer copies than the replication factor of the
+cluster. The replication factor is the number of nodes assigned to each
+storage partition.
+
+  $ humioctl cluster segments --repo ops --under-replicated
+
+Use 'humioctl cluster missing-segments' to find segments that no node holds.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if repoName == "" {
+				return fmt.Errorf("the --repo flag is required")
+			}
+			return cobra.ExactArgs(0)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			cluster, err := client.Clusters().Get()
+			exitOnError(cmd, err, "error fetching cluster information")
+
+			segments, err := client.Repositories().Segments(repoName)
+			exitOnError(cmd, err, "error fetching segments")
+
+			replicas := storageReplicationFactor(cluster)
+
+			var totalSize, underReplicatedSize int64
+			var underReplicated []api.Segment
+			var missing int
+			datasources := map[string]*datasourceSegmentStats{}
+
+			for _, segment := range segments {
+				ds, ok := datasources[segment.Datasource]
+				if !ok {
+					ds = &datasourceSegmentStats{name: segment.Datasource, oldest: segment.Start, newest: segment.End}
+					datasources[segment.Datasource] = ds
+				}
+				ds.segments++
+				ds.size += segment.CurrentSize
+				if segment.Start < ds.oldest {
+					ds.oldest = segment.Start
+				}
+				if segment.End > ds.newest {
+					ds.newest = segment.End
+				}
+				totalSize += segment.CurrentSize
+
+				if len(segment.Hosts) < replicas {
+					ds.underReplicated++
+					underReplicatedSize += segment.CurrentSize
+					underReplicated = append(underReplicated, segment)
+				}
+				if len(segment.Hosts) == 0 {
+					missing++
+				}
+			}
+
+			printTable(cmd, []string{
+				fmt.Sprintf("Repository | %s", repoName),
+				fmt.Sprintf("Segments | %d (%s)", len(segments), ByteCountDecimal(totalSize)),
+				fmt.Sprintf("Replication Factor | %d", replicas),
+				fmt.Sprintf("Under-replicated | %d (%s)", len(underReplicated), ByteCountDecimal(underReplicatedSize)),
+				fmt.Sprintf("Missing | %d", missing),
+			})
+
+			stats := make([]*datasourceSegmentStats, 0, len(datasources))
+			for _, ds := range datasources {
+				stats = append(stats, ds)
+			}
+			sort.Slice(stats, func(i, j int) bool { return stats[i].name < stats[j].name })
+
+			rows := []string{"Datasource | Segments | Size | Under-replicated | Oldest | Newest"}
+			for _, ds := range stats {
+				rows = append(rows, fmt.Sprintf("%s | %d | %s | %d | %s | %s",
+					ds.name, ds.segments, ByteCountDecimal(ds.size), ds.underReplicated,
+					formatSegmentTime(ds.oldest), formatSegmentTime(ds.newest)))
+			}
+			printTable(cmd, rows)
+
+			if listUnderReplicated && len(underReplicated) > 0 {
+				sort.Slice(underReplicated, func(i, j int) bool { return underReplicated[i].Start < underReplicated[j].Start })
+
+				rows := []string{"Segment | Datasource | Start | End | Size | Hosts"}
+				for _, segment := range underReplicated {
+					hosts := make([]string, len(segment.Hosts))
+					for i, host := range segment.Hosts {
+						hosts[i] = strconv.Itoa(host)
+					}
+					rows = append(rows, fmt.Sprintf("%s | %s | %s | %s | %s | %s",
+						segment.ID, segment.Datasource,
+						formatSegmentTime(segment.Start), formatSegmentTime(segment.End),
+						ByteCountDecimal(segment.CurrentSize), valueOrEmpty(strings.Join(hosts, ","))))
+				}
+				printTable(cmd, rows)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&repoName, "repo", "r", "", "The repository to show the segments of.")
+	cmd.Flags().BoolVar(&listUnderReplicated, "under-replicated", false, "List the segments that have fewer copies than the replication factor.")
+
+	return &cmd
+}
+
+// storageReplicationFactor returns the number of copies of each segment the
+// cluster is configured to keep.
+func storageReplicationFactor(cluster api.Cluster) int {
+	replicas := 0
+	for _, partition := range cluster.StoragePartitions {
+		if len(partition.NodeIds) > replicas {
+			replicas = len(partition.NodeIds)
+		}
+	}
+	return replicas
+}
+
+func formatSegmentTime(ms int64) string {
+	return msToTime(ms).UTC().Format(time.RFC3339)
+}
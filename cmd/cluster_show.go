@@ -15,18 +15,32 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/humio/cli/api"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
 func newClusterShowCmd() *cobra.Command {
+	var watch bool
+	var interval time.Duration
+
 	cmd := &cobra.Command{
 		Use:   "show",
 		Short: "Show the information about the current Humio cluster",
 		Args:  cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			client := NewApiClient(cmd)
+
+			if watch {
+				if interval <= 0 {
+					exitOnError(cmd, usageError("--interval must be positive"), "")
+				}
+				watchCluster(contextCancelledOnInterrupt(commandContext), cmd.OutOrStdout(), client, interval)
+				return
+			}
+
 			cluster, apiErr := client.Clusters().Get()
 			exitOnError(cmd, apiErr, "error fetching cluster information")
 			printClusterInfo(cmd, cluster)
@@ -34,6 +48,9 @@ func newClusterShowCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Continuously show an overview of the cluster nodes, ingest rates and segment replication, like 'status --watch'.")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Second, "When used with --watch: How often to refresh the overview.")
+
 	return cmd
 }
 
@@ -0,0 +1,131 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newClusterStorageRebalanceCmd() *cobra.Command {
+	var evict string
+	var noWait bool
+	var interval time.Duration
+
+	cmd := cobra.Command{
+		Use:   "rebalance [flags]",
+		Short: "Redistribute segments between the nodes of the cluster [Root Only]",
+		Long: `Starts redistributing segments so they are spread evenly between the nodes
+of the cluster.
+
+Use --evict to move all data away from a node before shutting it down. The
+node is marked as being evicted, its storage and ingest routes are moved to
+other nodes, and the command waits until no segments remain on it:
+
+  $ humioctl cluster storage rebalance --evict 3
+
+Interrupting the command stops waiting, but the eviction continues. Run the
+command again to resume waiting, or use --no-wait to not wait at all.`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			if evict == "" {
+				err := client.Clusters().StartDataRedistribution()
+				exitOnError(cmd, err, "error starting data redistribution")
+				cmd.Println("Data redistribution started")
+				return
+			}
+
+			nodeID, err := strconv.Atoi(evict)
+			exitOnError(cmd, err, "could not parse node id")
+
+			node, err := client.ClusterNodes().Get(nodeID)
+			exitOnError(cmd, err, "error fetching node information")
+
+			err = client.ClusterNodes().SetEvicted(nodeID, true)
+			exitOnError(cmd, err, "error marking node as being evicted")
+			cmd.Println(fmt.Sprintf("Node %d (%s) marked as being evicted", node.Id, node.Name))
+
+			err = client.Clusters().ClusterMoveStorageRouteAwayFromNode(nodeID)
+			exitOnError(cmd, err, "error moving storage partitions away from node")
+
+			err = client.Clusters().ClusterMoveIngestRoutesAwayFromNode(nodeID)
+			exitOnError(cmd, err, "error moving ingest partitions away from node")
+			cmd.Println("Partitions moved to other nodes")
+
+			err = client.Clusters().StartDataRedistribution()
+			exitOnError(cmd, err, "error starting data redistribution")
+			cmd.Println("Data redistribution started")
+
+			if noWait {
+				return
+			}
+
+			ctx := contextCancelledOnInterrupt(commandContext)
+
+			node, err = waitForNodeEvicted(ctx, cmd, client, nodeID, interval)
+			if errors.Is(err, context.Canceled) {
+				cmd.Println("Stopped waiting, the eviction continues in the background")
+				return
+			}
+			exitOnError(cmd, err, "error fetching node information")
+
+			cmd.Println(fmt.Sprintf("No segments remain on node %d (%s). It is safe to shut it down and unregister it with:", node.Id, node.Name))
+			cmd.Println()
+			cmd.Println(fmt.Sprintf("  $ humioctl cluster nodes unregister %d", node.Id))
+		},
+	}
+
+	cmd.Flags().StringVar(&evict, "evict", "", "The ID of a node to move all data away from.")
+	cmd.Flags().BoolVar(&noWait, "no-wait", false, "When used with --evict: Exit once the eviction has started.")
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "When used with --evict: How often to check if data remains on the node.")
+
+	return &cmd
+}
+
+// waitForNodeEvicted polls the node until it holds no segments, printing
+// the remaining size each time it changes.
+func waitForNodeEvicted(ctx context.Context, cmd *cobra.Command, client *api.Client, nodeID int, interval time.Duration) (api.ClusterNode, error) {
+	lastSize := -1.0
+	for {
+		node, err := client.ClusterNodes().Get(nodeID)
+		if err != nil {
+			return node, err
+		}
+
+		size := node.CurrentSize + node.InboundSegmentSize
+		if size == 0 {
+			return node, nil
+		}
+
+		if size != lastSize {
+			cmd.Println(fmt.Sprintf("%s  %s remaining on node %d", time.Now().Format("15:04:05"), ByteCountDecimal(int64(size)), nodeID))
+			lastSize = size
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return node, ctx.Err()
+		}
+	}
+}
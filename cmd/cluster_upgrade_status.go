@@ -0,0 +1,117 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func newClusterUpgradeStatusCmd() *cobra.Command {
+	var failOnSkew bool
+
+	cmd := cobra.Command{
+		Use:   "upgrade-status [flags]",
+		Short: "Show the Humio version of each cluster node [Root Only]",
+		Long: `Lists the Humio version and uptime of every node in the cluster, and
+whether the cluster is running more than one version, as it will be in
+the middle of a rolling upgrade.
+
+Use --fail-on-skew to exit with status 1 if the nodes are not all running
+the same version, e.g. to wait for a rolling upgrade to complete:
+
+  $ until humioctl cluster upgrade-status --fail-on-skew; do sleep 30; done`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			nodes, apiErr := client.ClusterNodes().ListVersions()
+			exitOnError(cmd, apiErr, "error fetching cluster nodes")
+
+			sort.Slice(nodes, func(i, j int) bool {
+				return nodes[i].Id < nodes[j].Id
+			})
+
+			nodesByVersion := map[string]int{}
+			rows := make([][]string, len(nodes))
+			for i, node := range nodes {
+				nodesByVersion[node.HumioVersion]++
+				rows[i] = []string{strconv.Itoa(node.Id), node.Name, valueOrEmpty(node.HumioVersion), formatUptime(node.StartedAt), yesNo(node.IsAvailable)}
+			}
+
+			w := tablewriter.NewWriter(cmd.OutOrStdout())
+			w.SetHeader([]string{"ID", "Name", "Version", "Uptime", "Available"})
+			w.AppendBulk(rows)
+			w.SetBorder(false)
+
+			w.Render()
+			cmd.Println()
+
+			versions := make([]string, 0, len(nodesByVersion))
+			for v := range nodesByVersion {
+				versions = append(versions, v)
+			}
+			sort.Strings(versions)
+
+			if len(versions) <= 1 {
+				cmd.Println(fmt.Sprintf("All %d nodes are running version %s", len(nodes), strings.Join(versions, "")))
+				return
+			}
+
+			summary := make([]string, len(versions))
+			for i, v := range versions {
+				summary[i] = fmt.Sprintf("%s (%d nodes)", valueOrEmpty(v), nodesByVersion[v])
+			}
+			cmd.Println(fmt.Sprintf("The cluster is running mixed versions: %s", strings.Join(summary, ", ")))
+
+			if failOnSkew {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&failOnSkew, "fail-on-skew", false, "Exit with status 1 if the nodes are running different versions.")
+
+	return &cmd
+}
+
+// formatUptime returns the time since startedAt, e.g. "3d 4h", or "-" if it is unknown.
+func formatUptime(startedAt string) string {
+	t, err := time.Parse(time.RFC3339, startedAt)
+	if err != nil {
+		return "-"
+	}
+
+	d := time.Since(t)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
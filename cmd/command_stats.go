@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// commandStats is the local record of which commands have been run, kept in
+// stats.json next to the config file when the config key stats is true.
+// Only command and flag names are recorded, never arguments or flag values.
+type commandStats struct {
+	Since    time.Time                `json:"since"`
+	Commands map[string]*commandUsage `json:"commands"`
+}
+
+type commandUsage struct {
+	Runs            int            `json:"runs"`
+	Failures        int            `json:"failures"`
+	TotalDurationMs int64          `json:"totalDurationMs"`
+	MaxDurationMs   int64          `json:"maxDurationMs"`
+	LastRun         time.Time      `json:"lastRun"`
+	Flags           map[string]int `json:"flags,omitempty"`
+}
+
+var (
+	statsCommand   *cobra.Command
+	statsStartedAt time.Time
+)
+
+func commandStatsFile() string {
+	return filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "stats.json")
+}
+
+func commandStatsEnabled() bool {
+	return viper.GetBool("stats")
+}
+
+// startCommandStats records the start of a command, if stats are enabled.
+func startCommandStats(cmd *cobra.Command) {
+	if commandStatsEnabled() {
+		statsCommand = cmd
+		statsStartedAt = time.Now()
+	}
+}
+
+// recordCommandStats adds the command started with startCommandStats to the
+// stats. It is called when the command completes or exits with an error.
+// Failing to record the stats is not reported, so it never affects the
+// command.
+func recordCommandStats(exitCode int) {
+	cmd := statsCommand
+	if cmd == nil {
+		return
+	}
+	statsCommand = nil
+
+	file := commandStatsFile()
+	unlock, err := lockConfigFile(file)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	stats, err := readCommandStats()
+	if err != nil {
+		return
+	}
+
+	name := cmd.CommandPath()
+	usage, ok := stats.Commands[name]
+	if !ok {
+		usage = &commandUsage{}
+		stats.Commands[name] = usage
+	}
+
+	duration := time.Since(statsStartedAt).Milliseconds()
+	usage.Runs++
+	if exitCode != 0 {
+		usage.Failures++
+	}
+	usage.TotalDurationMs += duration
+	if duration > usage.MaxDurationMs {
+		usage.MaxDurationMs = duration
+	}
+	usage.LastRun = time.Now().UTC()
+
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if usage.Flags == nil {
+			usage.Flags = map[string]int{}
+		}
+		usage.Flags[f.Name]++
+	})
+
+	content, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = writeFileAtomic(file, content, 0600)
+}
+
+// readCommandStats returns the recorded stats, which are empty if none
+// have been recorded.
+func readCommandStats() (commandStats, error) {
+	stats := commandStats{Since: time.Now().UTC(), Commands: map[string]*commandUsage{}}
+
+	content, err := ioutil.ReadFile(commandStatsFile())
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+
+	if err := json.Unmarshal(content, &stats); err != nil {
+		return stats, err
+	}
+	if stats.Commands == nil {
+		stats.Commands = map[string]*commandUsage{}
+	}
+	return stats, nil
+}
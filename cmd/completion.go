@@ -40,6 +40,10 @@ This command can generate shell autocompletions. e.g.
 Can be sourced as such
 
 	$ source <(humio completion bash)
+
+Besides commands and flags, the bash script completes the names of
+repositories, views, parsers and profiles. Names fetched from the server are
+cached for a minute. The zsh script only completes commands and flags.
 `
 
 var (
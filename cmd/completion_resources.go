@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const resourceCompletionCacheMaxAge = time.Minute
+
+// resourceCompletions lists, for each command, the kind of resource each of
+// its positional arguments names, so they can be completed with names
+// fetched from the server.
+var resourceCompletions = map[string][]string{
+	"alerts export":              {"view"},
+	"alerts export-all":          {"view"},
+	"alerts install":             {"view"},
+	"alerts list":                {"view"},
+	"alerts remove":              {"view"},
+	"alerts run":                 {"view"},
+	"alerts status":              {"view"},
+	"alerts watch-errors":        {"view"},
+	"fdr-feeds create":           {"repo"},
+	"fdr-feeds disable":          {"repo"},
+	"fdr-feeds enable":           {"repo"},
+	"fdr-feeds list":             {"repo"},
+	"fdr-feeds remove":           {"repo"},
+	"fdr-feeds update":           {"repo"},
+	"ingest":                     {"repo"},
+	"ingest-tokens add":          {"repo"},
+	"ingest-tokens list":         {"repo"},
+	"ingest-tokens remove":       {"repo"},
+	"ingest-tokens rotate":       {"repo"},
+	"ingest-tokens show":         {"repo"},
+	"ingest-tokens update":       {"repo"},
+	"ip-filters delete":          {"ip-filter"},
+	"ip-filters update":          {"ip-filter"},
+	"metrics send":               {"repo"},
+	"notifiers export":           {"view"},
+	"notifiers install":          {"view"},
+	"notifiers list":             {"view"},
+	"notifiers remove":           {"view"},
+	"notifiers show":             {"view"},
+	"notifiers test":             {"view"},
+	"packages install":           {"view"},
+	"packages list":              {"view"},
+	"packages uninstall":         {"view"},
+	"parsers export":             {"repo", "parser"},
+	"parsers export-all":         {"repo"},
+	"parsers install":            {"repo"},
+	"parsers list":               {"repo"},
+	"parsers remove":             {"repo", "parser"},
+	"profiles edit":              {"profile"},
+	"profiles remove":            {"profile"},
+	"profiles refresh":           {"profile"},
+	"profiles rename":            {"profile"},
+	"profiles set-default":       {"profile"},
+	"profiles tokens list":       {"profile"},
+	"profiles tokens remove":     {"profile"},
+	"profiles tokens set":        {"profile"},
+	"repos archiving disable":    {"repo"},
+	"repos archiving enable":     {"repo"},
+	"repos archiving show":       {"repo"},
+	"repos clone":                {"repo"},
+	"repos delete":               {"repo"},
+	"repos set-default-parser":   {"repo", "parser"},
+	"repos tag-groupings clear":  {"repo"},
+	"repos tag-groupings set":    {"repo"},
+	"repos tag-groupings show":   {"repo"},
+	"repos show":                 {"repo"},
+	"repos stats":                {"repo"},
+	"repos update":               {"repo"},
+	"search":                     {"view"},
+	"views export":               {"view"},
+	"views federated add-local":  {"view"},
+	"views federated add-remote": {"view"},
+	"views federated remove":     {"view"},
+	"views federated show":       {"view"},
+	"views federated update":     {"view"},
+	"views permissions grant":    {"view", "group"},
+	"views permissions list":     {"view"},
+	"views permissions revoke":   {"view", "group"},
+	"views show":                 {"view"},
+	"views update":               {"view"},
+}
+
+// bashCompletionFunction is called by the generated bash completion script
+// when an argument cannot be completed statically. It asks humioctl for the
+// names of the resources the argument can refer to.
+const bashCompletionFunction = `
+__humioctl_custom_func() {
+    local i flags=()
+    for ((i = 1; i < ${#words[@]} - 1; i++)); do
+        case "${words[i]}" in
+            -u|--profile|-c|--config|-a|--address|-t|--token)
+                flags+=("${words[i]}" "${words[i+1]}")
+                ;;
+            --profile=*|--config=*|--address=*|--token=*)
+                flags+=("${words[i]}")
+                ;;
+        esac
+    done
+
+    local out
+    out=$(${words[0]} __humioctl_complete "${flags[@]}" "${last_command}" "${nouns[@]}" 2>/dev/null) || return
+    COMPREPLY=( $(compgen -W "${out}" -- "${cur}") )
+}
+`
+
+func newResourceCompletionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "__humioctl_complete <command> [<args>...]",
+		Hidden: true,
+		Short:  "Prints the possible values of the next argument of a command, used by shell completion",
+		Args:   cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			// The command is given as in the completion script, e.g. humioctl_parsers_remove.
+			path := strings.Replace(strings.TrimPrefix(args[0], cmd.Root().Name()), "_", " ", -1)
+			kinds := resourceCompletions[strings.TrimSpace(path)]
+
+			previous := args[1:]
+			if len(previous) >= len(kinds) {
+				return
+			}
+
+			names, err := completeResourceNames(cmd, kinds[len(previous)], previous)
+			if err != nil {
+				return
+			}
+
+			for _, name := range names {
+				cmd.Println(name)
+			}
+		},
+	}
+}
+
+func completeResourceNames(cmd *cobra.Command, kind string, previous []string) ([]string, error) {
+	if kind == "profile" {
+		var names []string
+		for name := range viper.GetStringMap("profiles") {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	client, err := newApiClientE(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var repo string
+	if kind == "parser" {
+		repo = previous[0]
+	}
+
+	cacheFile := cachePath(fmt.Sprintf("completion-%s-%s-%s.json", cacheKey(client.Address()), kind, cacheKey(repo)))
+	if info, err := os.Stat(cacheFile); err == nil && time.Since(info.ModTime()) < resourceCompletionCacheMaxAge {
+		var names []string
+		if data, err := ioutil.ReadFile(cacheFile); err == nil && json.Unmarshal(data, &names) == nil {
+			return names, nil
+		}
+	}
+
+	names, err := fetchResourceNames(client, kind, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failing to cache the names is not a reason to fail the completion.
+	if data, err := json.Marshal(names); err == nil && len(names) > 0 {
+		if err := os.MkdirAll(filepath.Dir(cacheFile), 0700); err == nil {
+			_ = ioutil.WriteFile(cacheFile, data, 0600)
+		}
+	}
+
+	return names, nil
+}
+
+func fetchResourceNames(client *api.Client, kind string, repo string) ([]string, error) {
+	var names []string
+
+	switch kind {
+	case "repo":
+		repos, err := client.Repositories().List()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range repos {
+			names = append(names, r.Name)
+		}
+	case "view":
+		views, err := client.Views().List()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range views {
+			names = append(names, v.Name)
+		}
+	case "parser":
+		parsers, err := client.Parsers().List(repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range parsers {
+			names = append(names, p.Name)
+		}
+	case "group":
+		groups, err := client.Groups().List()
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			names = append(names, g.DisplayName)
+		}
+	case "ip-filter":
+		filters, err := client.IPFilters().List()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range filters {
+			names = append(names, f.Name)
+		}
+	default:
+		return nil, fmt.Errorf("unknown resource kind %q", kind)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	configLockTimeout = 10 * time.Second
+	// A lock older than this is assumed to be left behind by a crashed process.
+	configLockStale = 30 * time.Second
+)
+
+// updateConfig re-reads the config file, applies update to the settings and
+// writes them back. The config file lock is held from reading the file until
+// it has been replaced, so concurrent humioctl processes do not overwrite each
+// other's changes, and the file is replaced atomically, so it is never left
+// partially written. update should not ask for input, as other processes wait
+// for it.
+func updateConfig(update func() error) error {
+	configFile := viper.ConfigFileUsed()
+
+	dirName := filepath.Dir(configFile)
+	if dirErr := os.MkdirAll(dirName, 0700); dirErr != nil {
+		return fmt.Errorf("error creating config directory %s: %s", dirName, dirErr)
+	}
+
+	unlock, err := lockConfigFile(configFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := viper.ReadInConfig(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading config file: %s", err)
+	}
+
+	if err := update(); err != nil {
+		return err
+	}
+
+	content, err := yaml.Marshal(viper.AllSettings())
+	if err != nil {
+		return fmt.Errorf("error encoding config: %s", err)
+	}
+
+	if err := writeFileAtomic(configFile, content, 0600); err != nil {
+		return fmt.Errorf("error writing config file: %s", err)
+	}
+
+	return nil
+}
+
+// lockConfigFile creates a lock file next to the config file, waiting for
+// other processes to release theirs. It returns a function removing the lock.
+func lockConfigFile(configFile string) (func(), error) {
+	lockFile := configFile + ".lock"
+	deadline := time.Now().Add(configLockTimeout)
+
+	for {
+		f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockFile) }, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("error locking config file: %s", err)
+		}
+
+		if info, statErr := os.Stat(lockFile); statErr == nil && time.Since(info.ModTime()) > configLockStale {
+			os.Remove(lockFile)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the config file lock %s, remove it if no other humioctl process is running", lockFile)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory as
+// filename and renames it into place.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
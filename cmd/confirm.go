@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// allowForceEnvVar must be set to true for --force to be accepted, so it is
+// only used deliberately, e.g. in CI pipelines.
+const allowForceEnvVar = "HUMIO_ALLOW_FORCE"
+
+// confirmFlags are the flags of commands that delete resources. Deleting
+// must be confirmed by typing the name of the resource, or with --yes or
+// --confirm-name when not running interactively.
+type confirmFlags struct {
+	yes         bool
+	force       bool
+	confirmName string
+}
+
+func (c *confirmFlags) register(cmd *cobra.Command, kind string) {
+	cmd.Flags().BoolVarP(&c.yes, "yes", "y", false, fmt.Sprintf("Delete the %s without asking for confirmation.", kind))
+	cmd.Flags().StringVar(&c.confirmName, "confirm-name", "", fmt.Sprintf("Confirm deleting the %s by giving its name, for use in scripts.", kind))
+	cmd.Flags().BoolVar(&c.force, "force", false, fmt.Sprintf("Delete the %s without confirmation. Requires %s=true.", kind, allowForceEnvVar))
+}
+
+// confirm returns an error unless deleting the resource has been confirmed.
+func (c *confirmFlags) confirm(cmd *cobra.Command, kind, name string) error {
+	return c.confirmDeleting(cmd, fmt.Sprintf("the %s %q", kind, name), kind, name)
+}
+
+// confirmDeleting is like confirm, but for deleting what, e.g. some of the
+// contents of a resource, confirmed by the name of the resource.
+func (c *confirmFlags) confirmDeleting(cmd *cobra.Command, what, kind, name string) error {
+	if err := c.checkForce(); err != nil || c.force {
+		return err
+	}
+
+	if c.confirmName != "" {
+		if c.confirmName != name {
+			return usageError(fmt.Sprintf("--confirm-name %q does not match the %s %q", c.confirmName, kind, name))
+		}
+		return nil
+	}
+
+	if c.yes {
+		return nil
+	}
+
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return usageError(fmt.Sprintf("refusing to delete %s without confirmation, use --yes or --confirm-name", what))
+	}
+
+	cmd.Printf("This will permanently delete %s.\nType the name of the %s to confirm: ", what, kind)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(answer) != name {
+		return fmt.Errorf("the name did not match, nothing was deleted")
+	}
+	return nil
+}
+
+// errNotConfirmed is returned by confirmApplying if the user declined.
+var errNotConfirmed = errors.New("not confirmed")
+
+// confirmApplying returns an error unless a change that deletes nothing,
+// described by what, e.g. "apply the fix", has been confirmed by answering
+// yes when asked, or with --yes or --force. It returns errNotConfirmed if the
+// user declined.
+func (c *confirmFlags) confirmApplying(cmd *cobra.Command, what string) error {
+	if err := c.checkForce(); err != nil || c.force || c.yes {
+		return err
+	}
+
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return usageError(fmt.Sprintf("refusing to %s without confirmation, use --yes", what))
+	}
+
+	cmd.Printf("%s? [y/N]: ", strings.ToUpper(what[:1])+what[1:])
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		return errNotConfirmed
+	}
+	return nil
+}
+
+// checkForce returns an error if --force is used without allowForceEnvVar.
+func (c *confirmFlags) checkForce() error {
+	if c.force && os.Getenv(allowForceEnvVar) != "true" {
+		return usageError(fmt.Sprintf("--force can only be used when %s=true is set, use --yes instead", allowForceEnvVar))
+	}
+	return nil
+}
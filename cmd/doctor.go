@@ -0,0 +1,296 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Thresholds of the checks of the doctor command.
+const (
+	doctorMaxClockSkew   = 10 * time.Second
+	doctorSlowLatency    = time.Second
+	doctorCertExpiryWarn = 14 * 24 * time.Hour
+	doctorLatencySamples = 3
+	doctorConnectTimeout = 5 * time.Second
+)
+
+const (
+	doctorStatusOK      = "OK"
+	doctorStatusWarn    = "WARN"
+	doctorStatusFail    = "FAIL"
+	doctorStatusSkipped = "SKIP"
+)
+
+const doctorRemedyConnection = "Check that the address is correct and that the cluster can be reached from this machine, e.g. through a VPN or proxy."
+
+// doctorResult is the outcome of a check, with a suggestion of how to fix
+// it unless it passed.
+type doctorResult struct {
+	name   string
+	status string
+	detail string
+	remedy string
+}
+
+func newDoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose problems with the configuration and the connection to Humio.",
+		Long: `Checks the config file, DNS resolution of the cluster address, the TLS
+handshake, the API token, the clock skew between this machine and the
+cluster, and the latency of the API, and suggests how to fix failed checks.
+
+Checks that depend on a failed check are skipped. The exit code is 1 if any
+check failed, and 0 otherwise, also if there are warnings.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			results := runDoctorChecks(cmd)
+
+			failed := false
+			for _, r := range results {
+				cmd.Printf("[%-4s] %s: %s\n", r.status, r.name, r.detail)
+				if r.remedy != "" {
+					cmd.Printf("       %s\n", r.remedy)
+				}
+				if r.status == doctorStatusFail {
+					failed = true
+				}
+			}
+
+			if failed {
+				os.Exit(exitCodeError)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func runDoctorChecks(cmd *cobra.Command) []doctorResult {
+	var results []doctorResult
+	add := func(name, status, detail, remedy string) {
+		results = append(results, doctorResult{name: name, status: status, detail: detail, remedy: remedy})
+	}
+	skip := func(names ...string) {
+		for _, name := range names {
+			add(name, doctorStatusSkipped, "skipped because of the failed checks above", "")
+		}
+	}
+
+	checkDoctorConfigFile(add)
+
+	address := configString("address")
+	u, err := url.Parse(address)
+	switch {
+	case address == "":
+		add("Address", doctorStatusFail, "no cluster address is configured", "Run 'humioctl login' or 'humioctl profiles add', or set --address or HUMIO_ADDRESS.")
+		skip("DNS", "Connection", "Token", "Clock", "Latency")
+		return results
+	case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		add("Address", doctorStatusFail, fmt.Sprintf("%q is not a valid address", address), "The address must be a URL such as https://humio.example.com/.")
+		skip("DNS", "Connection", "Token", "Clock", "Latency")
+		return results
+	}
+	add("Address", doctorStatusOK, address, "")
+
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+
+	if net.ParseIP(host) == nil {
+		addrs, err := net.LookupHost(host)
+		if err != nil {
+			add("DNS", doctorStatusFail, err.Error(), fmt.Sprintf("Check the spelling of %s and your DNS settings, e.g. with 'nslookup %s'.", host, host))
+			skip("Connection", "Token", "Clock", "Latency")
+			return results
+		}
+		add("DNS", doctorStatusOK, fmt.Sprintf("%s resolves to %v", host, addrs), "")
+	} else {
+		add("DNS", doctorStatusOK, "the address is an IP address", "")
+	}
+
+	if ok := checkDoctorConnection(add, u.Scheme, host, port); !ok {
+		skip("Token", "Clock", "Latency")
+		return results
+	}
+
+	client, err := newApiClientE(cmd)
+	if err != nil {
+		add("Token", doctorStatusFail, err.Error(), "Check the token file or keychain entry of the profile.")
+		skip("Clock", "Latency")
+		return results
+	}
+
+	checkDoctorToken(add, client)
+	checkDoctorClockAndLatency(add, client)
+
+	return results
+}
+
+func checkDoctorConfigFile(add func(name, status, detail, remedy string)) {
+	file := viper.ConfigFileUsed()
+
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		add("Config", doctorStatusWarn, fmt.Sprintf("%s does not exist", file), "Run 'humioctl login' to create it, unless you configure humioctl with flags or environment variables.")
+		return
+	}
+	if err != nil {
+		add("Config", doctorStatusFail, err.Error(), "Check that the config file can be read.")
+		return
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		add("Config", doctorStatusFail, err.Error(), "Check that the config file can be read.")
+		return
+	}
+
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		add("Config", doctorStatusFail, fmt.Sprintf("%s is not valid YAML: %v", file, err), "Fix the syntax error, or move the file away and run 'humioctl login' to create a new one.")
+		return
+	}
+
+	if profileFlag != "" {
+		if profiles, ok := config["profiles"].(map[interface{}]interface{}); !ok || profiles[profileFlag] == nil {
+			add("Config", doctorStatusFail, fmt.Sprintf("the profile %q is not in %s", profileFlag, file), "Run 'humioctl profiles list' to see the available profiles.")
+			return
+		}
+	}
+
+	// Windows does not have Unix file permissions.
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		add("Config", doctorStatusWarn, fmt.Sprintf("%s can be read by other users (%s)", file, info.Mode().Perm()), fmt.Sprintf("The file can contain API tokens, restrict it with 'chmod 600 %s'.", file))
+		return
+	}
+
+	add("Config", doctorStatusOK, fmt.Sprintf("%s is valid", file), "")
+}
+
+func checkDoctorConnection(add func(name, status, detail, remedy string), scheme, host, port string) bool {
+	dialer := &net.Dialer{Timeout: doctorConnectTimeout}
+	target := net.JoinHostPort(host, port)
+
+	if scheme == "http" {
+		conn, err := dialer.Dial("tcp", target)
+		if err != nil {
+			add("Connection", doctorStatusFail, err.Error(), doctorRemedyConnection)
+			return false
+		}
+		conn.Close()
+		add("Connection", doctorStatusWarn, fmt.Sprintf("connected to %s without TLS", target), "The API token is sent unencrypted, use an https:// address if the cluster supports it.")
+		return true
+	}
+
+	conn, err := tls.DialWithDialer(dialer, "tcp", target, &tls.Config{ServerName: host})
+	if err != nil {
+		remedy := doctorRemedyConnection
+		if _, ok := err.(net.Error); !ok {
+			remedy = "The certificate of the cluster could not be verified. If it is signed by an internal CA, add the CA certificate to the trust store of this machine."
+		}
+		add("Connection", doctorStatusFail, err.Error(), remedy)
+		return false
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	cert := state.PeerCertificates[0]
+	expiresIn := time.Until(cert.NotAfter)
+	detail := fmt.Sprintf("TLS handshake with %s succeeded, the certificate expires %s", target, cert.NotAfter.Format("2006-01-02"))
+	if expiresIn < doctorCertExpiryWarn {
+		add("Connection", doctorStatusWarn, detail, "The certificate expires soon, ask the administrator of the cluster to renew it.")
+		return true
+	}
+	add("Connection", doctorStatusOK, detail, "")
+	return true
+}
+
+func checkDoctorToken(add func(name, status, detail, remedy string), client *api.Client) {
+	if client.Token() == "" {
+		add("Token", doctorStatusFail, "no API token is configured", "Run 'humioctl login', or set --token or HUMIO_TOKEN.")
+		return
+	}
+
+	username, err := client.Viewer().Username()
+	if err != nil {
+		if kind, _ := errorKind(err); kind == "unauthorized" {
+			add("Token", doctorStatusFail, "the API token was rejected", "The token is invalid or has expired. Get a new token from your account settings and run 'humioctl login'.")
+			return
+		}
+		add("Token", doctorStatusFail, err.Error(), "Check that the address points to the Humio API.")
+		return
+	}
+	add("Token", doctorStatusOK, fmt.Sprintf("authenticated as %s", username), "")
+}
+
+func checkDoctorClockAndLatency(add func(name, status, detail, remedy string), client *api.Client) {
+	var latencies []time.Duration
+	var skew time.Duration
+	var skewKnown bool
+
+	for i := 0; i < doctorLatencySamples; i++ {
+		start := time.Now()
+		resp, err := client.HTTPRequest(http.MethodGet, "api/v1/status", nil)
+		if err != nil {
+			add("Clock", doctorStatusSkipped, "the status endpoint could not be reached", "")
+			add("Latency", doctorStatusFail, err.Error(), doctorRemedyConnection)
+			return
+		}
+		latency := time.Since(start)
+		resp.Body.Close()
+		latencies = append(latencies, latency)
+
+		if serverTime, err := http.ParseTime(resp.Header.Get("Date")); err == nil && !skewKnown {
+			// The Date header has second precision, so assume it was set halfway through the request.
+			skew = serverTime.Sub(start.Add(latency / 2)).Round(time.Second)
+			skewKnown = true
+		}
+	}
+
+	switch {
+	case !skewKnown:
+		add("Clock", doctorStatusSkipped, "the server did not send its time", "")
+	case skew > doctorMaxClockSkew || skew < -doctorMaxClockSkew:
+		add("Clock", doctorStatusWarn, fmt.Sprintf("the clock of this machine is %s off from the cluster", skew), "Relative time ranges and timestamps of ingested events will be off. Enable time synchronization, e.g. NTP.")
+	default:
+		add("Clock", doctorStatusOK, fmt.Sprintf("the clock is %s off from the cluster", skew), "")
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	median := latencies[len(latencies)/2].Round(time.Millisecond)
+	if median > doctorSlowLatency {
+		add("Latency", doctorStatusWarn, fmt.Sprintf("median of %d requests %s", len(latencies), median), "The API responds slowly, check the network connection, or the load on the cluster with 'humioctl status --watch'.")
+		return
+	}
+	add("Latency", doctorStatusOK, fmt.Sprintf("median of %d requests %s", len(latencies), median), "")
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes used when a command fails, so scripts can tell the kinds of
+// errors apart.
+const (
+	exitCodeError        = 1
+	exitCodeUsage        = 2
+	exitCodeUnauthorized = 3
+	exitCodeForbidden    = 4
+	exitCodeNotFound     = 5
+	exitCodeGraphQL      = 6
+	exitCodeConnection   = 7
+	exitCodeServerError  = 8
+	exitCodeTimeout      = 9
+)
+
+// errorFormat is the value of the --error-format flag, either "text" or "json".
+var errorFormat string
+
+// usageError is an error caused by invalid arguments or flags.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// commandTimeoutError is reported when a command runs longer than --timeout.
+type commandTimeoutError time.Duration
+
+func (e commandTimeoutError) Error() string {
+	return fmt.Sprintf("the command did not complete within %s", time.Duration(e))
+}
+
+// errorKind returns a short identifier of the kind of error and the exit
+// code to use for it.
+func errorKind(err error) (string, int) {
+	var statusErr *api.StatusError
+	var graphQLErr api.GraphQLError
+	var urlErr *url.Error
+	var netErr net.Error
+	var opErr *net.OpError
+	var usageErr usageError
+	var timeoutErr commandTimeoutError
+
+	switch {
+	case errors.As(err, &usageErr):
+		return "usage", exitCodeUsage
+	case errors.As(err, &timeoutErr), errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout", exitCodeTimeout
+	case errors.As(err, &statusErr):
+		switch {
+		case statusErr.StatusCode == http.StatusUnauthorized:
+			return "unauthorized", exitCodeUnauthorized
+		case statusErr.StatusCode == http.StatusForbidden:
+			return "forbidden", exitCodeForbidden
+		case statusErr.StatusCode == http.StatusNotFound:
+			return "not_found", exitCodeNotFound
+		case statusErr.StatusCode >= 500:
+			return "server_error", exitCodeServerError
+		}
+		return "http_error", exitCodeError
+	case errors.As(err, &graphQLErr):
+		return "graphql_error", exitCodeGraphQL
+	case errors.As(err, &urlErr), errors.As(err, &opErr):
+		return "connection_error", exitCodeConnection
+	}
+
+	return "error", exitCodeError
+}
+
+// printError prints err prefixed by message, as text or as a JSON object
+// depending on --error-format, and returns the exit code for the error.
+func printError(cmd *cobra.Command, err error, message string) int {
+	kind, exitCode := errorKind(err)
+
+	text := err.Error()
+	if message != "" {
+		text = message + ": " + text
+	}
+
+	if errorFormat != "json" {
+		cmd.Println(text)
+		return exitCode
+	}
+
+	output := struct {
+		Error      string `json:"error"`
+		Kind       string `json:"kind"`
+		StatusCode int    `json:"statusCode,omitempty"`
+		ExitCode   int    `json:"exitCode"`
+	}{
+		Error:    text,
+		Kind:     kind,
+		ExitCode: exitCode,
+	}
+
+	var statusErr *api.StatusError
+	if errors.As(err, &statusErr) {
+		output.StatusCode = statusErr.StatusCode
+	}
+
+	data, _ := json.Marshal(output)
+	cmd.Println(string(data))
+
+	return exitCode
+}
+
+func exitOnError(cmd *cobra.Command, err error, message string) {
+	if err != nil {
+		// Requests aborted by --timeout fail with various errors, e.g.
+		// "context deadline exceeded" wrapped in the error of the request.
+		var timeoutErr commandTimeoutError
+		if commandTimedOut() && !errors.As(err, &timeoutErr) {
+			err = commandTimeoutError(commandTimeout)
+		}
+		// Print the error after the output shown in the pager.
+		stopPager()
+		exitCode := printError(cmd, err, message)
+		recordCommandStats(exitCode)
+		recordCommandLog(exitCode, err, message)
+		os.Exit(exitCode)
+	}
+}
+
+// commandTimedOut returns true if the command has run for longer than
+// --timeout.
+func commandTimedOut() bool {
+	return commandContext.Err() == context.DeadlineExceeded
+}
+
+func validateErrorFormat() error {
+	switch errorFormat {
+	case "text", "json":
+		return nil
+	}
+	return usageError(fmt.Sprintf("invalid value %q for --error-format, must be one of: text, json", errorFormat))
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// exportFileNames returns the file name of each exported asset. The file
+// name is the asset name with unsafe characters replaced, so exporting the
+// same assets again gives the same files. Names that would give the same
+// file name get a suffix derived from the full name.
+func exportFileNames(names []string) map[string]string {
+	byBase := map[string][]string{}
+	for _, name := range names {
+		base := unsafeFileNameChars.ReplaceAllString(name, "_")
+		if base == "" || base[0] == '.' {
+			base = "_" + base
+		}
+		byBase[base] = append(byBase[base], name)
+	}
+
+	fileNames := map[string]string{}
+	for base, names := range byBase {
+		for _, name := range names {
+			if len(names) == 1 {
+				fileNames[name] = base + ".yaml"
+				continue
+			}
+			h := fnv.New32a()
+			h.Write([]byte(name))
+			fileNames[name] = fmt.Sprintf("%s-%08x.yaml", base, h.Sum32())
+		}
+	}
+	return fileNames
+}
+
+// writeExportFiles writes the content of each asset, keyed by name, to its
+// file in dir, and returns the written files in order.
+func writeExportFiles(dir string, assets map[string][]byte) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(assets))
+	for name := range assets {
+		names = append(names, name)
+	}
+	fileNames := exportFileNames(names)
+
+	var files []string
+	for _, name := range names {
+		path := filepath.Join(dir, fileNames[name])
+		if err := ioutil.WriteFile(path, assets[name], 0644); err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
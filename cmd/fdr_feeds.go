@@ -0,0 +1,89 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/api"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func newFdrFeedsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "fdr-feeds",
+		Aliases: []string{"feeds"},
+		Short:   "Manage FDR feeds",
+		Long: `FDR feeds ingest data from CrowdStrike Falcon Data Replicator into a
+repository. Humio polls the SQS queue of the feed for notifications about new
+objects in S3, and ingests them using the parser of the feed.`,
+	}
+
+	cmd.AddCommand(newFdrFeedsListCmd())
+	cmd.AddCommand(newFdrFeedsCreateCmd())
+	cmd.AddCommand(newFdrFeedsUpdateCmd())
+	cmd.AddCommand(newFdrFeedsEnableCmd())
+	cmd.AddCommand(newFdrFeedsDisableCmd())
+	cmd.AddCommand(newFdrFeedsRemoveCmd())
+
+	return cmd
+}
+
+// parserNames maps the IDs of the parsers in a repository to their names.
+func parserNames(client *api.Client, repo string) (map[string]string, error) {
+	parsers, err := client.Parsers().List(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]string{}
+	for _, p := range parsers {
+		names[p.ID] = p.Name
+	}
+	return names, nil
+}
+
+// parserIDByName looks up the ID of a parser in the result of parserNames.
+func parserIDByName(names map[string]string, repo, name string) (string, error) {
+	for id, n := range names {
+		if n == name {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("could not find a parser with name '%s' in repo '%s'", name, repo)
+}
+
+func printFdrFeedTable(cmd *cobra.Command, feed *api.FdrFeed, parserName string) {
+	data := [][]string{
+		{"Name", feed.Name},
+		{"Description", valueOrEmpty(feed.Description)},
+		{"Parser", valueOrEmpty(parserName)},
+		{"Client ID", feed.ClientID},
+		{"SQS URL", feed.SqsURL},
+		{"S3 Identifier", feed.S3Identifier},
+		{"Enabled", yesNo(feed.Enabled)},
+	}
+
+	w := tablewriter.NewWriter(cmd.OutOrStdout())
+	w.AppendBulk(data)
+	w.SetBorder(false)
+	w.SetColumnSeparator(":")
+	w.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT})
+
+	cmd.Println()
+	w.Render()
+	cmd.Println()
+}
@@ -0,0 +1,74 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newFdrFeedsCreateCmd() *cobra.Command {
+	var feed api.FdrFeedCreate
+	var parserName string
+	var disabled bool
+
+	cmd := cobra.Command{
+		Use:   "create [flags] <repo> <name>",
+		Short: "Creates an FDR feed in a repository.",
+		Long: `Creates an FDR feed with name <name> in repository <repo>.
+
+The client ID and secret are the AWS credentials given by CrowdStrike for
+reading from the SQS queue and S3 bucket of the feed. The feed is enabled
+unless --disabled is given.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			for _, flag := range []string{"parser", "client-id", "client-secret", "sqs-url", "s3-identifier"} {
+				if !cmd.Flags().Changed(flag) {
+					return fmt.Errorf("the --%s flag is required", flag)
+				}
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			repo := args[0]
+			feed.Name = args[1]
+			feed.Enabled = !disabled
+
+			client := NewApiClient(cmd)
+
+			parsers, err := parserNames(client, repo)
+			exitOnError(cmd, err, "error fetching parsers")
+
+			feed.ParserID, err = parserIDByName(parsers, repo, parserName)
+			exitOnError(cmd, err, "error resolving parser")
+
+			created, err := client.FdrFeeds().Create(repo, feed)
+			exitOnError(cmd, err, "error creating FDR feed")
+
+			printFdrFeedTable(cmd, created, parserName)
+		},
+	}
+
+	cmd.Flags().StringVar(&feed.Description, "description", "", "A description of the feed.")
+	cmd.Flags().StringVarP(&parserName, "parser", "p", "", "The parser used to parse the ingested data.")
+	cmd.Flags().StringVar(&feed.ClientID, "client-id", "", "The AWS access key ID of the feed.")
+	cmd.Flags().StringVar(&feed.ClientSecret, "client-secret", "", "The AWS secret access key of the feed.")
+	cmd.Flags().StringVar(&feed.SqsURL, "sqs-url", "", "The URL of the SQS queue of the feed.")
+	cmd.Flags().StringVar(&feed.S3Identifier, "s3-identifier", "", "The S3 identifier of the feed, as given by CrowdStrike.")
+	cmd.Flags().BoolVar(&disabled, "disabled", false, "Create the feed without enabling it.")
+
+	return &cmd
+}
@@ -0,0 +1,55 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newFdrFeedsEnableCmd() *cobra.Command {
+	return newFdrFeedsToggleCmd(true)
+}
+
+// newFdrFeedsToggleCmd creates the enable or disable command, which only
+// differ in the state the feed is set to.
+func newFdrFeedsToggleCmd(enable bool) *cobra.Command {
+	verb, pastTense := "disable", "disabled"
+	if enable {
+		verb, pastTense = "enable", "enabled"
+	}
+
+	cmd := cobra.Command{
+		Use:   verb + " [flags] <repo> <name>",
+		Short: fmt.Sprintf("%ss an FDR feed.", strings.Title(verb)),
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			repo := args[0]
+			name := args[1]
+
+			client := NewApiClient(cmd)
+
+			_, err := client.FdrFeeds().Update(repo, name, api.FdrFeedChangeSet{Enabled: &enable})
+			exitOnError(cmd, err, "error updating FDR feed")
+
+			cmd.Println(fmt.Sprintf("FDR feed %s %s", name, pastTense))
+		},
+	}
+
+	return &cmd
+}
@@ -0,0 +1,50 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newFdrFeedsListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list [flags] <repo>",
+		Short: "List all FDR feeds in a repository.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			repo := args[0]
+
+			client := NewApiClient(cmd)
+
+			feeds, err := client.FdrFeeds().List(repo)
+			exitOnError(cmd, err, "error fetching FDR feeds")
+
+			parsers, err := parserNames(client, repo)
+			exitOnError(cmd, err, "error fetching parsers")
+
+			var output []string
+			output = append(output, "Name | Enabled | Parser | SQS URL")
+			for _, feed := range feeds {
+				output = append(output, fmt.Sprintf("%v | %v | %v | %v", feed.Name, yesNo(feed.Enabled), valueOrEmpty(parsers[feed.ParserID]), feed.SqsURL))
+			}
+
+			printTable(cmd, output)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,71 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newFdrFeedsUpdateCmd() *cobra.Command {
+	var nameFlag, descriptionFlag, parserFlag, clientIDFlag, clientSecretFlag, sqsURLFlag, s3IdentifierFlag stringPtrFlag
+
+	cmd := cobra.Command{
+		Use:   "update [flags] <repo> <name>",
+		Short: "Updates the settings of an FDR feed.",
+		Long: `Updates the FDR feed with name <name> in repository <repo>. Only the
+settings given as flags are changed.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			repo := args[0]
+			name := args[1]
+
+			client := NewApiClient(cmd)
+
+			changeset := api.FdrFeedChangeSet{
+				Name:         nameFlag.value,
+				Description:  descriptionFlag.value,
+				ClientID:     clientIDFlag.value,
+				ClientSecret: clientSecretFlag.value,
+				SqsURL:       sqsURLFlag.value,
+				S3Identifier: s3IdentifierFlag.value,
+			}
+
+			parsers, err := parserNames(client, repo)
+			exitOnError(cmd, err, "error fetching parsers")
+
+			if parserFlag.value != nil {
+				id, err := parserIDByName(parsers, repo, *parserFlag.value)
+				exitOnError(cmd, err, "error resolving parser")
+				changeset.ParserID = &id
+			}
+
+			feed, err := client.FdrFeeds().Update(repo, name, changeset)
+			exitOnError(cmd, err, "error updating FDR feed")
+
+			printFdrFeedTable(cmd, feed, parsers[feed.ParserID])
+		},
+	}
+
+	cmd.Flags().Var(&nameFlag, "name", "A new name for the feed.")
+	cmd.Flags().Var(&descriptionFlag, "description", "A description of the feed.")
+	cmd.Flags().VarP(&parserFlag, "parser", "p", "The parser used to parse the ingested data.")
+	cmd.Flags().Var(&clientIDFlag, "client-id", "The AWS access key ID of the feed.")
+	cmd.Flags().Var(&clientSecretFlag, "client-secret", "The AWS secret access key of the feed.")
+	cmd.Flags().Var(&sqsURLFlag, "sqs-url", "The URL of the SQS queue of the feed.")
+	cmd.Flags().Var(&s3IdentifierFlag, "s3-identifier", "The S3 identifier of the feed, as given by CrowdStrike.")
+
+	return &cmd
+}
@@ -28,17 +28,64 @@ func newHealthCmd() *cobra.Command {
 		failFlag       bool
 		warnAsDownFlag bool
 		selectChecks   []string
+		failOn         string
+		allProfiles    bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "health",
 		Short: "Health",
-		Args:  cobra.ExactArgs(0),
+		Long: `Shows the health checks of the Humio cluster.
+
+Using --fail-on the command can be used directly as a health probe, e.g. for
+Nagios or Kubernetes. The exit code then follows the Nagios conventions:
+
+  0  All selected checks are OK
+  1  At least one selected check is WARN (only with --fail-on=warn)
+  2  At least one selected check is DOWN
+  3  The health of the cluster could not be determined
+
+  $ humioctl health --select=cluster-time-skew --select=primary-disk-usage --fail-on=warn`,
+		Args: cobra.ExactArgs(0),
 
 		Run: func(cmd *cobra.Command, args []string) {
+			switch failOn {
+			case "", "warn", "error":
+			default:
+				exitOnError(cmd, fmt.Errorf("must be one of 'warn' or 'error', got %q", failOn), "invalid value for --fail-on")
+			}
+
+			if allProfiles {
+				if jsonFlag || versionFlag || uptimeFlag || failFlag || failOn != "" || len(selectChecks) > 0 {
+					exitOnError(cmd, usageError("--all-profiles can only be used to show a summary, not with --json, --version, --uptime, --fail, --fail-on or --select"), "")
+				}
+
+				printForAllProfiles(cmd, []string{"Status", "Message", "Version", "Uptime", "Checks Not OK"}, func(client *api.Client) ([][]string, error) {
+					health, err := client.Health()
+					if err != nil {
+						return nil, err
+					}
+
+					var notOK []string
+					for name, c := range health.ChecksMap() {
+						if c.Status != api.StatusOK {
+							notOK = append(notOK, name)
+						}
+					}
+					sort.Strings(notOK)
+
+					return [][]string{{string(health.Status), health.StatusMessage, health.Version, health.Uptime, strings.Join(notOK, ", ")}}, nil
+				})
+				return
+			}
+
 			client := NewApiClient(cmd)
 
 			health, err := client.Health()
+			if err != nil && failOn != "" {
+				cmd.Println(fmt.Errorf("error getting health information: %s", err))
+				os.Exit(healthExitUnknown)
+			}
 			exitOnError(cmd, err, "error getting health information")
 
 			switch {
@@ -51,11 +98,14 @@ func newHealthCmd() *cobra.Command {
 			}
 
 			m := health.ChecksMap()
+			missingChecks := 0
 			if len(selectChecks) > 0 {
 				newMap := map[string]api.HealthCheck{}
 				for _, s := range selectChecks {
 					if c, ok := m[s]; ok {
 						newMap[s] = c
+					} else {
+						missingChecks++
 					}
 				}
 				m = newMap
@@ -85,6 +135,15 @@ func newHealthCmd() *cobra.Command {
 
 				os.Exit(numDown)
 			}
+
+			if failOn != "" {
+				code := healthExitCode(m, failOn == "warn")
+				if code == healthExitOK && missingChecks > 0 {
+					// A check we were asked to look at does not exist, so we cannot tell if it is healthy.
+					code = healthExitUnknown
+				}
+				os.Exit(code)
+			}
 		},
 	}
 
@@ -95,11 +154,34 @@ func newHealthCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&warnAsDownFlag, "warn-as-down", false, "When used with --fail: Treat warnings as down")
 	cmd.Flags().StringSliceVarP(&selectChecks, "select", "s", nil, "Select checks to display. Specify multiple times for multiple checks.\n"+
 		"If the server does not support the selected value, it will be left out.\n"+
-		"Note: --select affects the checks that are considered by --fail")
+		"Note: --select affects the checks that are considered by --fail and --fail-on.\n"+
+		"With --fail-on, selecting a check the server does not have gives exit code 3.")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "Exit with a non-zero exit code if a selected check is 'warn' or worse, or 'error' (DOWN). See the exit codes above.")
+	registerAllProfilesFlag(cmd, &allProfiles)
 
 	return cmd
 }
 
+const (
+	healthExitOK      = 0
+	healthExitWarn    = 1
+	healthExitDown    = 2
+	healthExitUnknown = 3
+)
+
+func healthExitCode(checks map[string]api.HealthCheck, failOnWarn bool) int {
+	code := healthExitOK
+	for _, c := range checks {
+		switch {
+		case c.Status == api.StatusDown:
+			return healthExitDown
+		case c.Status == api.StatusWarn && failOnWarn:
+			code = healthExitWarn
+		}
+	}
+	return code
+}
+
 func encodeAsText(writer io.Writer, result healthCheckResult) {
 	tw := tablewriter.NewWriter(writer)
 	tw.SetAutoWrapText(false)
@@ -0,0 +1,113 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd() *cobra.Command {
+	var limit listLimitFlags
+
+	cmd := &cobra.Command{
+		Use:   "history [flags]",
+		Short: "List and re-run recorded searches",
+		Long: `Lists the searches run with 'humioctl search', most recent last, with the time
+range, how long they took and the number of results.
+
+A search can be run again using its number:
+
+  $ humioctl history run 12
+
+The history is kept in history.jsonl next to the config file and holds the
+last 1000 searches. Searches run with --no-history are not recorded.`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			entries, err := readQueryHistory()
+			exitOnError(cmd, err, "error reading the query history")
+
+			first := 0
+			if l := limit.value(); l > 0 && len(entries) > l {
+				first = len(entries) - l
+			}
+
+			rows := make([][]string, 0, len(entries)-first)
+			for i := first; i < len(entries); i++ {
+				entry := entries[i]
+
+				results := "error"
+				switch {
+				case entry.Results != nil:
+					results = strconv.Itoa(*entry.Results)
+				case entry.Error == "":
+					results = "-"
+				}
+
+				rows = append(rows, []string{
+					strconv.Itoa(i + 1),
+					entry.Time.Local().Format("2006-01-02 15:04:05"),
+					entry.Repository,
+					formatHistoryTimeRange(entry),
+					(time.Duration(entry.DurationMs) * time.Millisecond).String(),
+					results,
+					truncateString(strings.Join(strings.Fields(entry.Query), " "), 60),
+				})
+			}
+
+			w := tablewriter.NewWriter(cmd.OutOrStdout())
+			w.SetHeader([]string{"#", "Time", "Repo", "Range", "Duration", "Results", "Query"})
+			w.AppendBulk(rows)
+			w.SetBorder(false)
+			w.SetAutoWrapText(false)
+
+			w.Render()
+			cmd.Println()
+
+			limit.printTruncated(len(rows), len(entries), "searches")
+		},
+	}
+
+	limit.register(cmd, "searches")
+
+	cmd.AddCommand(newHistoryRunCmd())
+	cmd.AddCommand(newHistoryClearCmd())
+
+	return cmd
+}
+
+func formatHistoryTimeRange(entry queryHistoryEntry) string {
+	switch {
+	case entry.Live:
+		return fmt.Sprintf("live %s", entry.Start)
+	case entry.End != "":
+		return fmt.Sprintf("%s to %s", entry.Start, entry.End)
+	default:
+		return entry.Start
+	}
+}
+
+func truncateString(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,101 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newHistoryRunCmd() *cobra.Command {
+	var start, end string
+
+	cmd := &cobra.Command{
+		Use:   "run [flags] [<number>]",
+		Short: "Run a search from the query history again",
+		Long: `Runs the search with the given number in 'humioctl history' again, with the
+same repository, query, time range and parameters. Without a number the most
+recent search is run.
+
+The search is run against the current profile, which need not be the one it
+was recorded with. Use --start and --end to change the time range, e.g.
+
+  $ humioctl history run 12 --start 1h`,
+		Args: cobra.RangeArgs(0, 1),
+		Run: func(cmd *cobra.Command, args []string) {
+			entries, err := readQueryHistory()
+			exitOnError(cmd, err, "error reading the query history")
+
+			if len(entries) == 0 {
+				exitOnError(cmd, fmt.Errorf("the query history is empty"), "")
+			}
+
+			n := len(entries)
+			if len(args) == 1 {
+				n, err = strconv.Atoi(args[0])
+				if err != nil || n < 1 || n > len(entries) {
+					exitOnError(cmd, usageError(fmt.Sprintf("expected a number from 1 to %d, got %q", len(entries), args[0])), "")
+				}
+			}
+			entry := entries[n-1]
+
+			searchArgs := []string{entry.Repository, entry.Query}
+			if strings.Contains(entry.Repository, ",") {
+				// A search of several repositories, recorded comma separated.
+				searchArgs = []string{"--repo", entry.Repository, entry.Query}
+			}
+			if cmd.Flags().Changed("start") {
+				searchArgs = append(searchArgs, "--start", start)
+			} else if entry.Start != "" {
+				searchArgs = append(searchArgs, "--start", entry.Start)
+			}
+			if cmd.Flags().Changed("end") {
+				searchArgs = append(searchArgs, "--end", end)
+			} else if entry.End != "" {
+				searchArgs = append(searchArgs, "--end", entry.End)
+			}
+			if entry.Live {
+				searchArgs = append(searchArgs, "--live")
+			}
+
+			params := make([]string, 0, len(entry.Params))
+			for k, v := range entry.Params {
+				params = append(params, k+"="+v)
+			}
+			sort.Strings(params)
+			for _, p := range params {
+				searchArgs = append(searchArgs, "--param", p)
+			}
+
+			fmt.Fprintf(os.Stderr, "Running search %d in %s: %s\n", n, entry.Repository, truncateString(strings.Join(strings.Fields(entry.Query), " "), 60))
+
+			search := newSearchCmd()
+			search.SetArgs(searchArgs)
+			search.SetOutput(cmd.OutOrStdout())
+			err = search.Execute()
+			exitOnError(cmd, err, "error running search")
+		},
+	}
+
+	cmd.Flags().StringVarP(&start, "start", "s", "", "Query start time. Defaults to the start time of the recorded search.")
+	cmd.Flags().StringVarP(&end, "end", "e", "", "Query end time. Defaults to the end time of the recorded search.")
+
+	return cmd
+}
@@ -3,166 +3,297 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/hpcloud/tail"
 	"github.com/humio/cli/api"
 	"github.com/skratchdot/open-golang/open"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 var batchLimit = 500
-var events = make(chan string, batchLimit)
 
 type eventList struct {
 	Type     string            `json:"type"`
 	Fields   map[string]string `json:"fields"`
+	Tags     map[string]string `json:"tags,omitempty"`
 	Messages []string          `json:"messages"`
 }
 
-func tailFile(client *api.Client, repo string, filepath string, quiet bool) {
-
-	// Join Tail
-
-	t, err := tail.TailFile(filepath, tail.Config{Follow: true})
-
-	if err != nil {
-		log.Fatal(err)
+// tailFile sends the lines of a file until ctx is cancelled, waiting for
+// more lines at the end of the file. With a checkpoint, the file is followed
+// when it is rotated: once all lines of the moved or deleted file have been
+// read, the file replacing it is tailed from its start.
+func tailFile(ctx context.Context, sender *ingestSender, filepath string, quiet bool, checkpoint *ingestCheckpoint) error {
+	config := tail.Config{Follow: true}
+	if checkpoint != nil {
+		config.Location = checkpoint.seekInfo()
 	}
 
-	for line := range t.Lines {
-		sendLine(line.Text)
-		if !quiet {
-			fmt.Println(line.Text)
+	for {
+		t, err := tail.TailFile(filepath, config)
+		if err != nil {
+			return err
 		}
-	}
 
-	tailError := t.Wait()
+	read:
+		for {
+			select {
+			case line, ok := <-t.Lines:
+				if !ok {
+					break read
+				}
+				var pos checkpointPosition
+				if checkpoint != nil {
+					pos = checkpoint.lineRead(len(line.Text))
+				}
+				if !sender.sendLineAt(ctx, line.Text, pos) {
+					_ = t.Stop()
+					return nil
+				}
+				if !quiet {
+					fmt.Println(line.Text)
+				}
+			case <-ctx.Done():
+				_ = t.Stop()
+				return nil
+			}
+		}
+
+		// The lines are only closed when the tail fails, or stops because
+		// the file has been moved or deleted.
+		if err := t.Wait(); err != nil || checkpoint == nil {
+			return err
+		}
 
-	if tailError != nil {
-		log.Fatal(tailError)
+		checkpoint.fileReplaced()
+		config.Location = nil
 	}
 }
 
-func streamStdin(repo string, quiet bool) {
+func streamStdin(ctx context.Context, sender *ingestSender, repo string, quiet bool) {
 	log.Println("Humio Attached to StdIn, Forwarding to '" + repo + "'")
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		text := scanner.Text()
-		sendLine(text)
-		// TODO: We should be able to do this more efficiently.
-		// Somehow connecting Stdin to Stdout
-		if !quiet {
-			fmt.Println(text)
+
+	// Reading from stdin cannot be interrupted, so read in the background to
+	// be able to stop when ctx is cancelled.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			text := scanner.Text()
+			if !sender.sendLine(ctx, text) {
+				return
+			}
+			// TODO: We should be able to do this more efficiently.
+			// Somehow connecting Stdin to Stdout
+			if !quiet {
+				fmt.Println(text)
+			}
 		}
-	}
 
-	if scanner.Err() != nil {
-		log.Fatal(scanner.Err())
+		if scanner.Err() != nil {
+			log.Fatal(scanner.Err())
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
 	}
+}
 
-	waitForInterrupt()
+// ingestSender sends lines in batches in the background. A batch is sent
+// when it is full, or when no more lines are ready to be sent.
+type ingestSender struct {
+	events  chan queuedLine
+	stopped chan struct{}
+	done    chan struct{}
 }
 
-func waitForInterrupt() {
-	// Go signal notification works by sending `os.Signal`
-	// values on a channel. We'll create a channel to
-	// receive these notifications (we'll also make one to
-	// notify us when the program can exit).
-	sigs := make(chan os.Signal, 1)
-	done := make(chan bool, 1)
-
-	// `signal.Notify` registers the given channel to
-	// receive notifications of the specified signals.
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-
-	// This goroutine executes a blocking receive for
-	// signals. When it gets one it'll print it out
-	// and then notify the program that it can finish.
-	go func() {
-		sig := <-sigs
-		fmt.Println()
-		fmt.Println(sig)
-		done <- true
-	}()
+// queuedLine is a line to be sent, with its position in the file it was
+// read from when tailing with a checkpoint.
+type queuedLine struct {
+	text     string
+	position checkpointPosition
+}
 
-	// The program will wait here until it gets the
-	// expected signal (as indicated by the goroutine
-	// above sending a value on `done`) and then exit.
-	<-done
+func startSending(send func(batch []string) error) *ingestSender {
+	return startSendingWithCheckpoint(send, nil)
 }
 
-func startSending(client *api.Client, repo string, fields map[string]string, parserName string) {
+// startSendingWithCheckpoint is like startSending, but moves the checkpoint
+// to the position of the last line of each batch that has been sent.
+func startSendingWithCheckpoint(send func(batch []string) error, checkpoint *ingestCheckpoint) *ingestSender {
+	s := &ingestSender{
+		events:  make(chan queuedLine, batchLimit),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
 	go func() {
+		defer close(s.done)
+
 		var batch []string
+		var last checkpointPosition
+		add := func(line queuedLine) {
+			batch = append(batch, line.text)
+			last = line.position
+		}
+		flush := func() {
+			if len(batch) > 0 {
+				err := send(batch)
+				if checkpoint != nil {
+					if err != nil {
+						checkpoint.batchFailed()
+					} else if saveErr := checkpoint.batchSent(last); saveErr != nil {
+						err = fmt.Errorf("error saving checkpoint: %v", saveErr)
+					}
+				}
+				if err != nil {
+					fmt.Println(fmt.Errorf("error while sending data: %v", err))
+				}
+				batch = batch[:0]
+			}
+		}
+
 		for {
 			select {
-			case v := <-events:
-				batch = append(batch, v)
+			case v := <-s.events:
+				add(v)
 				if len(batch) >= batchLimit {
-					sendBatch(client, repo, batch, fields, parserName)
-					batch = batch[:0]
+					flush()
 				}
+				continue
 			default:
-				if len(batch) > 0 {
-					sendBatch(client, repo, batch, fields, parserName)
-					batch = batch[:0]
+			}
+
+			flush()
+
+			// Avoid busy waiting
+			select {
+			case v := <-s.events:
+				add(v)
+			case <-s.stopped:
+				for {
+					select {
+					case v := <-s.events:
+						add(v)
+						if len(batch) >= batchLimit {
+							flush()
+						}
+					default:
+						flush()
+						return
+					}
 				}
-				// Avoid busy waiting
-				batch = append(batch, <-events)
 			}
 		}
 	}()
+
+	return s
+}
+
+// sendLine queues a line to be sent. It returns false if ctx was cancelled
+// before the line could be queued.
+func (s *ingestSender) sendLine(ctx context.Context, line string) bool {
+	return s.sendLineAt(ctx, line, checkpointPosition{})
+}
+
+// sendLineAt is like sendLine for a line read from a file at pos.
+func (s *ingestSender) sendLineAt(ctx context.Context, line string, pos checkpointPosition) bool {
+	select {
+	case s.events <- queuedLine{text: line, position: pos}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
-func sendLine(line string) {
-	events <- line
+// stop sends the lines that have been queued and waits for them to be sent.
+// No more lines can be sent afterwards.
+func (s *ingestSender) stop() {
+	close(s.stopped)
+	<-s.done
 }
 
-func sendBatch(client *api.Client, repo string, messages []string, fields map[string]string, parserName string) {
+func sendBatch(client *api.Client, repo string, messages []string, fields, tags map[string]string, parserName string, compression *ingestCompression) error {
 	lineJSON, err := json.Marshal([1]eventList{
 		eventList{
 			Type:     parserName,
 			Fields:   fields,
+			Tags:     tags,
 			Messages: messages,
 		}})
 
 	if err != nil {
-		fmt.Printf("error while sending data: %v", err)
-		return
+		return err
 	}
 
-	url := "api/v1/repositories/" + repo + "/ingest-messages"
-	resp, err := client.HTTPRequest(http.MethodPost, url, bytes.NewBuffer(lineJSON))
+	return postIdempotentIngestRequest(client, "api/v1/repositories/"+repo+"/ingest-messages", lineJSON, compression)
+}
+
+func postIngestRequest(client *api.Client, url string, body []byte) error {
+	return postIngestRequestWithHeaders(client, url, body, nil)
+}
+
+// postIdempotentIngestRequest is like postIngestRequest, but sets an
+// Idempotency-Key header derived from the body, so a server or proxy
+// supporting it can discard requests that are sent again, e.g. when they
+// are retried after a timeout. The body is compressed unless compression is
+// nil or has been turned off.
+func postIdempotentIngestRequest(client *api.Client, url string, body []byte, compression *ingestCompression) error {
+	sum := sha256.Sum256(body)
+	return compression.post(client, url, body, map[string]string{"Idempotency-Key": hex.EncodeToString(sum[:])})
+}
+
+func postIngestRequestWithHeaders(client *api.Client, url string, body []byte, headers map[string]string) error {
+	resp, err := client.HTTPRequestContextWithHeaders(context.Background(), http.MethodPost, url, bytes.NewBuffer(body), headers)
 
 	if err != nil {
-		fmt.Println((fmt.Errorf("error while sending data: %v", err)))
+		return err
 	}
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode > 400 {
+	if resp.StatusCode >= 400 {
 		responseData, err := ioutil.ReadAll(resp.Body)
 
 		if err != nil {
-			fmt.Println(fmt.Errorf("error while sending data: %v", err))
+			return err
 		}
 
-		fmt.Println((fmt.Errorf("Bad response while sending events: %s", string(responseData))))
+		return &api.StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("bad response while sending events: %s", string(responseData))}
 	}
+
+	return nil
 }
 
 func newIngestCmd() *cobra.Command {
-	var parserName, filepath, label string
-	var openBrowser, noSession, quiet bool
+	var listenHEC, listenToken, metricsListen string
+	var parserName, label, timestampField, checkpointFile, inputFormatName, timestampFormat, timezone string
+	var openBrowser, noSession, quiet, jsonInput, preserveTimestamps, dryRun, dedup, compress bool
+	var showEvents int
+	var dedupWindow time.Duration
+	var dedupFile string
+	var tagFields, tailPaths []string
+	var tagFlags keyValueFlag
+	var s3 s3IngestOptions
+	var kafka kafkaIngestOptions
+	var delimited delimitedOptions
 
 	cmd := cobra.Command{
 		Use:   "ingest [flags] [<repo>]",
@@ -180,10 +311,154 @@ You can pipe the output of another process through humio:
 
   $ tail -f /var/log/syslog | humio ingest --ingest-token=af21... --parser=syslog
 
+The ingested data is echoed to stdout. When stderr is a terminal, a progress
+bar with the data and events sent per second is shown instead, with the
+time left if stdin is redirected from a file, and the data is only echoed
+if stdout is redirected. A summary with the number of
+events that could not be sent and the requests retried is printed at the
+end. Use --quiet to print neither, e.g. in scripts:
+
+  $ humioctl ingest web --parser=accesslog < access.log
+
 Alternatively, you can use the --tail=<file> argument, which
-has the same effect.`,
-		ValidArgs: []string{"repo"},
-		Args:      cobra.RangeArgs(0, 1),
+has the same effect. --tail can be given multiple times and accepts glob
+patterns, which are checked for new files while tailing, so files created
+later are sent as well. Each event gets a @source field with the name of
+the file it was read from:
+
+  $ humioctl ingest dev --tail='/var/log/*.log' --tail=./app/logs/server.log
+
+When tailing a single file you can use --checkpoint-file=<file> to record
+how far into the file data has been sent. If the CLI is restarted with the
+same checkpoint file it resumes where it left off, and starts from the
+beginning of the file if it has been rotated or truncated in the meantime.
+The checkpoint is not moved past a batch that could not be sent, so its
+lines are sent again when the CLI is restarted.
+
+If your input is newline-delimited JSON you can use --json to send each
+line as a structured event. The JSON attributes become fields on the event
+instead of being parsed from @rawstring:
+
+  $ cat events.ndjson | humioctl ingest --json --timestamp-field=time --tag-field=host
+
+Lines that are not valid JSON objects are sent with only a @rawstring.
+
+For quick tests of security log sources, --input-format does a minimal
+normalization of common formats before sending, extracting the timestamp
+and fields and assigning tags, so no parser is needed:
+
+  syslog    RFC 5424 and RFC 3164 syslog lines
+  jsonl     Newline-delimited JSON, the same as --json
+  cef       ArcSight Common Event Format, tagged by vendor and product
+  leef      QRadar Log Event Extended Format, tagged by vendor and product
+  evtx-xml  Windows events in XML, one per line, tagged by channel, e.g.
+            from 'wevtutil qe Security /f:xml'
+  csv, tsv  Comma or tab separated values, with the fields named by the
+            header row
+
+  $ humioctl ingest --input-format=cef --tail=/var/log/firewall.log
+
+The original line is kept as @rawstring, except for jsonl. Lines that are
+not in the format are sent with only a @rawstring.
+
+Use --tag to add tags to all events, e.g. to keep the data of each
+environment in its own datasource. Input formats only assign their own tags
+when neither --tag nor --tag-field is given:
+
+  $ humioctl ingest web --tag env=prod --tag region=eu --tail=/var/log/nginx/access.log
+
+With csv and tsv, the first line of each file is the header row naming the
+fields, unless the names are given with --csv-columns. Records must be on a
+single line. Values are sent as strings, unless --csv-types gives the type
+of a column or --csv-infer-types is used to send numbers and booleans as such:
+
+  $ humioctl ingest sales --input-format=csv --csv-types=amount=float,quantity=int --timestamp-field=date --tail=sales.csv
+
+When backfilling historical logs use --preserve-timestamps to set @timestamp
+to the timestamp found in each line, instead of the time it is ingested.
+Common formats such as ISO 8601, syslog, access log and epoch seconds or
+milliseconds are detected automatically, or you can give a strptime-style
+layout with --timestamp-format. Timestamps without a time zone are read in
+--timezone, which defaults to UTC:
+
+  $ humioctl ingest app --preserve-timestamps --timestamp-format="%d.%m.%Y %H:%M:%S" --timezone=Europe/Copenhagen --tail=app.log
+
+The lines are sent as structured events with a @rawstring, so they are not
+parsed by a parser. Lines without a timestamp use the time they are read.
+With --json or --input-format the timestamp is taken from the event, and
+only looked for in the line if the event has none.
+
+To test appliances that can only send to a Splunk HTTP Event Collector,
+use --listen-hec to run a local collector that forwards the events it
+receives to <repo>. The event, raw and health endpoints under
+/services/collector are supported, as well as Humio's
+/api/v1/ingest/humio-structured and humio-unstructured endpoints. Events
+from the event endpoint are sent as structured events with their time, host,
+source, sourcetype and fields. Clients must authenticate with the token
+given by --listen-token, which is required unless the listener is bound to
+a loopback address, e.g. localhost:8088. Requests larger than 32MB are
+rejected.
+
+  $ humioctl ingest appliances --listen-hec=:8088 --listen-token=secret
+
+To backfill archived logs from AWS S3 use --s3-url with the URL of an
+object, or of a prefix ending in a slash to ingest all objects under it.
+Gzipped objects are decompressed. Credentials and region are read from
+the standard AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN
+and AWS_REGION environment variables:
+
+  $ humioctl ingest weblogs --parser=accesslog --s3-url=s3://my-bucket/logs/2020/01/
+
+To bridge a Kafka topic to Humio use --kafka with the name of the topic.
+Each message is sent as an event. With --kafka-group the ingest joins the
+consumer group and consumes the partitions assigned to it, so several
+ingests, or other consumers of the topic, can share the partitions. The
+offsets of sent messages are committed to the group, so a restarted ingest
+resumes where it left off. The password for SASL authentication is read from
+the KAFKA_PASSWORD environment variable:
+
+  $ humioctl ingest weblogs --kafka=weblogs --kafka-brokers=kafka1:9092,kafka2:9092 --kafka-group=humio-bridge
+
+Messages can be compressed with gzip, snappy, lz4 or zstd, and Kafka 1.0 or
+later is required.
+
+To check how your flags map the input to events before sending anything,
+use --dry-run. The first events, 10 unless --show-events is given, are
+printed as JSON with the fields, tags, @timestamp and parser they would be
+sent with, and nothing is sent. Structured events, i.e. with --json,
+--input-format or --preserve-timestamps, are not parsed and have no parser.
+Lines sent to a parser have no @timestamp, as it is assigned by the parser:
+
+  $ head -n 100 events.ndjson | humioctl ingest --json --tag-field=host --dry-run --show-events=3
+
+Use --dedup to skip lines that are identical to a line read within the last
+--dedup-window, e.g. when the same events are read from several files. To
+be able to re-run a backfill that partially failed without creating
+duplicates, record the lines sent with --dedup-file. Lines recorded in the
+file by earlier runs are skipped, regardless of the window. Only a hash of
+each line is kept, but all of them are held in memory:
+
+  $ humioctl ingest archive --dedup-file=backfill.dedup --preserve-timestamps < old.log
+
+Each request also has an Idempotency-Key header derived from the events in
+it, so a proxy or server supporting it can discard requests that are sent
+twice, e.g. when retried after a timeout. Use --no-session for the key to be
+the same when the same events are sent again by another run.
+
+The requests are compressed with gzip, which typically reduces the data
+sent by 80-90%, e.g. when backfilling over a slow link. If the server or a
+proxy in front of it rejects compressed requests, they are sent
+uncompressed instead. Use --compress=false to never compress them, e.g. to
+save CPU on a fast network.
+
+To monitor a long-running ingest, e.g. with --tail, --listen-hec or --kafka,
+use --metrics-listen to serve metrics in the Prometheus text format on
+/metrics at an address. They include the events and bytes sent, the events
+that could not be sent, and the requests made to Humio by endpoint and
+status code, with the errors, retries and a histogram of their latency:
+
+  $ humioctl ingest web --tail=/var/log/nginx/access.log --metrics-listen=localhost:9464`,
+		Args: cobra.RangeArgs(0, 1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var repo string
 
@@ -194,8 +469,35 @@ has the same effect.`,
 				repo = "sandbox"
 			}
 
+			if dryRun {
+				if listenHEC != "" || checkpointFile != "" || kafka.group != "" || openBrowser || metricsListen != "" {
+					exitOnError(cmd, usageError("--dry-run cannot be used together with --listen-hec, --checkpoint-file, --kafka-group, --metrics-listen or --open"), "")
+				}
+				if showEvents < 1 {
+					exitOnError(cmd, usageError("--show-events must be at least 1"), "")
+				}
+			} else if cmd.Flags().Changed("show-events") {
+				exitOnError(cmd, usageError("--show-events can only be used with --dry-run"), "")
+			}
+
+			if dedupFile != "" {
+				if dryRun {
+					exitOnError(cmd, usageError("--dedup-file cannot be used together with --dry-run"), "")
+				}
+				dedup = true
+			}
+			if cmd.Flags().Changed("dedup-window") && !dedup {
+				exitOnError(cmd, usageError("--dedup-window can only be used with --dedup or --dedup-file"), "")
+			}
+
 			client := NewApiClient(cmd)
 
+			var metrics *ingestMetrics
+			if metricsListen != "" {
+				metrics = newIngestMetrics()
+				client = client.WithRequestLogger(metrics.observe)
+			}
+
 			var key string
 			fields := map[string]string{}
 
@@ -213,6 +515,15 @@ has the same effect.`,
 				key = "%40label%3D" + label
 			}
 
+			// Tags may be given as they are written in queries, e.g. #env=prod.
+			var tags map[string]string
+			for k, v := range tagFlags.values {
+				if tags == nil {
+					tags = map[string]string{}
+				}
+				tags[strings.TrimPrefix(k, "#")] = v
+			}
+
 			// Open the browser (First so it has a chance to load)
 			if openBrowser {
 				err := open.Start(client.Address() + repo + "/search?live=true&start=1d&query=" + key)
@@ -221,25 +532,266 @@ has the same effect.`,
 				}
 			}
 
-			startSending(client, repo, fields, parserName)
+			if jsonInput {
+				if inputFormatName != "" && inputFormatName != "jsonl" {
+					exitOnError(cmd, usageError("--json cannot be used together with --input-format"), "")
+				}
+				inputFormatName = "jsonl"
+			}
+
+			var timestamps *timestampExtractor
+			if preserveTimestamps {
+				if cmd.Flags().Changed("parser") {
+					exitOnError(cmd, usageError("--preserve-timestamps cannot be used together with --parser, since the events are not parsed"), "")
+				}
 
-			if filepath != "" {
-				tailFile(client, repo, filepath, quiet)
+				location, err := time.LoadLocation(timezone)
+				if err != nil {
+					exitOnError(cmd, usageError(fmt.Sprintf("invalid time zone %q", timezone)), "")
+				}
+
+				timestamps, err = newTimestampExtractor(timestampFormat, location)
+				if err != nil {
+					exitOnError(cmd, usageError(err.Error()), "")
+				}
+			} else if cmd.Flags().Changed("timestamp-format") || cmd.Flags().Changed("timezone") {
+				exitOnError(cmd, usageError("--timestamp-format and --timezone can only be used with --preserve-timestamps"), "")
+			}
+
+			// On Ctrl-C, stop reading input, but send what has already been read before exiting.
+			ctx := contextCancelledOnInterrupt(commandContext)
+
+			// With --dry-run the events are printed instead of sent, and
+			// reading stops when enough events have been shown.
+			var preview *ingestPreview
+			if dryRun {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				preview = &ingestPreview{out: os.Stdout, limit: showEvents, done: cancel}
+			}
+
+			var compression *ingestCompression
+			if compress {
+				compression = &ingestCompression{}
+			}
+
+			// newSend returns a function sending batches of lines with the given fields.
+			var newSend func(fields map[string]string) func(batch []string) error
+			if inputFormatName != "" || preserveTimestamps {
+				format, ok := inputFormats[inputFormatName]
+				if inputFormatName == "" {
+					// Send plain lines as @rawstring, with the timestamp found in the line.
+					format, ok = plainLineFormat, true
+				}
+				if !ok {
+					exitOnError(cmd, usageError(fmt.Sprintf("unsupported input format %q, must be one of: %s", inputFormatName, strings.Join(inputFormatNames(), ", "))), "")
+				}
+
+				if format.delimiter != 0 {
+					if err := delimited.validate(); err != nil {
+						exitOnError(cmd, usageError(err.Error()), "")
+					}
+				} else if cmd.Flags().Changed("csv-columns") || cmd.Flags().Changed("csv-types") || delimited.inferTypes {
+					exitOnError(cmd, usageError("--csv-columns, --csv-types and --csv-infer-types can only be used with --input-format=csv or tsv"), "")
+				}
+
+				newSend = func(fields map[string]string) func(batch []string) error {
+					mapping := structuredFieldMapping{
+						format:         format,
+						timestampField: timestampField,
+						tagFields:      tagFields,
+						tags:           tags,
+						timestamps:     timestamps,
+					}
+					// Each input has its own header row.
+					if format.delimiter != 0 {
+						mapping.format.parse = delimited.newParser(format.delimiter)
+					}
+					if preview != nil {
+						return preview.send(fields, nil, &mapping, "")
+					}
+					return func(batch []string) error {
+						return sendStructuredBatch(client, repo, batch, fields, mapping, compression)
+					}
+				}
 			} else {
-				streamStdin(repo, quiet)
+				newSend = func(fields map[string]string) func(batch []string) error {
+					if preview != nil {
+						return preview.send(fields, tags, nil, parserName)
+					}
+					return func(batch []string) error {
+						return sendBatch(client, repo, batch, fields, tags, parserName, compression)
+					}
+				}
+			}
+
+			// Only stdin redirected from a file has a known size.
+			var total int64
+			if len(tailPaths) == 0 && s3.url == "" && kafka.topic == "" {
+				total = stdinSize()
+			}
+			progress := newIngestProgress(client, total, quiet || dryRun)
+			// S3 shows the progress of the objects read instead.
+			progress.show = progress.show && s3.url == ""
+
+			if metrics != nil {
+				metrics.progress = progress
+				exitOnError(cmd, metrics.serve(ctx, metricsListen, quiet), "error serving metrics")
+			}
+
+			// The data is not echoed to a terminal the progress bar is drawn on.
+			noEcho := quiet || dryRun || (progress.show && terminal.IsTerminal(int(os.Stdout.Fd())))
+
+			countedSend := newSend
+			newSend = func(fields map[string]string) func(batch []string) error {
+				return progress.count(countedSend(fields))
+			}
+
+			if dedup {
+				deduplicator, err := newIngestDeduplicator(dedupWindow, dedupFile)
+				exitOnError(cmd, err, "error setting up deduplication")
+				defer deduplicator.close(quiet)
+
+				dedupSend := newSend
+				newSend = func(fields map[string]string) func(batch []string) error {
+					return deduplicator.wrap(dedupSend(fields))
+				}
+			}
+
+			send := newSend(fields)
+			if preview != nil {
+				defer preview.summary(repo, quiet)
+			}
+
+			if listenHEC != "" {
+				if len(tailPaths) > 0 || s3.url != "" || kafka.topic != "" {
+					return fmt.Errorf("--listen-hec cannot be used together with --tail, --s3-url or --kafka")
+				}
+				if listenToken == "" && !isLoopbackAddress(listenHEC) {
+					exitOnError(cmd, usageError("--listen-token is required unless --listen-hec is a loopback address, e.g. localhost:8088"), "")
+				}
+
+				listener := &hecListener{client: client, repo: repo, fields: fields, tags: tags, parser: parserName, token: listenToken, quiet: quiet, progress: progress}
+				exitOnError(cmd, listener.listen(ctx, listenHEC), "error listening for events")
+				return nil
+			}
+
+			if s3.url != "" {
+				if len(tailPaths) > 0 {
+					return fmt.Errorf("--s3-url cannot be used together with --tail")
+				}
+
+				progress.start()
+				err := ingestFromS3(ctx, s3, quiet, send)
+				progress.stop()
+				if errors.Is(err, context.Canceled) {
+					err = nil
+				}
+				exitOnError(cmd, err, "error ingesting from S3")
+				return nil
 			}
 
+			if kafka.topic != "" {
+				if len(tailPaths) > 0 || s3.url != "" {
+					return fmt.Errorf("--kafka cannot be used together with --tail or --s3-url")
+				}
+				if len(kafka.brokers) == 0 {
+					exitOnError(cmd, usageError("--kafka-brokers is required when using --kafka"), "")
+				}
+				if kafka.start != "earliest" && kafka.start != "latest" {
+					exitOnError(cmd, usageError("--kafka-start must be either earliest or latest"), "")
+				}
+
+				progress.start()
+				err := ingestFromKafka(ctx, kafka, noEcho, send)
+				progress.stop()
+				if errors.Is(err, context.Canceled) {
+					err = nil
+				}
+				exitOnError(cmd, err, "error ingesting from Kafka")
+				return nil
+			}
+
+			var checkpoint *ingestCheckpoint
+			if checkpointFile != "" {
+				if len(tailPaths) != 1 || isGlobPattern(tailPaths[0]) {
+					return fmt.Errorf("--checkpoint-file can only be used together with --tail of a single file")
+				}
+
+				var err error
+				checkpoint, err = loadCheckpoint(checkpointFile, tailPaths[0])
+				exitOnError(cmd, err, "error loading checkpoint")
+			}
+
+			progress.start()
+			defer progress.stop()
+
+			if len(tailPaths) > 0 {
+				err := tailFiles(ctx, tailPaths, noEcho, func(file string) *ingestSender {
+					fileFields := map[string]string{"@source": file}
+					for k, v := range fields {
+						fileFields[k] = v
+					}
+
+					return startSendingWithCheckpoint(newSend(fileFields), checkpoint)
+				}, checkpoint)
+				exitOnError(cmd, err, "error tailing files")
+				return nil
+			}
+
+			sender := startSending(send)
+			streamStdin(ctx, sender, repo, noEcho)
+			sender.stop()
+
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&parserName, "parser", "p", "default", "Use a specific parser for ingestion.")
-	cmd.Flags().StringVarP(&filepath, "tail", "f", "", "A file to tail instead of listening to stdin.")
+	cmd.Flags().StringSliceVarP(&tailPaths, "tail", "f", nil, "A file, or glob pattern of files, to tail instead of listening to stdin. Specify multiple times for multiple files.")
 	cmd.Flags().StringP("ingest-token", "i", "", "The ingest token to use. Defaults to your Account API token.")
 	cmd.Flags().BoolVarP(&openBrowser, "open", "o", false, "Open the browser with live tail of the stream.")
 	cmd.Flags().StringVarP(&label, "label", "l", "", "Adds a @label=<lavel> field to each event. This can help you find specific data send by the CLI when searching in the UI.")
 	cmd.Flags().BoolVarP(&noSession, "no-session", "n", false, "No @session field will be added to each event. @session assigns a new UUID to each executing of the Humio CLI.")
-	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Don't print ingested data to stdout.")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Don't print ingested data to stdout, or the progress and summary to stderr.")
+	cmd.Flags().StringVar(&checkpointFile, "checkpoint-file", "", "When used with --tail: A file used to record progress, so a restarted ingest resumes where it left off.")
+	cmd.Flags().BoolVar(&jsonInput, "json", false, "Parse each line as a JSON object and send its attributes as structured fields.")
+	cmd.Flags().StringVar(&inputFormatName, "input-format", "", "Normalize lines of a known format locally and send them as structured events. One of: cef, csv, evtx-xml, jsonl, leef, syslog, tsv.")
+	cmd.Flags().StringSliceVar(&delimited.columns, "csv-columns", nil, "When used with --input-format=csv or tsv: The names of the columns, for input without a header row.")
+	cmd.Flags().Var(&delimited.types, "csv-types", "When used with --input-format=csv or tsv: The type of a column, one of string, int, float or bool, e.g. --csv-types=amount=float. Specify multiple times for multiple columns.")
+	cmd.Flags().BoolVar(&delimited.inferTypes, "csv-infer-types", false, "When used with --input-format=csv or tsv: Send values that look like numbers or booleans as such, unless --csv-types is given for the column.")
+	cmd.Flags().StringVar(&timestampField, "timestamp-field", "", "When used with --json or --input-format: The field to use as @timestamp. Defaults to the timestamp of the format, or the time the event was read.")
+	cmd.Flags().StringSliceVar(&tagFields, "tag-field", nil, "When used with --json or --input-format: A field to send as a tag. Specify multiple times for multiple tags.")
+	cmd.Flags().Var(&tagFlags, "tag", "A tag to add to all events, e.g. env=prod. Takes precedence over tags from --tag-field. Specify multiple times for multiple tags.")
+	cmd.Flags().BoolVar(&preserveTimestamps, "preserve-timestamps", false, "Set @timestamp to the timestamp found in each line instead of the time it is ingested.")
+	cmd.Flags().StringVar(&timestampFormat, "timestamp-format", "", "When used with --preserve-timestamps: The strptime-style layout of the timestamps, e.g. \"%Y-%m-%d %H:%M:%S\". Defaults to detecting common formats.")
+	cmd.Flags().StringVar(&timezone, "timezone", "UTC", "When used with --preserve-timestamps: The time zone of timestamps without one, e.g. Europe/Copenhagen or Local.")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the first events as they would be sent, with their fields, tags, timestamp and parser, instead of sending them.")
+	cmd.Flags().IntVar(&showEvents, "show-events", 10, "When used with --dry-run: The number of events to print.")
+	cmd.Flags().BoolVar(&dedup, "dedup", false, "Skip lines that are identical to a line read within --dedup-window.")
+	cmd.Flags().DurationVar(&dedupWindow, "dedup-window", 10*time.Minute, "When used with --dedup: How long a line is remembered to skip duplicates of it.")
+	cmd.Flags().StringVar(&dedupFile, "dedup-file", "", "Record the lines sent in this file and skip lines recorded by earlier runs, e.g. when re-running a backfill. Implies --dedup.")
+	cmd.Flags().BoolVar(&compress, "compress", true, "Compress the requests with gzip. Use --compress=false to send them uncompressed.")
+	cmd.Flags().StringVar(&listenHEC, "listen-hec", "", "Listen for events on an address, e.g. :8088, emulating the Splunk HTTP Event Collector and Humio's ingest API.")
+	cmd.Flags().StringVar(&listenToken, "listen-token", "", "When used with --listen-hec: The token clients must send. Required unless --listen-hec is a loopback address, which accepts any token without it.")
+	cmd.Flags().StringVar(&metricsListen, "metrics-listen", "", "Serve metrics of the ingest in the Prometheus text format on /metrics at an address, e.g. localhost:9464.")
+	cmd.Flags().StringVar(&s3.url, "s3-url", "", "Ingest the object, or all objects under the prefix, at an S3 URL, e.g. s3://bucket/prefix/.")
+	cmd.Flags().StringVar(&s3.region, "s3-region", "", "When used with --s3-url: The AWS region of the bucket. Defaults to $AWS_REGION or us-east-1.")
+	cmd.Flags().StringVar(&s3.endpoint, "s3-endpoint", "", "When used with --s3-url: An S3 compatible endpoint to use instead of AWS, e.g. http://localhost:9000.")
+	cmd.Flags().IntVar(&s3.concurrency, "s3-concurrency", 4, "When used with --s3-url: The number of objects to read in parallel.")
+
+	cmd.Flags().StringVar(&kafka.topic, "kafka", "", "Consume messages from a Kafka topic instead of listening to stdin.")
+	cmd.Flags().StringSliceVar(&kafka.brokers, "kafka-brokers", nil, "When used with --kafka: Comma separated host:port addresses of Kafka brokers to connect to.")
+	cmd.Flags().StringVar(&kafka.group, "kafka-group", "", "When used with --kafka: The consumer group to join and commit offsets to. Without a group all partitions are consumed and nothing is committed.")
+	cmd.Flags().StringVar(&kafka.start, "kafka-start", "latest", "When used with --kafka: Where to start partitions without a committed offset, either earliest or latest.")
+	cmd.Flags().BoolVar(&kafka.tls, "kafka-tls", false, "When used with --kafka: Connect to the brokers using TLS.")
+	cmd.Flags().StringVar(&kafka.tlsCAFile, "kafka-tls-ca", "", "When used with --kafka: A PEM file with the CA certificates to verify the brokers with. Implies --kafka-tls.")
+	cmd.Flags().BoolVar(&kafka.tlsInsecure, "kafka-tls-insecure", false, "When used with --kafka: Don't verify the certificates of the brokers. Implies --kafka-tls.")
+	cmd.Flags().StringVar(&kafka.saslMechanism, "kafka-sasl-mechanism", "", "When used with --kafka: Authenticate using SASL, either PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512.")
+	cmd.Flags().StringVar(&kafka.saslUsername, "kafka-username", "", "When used with --kafka-sasl-mechanism: The username to authenticate as.")
+	cmd.Flags().DurationVar(&kafka.pollInterval, "kafka-poll-interval", 500*time.Millisecond, "When used with --kafka: How long to wait for new messages before sending the ones received.")
+
+	cmd.AddCommand(newIngestBenchCmd())
 
 	return &cmd
 }
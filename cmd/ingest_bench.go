@@ -0,0 +1,255 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/humio/cli/api"
+	"github.com/humio/cli/prompt"
+	"github.com/spf13/cobra"
+)
+
+type ingestBenchOptions struct {
+	rate        int
+	duration    time.Duration
+	eventSize   int
+	cardinality int
+	batchSize   int
+	concurrency int
+}
+
+func newIngestBenchCmd() *cobra.Command {
+	var opts ingestBenchOptions
+
+	cmd := cobra.Command{
+		Use:   "bench [flags] [<repo>]",
+		Short: "Measure ingest throughput by sending synthetic events.",
+		Long: `Sends synthetic events to the repository <repo>, or your 'sandbox'
+repository if <repo> is not specified, and reports the achieved events per
+second, the latency of the ingest requests and the error rate.
+
+Each event has a @rawstring of --event-size bytes and a 'key' field with one
+of --cardinality distinct values. All events of a run share a 'benchId'
+field, so they can be found or deleted afterwards.
+
+  $ humioctl ingest bench loadtest --rate 50000 --duration 5m --concurrency 16
+
+Use --rate 0 to send as fast as possible.`,
+		Args: cobra.RangeArgs(0, 1),
+		Run: func(cmd *cobra.Command, args []string) {
+			repo := "sandbox"
+			if len(args) == 1 {
+				repo = args[0]
+			}
+
+			if opts.batchSize < 1 || opts.concurrency < 1 || opts.eventSize < 1 || opts.cardinality < 1 || opts.rate < 0 {
+				exitOnError(cmd, usageError("--batch-size, --concurrency, --event-size and --cardinality must be positive, and --rate must not be negative"), "")
+			}
+
+			client := NewApiClient(cmd)
+
+			ctx, cancel := context.WithTimeout(contextCancelledOnInterrupt(commandContext), opts.duration)
+			defer cancel()
+
+			result := &ingestBenchResult{start: time.Now()}
+			reporter := startProgressReporter(os.Stderr, result.progressLine)
+			runIngestBench(ctx, client, repo, opts, result)
+			reporter.stop(true)
+
+			printIngestBenchResult(cmd, result)
+
+			if result.events == 0 && result.errors > 0 {
+				exitOnError(cmd, result.lastErr, "no events could be sent")
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.rate, "rate", 1000, "The number of events to send per second, or 0 to send as fast as possible.")
+	cmd.Flags().DurationVar(&opts.duration, "duration", 30*time.Second, "How long to send events for.")
+	cmd.Flags().IntVar(&opts.eventSize, "event-size", 256, "The size of each event in bytes.")
+	cmd.Flags().IntVar(&opts.cardinality, "cardinality", 100, "The number of distinct values of the 'key' field.")
+	cmd.Flags().IntVar(&opts.batchSize, "batch-size", batchLimit, "The number of events sent in each request.")
+	cmd.Flags().IntVar(&opts.concurrency, "concurrency", 4, "The number of requests to send in parallel.")
+
+	return &cmd
+}
+
+type ingestBenchResult struct {
+	// The counters are first to be 64-bit aligned for atomic access.
+	events, bytes, requests, errors int64
+
+	start     time.Time
+	elapsed   time.Duration
+	lastErr   error
+	latencies []time.Duration
+	mu        sync.Mutex
+}
+
+// progressLine returns the progress of a running benchmark.
+func (r *ingestBenchResult) progressLine(now time.Time) string {
+	events := atomic.LoadInt64(&r.events)
+	return fmt.Sprintf("  %d events sent, %.0f events/s, %d errors", events, perSecond(events, now.Sub(r.start)), atomic.LoadInt64(&r.errors))
+}
+
+func (r *ingestBenchResult) eventsPerSecond() float64 {
+	return perSecond(r.events, r.elapsed)
+}
+
+// percentile returns the latency below which p percent of the requests completed.
+func (r *ingestBenchResult) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(r.latencies))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(r.latencies) {
+		i = len(r.latencies) - 1
+	}
+	return r.latencies[i]
+}
+
+// runIngestBench sends events until ctx is done and records them in result,
+// whose start must be set.
+func runIngestBench(ctx context.Context, client *api.Client, repo string, opts ingestBenchOptions, result *ingestBenchResult) {
+	u, _ := uuid.NewV4()
+	benchID := u.String()
+
+	batches := make(chan []structuredEvent, opts.concurrency)
+
+	// Generate batches at the requested rate. If the requests cannot keep up,
+	// generating blocks and the achieved rate is lower than requested.
+	go func() {
+		defer close(batches)
+
+		var tick <-chan time.Time
+		if opts.rate > 0 {
+			interval := time.Duration(float64(time.Second) * float64(opts.batchSize) / float64(opts.rate))
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		padding := strings.Repeat("x", opts.eventSize)
+		var seq int64
+		for {
+			if tick != nil {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			batch := make([]structuredEvent, opts.batchSize)
+			now := time.Now().Format(time.RFC3339Nano)
+			for i := range batch {
+				seq++
+				key := fmt.Sprintf("key-%d", rand.Intn(opts.cardinality))
+				raw := fmt.Sprintf("%s benchId=%s key=%s seq=%d payload=", now, benchID, key, seq)
+				if len(raw) < opts.eventSize {
+					raw += padding[:opts.eventSize-len(raw)]
+				}
+				batch[i] = structuredEvent{
+					Timestamp:  now,
+					RawString:  raw,
+					Attributes: map[string]interface{}{"benchId": benchID, "key": key},
+				}
+			}
+
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				body, err := json.Marshal([]structuredEventList{{Events: batch}})
+				if err != nil {
+					continue
+				}
+
+				start := time.Now()
+				err = postIngestRequest(client, "api/v1/repositories/"+repo+"/ingest", body)
+				latency := time.Since(start)
+
+				atomic.AddInt64(&result.requests, 1)
+				result.mu.Lock()
+				result.latencies = append(result.latencies, latency)
+				if err != nil {
+					atomic.AddInt64(&result.errors, 1)
+					result.lastErr = err
+				}
+				result.mu.Unlock()
+
+				if err == nil {
+					atomic.AddInt64(&result.events, int64(len(batch)))
+					atomic.AddInt64(&result.bytes, int64(len(batch)*opts.eventSize))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	result.elapsed = time.Since(result.start)
+	sort.Slice(result.latencies, func(i, j int) bool { return result.latencies[i] < result.latencies[j] })
+}
+
+func printIngestBenchResult(cmd *cobra.Command, r *ingestBenchResult) {
+	bytesPerSecond, suffix := prompt.AddSISuffix(float64(r.bytes)/r.elapsed.Seconds(), true)
+
+	var errorRate float64
+	if r.requests > 0 {
+		errorRate = float64(r.errors) / float64(r.requests) * 100
+	}
+
+	var output []string
+	output = append(output, fmt.Sprintf("Duration | %s", r.elapsed.Round(time.Millisecond)))
+	output = append(output, fmt.Sprintf("Events sent | %d", r.events))
+	output = append(output, fmt.Sprintf("Events/s | %.0f", r.eventsPerSecond()))
+	output = append(output, fmt.Sprintf("Throughput | %.1f %sB/s", bytesPerSecond, suffix))
+	output = append(output, fmt.Sprintf("Requests | %d", r.requests))
+	output = append(output, fmt.Sprintf("Errors | %d (%.1f%%)", r.errors, errorRate))
+	output = append(output, fmt.Sprintf("Latency p50 | %s", r.percentile(50).Round(time.Millisecond)))
+	output = append(output, fmt.Sprintf("Latency p90 | %s", r.percentile(90).Round(time.Millisecond)))
+	output = append(output, fmt.Sprintf("Latency p99 | %s", r.percentile(99).Round(time.Millisecond)))
+	output = append(output, fmt.Sprintf("Latency max | %s", r.percentile(100).Round(time.Millisecond)))
+
+	printTable(cmd, output)
+
+	if r.lastErr != nil {
+		cmd.PrintErrln(fmt.Sprintf("Last error: %s", r.lastErr))
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/hpcloud/tail"
+)
+
+type checkpointState struct {
+	File   string `json:"file"`
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+// checkpointPosition is the position in a tailed file after a line read
+// from it.
+type checkpointPosition struct {
+	inode  uint64
+	offset int64
+}
+
+// ingestCheckpoint keeps track of how far into a tailed file we have
+// successfully sent data, so a restarted ingest can resume from there.
+//
+// Each line read is given its position, which is sent along with it, and
+// the checkpoint is moved to the position of the last line of a batch when
+// the batch has been sent. Once a batch fails the checkpoint is not moved
+// any further, so a restarted ingest sends its lines again.
+type ingestCheckpoint struct {
+	path  string
+	mu    sync.Mutex
+	state checkpointState
+
+	// readInode and readOffset are the inode of the file lines are read
+	// from, and the offset after the last line read from it. readInode is
+	// not known until the first line is read from a file that has replaced
+	// a rotated one.
+	readInode      uint64
+	readInodeKnown bool
+	readOffset     int64
+
+	// failed is set when a batch could not be sent.
+	failed bool
+}
+
+func loadCheckpoint(checkpointPath, file string) (*ingestCheckpoint, error) {
+	c := &ingestCheckpoint{path: checkpointPath}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	inode := fileInode(info)
+
+	data, err := ioutil.ReadFile(checkpointPath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("error reading checkpoint file: %v", err)
+	default:
+		if err := json.Unmarshal(data, &c.state); err != nil {
+			return nil, fmt.Errorf("error parsing checkpoint file: %v", err)
+		}
+	}
+
+	// Only resume if we are looking at the same file as last time. If it has been
+	// rotated or truncated in the meantime we start from the beginning of the new one.
+	sameFile := c.state.File == file && c.state.Inode == inode && c.state.Offset <= info.Size()
+	if !sameFile {
+		c.state = checkpointState{File: file, Inode: inode}
+	}
+	c.readInode = c.state.Inode
+	c.readInodeKnown = true
+	c.readOffset = c.state.Offset
+
+	return c, nil
+}
+
+func (c *ingestCheckpoint) seekInfo() *tail.SeekInfo {
+	return &tail.SeekInfo{Offset: c.state.Offset, Whence: os.SEEK_SET}
+}
+
+// fileReplaced records that the file has been moved or deleted after all its
+// lines were read, and that lines are now read from the start of the file
+// replacing it.
+func (c *ingestCheckpoint) fileReplaced() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readInodeKnown = false
+	c.readOffset = 0
+}
+
+// lineRead records that a line of length n (excluding the line break) has
+// been read, and returns the position after it.
+func (c *ingestCheckpoint) lineRead(n int) checkpointPosition {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.readInodeKnown {
+		// If the file has been replaced again since the line was read, the
+		// inode does not match on restart, and the file is sent from the
+		// start. Events may be sent twice, but none are lost.
+		if info, err := os.Stat(c.state.File); err == nil {
+			c.readInode = fileInode(info)
+		}
+		c.readInodeKnown = true
+	}
+
+	c.readOffset += int64(n) + 1
+	return checkpointPosition{inode: c.readInode, offset: c.readOffset}
+}
+
+// batchSent records that the lines up to pos have been sent and saves the
+// checkpoint, unless an earlier batch failed.
+func (c *ingestCheckpoint) batchSent(pos checkpointPosition) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failed {
+		return nil
+	}
+
+	c.state.Inode = pos.inode
+	c.state.Offset = pos.offset
+	return c.save()
+}
+
+// batchFailed records that a batch could not be sent. The checkpoint is not
+// moved past it, so its lines are sent again when the ingest is restarted.
+func (c *ingestCheckpoint) batchFailed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.failed {
+		log.Printf("Not saving the checkpoint past lines that could not be sent, so they are sent again when the ingest is restarted")
+	}
+	c.failed = true
+}
+
+func (c *ingestCheckpoint) save() error {
+	data, err := json.Marshal(c.state)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it, so we never leave a half-written checkpoint.
+	return writeFileAtomic(c.path, data, 0644)
+}
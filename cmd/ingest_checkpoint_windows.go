@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package cmd
+
+import (
+	"os"
+)
+
+// fileInode is not supported on Windows, so rotation can only be
+// detected by the file being smaller than the checkpointed offset.
+func fileInode(info os.FileInfo) uint64 {
+	return 0
+}
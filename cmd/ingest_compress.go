@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"github.com/humio/cli/api"
+)
+
+// ingestCompression gzips the bodies of ingest requests. If the server, or a
+// proxy in front of it, rejects a compressed request with 415 Unsupported
+// Media Type, compression is turned off and the request is sent again
+// uncompressed.
+type ingestCompression struct {
+	disabled int32
+}
+
+func (c *ingestCompression) enabled() bool {
+	return c != nil && atomic.LoadInt32(&c.disabled) == 0
+}
+
+// post sends an ingest request, compressed unless compression is off. A nil
+// compression sends all requests uncompressed.
+func (c *ingestCompression) post(client *api.Client, url string, body []byte, headers map[string]string) error {
+	if !c.enabled() {
+		return postIngestRequestWithHeaders(client, url, body, headers)
+	}
+
+	compressed, err := gzipBody(body)
+	if err != nil {
+		return err
+	}
+
+	gzipHeaders := map[string]string{"Content-Encoding": "gzip"}
+	for k, v := range headers {
+		gzipHeaders[k] = v
+	}
+
+	err = postIngestRequestWithHeaders(client, url, compressed, gzipHeaders)
+	if statusErr, ok := err.(*api.StatusError); ok && statusErr.StatusCode == http.StatusUnsupportedMediaType {
+		if atomic.CompareAndSwapInt32(&c.disabled, 0, 1) {
+			fmt.Fprintln(os.Stderr, "The server does not accept compressed requests, sending them uncompressed.")
+		}
+		return postIngestRequestWithHeaders(client, url, body, headers)
+	}
+	return err
+}
+
+func gzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
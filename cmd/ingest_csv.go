@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// delimitedOptions are the options of the csv and tsv input formats.
+type delimitedOptions struct {
+	// columns are the names of the columns. If empty, they are read from the
+	// header row of the input.
+	columns []string
+
+	// types maps columns to the type their values are sent as.
+	types keyValueFlag
+
+	// inferTypes sends values that look like numbers or booleans as such.
+	inferTypes bool
+}
+
+var delimitedColumnTypes = []string{"string", "int", "float", "bool"}
+
+func (o delimitedOptions) validate() error {
+	for column, t := range o.types.values {
+		valid := false
+		for _, name := range delimitedColumnTypes {
+			valid = valid || t == name
+		}
+		if !valid {
+			return fmt.Errorf("invalid type %q of column %s, must be one of: %s", t, column, strings.Join(delimitedColumnTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// newParser returns a parse function for an input, e.g. a file, which reads
+// the header row unless the columns are given. Records must be on a single
+// line. Lines identical to the header row are skipped, so concatenated files
+// with the same header can be ingested together.
+func (o delimitedOptions) newParser(delimiter rune) func(line string) (interface{}, map[string]interface{}, bool) {
+	columns := o.columns
+	var header string
+	var warnOnce sync.Once
+
+	return func(line string) (interface{}, map[string]interface{}, bool) {
+		r := csv.NewReader(strings.NewReader(line))
+		r.Comma = delimiter
+		r.LazyQuotes = true
+		r.FieldsPerRecord = -1
+
+		record, err := r.Read()
+		if err != nil {
+			return nil, nil, false
+		}
+
+		if columns == nil {
+			columns = make([]string, len(record))
+			for i, name := range record {
+				columns[i] = strings.TrimSpace(name)
+			}
+			header = line
+			return nil, nil, true
+		}
+		if line == header {
+			return nil, nil, true
+		}
+
+		attributes := map[string]interface{}{}
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+
+			column := fmt.Sprintf("column%d", i+1)
+			if i < len(columns) && columns[i] != "" {
+				column = columns[i]
+			}
+
+			v, err := o.convert(column, value)
+			if err != nil {
+				warnOnce.Do(func() {
+					log.Printf("Sending values that cannot be converted as strings: %v", err)
+				})
+			}
+			attributes[column] = v
+		}
+
+		return nil, attributes, true
+	}
+}
+
+// convert returns the value as the type of the column, or as a string if
+// it cannot be converted.
+func (o delimitedOptions) convert(column, value string) (interface{}, error) {
+	t, ok := o.types.values[column]
+	if !ok {
+		if o.inferTypes {
+			return inferValueType(value), nil
+		}
+		return value, nil
+	}
+
+	var v interface{}
+	var err error
+	switch t {
+	case "int":
+		v, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	case "float":
+		v, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case "bool":
+		v, err = strconv.ParseBool(strings.TrimSpace(value))
+	default:
+		return value, nil
+	}
+	if err != nil {
+		return value, fmt.Errorf("column %s: %q is not a valid %s", column, value, t)
+	}
+	return v, nil
+}
+
+func inferValueType(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	switch strings.ToLower(value) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return value
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type lineHash [16]byte
+
+func hashLine(line string) lineHash {
+	var h lineHash
+	sum := sha256.Sum256([]byte(line))
+	copy(h[:], sum[:])
+	return h
+}
+
+// ingestDeduplicator drops lines that have already been sent, so the same
+// event is not ingested twice, e.g. when a log file is re-read or a backfill
+// is re-run.
+type ingestDeduplicator struct {
+	// skipped is first to be 64-bit aligned for atomic access.
+	skipped int64
+
+	// window is how long a line is remembered after it was read.
+	window time.Duration
+
+	mu sync.Mutex
+	// seen holds the lines read within the window and when they were read.
+	seen      map[lineHash]time.Time
+	lastPurge time.Time
+	// sent holds the lines recorded in the state file, which are remembered
+	// regardless of the window.
+	sent map[lineHash]struct{}
+	file *os.File
+}
+
+// newIngestDeduplicator returns a deduplicator remembering lines for the
+// window. If statePath is not empty, the lines sent are recorded in the
+// file, and lines recorded by earlier runs are skipped.
+func newIngestDeduplicator(window time.Duration, statePath string) (*ingestDeduplicator, error) {
+	d := &ingestDeduplicator{
+		window:    window,
+		seen:      map[lineHash]time.Time{},
+		lastPurge: time.Now(),
+		sent:      map[lineHash]struct{}{},
+	}
+
+	if statePath == "" {
+		return d, nil
+	}
+
+	file, err := os.OpenFile(statePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening deduplication file: %v", err)
+	}
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		var h lineHash
+		decoded, err := hex.DecodeString(scanner.Text())
+		if err != nil || len(decoded) != len(h) {
+			file.Close()
+			return nil, fmt.Errorf("error parsing deduplication file: invalid hash on line %d", n)
+		}
+		copy(h[:], decoded)
+		d.sent[h] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading deduplication file: %v", err)
+	}
+
+	d.file = file
+	return d, nil
+}
+
+// wrap returns a function sending the lines of each batch that are not
+// duplicates, and recording them as sent if sending succeeds.
+func (d *ingestDeduplicator) wrap(send func(batch []string) error) func(batch []string) error {
+	return func(batch []string) error {
+		lines, hashes := d.filter(batch, time.Now())
+		if len(lines) == 0 {
+			return nil
+		}
+
+		if err := send(lines); err != nil {
+			// Forget the lines, so they are sent if they are read again.
+			d.forget(hashes)
+			return err
+		}
+
+		return d.record(hashes)
+	}
+}
+
+// filter returns the lines of the batch that have not been read within the
+// window or recorded as sent, and their hashes.
+func (d *ingestDeduplicator) filter(batch []string, now time.Time) ([]string, []lineHash) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.lastPurge) > d.window {
+		for h, t := range d.seen {
+			if now.Sub(t) > d.window {
+				delete(d.seen, h)
+			}
+		}
+		d.lastPurge = now
+	}
+
+	lines := make([]string, 0, len(batch))
+	hashes := make([]lineHash, 0, len(batch))
+	for _, line := range batch {
+		h := hashLine(line)
+		if _, ok := d.sent[h]; ok {
+			atomic.AddInt64(&d.skipped, 1)
+			continue
+		}
+		if t, ok := d.seen[h]; ok && now.Sub(t) <= d.window {
+			atomic.AddInt64(&d.skipped, 1)
+			continue
+		}
+
+		d.seen[h] = now
+		lines = append(lines, line)
+		hashes = append(hashes, h)
+	}
+	return lines, hashes
+}
+
+func (d *ingestDeduplicator) forget(hashes []lineHash) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, h := range hashes {
+		delete(d.seen, h)
+	}
+}
+
+// record adds the hashes of sent lines to the state file, if any.
+func (d *ingestDeduplicator) record(hashes []lineHash) error {
+	if d.file == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	buf := make([]byte, 0, len(hashes)*(2*len(lineHash{})+1))
+	for _, h := range hashes {
+		d.sent[h] = struct{}{}
+		buf = append(buf, hex.EncodeToString(h[:])...)
+		buf = append(buf, '\n')
+	}
+
+	if _, err := d.file.Write(buf); err != nil {
+		return fmt.Errorf("error saving deduplication file: %v", err)
+	}
+	return nil
+}
+
+// close closes the state file and prints the number of lines skipped,
+// unless quiet.
+func (d *ingestDeduplicator) close(quiet bool) {
+	if d.file != nil {
+		d.file.Close()
+	}
+
+	if skipped := atomic.LoadInt64(&d.skipped); skipped > 0 && !quiet {
+		fmt.Fprintf(os.Stderr, "Skipped %d duplicate events.\n", skipped)
+	}
+}
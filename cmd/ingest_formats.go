@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// inputFormat does a minimal local normalization of lines in a known log
+// format, so they can be sent as structured events without a parser.
+type inputFormat struct {
+	// parse returns the timestamp and fields of a line, and false if the
+	// line is not in the format. The timestamp may be nil.
+	parse func(line string) (interface{}, map[string]interface{}, bool)
+
+	// keepRawString sends the line as @rawstring in addition to its fields.
+	keepRawString bool
+
+	// tagFields are the fields sent as tags unless --tag-field is given.
+	tagFields []string
+
+	// delimiter is set for the csv and tsv formats, which need to read the
+	// header row of each input, so parse is created per input.
+	delimiter rune
+}
+
+var inputFormats = map[string]inputFormat{
+	"jsonl":    {parse: parseJSONLine},
+	"syslog":   {parse: parseSyslogLine, keepRawString: true},
+	"cef":      {parse: parseCEFLine, keepRawString: true, tagFields: []string{"cef.vendor", "cef.product"}},
+	"leef":     {parse: parseLEEFLine, keepRawString: true, tagFields: []string{"leef.vendor", "leef.product"}},
+	"evtx-xml": {parse: parseEventXMLLine, keepRawString: true, tagFields: []string{"Channel"}},
+	"csv":      {delimiter: ',', keepRawString: true},
+	"tsv":      {delimiter: '\t', keepRawString: true},
+}
+
+// plainLineFormat sends each line as it is, as a @rawstring.
+var plainLineFormat = inputFormat{
+	parse: func(string) (interface{}, map[string]interface{}, bool) { return nil, nil, false },
+}
+
+func inputFormatNames() []string {
+	names := make([]string, 0, len(inputFormats))
+	for name := range inputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseJSONLine(line string) (interface{}, map[string]interface{}, bool) {
+	var attributes map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &attributes); err != nil || attributes == nil {
+		return nil, nil, false
+	}
+	return nil, attributes, true
+}
+
+var (
+	// <165>1 2003-10-11T22:14:15.003Z host app 1234 ID47 [sd] message
+	syslog5424Pattern = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\S+) (\S+) (-|(?:\[(?:[^\]\\]|\\.)*\])+) ?(.*)$`)
+
+	// <34>Oct 11 22:14:15 host app[1234]: message, with the priority being optional.
+	syslog3164Pattern = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d) (\S+) ([^:\[\s]+)(?:\[(\d+)\])?: ?(.*)$`)
+)
+
+func parseSyslogLine(line string) (interface{}, map[string]interface{}, bool) {
+	attributes := map[string]interface{}{}
+
+	if m := syslog5424Pattern.FindStringSubmatch(line); m != nil {
+		setSyslogPriority(attributes, m[1])
+		setUnlessNil(attributes, "host", m[3])
+		setUnlessNil(attributes, "app", m[4])
+		setUnlessNil(attributes, "pid", m[5])
+		setUnlessNil(attributes, "msgid", m[6])
+		attributes["message"] = m[8]
+
+		var timestamp interface{}
+		if m[2] != "-" {
+			timestamp = m[2]
+		}
+		return timestamp, attributes, true
+	}
+
+	if m := syslog3164Pattern.FindStringSubmatch(line); m != nil {
+		setSyslogPriority(attributes, m[1])
+		attributes["host"] = m[3]
+		attributes["app"] = m[4]
+		setUnlessNil(attributes, "pid", m[5])
+		attributes["message"] = m[6]
+
+		var timestamp interface{}
+		if t, err := parseSyslogTimestamp(m[2], time.Now()); err == nil {
+			timestamp = t.Format(time.RFC3339Nano)
+		}
+		return timestamp, attributes, true
+	}
+
+	return nil, nil, false
+}
+
+func setSyslogPriority(attributes map[string]interface{}, priority string) {
+	if p, err := strconv.Atoi(priority); err == nil {
+		attributes["facility"] = p / 8
+		attributes["severity"] = p % 8
+	}
+}
+
+// setUnlessNil sets the attribute unless the value is the syslog nil value "-".
+func setUnlessNil(attributes map[string]interface{}, key, value string) {
+	if value != "" && value != "-" {
+		attributes[key] = value
+	}
+}
+
+// parseSyslogTimestamp parses a timestamp without a year, assuming it is at
+// most a day in the future.
+func parseSyslogTimestamp(s string, now time.Time) (time.Time, error) {
+	t, err := time.ParseInLocation("Jan _2 15:04:05", s, time.Local)
+	if err != nil {
+		return t, err
+	}
+
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, nil
+}
+
+var cefHeaderFields = []string{"cef.version", "cef.vendor", "cef.product", "cef.device_version", "cef.signature_id", "cef.name", "cef.severity"}
+
+// parseCEFLine parses an ArcSight Common Event Format line, optionally
+// preceded by a syslog header.
+func parseCEFLine(line string) (interface{}, map[string]interface{}, bool) {
+	i := strings.Index(line, "CEF:")
+	if i < 0 {
+		return nil, nil, false
+	}
+
+	header, extension, ok := splitHeader(line[i+len("CEF:"):], len(cefHeaderFields))
+	if !ok {
+		return nil, nil, false
+	}
+
+	attributes := map[string]interface{}{}
+	for i, field := range cefHeaderFields {
+		attributes[field] = header[i]
+	}
+	for k, v := range parseCEFExtension(extension) {
+		attributes[k] = v
+	}
+
+	return epochMillisField(attributes, "rt"), attributes, true
+}
+
+// splitHeader splits n pipe separated header fields, in which pipes may be
+// escaped by a backslash, from the extension following them.
+func splitHeader(s string, n int) ([]string, string, bool) {
+	var fields []string
+	var field strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\'):
+			field.WriteByte(s[i+1])
+			i++
+		case s[i] == '|':
+			fields = append(fields, field.String())
+			field.Reset()
+			if len(fields) == n {
+				return fields, s[i+1:], true
+			}
+		default:
+			field.WriteByte(s[i])
+		}
+	}
+
+	return nil, "", false
+}
+
+var cefExtensionKeyPattern = regexp.MustCompile(`(?:^|\s)([\w.\[\]-]+)=`)
+
+// parseCEFExtension parses space separated key=value pairs, in which values
+// may contain spaces and escaped equal signs.
+func parseCEFExtension(s string) map[string]string {
+	values := map[string]string{}
+
+	matches := cefExtensionKeyPattern.FindAllStringSubmatchIndex(s, -1)
+	for i, m := range matches {
+		end := len(s)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+
+		value := strings.NewReplacer(`\=`, `=`, `\\`, `\`, `\n`, "\n", `\r`, "\r").Replace(s[m[1]:end])
+		values[s[m[2]:m[3]]] = strings.TrimSpace(value)
+	}
+
+	return values
+}
+
+var leefHeaderFields = []string{"leef.version", "leef.vendor", "leef.product", "leef.product_version", "leef.event_id"}
+
+// parseLEEFLine parses an IBM QRadar Log Event Extended Format line,
+// optionally preceded by a syslog header.
+func parseLEEFLine(line string) (interface{}, map[string]interface{}, bool) {
+	i := strings.Index(line, "LEEF:")
+	if i < 0 {
+		return nil, nil, false
+	}
+
+	header, extension, ok := splitHeader(line[i+len("LEEF:"):], len(leefHeaderFields))
+	if !ok {
+		return nil, nil, false
+	}
+
+	attributes := map[string]interface{}{}
+	for i, field := range leefHeaderFields {
+		attributes[field] = header[i]
+	}
+
+	// LEEF 2.0 has the attribute delimiter as an extra header field.
+	delimiter := "\t"
+	if strings.HasPrefix(header[0], "2.") {
+		if j := strings.IndexByte(extension, '|'); j >= 0 {
+			delimiter = leefDelimiter(extension[:j])
+			extension = extension[j+1:]
+		}
+	}
+
+	for _, pair := range strings.Split(extension, delimiter) {
+		if kv := strings.SplitN(pair, "=", 2); len(kv) == 2 && kv[0] != "" {
+			attributes[kv[0]] = kv[1]
+		}
+	}
+
+	return epochMillisField(attributes, "devTime"), attributes, true
+}
+
+// leefDelimiter returns the delimiter given as a character or a hex value like x5E.
+func leefDelimiter(s string) string {
+	if len(s) > 1 && (s[0] == 'x' || s[0] == 'X') {
+		if b, err := strconv.ParseUint(s[1:], 16, 8); err == nil {
+			return string(rune(b))
+		}
+	}
+	if s == "" {
+		return "\t"
+	}
+	return s
+}
+
+// epochMillisField returns the value of the field as a timestamp if it is
+// milliseconds since the epoch, and nil otherwise.
+func epochMillisField(attributes map[string]interface{}, field string) interface{} {
+	s, _ := attributes[field].(string)
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms
+	}
+	return nil
+}
+
+// windowsEvent is a Windows event in the XML format written by e.g.
+// 'wevtutil qe Security /f:xml', with one event per line.
+type windowsEvent struct {
+	System struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		}
+		EventID     string
+		Level       string
+		Task        string
+		Keywords    string
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		}
+		EventRecordID string
+		Channel       string
+		Computer      string
+		Security      struct {
+			UserID string `xml:"UserID,attr"`
+		}
+	}
+	EventData struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		}
+	}
+}
+
+func parseEventXMLLine(line string) (interface{}, map[string]interface{}, bool) {
+	var event windowsEvent
+	if err := xml.Unmarshal([]byte(line), &event); err != nil {
+		return nil, nil, false
+	}
+
+	s := event.System
+	attributes := map[string]interface{}{}
+	for k, v := range map[string]string{
+		"Provider":      s.Provider.Name,
+		"EventID":       s.EventID,
+		"Level":         s.Level,
+		"Task":          s.Task,
+		"Keywords":      s.Keywords,
+		"EventRecordID": s.EventRecordID,
+		"Channel":       s.Channel,
+		"Computer":      s.Computer,
+		"UserID":        s.Security.UserID,
+	} {
+		if v != "" {
+			attributes[k] = v
+		}
+	}
+
+	for i, data := range event.EventData.Data {
+		name := data.Name
+		if name == "" {
+			name = fmt.Sprintf("Data%d", i)
+		}
+		attributes["EventData."+name] = data.Value
+	}
+
+	var timestamp interface{}
+	if s.TimeCreated.SystemTime != "" {
+		timestamp = s.TimeCreated.SystemTime
+	}
+	return timestamp, attributes, true
+}
@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+)
+
+// hecResponse is the response body of the Splunk HTTP Event Collector.
+type hecResponse struct {
+	Text string `json:"text"`
+	Code int    `json:"code"`
+}
+
+// Status codes of the Splunk HTTP Event Collector.
+const (
+	hecCodeSuccess       = 0
+	hecCodeTokenRequired = 2
+	hecCodeInvalidToken  = 4
+	hecCodeNoData        = 5
+	hecCodeInvalidData   = 6
+	hecCodeServerBusy    = 9
+	hecCodeHealthy       = 17
+)
+
+// hecMaxBodySize is the largest request body accepted by the listener.
+const hecMaxBodySize = 32 * 1024 * 1024
+
+// errHECBodyTooLarge is the error of http.MaxBytesReader when a body is
+// larger than hecMaxBodySize.
+const errHECBodyTooLarge = "http: request body too large"
+
+// isLoopbackAddress returns true if addr only listens on the loopback
+// interface, e.g. localhost:8088. Addresses without a host, e.g. :8088,
+// listen on all interfaces.
+func isLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// checkHECListenToken returns an error if no token is given for a listener
+// that can be reached from other hosts, as anyone could send events to the
+// repository with it.
+func checkHECListenToken(addr, token string) error {
+	if token == "" && !isLoopbackAddress(addr) {
+		return fmt.Errorf("a token is required when listening on %s, which can be reached from other hosts; listen on a loopback address, e.g. localhost:8088, to accept any token", addr)
+	}
+	return nil
+}
+
+// hecEvent is an event sent to the event endpoint of the HTTP Event Collector.
+type hecEvent struct {
+	Time       json.Number            `json:"time"`
+	Host       string                 `json:"host"`
+	Source     string                 `json:"source"`
+	SourceType string                 `json:"sourcetype"`
+	Index      string                 `json:"index"`
+	Event      json.RawMessage        `json:"event"`
+	Fields     map[string]interface{} `json:"fields"`
+}
+
+// hecListener receives events on the endpoints of the Splunk HTTP Event
+// Collector and of Humio's ingest API, and forwards them to a repository.
+type hecListener struct {
+	client *api.Client
+	repo   string
+	fields map[string]string
+	tags   map[string]string
+	parser string
+	token  string
+	quiet  bool
+	// progress, if set, counts the events forwarded, e.g. for the metrics
+	// served with --metrics-listen.
+	progress *ingestProgress
+}
+
+// listen serves the endpoints on addr until ctx is cancelled.
+func (l *hecListener) listen(ctx context.Context, addr string) error {
+	if err := checkHECListenToken(addr, l.token); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/services/collector", l.handleEvents)
+	mux.HandleFunc("/services/collector/event", l.handleEvents)
+	mux.HandleFunc("/services/collector/event/1.0", l.handleEvents)
+	mux.HandleFunc("/services/collector/raw", l.handleRaw)
+	mux.HandleFunc("/services/collector/raw/1.0", l.handleRaw)
+	mux.HandleFunc("/services/collector/health", l.handleHealth)
+	mux.HandleFunc("/services/collector/health/1.0", l.handleHealth)
+	mux.HandleFunc("/api/v1/ingest/humio-unstructured", l.handleHumioUnstructured)
+	mux.HandleFunc("/api/v1/ingest/humio-structured", l.handleHumioStructured)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Listening for events on %s, forwarding to '%s'", addr, l.repo)
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func writeHECResponse(w http.ResponseWriter, status int, code int, text string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(hecResponse{Text: text, Code: code})
+}
+
+// writeHECDecodeError responds to a request whose body could not be read.
+func writeHECDecodeError(w http.ResponseWriter, err error) {
+	if err != nil && strings.Contains(err.Error(), errHECBodyTooLarge) {
+		writeHECResponse(w, http.StatusRequestEntityTooLarge, hecCodeInvalidData, "Request is too large")
+		return
+	}
+	writeHECResponse(w, http.StatusBadRequest, hecCodeInvalidData, "Invalid data format")
+}
+
+// authorize checks the token of the request if --listen-token is set, and
+// limits the size of the body. HEC clients send "Authorization: Splunk
+// <token>", Humio clients use Bearer.
+func (l *hecListener) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		writeHECResponse(w, http.StatusMethodNotAllowed, hecCodeInvalidData, "Only POST is supported")
+		return false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, hecMaxBodySize)
+	if l.token == "" {
+		return true
+	}
+
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		writeHECResponse(w, http.StatusUnauthorized, hecCodeTokenRequired, "Token is required")
+		return false
+	}
+
+	pieces := strings.SplitN(auth, " ", 2)
+	if len(pieces) != 2 || subtle.ConstantTimeCompare([]byte(pieces[1]), []byte(l.token)) != 1 {
+		writeHECResponse(w, http.StatusForbidden, hecCodeInvalidToken, "Invalid token")
+		return false
+	}
+	return true
+}
+
+// forward sends the body with the given number of events to the ingest API,
+// responding with an error if it fails.
+func (l *hecListener) forward(w http.ResponseWriter, path string, body interface{}, events int) {
+	content, err := json.Marshal(body)
+	if err == nil {
+		err = postIngestRequest(l.client, "api/v1/repositories/"+l.repo+"/"+path, content)
+	}
+	if l.progress != nil {
+		l.progress.record(int64(events), int64(len(content)), err)
+	}
+	if err != nil {
+		log.Printf("error while sending data: %v", err)
+		writeHECResponse(w, http.StatusServiceUnavailable, hecCodeServerBusy, "Server is busy")
+		return
+	}
+	writeHECResponse(w, http.StatusOK, hecCodeSuccess, "Success")
+}
+
+func (l *hecListener) print(line string) {
+	if !l.quiet {
+		fmt.Println(line)
+	}
+}
+
+func (l *hecListener) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeHECResponse(w, http.StatusOK, hecCodeHealthy, "HEC is healthy")
+}
+
+// handleEvents handles a batch of HEC events, which are JSON objects
+// following each other in the body.
+func (l *hecListener) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !l.authorize(w, r) {
+		return
+	}
+
+	var events []structuredEvent
+	dec := json.NewDecoder(r.Body)
+	for {
+		var e hecEvent
+		err := dec.Decode(&e)
+		if err == io.EOF {
+			break
+		}
+		if err != nil || len(e.Event) == 0 {
+			writeHECDecodeError(w, err)
+			return
+		}
+
+		event, err := l.toStructuredEvent(e)
+		if err != nil {
+			writeHECResponse(w, http.StatusBadRequest, hecCodeInvalidData, "Invalid data format")
+			return
+		}
+		l.print(event.RawString)
+		events = append(events, event)
+	}
+
+	if len(events) == 0 {
+		writeHECResponse(w, http.StatusBadRequest, hecCodeNoData, "No data")
+		return
+	}
+
+	l.forward(w, "ingest", []structuredEventList{{Tags: l.tags, Events: events}}, len(events))
+}
+
+func (l *hecListener) toStructuredEvent(e hecEvent) (structuredEvent, error) {
+	event := structuredEvent{Attributes: map[string]interface{}{}}
+
+	var s string
+	if err := json.Unmarshal(e.Event, &s); err == nil {
+		event.RawString = s
+	} else {
+		var attributes map[string]interface{}
+		if err := json.Unmarshal(e.Event, &attributes); err != nil {
+			return event, err
+		}
+		for k, v := range attributes {
+			event.Attributes[k] = v
+		}
+		event.RawString = string(e.Event)
+	}
+
+	event.Timestamp = time.Now().Format(time.RFC3339Nano)
+	if e.Time != "" {
+		seconds, err := strconv.ParseFloat(string(e.Time), 64)
+		if err != nil {
+			return event, err
+		}
+		event.Timestamp = time.Unix(0, int64(seconds*float64(time.Second))).Format(time.RFC3339Nano)
+	}
+
+	for k, v := range map[string]string{"host": e.Host, "source": e.Source, "sourcetype": e.SourceType, "index": e.Index} {
+		if v != "" {
+			event.Attributes[k] = v
+		}
+	}
+	for k, v := range e.Fields {
+		event.Attributes[k] = v
+	}
+	for k, v := range l.fields {
+		event.Attributes[k] = v
+	}
+
+	return event, nil
+}
+
+// handleRaw handles the raw endpoint, where each line of the body is an event.
+func (l *hecListener) handleRaw(w http.ResponseWriter, r *http.Request) {
+	if !l.authorize(w, r) {
+		return
+	}
+
+	var messages []string
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.TrimSpace(line) != "" {
+			l.print(line)
+			messages = append(messages, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		writeHECDecodeError(w, err)
+		return
+	}
+	if len(messages) == 0 {
+		writeHECResponse(w, http.StatusBadRequest, hecCodeNoData, "No data")
+		return
+	}
+
+	l.forward(w, "ingest-messages", []eventList{{Type: l.parser, Fields: l.fields, Tags: l.tags, Messages: messages}}, len(messages))
+}
+
+// handleHumioUnstructured handles Humio's unstructured ingest endpoint, so
+// clients configured for Humio can be pointed at the listener.
+func (l *hecListener) handleHumioUnstructured(w http.ResponseWriter, r *http.Request) {
+	if !l.authorize(w, r) {
+		return
+	}
+
+	var lists []eventList
+	if err := json.NewDecoder(r.Body).Decode(&lists); err != nil {
+		writeHECDecodeError(w, err)
+		return
+	}
+
+	var events int
+	for i := range lists {
+		events += len(lists[i].Messages)
+		if lists[i].Type == "" {
+			lists[i].Type = l.parser
+		}
+		if lists[i].Fields == nil {
+			lists[i].Fields = map[string]string{}
+		}
+		for k, v := range l.fields {
+			lists[i].Fields[k] = v
+		}
+		lists[i].Tags = withTags(lists[i].Tags, l.tags)
+		for _, m := range lists[i].Messages {
+			l.print(m)
+		}
+	}
+
+	l.forward(w, "ingest-messages", lists, events)
+}
+
+// handleHumioStructured handles Humio's structured ingest endpoint.
+func (l *hecListener) handleHumioStructured(w http.ResponseWriter, r *http.Request) {
+	if !l.authorize(w, r) {
+		return
+	}
+
+	var lists []structuredEventList
+	if err := json.NewDecoder(r.Body).Decode(&lists); err != nil {
+		writeHECDecodeError(w, err)
+		return
+	}
+
+	var events int
+	for i := range lists {
+		events += len(lists[i].Events)
+		lists[i].Tags = withTags(lists[i].Tags, l.tags)
+		for j := range lists[i].Events {
+			event := &lists[i].Events[j]
+			if event.Attributes == nil {
+				event.Attributes = map[string]interface{}{}
+			}
+			for k, v := range l.fields {
+				event.Attributes[k] = v
+			}
+			l.print(event.RawString)
+		}
+	}
+
+	l.forward(w, "ingest", lists, events)
+}
+
+// withTags returns the tags of an event list sent to the listener with the
+// tags given by --tag added.
+func withTags(tags, added map[string]string) map[string]string {
+	if len(added) == 0 {
+		return tags
+	}
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	for k, v := range added {
+		tags[k] = v
+	}
+	return tags
+}
@@ -0,0 +1,658 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type kafkaIngestOptions struct {
+	topic         string
+	brokers       []string
+	group         string
+	start         string
+	tls           bool
+	tlsCAFile     string
+	tlsInsecure   bool
+	saslMechanism string
+	saslUsername  string
+	pollInterval  time.Duration
+}
+
+func (opts kafkaIngestOptions) dialer() (*kafkaDialer, error) {
+	d := &kafkaDialer{}
+
+	if opts.tls || opts.tlsCAFile != "" || opts.tlsInsecure {
+		d.tls = &tls.Config{InsecureSkipVerify: opts.tlsInsecure}
+		if opts.tlsCAFile != "" {
+			pem, err := ioutil.ReadFile(opts.tlsCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("error reading CA file: %w", err)
+			}
+			d.tls.RootCAs = x509.NewCertPool()
+			if !d.tls.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", opts.tlsCAFile)
+			}
+		}
+	}
+
+	if opts.saslMechanism != "" {
+		d.saslMechanism = strings.ToUpper(opts.saslMechanism)
+		switch d.saslMechanism {
+		case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+		default:
+			return nil, fmt.Errorf("unsupported SASL mechanism %q, must be one of: PLAIN, SCRAM-SHA-256, SCRAM-SHA-512", opts.saslMechanism)
+		}
+		d.username = opts.saslUsername
+		d.password = os.Getenv("KAFKA_PASSWORD")
+	}
+
+	return d, nil
+}
+
+const (
+	// kafkaSessionTimeout is how long the coordinator of the group waits for
+	// a heartbeat before it removes the member from the group, and
+	// kafkaHeartbeatInterval how often heartbeats are sent.
+	kafkaSessionTimeout    = 30 * time.Second
+	kafkaHeartbeatInterval = 3 * time.Second
+
+	// kafkaRebalanceTimeout is how long the members of the group have to
+	// join it again when it is rebalanced. Members only join again once the
+	// messages they have received are sent, so it is as long as the default
+	// max.poll.interval.ms of the Java client.
+	kafkaRebalanceTimeout = 5 * time.Minute
+)
+
+// kafkaConsumer consumes the partitions of a topic, connecting to the
+// leader of each partition. With a consumer group, the partitions are
+// assigned to it as a member of the group, and otherwise it consumes all
+// partitions.
+type kafkaConsumer struct {
+	opts   kafkaIngestOptions
+	dialer *kafkaDialer
+
+	conns       map[string]*kafkaConn
+	brokers     map[int32]kafkaBroker
+	leaders     map[int32]int32
+	assigned    []int32
+	offsets     map[int32]int64
+	coordinator string
+
+	// memberID and generation identify the consumer as a member of the
+	// current generation of the group.
+	memberID   string
+	generation int32
+	// rejoin is set, atomically, when the consumer must join the group
+	// again, e.g. because a member has joined or left it.
+	rejoin        int32
+	stopHeartbeat func()
+}
+
+// ingestFromKafka sends the messages of the topic using send until ctx is
+// cancelled. If a consumer group is given, the offsets of the messages sent
+// are committed to it, and consuming resumes from them when restarted.
+func ingestFromKafka(ctx context.Context, opts kafkaIngestOptions, quiet bool, send func(batch []string) error) error {
+	dialer, err := opts.dialer()
+	if err != nil {
+		return err
+	}
+
+	c := &kafkaConsumer{
+		opts:   opts,
+		dialer: dialer,
+		conns:  map[string]*kafkaConn{},
+	}
+	defer c.close()
+
+	if err := c.refreshMetadata(ctx); err != nil {
+		return err
+	}
+	if err := c.assign(ctx); err != nil {
+		return err
+	}
+
+	log.Printf("Humio Attached to Kafka topic '%s', %d partitions", opts.topic, len(c.leaders))
+
+	for ctx.Err() == nil {
+		if atomic.LoadInt32(&c.rejoin) != 0 {
+			if err := c.assign(ctx); err != nil {
+				return err
+			}
+		}
+
+		messages, err := c.poll(ctx)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			continue
+		}
+
+		lines := make([]string, len(messages))
+		for i, m := range messages {
+			lines[i] = string(m.value)
+			if !quiet {
+				fmt.Println(lines[i])
+			}
+		}
+
+		for i := 0; i < len(lines); i += batchLimit {
+			end := i + batchLimit
+			if end > len(lines) {
+				end = len(lines)
+			}
+			if err := send(lines[i:end]); err != nil {
+				return fmt.Errorf("error sending messages, offsets have not been committed: %w", err)
+			}
+		}
+
+		if err := c.commit(ctx); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+func (c *kafkaConsumer) close() {
+	if c.stopHeartbeat != nil {
+		c.stopHeartbeat()
+	}
+	// Leave the group, so the partitions are assigned to the other members
+	// right away.
+	if c.memberID != "" {
+		if conn, ok := c.conns[c.coordinator]; ok {
+			_ = conn.leaveGroup(c.opts.group, c.memberID)
+		}
+	}
+	for _, conn := range c.conns {
+		conn.close()
+	}
+}
+
+// conn returns a connection to the broker, connecting if needed.
+func (c *kafkaConsumer) conn(ctx context.Context, addr string) (*kafkaConn, error) {
+	if conn, ok := c.conns[addr]; ok {
+		return conn, nil
+	}
+
+	conn, err := c.dialer.dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	c.conns[addr] = conn
+	return conn, nil
+}
+
+// drop closes the connection to the broker after an error, so the next
+// request reconnects.
+func (c *kafkaConsumer) drop(addr string) {
+	if conn, ok := c.conns[addr]; ok {
+		conn.close()
+		delete(c.conns, addr)
+	}
+}
+
+func (c *kafkaConsumer) refreshMetadata(ctx context.Context) error {
+	var lastErr error
+	for _, addr := range c.opts.brokers {
+		conn, err := c.conn(ctx, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		brokers, topics, err := conn.metadata(c.opts.topic)
+		if err != nil {
+			var kafkaErr kafkaError
+			if errors.As(err, &kafkaErr) {
+				return err
+			}
+			c.drop(addr)
+			lastErr = err
+			continue
+		}
+
+		c.brokers = brokers
+		c.leaders = map[int32]int32{}
+		for _, p := range topics[c.opts.topic] {
+			c.leaders[p.id] = p.leader
+		}
+		return nil
+	}
+
+	return fmt.Errorf("error connecting to Kafka: %w", lastErr)
+}
+
+func (c *kafkaConsumer) partitions() []int32 {
+	partitions := make([]int32, 0, len(c.leaders))
+	for p := range c.leaders {
+		partitions = append(partitions, p)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+	return partitions
+}
+
+// assign sets the partitions to consume and the offsets to start them at.
+// Without a group all partitions are consumed, and otherwise the consumer
+// joins the group and consumes the partitions assigned to it.
+func (c *kafkaConsumer) assign(ctx context.Context) error {
+	if c.opts.group == "" {
+		c.assigned = c.partitions()
+		return c.initOffsets(ctx)
+	}
+
+	if c.stopHeartbeat != nil {
+		c.stopHeartbeat()
+		c.stopHeartbeat = nil
+	}
+	atomic.StoreInt32(&c.rejoin, 0)
+
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		err = c.joinGroup(ctx)
+		switch {
+		case err == nil:
+		case errors.Is(err, kafkaErrUnknownMemberID), errors.Is(err, kafkaErrIllegalGeneration):
+			// The member was removed from the group, e.g. because its session
+			// timed out. Join as a new member.
+			c.memberID = ""
+			continue
+		case errors.Is(err, kafkaErrRebalanceInProgress):
+			continue
+		case errors.Is(err, kafkaErrNotCoordinator), errors.Is(err, kafkaErrCoordinatorNotAvailable), errors.Is(err, kafkaErrCoordinatorLoading):
+			c.drop(c.coordinator)
+			c.coordinator = ""
+			if sleepUnlessDone(ctx, time.Second) != nil {
+				return ctx.Err()
+			}
+			continue
+		default:
+			return fmt.Errorf("error joining group %s: %w", c.opts.group, err)
+		}
+		break
+	}
+	if err != nil {
+		return fmt.Errorf("error joining group %s: %w", c.opts.group, err)
+	}
+
+	if err := c.initOffsets(ctx); err != nil {
+		return err
+	}
+	c.startHeartbeat(ctx)
+
+	log.Printf("Joined group %s, consuming partitions %v of topic %s", c.opts.group, c.assigned, c.opts.topic)
+	return nil
+}
+
+// joinGroup joins the group and sets the partitions assigned to the
+// consumer. If it is elected leader of the group it assigns the partitions
+// of all members.
+func (c *kafkaConsumer) joinGroup(ctx context.Context) error {
+	conn, err := c.coordinatorConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	joined, err := conn.joinGroup(c.opts.group, c.memberID, c.opts.topic, kafkaSessionTimeout, kafkaRebalanceTimeout)
+	if err != nil {
+		return err
+	}
+	c.memberID = joined.memberID
+	c.generation = joined.generation
+
+	var assignments map[string]map[string][]int32
+	if joined.leader == joined.memberID {
+		assignments, err = c.assignRanges(ctx, joined.members)
+		if err != nil {
+			return err
+		}
+	}
+
+	assigned, err := conn.syncGroup(c.opts.group, c.generation, c.memberID, assignments)
+	if err != nil {
+		return err
+	}
+
+	c.assigned = assigned[c.opts.topic]
+	sort.Slice(c.assigned, func(i, j int) bool { return c.assigned[i] < c.assigned[j] })
+	return c.refreshMetadata(ctx)
+}
+
+// assignRanges assigns the partitions of each topic to the members of the
+// group subscribing to it, like the range assignor of the Java client: in
+// order of their member IDs, each member is assigned a range of partitions,
+// with the first members getting one more partition if they cannot be
+// divided evenly.
+func (c *kafkaConsumer) assignRanges(ctx context.Context, members []kafkaGroupMember) (map[string]map[string][]int32, error) {
+	subscribers := map[string][]string{}
+	for _, m := range members {
+		for _, topic := range m.topics {
+			subscribers[topic] = append(subscribers[topic], m.id)
+		}
+	}
+	topics := make([]string, 0, len(subscribers))
+	for topic := range subscribers {
+		topics = append(topics, topic)
+	}
+
+	var partitions map[string][]kafkaPartition
+	var lastErr error
+	for _, addr := range c.opts.brokers {
+		conn, err := c.conn(ctx, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_, partitions, lastErr = conn.metadata(topics...)
+		if lastErr == nil {
+			break
+		}
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("error fetching partitions to assign: %w", lastErr)
+	}
+
+	assignments := map[string]map[string][]int32{}
+	for _, m := range members {
+		assignments[m.id] = map[string][]int32{}
+	}
+	for topic, memberIDs := range subscribers {
+		ids := make([]int32, 0, len(partitions[topic]))
+		for _, p := range partitions[topic] {
+			ids = append(ids, p.id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		sort.Strings(memberIDs)
+
+		per, extra := len(ids)/len(memberIDs), len(ids)%len(memberIDs)
+		start := 0
+		for i, id := range memberIDs {
+			n := per
+			if i < extra {
+				n++
+			}
+			if n > 0 {
+				assignments[id][topic] = ids[start : start+n]
+			}
+			start += n
+		}
+	}
+	return assignments, nil
+}
+
+// startHeartbeat sends heartbeats to the coordinator of the group in the
+// background, on a connection of its own, until stopHeartbeat is called.
+// When the group is rebalanced, rejoin is set and the heartbeats stop.
+func (c *kafkaConsumer) startHeartbeat(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	coordinator, group, generation, memberID := c.coordinator, c.opts.group, c.generation, c.memberID
+
+	go func() {
+		defer close(done)
+
+		var conn *kafkaConn
+		defer func() {
+			if conn != nil {
+				conn.close()
+			}
+		}()
+
+		ticker := time.NewTicker(kafkaHeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if conn == nil {
+				var err error
+				if conn, err = c.dialer.dial(ctx, coordinator); err != nil {
+					// Try again at the next heartbeat. If the coordinator cannot
+					// be reached before the session times out, the next commit
+					// fails and the consumer joins the group again.
+					conn = nil
+					continue
+				}
+			}
+
+			err := conn.heartbeat(group, generation, memberID)
+			var kafkaErr kafkaError
+			switch {
+			case err == nil:
+			case errors.As(err, &kafkaErr):
+				// The group is rebalancing, the member has been removed from
+				// it, or the coordinator has moved.
+				atomic.StoreInt32(&c.rejoin, 1)
+				return
+			default:
+				conn.close()
+				conn = nil
+			}
+		}
+	}()
+
+	c.stopHeartbeat = func() {
+		cancel()
+		<-done
+	}
+}
+
+// initOffsets starts each assigned partition at the offset committed by the
+// group, or at the start or end of the partition if there is none.
+func (c *kafkaConsumer) initOffsets(ctx context.Context) error {
+	c.offsets = map[int32]int64{}
+	if len(c.assigned) == 0 {
+		return nil
+	}
+
+	if c.opts.group != "" {
+		conn, err := c.coordinatorConn(ctx)
+		if err != nil {
+			return err
+		}
+		committed, err := conn.offsetFetch(c.opts.group, c.opts.topic, c.assigned)
+		if err != nil {
+			return fmt.Errorf("error fetching committed offsets: %w", err)
+		}
+		for p, offset := range committed {
+			c.offsets[p] = offset
+		}
+	}
+
+	var missing []int32
+	for _, p := range c.assigned {
+		if _, ok := c.offsets[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+	return c.resetOffsets(ctx, missing)
+}
+
+// resetOffsets sets the offsets of the partitions according to --kafka-start.
+func (c *kafkaConsumer) resetOffsets(ctx context.Context, partitions []int32) error {
+	at := int64(kafkaLatestOffset)
+	if c.opts.start == "earliest" {
+		at = kafkaEarliestOffset
+	}
+
+	for leader, ps := range c.byLeader(partitions) {
+		conn, err := c.conn(ctx, c.brokers[leader].addr)
+		if err != nil {
+			return err
+		}
+		offsets, err := conn.listOffsets(c.opts.topic, ps, at)
+		if err != nil {
+			return err
+		}
+		for p, offset := range offsets {
+			c.offsets[p] = offset
+		}
+	}
+	return nil
+}
+
+func (c *kafkaConsumer) byLeader(partitions []int32) map[int32][]int32 {
+	byLeader := map[int32][]int32{}
+	for _, p := range partitions {
+		leader := c.leaders[p]
+		byLeader[leader] = append(byLeader[leader], p)
+	}
+	return byLeader
+}
+
+func (c *kafkaConsumer) coordinatorConn(ctx context.Context) (*kafkaConn, error) {
+	if c.coordinator == "" {
+		var lastErr error
+		for _, addr := range c.opts.brokers {
+			conn, err := c.conn(ctx, addr)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			c.coordinator, lastErr = conn.findCoordinator(c.opts.group)
+			if lastErr == nil {
+				break
+			}
+		}
+		if c.coordinator == "" {
+			return nil, fmt.Errorf("error finding coordinator of group %s: %w", c.opts.group, lastErr)
+		}
+	}
+
+	return c.conn(ctx, c.coordinator)
+}
+
+// poll fetches new messages from the leader of each partition and advances
+// the offsets past them.
+func (c *kafkaConsumer) poll(ctx context.Context) ([]kafkaMessage, error) {
+	if len(c.assigned) == 0 {
+		// The group has more members than the topic has partitions. Wait to
+		// be assigned partitions when the group is rebalanced.
+		_ = sleepUnlessDone(ctx, c.opts.pollInterval)
+		return nil, nil
+	}
+
+	var messages []kafkaMessage
+	var refresh, reset []int32
+
+	for leader, partitions := range c.byLeader(c.assigned) {
+		broker, ok := c.brokers[leader]
+		if !ok {
+			refresh = append(refresh, partitions...)
+			continue
+		}
+
+		conn, err := c.conn(ctx, broker.addr)
+		if err != nil {
+			refresh = append(refresh, partitions...)
+			continue
+		}
+
+		offsets := map[int32]int64{}
+		for _, p := range partitions {
+			offsets[p] = c.offsets[p]
+		}
+
+		results, err := conn.fetch(c.opts.topic, offsets, c.opts.pollInterval)
+		if err != nil {
+			c.drop(broker.addr)
+			refresh = append(refresh, partitions...)
+			continue
+		}
+
+		for p, result := range results {
+			switch {
+			case errors.Is(result.err, kafkaErrOffsetOutOfRange):
+				reset = append(reset, p)
+			case errors.Is(result.err, kafkaErrNotLeaderForPartition), errors.Is(result.err, kafkaErrUnknownTopicOrPartition):
+				refresh = append(refresh, p)
+			case result.err != nil:
+				return nil, fmt.Errorf("error fetching partition %d: %w", p, result.err)
+			default:
+				messages = append(messages, result.messages...)
+				c.offsets[p] = result.next
+			}
+		}
+	}
+
+	if len(refresh) > 0 {
+		// Wait a bit for a new leader to be elected.
+		if err := sleepUnlessDone(ctx, time.Second); err != nil {
+			return messages, nil
+		}
+		if err := c.refreshMetadata(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(reset) > 0 {
+		log.Printf("Offsets of partitions %v are out of range, starting from the %s offset", reset, c.opts.start)
+		if err := c.resetOffsets(ctx, reset); err != nil {
+			return nil, err
+		}
+	}
+
+	return messages, nil
+}
+
+// commit commits the current offsets to the consumer group, if any. If the
+// group is being rebalanced the offsets are not committed, and the consumer
+// joins the group again before polling, so messages sent since the last
+// commit may be sent again by the member the partitions are assigned to.
+func (c *kafkaConsumer) commit(ctx context.Context) error {
+	if c.opts.group == "" || len(c.offsets) == 0 {
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var conn *kafkaConn
+		conn, err = c.coordinatorConn(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = conn.offsetCommit(c.opts.group, c.generation, c.memberID, c.opts.topic, c.offsets)
+		if err == nil || !(errors.Is(err, kafkaErrNotCoordinator) || errors.Is(err, kafkaErrCoordinatorNotAvailable) || errors.Is(err, kafkaErrCoordinatorLoading)) {
+			break
+		}
+
+		// The group has moved to another coordinator.
+		c.drop(c.coordinator)
+		c.coordinator = ""
+	}
+
+	if errors.Is(err, kafkaErrRebalanceInProgress) || errors.Is(err, kafkaErrIllegalGeneration) || errors.Is(err, kafkaErrUnknownMemberID) {
+		log.Printf("Could not commit offsets while group %s is rebalancing, messages since the last commit may be sent again: %v", c.opts.group, err)
+		atomic.StoreInt32(&c.rejoin, 1)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error committing offsets: %w", err)
+	}
+	return nil
+}
+
+func sleepUnlessDone(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
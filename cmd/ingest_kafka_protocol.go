@@ -0,0 +1,1020 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// This is a minimal Kafka client implementing only the requests needed to
+// consume a topic as a member of a consumer group, using protocol versions
+// supported by Kafka 1.0 and later.
+
+const (
+	kafkaFetchKey            = 1
+	kafkaListOffsetsKey      = 2
+	kafkaMetadataKey         = 3
+	kafkaOffsetCommitKey     = 8
+	kafkaOffsetFetchKey      = 9
+	kafkaFindCoordinatorKey  = 10
+	kafkaJoinGroupKey        = 11
+	kafkaHeartbeatKey        = 12
+	kafkaLeaveGroupKey       = 13
+	kafkaSyncGroupKey        = 14
+	kafkaSaslHandshakeKey    = 17
+	kafkaSaslAuthenticateKey = 36
+
+	kafkaEarliestOffset = -2
+	kafkaLatestOffset   = -1
+
+	kafkaRequestTimeout = 30 * time.Second
+)
+
+// kafkaError is an error code returned by a Kafka broker.
+type kafkaError int16
+
+const (
+	kafkaErrOffsetOutOfRange        kafkaError = 1
+	kafkaErrUnknownTopicOrPartition kafkaError = 3
+	kafkaErrNotLeaderForPartition   kafkaError = 6
+	kafkaErrCoordinatorLoading      kafkaError = 14
+	kafkaErrCoordinatorNotAvailable kafkaError = 15
+	kafkaErrNotCoordinator          kafkaError = 16
+	kafkaErrIllegalGeneration       kafkaError = 22
+	kafkaErrUnknownMemberID         kafkaError = 25
+	kafkaErrRebalanceInProgress     kafkaError = 27
+)
+
+// kafkaConsumerProtocol is the protocol type of consumer groups, and
+// kafkaRangeAssignor the name of the partition assignment strategy used, the
+// default of the Java client, so the group can be shared with it.
+const (
+	kafkaConsumerProtocol = "consumer"
+	kafkaRangeAssignor    = "range"
+)
+
+var kafkaErrorNames = map[kafkaError]string{
+	kafkaErrOffsetOutOfRange:        "offset out of range",
+	kafkaErrUnknownTopicOrPartition: "unknown topic or partition",
+	kafkaErrNotLeaderForPartition:   "not leader for partition",
+	kafkaErrCoordinatorLoading:      "coordinator load in progress",
+	kafkaErrCoordinatorNotAvailable: "coordinator not available",
+	kafkaErrNotCoordinator:          "not coordinator",
+	kafkaErrIllegalGeneration:       "illegal generation",
+	kafkaErrUnknownMemberID:         "unknown member id",
+	kafkaErrRebalanceInProgress:     "rebalance in progress",
+	29:                              "topic authorization failed",
+	30:                              "group authorization failed",
+	33:                              "unsupported SASL mechanism",
+	58:                              "SASL authentication failed",
+}
+
+func (e kafkaError) Error() string {
+	if name, ok := kafkaErrorNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("kafka error code %d", int16(e))
+}
+
+func kafkaErr(code int16) error {
+	if code == 0 {
+		return nil
+	}
+	return kafkaError(code)
+}
+
+var errKafkaShortResponse = errors.New("kafka response is too short")
+
+type kafkaEncoder struct {
+	b []byte
+}
+
+func (e *kafkaEncoder) int8(v int8) {
+	e.b = append(e.b, byte(v))
+}
+
+func (e *kafkaEncoder) int16(v int16) {
+	e.b = append(e.b, byte(v>>8), byte(v))
+}
+
+func (e *kafkaEncoder) int32(v int32) {
+	e.b = append(e.b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func (e *kafkaEncoder) int64(v int64) {
+	e.int32(int32(v >> 32))
+	e.int32(int32(v))
+}
+
+func (e *kafkaEncoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.b = append(e.b, s...)
+}
+
+func (e *kafkaEncoder) nullString() {
+	e.int16(-1)
+}
+
+func (e *kafkaEncoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.b = append(e.b, b...)
+}
+
+func (e *kafkaEncoder) nullBytes() {
+	e.int32(-1)
+}
+
+// kafkaDecoder reads a response. After the first read beyond the end of the
+// response all reads return zero values and err is set.
+type kafkaDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *kafkaDecoder) take(n int) []byte {
+	if d.err != nil || n < 0 || len(d.b) < n {
+		d.err = errKafkaShortResponse
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+func (d *kafkaDecoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
+
+// arrayLen returns the number of elements of an array, or 0 for a null array.
+func (d *kafkaDecoder) arrayLen() int {
+	n := d.int32()
+	if n < 0 {
+		return 0
+	}
+	// Every element is at least one byte, which protects against huge
+	// allocations when the response is corrupt.
+	if int(n) > len(d.b) {
+		d.err = errKafkaShortResponse
+		return 0
+	}
+	return int(n)
+}
+
+// varint reads a zigzag encoded variable length integer as used in records.
+func (d *kafkaDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.err = errKafkaShortResponse
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *kafkaDecoder) varBytes() []byte {
+	n := d.varint()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
+
+type kafkaDialer struct {
+	tls           *tls.Config
+	saslMechanism string
+	username      string
+	password      string
+}
+
+type kafkaConn struct {
+	conn          net.Conn
+	correlationID int32
+}
+
+func (d *kafkaDialer) dial(ctx context.Context, addr string) (*kafkaConn, error) {
+	dialer := net.Dialer{Timeout: kafkaRequestTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.tls != nil {
+		config := d.tls.Clone()
+		if config.ServerName == "" {
+			host, _, _ := net.SplitHostPort(addr)
+			config.ServerName = host
+		}
+		tlsConn := tls.Client(conn, config)
+		tlsConn.SetDeadline(time.Now().Add(kafkaRequestTimeout))
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	c := &kafkaConn{conn: conn}
+
+	if d.saslMechanism != "" {
+		if err := c.authenticate(d.saslMechanism, d.username, d.password); err != nil {
+			c.close()
+			return nil, fmt.Errorf("error authenticating to %s: %w", addr, err)
+		}
+	}
+
+	return c, nil
+}
+
+func (c *kafkaConn) close() {
+	c.conn.Close()
+}
+
+// request sends a request and returns a decoder for the body of the response.
+func (c *kafkaConn) request(apiKey, version int16, body []byte, timeout time.Duration) (*kafkaDecoder, error) {
+	c.correlationID++
+
+	var e kafkaEncoder
+	e.int32(0) // Size, set below
+	e.int16(apiKey)
+	e.int16(version)
+	e.int32(c.correlationID)
+	e.string("humioctl")
+	e.b = append(e.b, body...)
+	binary.BigEndian.PutUint32(e.b, uint32(len(e.b)-4))
+
+	c.conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := c.conn.Write(e.b); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(c.conn, size[:]); err != nil {
+		return nil, err
+	}
+
+	response := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(c.conn, response); err != nil {
+		return nil, err
+	}
+
+	d := &kafkaDecoder{b: response}
+	if id := d.int32(); id != c.correlationID {
+		return nil, fmt.Errorf("kafka response has correlation id %d, expected %d", id, c.correlationID)
+	}
+	return d, nil
+}
+
+type kafkaBroker struct {
+	id   int32
+	addr string
+}
+
+type kafkaPartition struct {
+	id     int32
+	leader int32
+	err    error
+}
+
+// metadata returns the brokers of the cluster and the partitions of the topics.
+func (c *kafkaConn) metadata(topics ...string) (map[int32]kafkaBroker, map[string][]kafkaPartition, error) {
+	var e kafkaEncoder
+	e.int32(int32(len(topics)))
+	for _, topic := range topics {
+		e.string(topic)
+	}
+	e.int8(0) // Don't create the topics
+
+	d, err := c.request(kafkaMetadataKey, 4, e.b, kafkaRequestTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d.int32() // Throttle time
+
+	brokers := map[int32]kafkaBroker{}
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // Rack
+		brokers[id] = kafkaBroker{id: id, addr: net.JoinHostPort(host, strconv.Itoa(int(port)))}
+	}
+
+	d.string() // Cluster ID
+	d.int32()  // Controller ID
+
+	partitions := map[string][]kafkaPartition{}
+	var topicErr error
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		code := d.int16()
+		topic := d.string()
+		d.int8() // Is internal
+		if err := kafkaErr(code); err != nil && topicErr == nil {
+			topicErr = fmt.Errorf("error fetching topic %s: %w", topic, err)
+		}
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			p := kafkaPartition{err: kafkaErr(d.int16())}
+			p.id = d.int32()
+			p.leader = d.int32()
+			for k, r := 0, d.arrayLen(); k < r; k++ {
+				d.int32() // Replicas
+			}
+			for k, r := 0, d.arrayLen(); k < r; k++ {
+				d.int32() // In-sync replicas
+			}
+			partitions[topic] = append(partitions[topic], p)
+		}
+	}
+
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+	if topicErr != nil {
+		return nil, nil, topicErr
+	}
+	return brokers, partitions, nil
+}
+
+// findCoordinator returns the address of the broker managing the offsets of the group.
+func (c *kafkaConn) findCoordinator(group string) (string, error) {
+	var e kafkaEncoder
+	e.string(group)
+	e.int8(0) // Group, rather than transaction
+
+	d, err := c.request(kafkaFindCoordinatorKey, 1, e.b, kafkaRequestTimeout)
+	if err != nil {
+		return "", err
+	}
+
+	d.int32() // Throttle time
+	code := d.int16()
+	message := d.string()
+	d.int32() // Node ID
+	host := d.string()
+	port := d.int32()
+
+	if d.err != nil {
+		return "", d.err
+	}
+	if err := kafkaErr(code); err != nil {
+		if message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port))), nil
+}
+
+// listOffsets returns the offset of each partition at the time, which is
+// kafkaEarliestOffset or kafkaLatestOffset.
+func (c *kafkaConn) listOffsets(topic string, partitions []int32, time int64) (map[int32]int64, error) {
+	var e kafkaEncoder
+	e.int32(-1) // Replica ID
+	e.int32(1)
+	e.string(topic)
+	e.int32(int32(len(partitions)))
+	for _, p := range partitions {
+		e.int32(p)
+		e.int64(time)
+	}
+
+	d, err := c.request(kafkaListOffsetsKey, 1, e.b, kafkaRequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := map[int32]int64{}
+	var firstErr error
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.string() // Topic
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			partition := d.int32()
+			code := d.int16()
+			d.int64() // Timestamp
+			offset := d.int64()
+			if err := kafkaErr(code); err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("error listing offsets of partition %d: %w", partition, err)
+				}
+				continue
+			}
+			offsets[partition] = offset
+		}
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	return offsets, firstErr
+}
+
+// offsetFetch returns the offsets committed by the group. Partitions
+// without a committed offset are not included.
+func (c *kafkaConn) offsetFetch(group, topic string, partitions []int32) (map[int32]int64, error) {
+	var e kafkaEncoder
+	e.string(group)
+	e.int32(1)
+	e.string(topic)
+	e.int32(int32(len(partitions)))
+	for _, p := range partitions {
+		e.int32(p)
+	}
+
+	d, err := c.request(kafkaOffsetFetchKey, 1, e.b, kafkaRequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := map[int32]int64{}
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.string() // Topic
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			partition := d.int32()
+			offset := d.int64()
+			d.string() // Metadata
+			if err := kafkaErr(d.int16()); err != nil {
+				return nil, fmt.Errorf("error fetching offset of partition %d: %w", partition, err)
+			}
+			if offset >= 0 {
+				offsets[partition] = offset
+			}
+		}
+	}
+
+	return offsets, d.err
+}
+
+// offsetCommit commits the offsets of the next messages to consume for the
+// group, as the member of the generation of the group.
+func (c *kafkaConn) offsetCommit(group string, generation int32, memberID, topic string, offsets map[int32]int64) error {
+	var e kafkaEncoder
+	e.string(group)
+	e.int32(generation)
+	e.string(memberID)
+	e.int64(-1) // Retention time, use the broker default
+	e.int32(1)
+	e.string(topic)
+	e.int32(int32(len(offsets)))
+	for p, offset := range offsets {
+		e.int32(p)
+		e.int64(offset)
+		e.nullString()
+	}
+
+	d, err := c.request(kafkaOffsetCommitKey, 2, e.b, kafkaRequestTimeout)
+	if err != nil {
+		return err
+	}
+
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.string() // Topic
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			partition := d.int32()
+			if err := kafkaErr(d.int16()); err != nil {
+				return fmt.Errorf("error committing offset of partition %d: %w", partition, err)
+			}
+		}
+	}
+
+	return d.err
+}
+
+// kafkaGroupMember is a member of a consumer group, with the topics it
+// subscribes to.
+type kafkaGroupMember struct {
+	id     string
+	topics []string
+}
+
+// kafkaJoinedGroup is the generation of a consumer group a member has
+// joined. Only the leader of the group is given its members, and assigns
+// the partitions to them.
+type kafkaJoinedGroup struct {
+	generation int32
+	memberID   string
+	leader     string
+	members    []kafkaGroupMember
+}
+
+// joinGroup joins the consumer group, subscribing to the topic. An empty
+// member ID joins the group as a new member. It returns once all members
+// have joined the new generation of the group, or rebalanceTimeout has
+// passed.
+func (c *kafkaConn) joinGroup(group, memberID, topic string, sessionTimeout, rebalanceTimeout time.Duration) (kafkaJoinedGroup, error) {
+	// The subscription, version 0 of the consumer protocol.
+	var subscription kafkaEncoder
+	subscription.int16(0)
+	subscription.int32(1)
+	subscription.string(topic)
+	subscription.nullBytes() // User data
+
+	var e kafkaEncoder
+	e.string(group)
+	e.int32(int32(sessionTimeout / time.Millisecond))
+	e.int32(int32(rebalanceTimeout / time.Millisecond))
+	e.string(memberID)
+	e.string(kafkaConsumerProtocol)
+	e.int32(1)
+	e.string(kafkaRangeAssignor)
+	e.bytes(subscription.b)
+
+	d, err := c.request(kafkaJoinGroupKey, 2, e.b, kafkaRequestTimeout+rebalanceTimeout)
+	if err != nil {
+		return kafkaJoinedGroup{}, err
+	}
+
+	d.int32() // Throttle time
+	code := d.int16()
+	joined := kafkaJoinedGroup{generation: d.int32()}
+	d.string() // Protocol
+	joined.leader = d.string()
+	joined.memberID = d.string()
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		member := kafkaGroupMember{id: d.string()}
+		m := &kafkaDecoder{b: d.bytes()}
+		m.int16() // Version
+		for j, t := 0, m.arrayLen(); j < t; j++ {
+			member.topics = append(member.topics, m.string())
+		}
+		if m.err != nil {
+			return kafkaJoinedGroup{}, fmt.Errorf("invalid subscription of member %s: %w", member.id, m.err)
+		}
+		joined.members = append(joined.members, member)
+	}
+
+	if d.err != nil {
+		return kafkaJoinedGroup{}, d.err
+	}
+	return joined, kafkaErr(code)
+}
+
+// syncGroup completes joining the generation of the group, and returns the
+// partitions assigned to the member by topic. The leader of the group gives
+// the assignments of all members, by member ID.
+func (c *kafkaConn) syncGroup(group string, generation int32, memberID string, assignments map[string]map[string][]int32) (map[string][]int32, error) {
+	var e kafkaEncoder
+	e.string(group)
+	e.int32(generation)
+	e.string(memberID)
+	e.int32(int32(len(assignments)))
+	for member, topics := range assignments {
+		// The assignment, version 0 of the consumer protocol.
+		var assignment kafkaEncoder
+		assignment.int16(0)
+		assignment.int32(int32(len(topics)))
+		for topic, partitions := range topics {
+			assignment.string(topic)
+			assignment.int32(int32(len(partitions)))
+			for _, p := range partitions {
+				assignment.int32(p)
+			}
+		}
+		assignment.nullBytes() // User data
+
+		e.string(member)
+		e.bytes(assignment.b)
+	}
+
+	d, err := c.request(kafkaSyncGroupKey, 1, e.b, kafkaRequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	d.int32() // Throttle time
+	if err := kafkaErr(d.int16()); err != nil {
+		return nil, err
+	}
+
+	assigned := map[string][]int32{}
+	a := &kafkaDecoder{b: d.bytes()}
+	if len(a.b) == 0 {
+		// Nothing is assigned to the member.
+		return assigned, d.err
+	}
+	a.int16() // Version
+	for i, n := 0, a.arrayLen(); i < n; i++ {
+		topic := a.string()
+		for j, m := 0, a.arrayLen(); j < m; j++ {
+			assigned[topic] = append(assigned[topic], a.int32())
+		}
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	if a.err != nil {
+		return nil, fmt.Errorf("invalid assignment: %w", a.err)
+	}
+	return assigned, nil
+}
+
+// heartbeat tells the coordinator that the member is alive. It returns
+// kafkaErrRebalanceInProgress when the member must join the group again.
+func (c *kafkaConn) heartbeat(group string, generation int32, memberID string) error {
+	var e kafkaEncoder
+	e.string(group)
+	e.int32(generation)
+	e.string(memberID)
+
+	d, err := c.request(kafkaHeartbeatKey, 1, e.b, kafkaRequestTimeout)
+	if err != nil {
+		return err
+	}
+
+	d.int32() // Throttle time
+	code := d.int16()
+	if d.err != nil {
+		return d.err
+	}
+	return kafkaErr(code)
+}
+
+// leaveGroup leaves the group, so its partitions are assigned to the other
+// members right away rather than when the session times out.
+func (c *kafkaConn) leaveGroup(group, memberID string) error {
+	var e kafkaEncoder
+	e.string(group)
+	e.string(memberID)
+
+	d, err := c.request(kafkaLeaveGroupKey, 1, e.b, kafkaRequestTimeout)
+	if err != nil {
+		return err
+	}
+
+	d.int32() // Throttle time
+	code := d.int16()
+	if d.err != nil {
+		return d.err
+	}
+	return kafkaErr(code)
+}
+
+type kafkaMessage struct {
+	partition int32
+	offset    int64
+	value     []byte
+}
+
+type kafkaFetchResult struct {
+	messages []kafkaMessage
+	// next is the offset to fetch from next time.
+	next int64
+	err  error
+}
+
+// fetch returns the messages of each partition from its offset, waiting up
+// to maxWait for messages to arrive.
+func (c *kafkaConn) fetch(topic string, offsets map[int32]int64, maxWait time.Duration) (map[int32]kafkaFetchResult, error) {
+	var e kafkaEncoder
+	e.int32(-1) // Replica ID
+	e.int32(int32(maxWait / time.Millisecond))
+	e.int32(1)                // Min bytes
+	e.int32(50 * 1024 * 1024) // Max bytes
+	e.int8(0)                 // Read uncommitted
+	e.int32(1)
+	e.string(topic)
+	e.int32(int32(len(offsets)))
+	for p, offset := range offsets {
+		e.int32(p)
+		e.int64(offset)
+		e.int32(1024 * 1024) // Max bytes of the partition
+	}
+
+	d, err := c.request(kafkaFetchKey, 4, e.b, kafkaRequestTimeout+maxWait)
+	if err != nil {
+		return nil, err
+	}
+
+	d.int32() // Throttle time
+
+	results := map[int32]kafkaFetchResult{}
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.string() // Topic
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			partition := d.int32()
+			code := d.int16()
+			d.int64() // High watermark
+			d.int64() // Last stable offset
+			for k, a := 0, d.arrayLen(); k < a; k++ {
+				d.int64() // Aborted transaction producer ID
+				d.int64() // Aborted transaction first offset
+			}
+			records := d.bytes()
+
+			if err := kafkaErr(code); err != nil {
+				results[partition] = kafkaFetchResult{err: err}
+				continue
+			}
+
+			messages, next, err := decodeKafkaRecordBatches(partition, records, offsets[partition])
+			results[partition] = kafkaFetchResult{messages: messages, next: next, err: err}
+		}
+	}
+
+	return results, d.err
+}
+
+// decodeKafkaRecordBatches returns the messages at or after offset in the
+// record batches, and the offset following the last complete batch.
+func decodeKafkaRecordBatches(partition int32, data []byte, offset int64) ([]kafkaMessage, int64, error) {
+	var messages []kafkaMessage
+	next := offset
+
+	for len(data) >= 12 {
+		d := &kafkaDecoder{b: data}
+		baseOffset := d.int64()
+		length := int(d.int32())
+		if len(d.b) < length {
+			// The last batch may be cut off by the size limit of the response.
+			break
+		}
+		data = d.b[length:]
+		d.b = d.b[:length]
+
+		d.int32() // Partition leader epoch
+		if magic := d.int8(); magic != 2 {
+			return nil, next, fmt.Errorf("unsupported message format version %d, Kafka 0.11 or later is required", magic)
+		}
+		d.int32() // CRC
+		attributes := d.int16()
+		lastOffsetDelta := d.int32()
+		d.int64() // First timestamp
+		d.int64() // Max timestamp
+		d.int64() // Producer ID
+		d.int16() // Producer epoch
+		d.int32() // Base sequence
+		count := int(d.int32())
+		if d.err != nil {
+			return nil, next, d.err
+		}
+
+		if end := baseOffset + int64(lastOffsetDelta) + 1; end > next {
+			next = end
+		}
+
+		// Control batches mark the end of transactions and contain no messages.
+		if attributes&0x20 != 0 {
+			continue
+		}
+
+		if codec := attributes & 0x7; codec != 0 {
+			records, err := decompressKafkaRecords(codec, d.b)
+			if err != nil {
+				return nil, next, err
+			}
+			d.b = records
+		}
+
+		for i := 0; i < count; i++ {
+			r := &kafkaDecoder{b: d.take(int(d.varint()))}
+			r.int8()   // Attributes
+			r.varint() // Timestamp delta
+			offsetDelta := r.varint()
+			r.varBytes() // Key
+			value := r.varBytes()
+			if d.err != nil || r.err != nil {
+				return nil, next, errKafkaShortResponse
+			}
+
+			if o := baseOffset + offsetDelta; o >= offset {
+				messages = append(messages, kafkaMessage{partition: partition, offset: o, value: value})
+			}
+		}
+	}
+
+	return messages, next, nil
+}
+
+// kafkaXerialSnappyHeader starts snappy compressed records framed by the
+// Java client, in chunks each compressed on its own.
+var kafkaXerialSnappyHeader = []byte{0x82, 'S', 'N', 'A', 'P', 'P', 'Y', 0}
+
+// decompressKafkaRecords decompresses the records of a batch compressed
+// with the codec given by the attributes of the batch.
+func decompressKafkaRecords(codec int16, data []byte) ([]byte, error) {
+	switch codec {
+	case 1:
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(gz)
+	case 2:
+		if !bytes.HasPrefix(data, kafkaXerialSnappyHeader) {
+			return snappy.Decode(nil, data)
+		}
+		// The header is followed by the version and the compatible version.
+		d := &kafkaDecoder{b: data[len(kafkaXerialSnappyHeader):]}
+		d.int32()
+		d.int32()
+		var records []byte
+		for d.err == nil && len(d.b) > 0 {
+			chunk, err := snappy.Decode(nil, d.bytes())
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, chunk...)
+		}
+		return records, d.err
+	case 3:
+		return ioutil.ReadAll(lz4.NewReader(bytes.NewReader(data)))
+	case 4:
+		zr, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return zr.DecodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("unsupported compression codec %d", codec)
+	}
+}
+
+// authenticate authenticates the connection using SASL PLAIN or SCRAM.
+func (c *kafkaConn) authenticate(mechanism, username, password string) error {
+	var e kafkaEncoder
+	e.string(mechanism)
+
+	d, err := c.request(kafkaSaslHandshakeKey, 1, e.b, kafkaRequestTimeout)
+	if err != nil {
+		return err
+	}
+	if err := kafkaErr(d.int16()); err != nil {
+		var enabled []string
+		for i, n := 0, d.arrayLen(); i < n; i++ {
+			enabled = append(enabled, d.string())
+		}
+		return fmt.Errorf("%w, the broker supports: %s", err, strings.Join(enabled, ", "))
+	}
+
+	switch mechanism {
+	case "PLAIN":
+		_, err := c.saslAuthenticate([]byte("\x00" + username + "\x00" + password))
+		return err
+	case "SCRAM-SHA-256":
+		return c.scramAuthenticate(sha256.New, username, password)
+	case "SCRAM-SHA-512":
+		return c.scramAuthenticate(sha512.New, username, password)
+	default:
+		return fmt.Errorf("unsupported SASL mechanism %s", mechanism)
+	}
+}
+
+func (c *kafkaConn) saslAuthenticate(message []byte) ([]byte, error) {
+	var e kafkaEncoder
+	e.bytes(message)
+
+	d, err := c.request(kafkaSaslAuthenticateKey, 0, e.b, kafkaRequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	code := d.int16()
+	errorMessage := d.string()
+	response := d.bytes()
+	if err := kafkaErr(code); err != nil {
+		if errorMessage != "" {
+			return nil, fmt.Errorf("%w: %s", err, errorMessage)
+		}
+		return nil, err
+	}
+	return response, d.err
+}
+
+// scramAuthenticate performs a SCRAM exchange as described in RFC 5802.
+func (c *kafkaConn) scramAuthenticate(h func() hash.Hash, username, password string) error {
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	clientNonce := base64.RawStdEncoding.EncodeToString(nonce)
+
+	name := strings.NewReplacer("=", "=3D", ",", "=2C").Replace(username)
+	clientFirstBare := "n=" + name + ",r=" + clientNonce
+
+	serverFirst, err := c.saslAuthenticate([]byte("n,," + clientFirstBare))
+	if err != nil {
+		return err
+	}
+
+	attrs := scramAttributes(string(serverFirst))
+	serverNonce := attrs["r"]
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	if err != nil {
+		return fmt.Errorf("invalid SCRAM salt: %v", err)
+	}
+	iterations, err := strconv.Atoi(attrs["i"])
+	if err != nil || !strings.HasPrefix(serverNonce, clientNonce) {
+		return fmt.Errorf("invalid SCRAM server message")
+	}
+
+	mac := func(key []byte, data string) []byte {
+		m := hmac.New(h, key)
+		m.Write([]byte(data))
+		return m.Sum(nil)
+	}
+
+	saltedPassword := pbkdf2.Key([]byte(password), salt, iterations, h().Size(), h)
+	clientKey := mac(saltedPassword, "Client Key")
+	storedKey := h()
+	storedKey.Write(clientKey)
+
+	clientFinalWithoutProof := "c=biws,r=" + serverNonce
+	authMessage := clientFirstBare + "," + string(serverFirst) + "," + clientFinalWithoutProof
+
+	proof := mac(storedKey.Sum(nil), authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+
+	serverFinal, err := c.saslAuthenticate([]byte(clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)))
+	if err != nil {
+		return err
+	}
+
+	attrs = scramAttributes(string(serverFinal))
+	if e, ok := attrs["e"]; ok {
+		return fmt.Errorf("SCRAM authentication failed: %s", e)
+	}
+	serverSignature := mac(mac(saltedPassword, "Server Key"), authMessage)
+	if attrs["v"] != base64.StdEncoding.EncodeToString(serverSignature) {
+		return fmt.Errorf("invalid SCRAM server signature")
+	}
+
+	return nil
+}
+
+func scramAttributes(message string) map[string]string {
+	attrs := map[string]string{}
+	for _, part := range strings.Split(message, ",") {
+		if kv := strings.SplitN(part, "=", 2); len(kv) == 2 {
+			attrs[kv[0]] = kv[1]
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/humio/cli/api"
+)
+
+// requestDurationBuckets are the upper bounds in seconds of the buckets of
+// the request latency histogram, the default buckets of Prometheus clients.
+var requestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// ingestMetrics are the metrics of an ingest, served in the Prometheus text
+// format with --metrics-listen, so a long-running ingest, e.g. with --tail or
+// --listen-hec, can be monitored like any other daemon.
+type ingestMetrics struct {
+	// progress holds the counts of events sent. It is set once the ingest
+	// starts.
+	progress *ingestProgress
+
+	mu        sync.Mutex
+	requests  map[requestMetricKey]int64
+	errors    map[string]int64
+	retries   map[string]int64
+	durations map[string]*durationHistogram
+}
+
+// requestMetricKey are the labels of the requests counter.
+type requestMetricKey struct {
+	endpoint string
+	code     int
+}
+
+// durationHistogram is a cumulative histogram of request durations.
+type durationHistogram struct {
+	// counts holds the number of requests in each bucket of
+	// requestDurationBuckets, not including the ones in the smaller buckets.
+	counts []int64
+	count  int64
+	sum    float64
+}
+
+func newIngestMetrics() *ingestMetrics {
+	return &ingestMetrics{
+		requests:  map[requestMetricKey]int64{},
+		errors:    map[string]int64{},
+		retries:   map[string]int64{},
+		durations: map[string]*durationHistogram{},
+	}
+}
+
+// requestEndpoint returns the kind of endpoint a request was made to, used to
+// tell ingest requests apart from the others in the metrics.
+func requestEndpoint(path string) string {
+	switch {
+	case strings.HasSuffix(path, "/ingest") || strings.HasSuffix(path, "/ingest-messages") || strings.Contains(path, "api/v1/ingest/"):
+		return "ingest"
+	case strings.HasSuffix(path, "/graphql"):
+		return "graphql"
+	default:
+		return "other"
+	}
+}
+
+// observe records a request made by the API client. It is passed to
+// api.Client.WithRequestLogger.
+func (m *ingestMetrics) observe(r api.RequestLog) {
+	endpoint := requestEndpoint(r.Path)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r.Err != nil {
+		m.errors[endpoint]++
+	} else {
+		m.requests[requestMetricKey{endpoint: endpoint, code: r.StatusCode}]++
+	}
+	m.retries[endpoint] += int64(r.Retries)
+
+	h, ok := m.durations[endpoint]
+	if !ok {
+		h = &durationHistogram{counts: make([]int64, len(requestDurationBuckets))}
+		m.durations[endpoint] = h
+	}
+	seconds := r.Duration.Seconds()
+	for i, bound := range requestDurationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// write writes the metrics in the Prometheus text format.
+func (m *ingestMetrics) write(w io.Writer) {
+	if p := m.progress; p != nil {
+		writeMetric(w, "humioctl_ingest_events_total", "counter", "Events sent to Humio.", map[string]int64{"": atomic.LoadInt64(&p.events)})
+		writeMetric(w, "humioctl_ingest_bytes_total", "counter", "Bytes of input sent to Humio. With --listen-hec, the size of the requests forwarded.", map[string]int64{"": atomic.LoadInt64(&p.bytes)})
+		writeMetric(w, "humioctl_ingest_failed_events_total", "counter", "Events that could not be sent to Humio.", map[string]int64{"": atomic.LoadInt64(&p.failedEvents)})
+		writeMetric(w, "humioctl_ingest_failed_batches_total", "counter", "Batches of events that could not be sent to Humio.", map[string]int64{"": atomic.LoadInt64(&p.failedBatches)})
+		writeMetric(w, "humioctl_ingest_start_time_seconds", "gauge", "Time the ingest started, in seconds since the Unix epoch.", map[string]int64{"": p.started.Unix()})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requests := map[string]int64{}
+	for key, n := range m.requests {
+		requests[fmt.Sprintf(`endpoint="%s",code="%d"`, key.endpoint, key.code)] = n
+	}
+	writeMetric(w, "humioctl_requests_total", "counter", "HTTP requests made to Humio, by endpoint and status code.", requests)
+	writeMetric(w, "humioctl_request_errors_total", "counter", "HTTP requests to Humio that failed without a response, e.g. because of a timeout.", endpointLabels(m.errors))
+	writeMetric(w, "humioctl_request_retries_total", "counter", "HTTP requests to Humio retried because of rate limiting.", endpointLabels(m.retries))
+
+	fmt.Fprintln(w, "# HELP humioctl_request_duration_seconds Duration of HTTP requests made to Humio, including waiting to retry them.")
+	fmt.Fprintln(w, "# TYPE humioctl_request_duration_seconds histogram")
+	for _, endpoint := range sortedEndpoints(m.durations) {
+		h := m.durations[endpoint]
+		var cumulative int64
+		for i, bound := range requestDurationBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "humioctl_request_duration_seconds_bucket{endpoint=\"%s\",le=\"%s\"} %d\n", endpoint, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(w, "humioctl_request_duration_seconds_bucket{endpoint=\"%s\",le=\"+Inf\"} %d\n", endpoint, h.count)
+		fmt.Fprintf(w, "humioctl_request_duration_seconds_sum{endpoint=\"%s\"} %s\n", endpoint, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "humioctl_request_duration_seconds_count{endpoint=\"%s\"} %d\n", endpoint, h.count)
+	}
+}
+
+// writeMetric writes a metric with its values by labels, where the empty
+// string is a value without labels.
+func writeMetric(w io.Writer, name, kind, help string, values map[string]int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+
+	labels := make([]string, 0, len(values))
+	for l := range values {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+
+	for _, l := range labels {
+		if l == "" {
+			fmt.Fprintf(w, "%s %d\n", name, values[l])
+		} else {
+			fmt.Fprintf(w, "%s{%s} %d\n", name, l, values[l])
+		}
+	}
+}
+
+func endpointLabels(values map[string]int64) map[string]int64 {
+	labeled := make(map[string]int64, len(values))
+	for endpoint, n := range values {
+		labeled[fmt.Sprintf(`endpoint="%s"`, endpoint)] = n
+	}
+	return labeled
+}
+
+func sortedEndpoints(m map[string]*durationHistogram) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// serve serves the metrics on /metrics at addr until ctx is cancelled. It
+// returns an error if it cannot listen on addr, e.g. because the port is in
+// use.
+func (m *ingestMetrics) serve(ctx context.Context, addr string, quiet bool) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.write(w)
+	})
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Serving metrics on http://%s/metrics\n", listener.Addr())
+	}
+	return nil
+}
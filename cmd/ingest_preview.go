@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// previewEvent is an event as it would be sent by ingest, shown by --dry-run.
+type previewEvent struct {
+	// Parser is the parser the event would be parsed by, empty for
+	// structured events, which are not parsed.
+	Parser    string                 `json:"parser,omitempty"`
+	Timestamp interface{}            `json:"@timestamp,omitempty"`
+	Tags      map[string]string      `json:"tags,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+	RawString string                 `json:"@rawstring,omitempty"`
+}
+
+// ingestPreview prints the first events of an ingest instead of sending
+// them, and calls done when enough events have been shown.
+type ingestPreview struct {
+	out   io.Writer
+	limit int
+	done  func()
+
+	mu    sync.Mutex
+	shown int
+}
+
+// send returns a function printing batches of lines as they would be sent
+// with the given fields, either as structured events with mapping, or as
+// lines parsed by parserName with the given tags if mapping is nil.
+func (p *ingestPreview) send(fields, tags map[string]string, mapping *structuredFieldMapping, parserName string) func(batch []string) error {
+	return func(batch []string) error {
+		for _, line := range batch {
+			event := previewEvent{Fields: map[string]interface{}{}}
+			if mapping != nil {
+				structured, tags, ok := mapping.toStructuredEvent(line, fields)
+				if !ok {
+					continue
+				}
+				event.Timestamp = structured.Timestamp
+				event.Tags = tags
+				event.Fields = structured.Attributes
+				event.RawString = structured.RawString
+			} else {
+				event.Parser = parserName
+				event.Tags = tags
+				event.RawString = line
+				for k, v := range fields {
+					event.Fields[k] = v
+				}
+			}
+
+			if !p.show(event) {
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+// show prints the event, unless the limit has been reached. It returns false
+// if no more events are to be shown.
+func (p *ingestPreview) show(event previewEvent) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.shown >= p.limit {
+		return false
+	}
+
+	data, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return false
+	}
+	fmt.Fprintln(p.out, string(data))
+
+	p.shown++
+	if p.shown == p.limit {
+		p.done()
+		return false
+	}
+	return true
+}
+
+// summary prints the number of events shown to stderr, unless quiet.
+func (p *ingestPreview) summary(repo string, quiet bool) {
+	if quiet {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintf(os.Stderr, "Dry run: showed %d events, nothing was sent to %s.\n", p.shown, repo)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/humio/cli/api"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// ingestProgressWidth is the number of characters in the bar itself.
+const ingestProgressWidth = 24
+
+// ingestProgress counts the events sent by an ingest, shows them as a
+// progress bar while ingesting and prints a summary at the end.
+type ingestProgress struct {
+	// The counters are first to be 64-bit aligned for atomic access.
+	bytes, events, failedBatches, failedEvents int64
+
+	client *api.Client
+	out    io.Writer
+	// total is the size of the input in bytes, or 0 if it is not known.
+	total int64
+	// show is true if the progress bar is drawn.
+	show bool
+	// quiet is true if neither the progress bar nor the summary is printed.
+	quiet bool
+
+	started  time.Time
+	retries  int64
+	reporter *progressReporter
+}
+
+// newIngestProgress returns the progress of an ingest. The progress bar is
+// only drawn if stderr is a terminal.
+func newIngestProgress(client *api.Client, total int64, quiet bool) *ingestProgress {
+	return &ingestProgress{
+		client:  client,
+		out:     os.Stderr,
+		total:   total,
+		show:    !quiet && terminal.IsTerminal(int(os.Stderr.Fd())),
+		quiet:   quiet,
+		started: time.Now(),
+		retries: client.Retries(),
+	}
+}
+
+// stdinSize returns the size of stdin if it is redirected from a file, so
+// the progress bar can show how much is left, or 0 otherwise.
+func stdinSize() int64 {
+	info, err := os.Stdin.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return info.Size()
+}
+
+// count wraps send to count the events of the batches it sends.
+func (p *ingestProgress) count(send func(batch []string) error) func(batch []string) error {
+	return func(batch []string) error {
+		var size int64
+		for _, line := range batch {
+			// Include the newline ending the line in the input.
+			size += int64(len(line)) + 1
+		}
+
+		err := send(batch)
+		p.record(int64(len(batch)), size, err)
+		return err
+	}
+}
+
+// record counts a batch of events of the given size in bytes, as sent or,
+// if err is not nil, as failed.
+func (p *ingestProgress) record(events, bytes int64, err error) {
+	if err != nil {
+		atomic.AddInt64(&p.failedBatches, 1)
+		atomic.AddInt64(&p.failedEvents, events)
+		return
+	}
+	atomic.AddInt64(&p.bytes, bytes)
+	atomic.AddInt64(&p.events, events)
+}
+
+// start draws the progress bar in the background until stop is called.
+func (p *ingestProgress) start() {
+	if p.show {
+		p.reporter = startProgressReporter(p.out, p.line)
+	}
+}
+
+// stop removes the progress bar and prints the summary.
+func (p *ingestProgress) stop() {
+	if p.reporter != nil {
+		p.reporter.stop(false)
+		p.reporter = nil
+	}
+
+	if !p.quiet {
+		fmt.Fprintln(p.out, p.summary(time.Now()))
+	}
+}
+
+// line returns the progress bar, e.g.
+//
+//	[=========>              ]  40%  12 MB / 30 MB  104233 events  8512 events/s  ETA 21s
+func (p *ingestProgress) line(now time.Time) string {
+	bytes := atomic.LoadInt64(&p.bytes)
+	events := atomic.LoadInt64(&p.events)
+	elapsed := now.Sub(p.started)
+	rate := perSecond(events, elapsed)
+
+	var parts []string
+	if p.total > 0 {
+		fraction := float64(bytes) / float64(p.total)
+		if fraction > 1 {
+			fraction = 1
+		}
+
+		filled := int(fraction * ingestProgressWidth)
+		bar := strings.Repeat("=", filled)
+		if filled < ingestProgressWidth {
+			bar += ">" + strings.Repeat(" ", ingestProgressWidth-filled-1)
+		}
+
+		parts = append(parts,
+			fmt.Sprintf("[%s] %3.0f%%", bar, fraction*100),
+			fmt.Sprintf("%s / %s", ByteCountDecimal(bytes), ByteCountDecimal(p.total)))
+	} else {
+		parts = append(parts, ByteCountDecimal(bytes))
+	}
+
+	parts = append(parts,
+		fmt.Sprintf("%d events", events),
+		fmt.Sprintf("%.0f events/s", rate))
+
+	if p.total > 0 && bytes > 0 && bytes < p.total {
+		bytesPerSecond := float64(bytes) / elapsed.Seconds()
+		eta := time.Duration(float64(p.total-bytes) / bytesPerSecond * float64(time.Second))
+		parts = append(parts, "ETA "+eta.Round(time.Second).String())
+	} else {
+		parts = append(parts, elapsed.Round(time.Second).String())
+	}
+
+	if failed := atomic.LoadInt64(&p.failedBatches); failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", atomic.LoadInt64(&p.failedEvents)))
+	}
+
+	return strings.Join(parts, "  ")
+}
+
+// summary returns a description of what was sent, including the failed
+// batches and the requests retried because of rate limiting.
+func (p *ingestProgress) summary(now time.Time) string {
+	events := atomic.LoadInt64(&p.events)
+	elapsed := now.Sub(p.started)
+
+	summary := fmt.Sprintf("Sent %d events (%s) in %s, %.0f events/s.",
+		events,
+		ByteCountDecimal(atomic.LoadInt64(&p.bytes)),
+		elapsed.Round(time.Millisecond),
+		perSecond(events, elapsed))
+
+	if failed := atomic.LoadInt64(&p.failedBatches); failed > 0 {
+		summary += fmt.Sprintf(" %d events in %d batches could not be sent.", atomic.LoadInt64(&p.failedEvents), failed)
+	}
+	if retries := p.client.Retries() - p.retries; retries > 0 {
+		summary += fmt.Sprintf(" %d requests were retried because of rate limiting.", retries)
+	}
+
+	return summary
+}
+
+func perSecond(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
@@ -0,0 +1,440 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/humio/cli/prompt"
+)
+
+type s3IngestOptions struct {
+	url         string
+	region      string
+	endpoint    string
+	concurrency int
+}
+
+// s3Client is a minimal S3 client supporting listing and downloading objects,
+// authenticated with AWS Signature Version 4 using the standard AWS_*
+// environment variables. Without credentials requests are sent anonymously.
+type s3Client struct {
+	region       string
+	endpoint     string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	httpClient   *http.Client
+}
+
+type s3Object struct {
+	Key  string `xml:"Key"`
+	Size int64  `xml:"Size"`
+}
+
+func newS3Client(region, endpoint string) *s3Client {
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &s3Client{
+		region:       region,
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		httpClient:   &http.Client{},
+	}
+}
+
+// parseS3URL splits s3://bucket/key into the bucket and the key or prefix.
+func parseS3URL(s string) (string, string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("expected a URL of the form s3://<bucket>/<key or prefix>, got %q", s)
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+func (c *s3Client) objectURL(bucket, key string, query url.Values) string {
+	var base, path string
+	if c.endpoint != "" {
+		// Custom endpoints, e.g. MinIO, generally only support path-style requests.
+		base = c.endpoint
+		path = "/" + bucket + "/" + key
+	} else {
+		base = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, c.region)
+		path = "/" + key
+	}
+
+	u := base + s3URIEncode(path, false)
+	if len(query) > 0 {
+		u += "?" + s3CanonicalQuery(query)
+	}
+	return u
+}
+
+func (c *s3Client) get(ctx context.Context, bucket, key string, query url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.objectURL(bucket, key, query), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.accessKey != "" {
+		c.sign(req, time.Now().UTC())
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("S3 responded with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return resp, nil
+}
+
+// listObjects returns all objects in bucket whose key starts with prefix.
+func (c *s3Client) listObjects(ctx context.Context, bucket, prefix string) ([]s3Object, error) {
+	var objects []s3Object
+	var continuationToken string
+
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		resp, err := c.get(ctx, bucket, "", query)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Contents              []s3Object `xml:"Contents"`
+			IsTruncated           bool       `xml:"IsTruncated"`
+			NextContinuationToken string     `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse object list: %v", err)
+		}
+
+		for _, o := range result.Contents {
+			if !strings.HasSuffix(o.Key, "/") {
+				objects = append(objects, o)
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req.
+func (c *s3Client) sign(req *http.Request, now time.Time) {
+	const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", emptyPayloadHash)
+	if c.sessionToken != "" {
+		req.Header.Set("x-amz-security-token", c.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + c.secretKey)
+	for _, part := range []string{date, c.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", c.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3URIEncode encodes s as required by Signature Version 4, leaving
+// slashes unencoded unless encodeSlash is set.
+func s3URIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3URIEncode(k, true)+"="+s3URIEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// ingestFromS3 sends every line of the objects at the S3 URL using send.
+// A URL ending in a slash is a prefix and all objects under it are ingested.
+// Objects are decompressed if they are gzipped. If ctx is cancelled, no more
+// lines are read, but the lines read so far are sent before returning.
+func ingestFromS3(ctx context.Context, opts s3IngestOptions, quiet bool, send func(batch []string) error) error {
+	bucket, key, err := parseS3URL(opts.url)
+	if err != nil {
+		return err
+	}
+
+	client := newS3Client(opts.region, opts.endpoint)
+
+	var objects []s3Object
+	if key == "" || strings.HasSuffix(key, "/") {
+		objects, err = client.listObjects(ctx, bucket, key)
+		if err != nil {
+			return fmt.Errorf("error listing objects: %v", err)
+		}
+	} else {
+		objects = []s3Object{{Key: key}}
+	}
+
+	if len(objects) == 0 {
+		return fmt.Errorf("no objects found at %s", opts.url)
+	}
+
+	lines := make(chan string, batchLimit)
+	sendErrs := make(chan error, 1)
+	go func() {
+		sendErrs <- sendLines(lines, send)
+	}()
+
+	var progress *s3Progress
+	if !quiet {
+		progress = newS3Progress(len(objects))
+	}
+
+	concurrency := opts.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	var readErr error
+	var readErrOnce sync.Once
+	work := make(chan s3Object)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for o := range work {
+				if err := readS3Object(ctx, client, bucket, o.Key, lines, progress); err != nil {
+					readErrOnce.Do(func() { readErr = fmt.Errorf("error reading %s: %v", o.Key, err) })
+				}
+				if progress != nil {
+					progress.objectDone()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, o := range objects {
+		select {
+		case work <- o:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(work)
+	wg.Wait()
+	close(lines)
+
+	sendErr := <-sendErrs
+	if progress != nil {
+		progress.finish()
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return sendErr
+}
+
+func readS3Object(ctx context.Context, client *s3Client, bucket, key string, lines chan<- string, progress *s3Progress) error {
+	resp, err := client.get(ctx, bucket, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var r io.Reader = resp.Body
+	if progress != nil {
+		r = io.TeeReader(r, progress)
+	}
+
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// sendLines sends lines in batches until the channel is closed, and returns
+// the first error encountered.
+func sendLines(lines <-chan string, send func(batch []string) error) error {
+	var firstErr error
+	batch := make([]string, 0, batchLimit)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := send(batch); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		batch = batch[:0]
+	}
+
+	for line := range lines {
+		batch = append(batch, line)
+		if len(batch) >= batchLimit {
+			flush()
+		}
+	}
+	flush()
+
+	return firstErr
+}
+
+type s3Progress struct {
+	total    int
+	objects  int64
+	bytes    int64
+	reporter *progressReporter
+}
+
+func newS3Progress(total int) *s3Progress {
+	p := &s3Progress{total: total}
+	p.reporter = startProgressReporter(os.Stderr, p.line)
+	return p
+}
+
+func (p *s3Progress) Write(b []byte) (int, error) {
+	atomic.AddInt64(&p.bytes, int64(len(b)))
+	return len(b), nil
+}
+
+func (p *s3Progress) objectDone() {
+	atomic.AddInt64(&p.objects, 1)
+}
+
+func (p *s3Progress) line(time.Time) string {
+	v, suffix := prompt.AddSISuffix(float64(atomic.LoadInt64(&p.bytes)), true)
+	return fmt.Sprintf("  Ingested %d/%d objects (%.1f %sB)", atomic.LoadInt64(&p.objects), p.total, v, suffix)
+}
+
+func (p *s3Progress) finish() {
+	p.reporter.stop(true)
+}
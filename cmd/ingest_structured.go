@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+)
+
+type structuredEvent struct {
+	Timestamp  interface{}            `json:"timestamp"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	RawString  string                 `json:"rawstring,omitempty"`
+}
+
+type structuredEventList struct {
+	Tags   map[string]string `json:"tags,omitempty"`
+	Events []structuredEvent `json:"events"`
+}
+
+type structuredFieldMapping struct {
+	format         inputFormat
+	timestampField string
+	tagFields      []string
+	// tags are added to all events.
+	tags       map[string]string
+	timestamps *timestampExtractor
+}
+
+// toStructuredEvent parses line in the input format and returns the event and its tags.
+// If the line is not in the format it is sent as a plain @rawstring. It returns
+// false if the line is not an event, e.g. the header row of a CSV file.
+func (m structuredFieldMapping) toStructuredEvent(line string, fields map[string]string) (structuredEvent, map[string]string, bool) {
+	event := structuredEvent{
+		Attributes: map[string]interface{}{},
+	}
+
+	timestamp, attributes, ok := m.format.parse(line)
+	if ok && attributes == nil {
+		return event, nil, false
+	}
+	if !ok {
+		event.RawString = line
+		attributes = map[string]interface{}{}
+	} else if m.format.keepRawString {
+		event.RawString = line
+	}
+	event.Timestamp = timestamp
+
+	tagFields := m.tagFields
+	if len(tagFields) == 0 && len(m.tags) == 0 {
+		tagFields = m.format.tagFields
+	}
+
+	tags := map[string]string{}
+	for _, f := range tagFields {
+		if v, ok := attributes[f]; ok {
+			tags[f] = fmt.Sprint(v)
+			delete(attributes, f)
+		}
+	}
+	for k, v := range m.tags {
+		tags[k] = v
+	}
+
+	if m.timestampField != "" {
+		if v, ok := attributes[m.timestampField]; ok {
+			event.Timestamp = v
+			delete(attributes, m.timestampField)
+		}
+	}
+
+	if m.timestamps != nil {
+		// Timestamps in fields may be in a layout Humio does not understand.
+		if s, ok := event.Timestamp.(string); ok {
+			if t, ok := m.timestamps.extract(s); ok {
+				event.Timestamp = t.Format(time.RFC3339Nano)
+			}
+		}
+		if event.Timestamp == nil {
+			event.Timestamp = m.timestamps.timestamp(line)
+		}
+	}
+
+	if event.Timestamp == nil {
+		event.Timestamp = time.Now().Format(time.RFC3339Nano)
+	}
+
+	for k, v := range attributes {
+		event.Attributes[k] = v
+	}
+
+	for k, v := range fields {
+		event.Attributes[k] = v
+	}
+
+	return event, tags, true
+}
+
+func tagsKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func sendStructuredBatch(client *api.Client, repo string, lines []string, fields map[string]string, mapping structuredFieldMapping, compression *ingestCompression) error {
+	// Events can only share tags within the same event list, so group them by their tags.
+	var lists []*structuredEventList
+	listsByTags := map[string]*structuredEventList{}
+
+	for _, line := range lines {
+		event, tags, ok := mapping.toStructuredEvent(line, fields)
+		if !ok {
+			continue
+		}
+
+		key := tagsKey(tags)
+		list, ok := listsByTags[key]
+		if !ok {
+			list = &structuredEventList{Tags: tags}
+			listsByTags[key] = list
+			lists = append(lists, list)
+		}
+		list.Events = append(list.Events, event)
+	}
+
+	if len(lists) == 0 {
+		return nil
+	}
+
+	eventsJSON, err := json.Marshal(lists)
+
+	if err != nil {
+		return err
+	}
+
+	return postIdempotentIngestRequest(client, "api/v1/repositories/"+repo+"/ingest", eventsJSON, compression)
+}
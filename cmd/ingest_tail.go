@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tailGlobInterval is how often glob patterns given to --tail are checked
+// for new files.
+const tailGlobInterval = 2 * time.Second
+
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// tailFiles tails the files at the paths until ctx is cancelled, each with
+// its own sender. Paths can be glob patterns, which are checked for new
+// files every tailGlobInterval, so files created while tailing are tailed
+// as well. It returns an error, after stopping tailing all files, if a path
+// that is not a pattern cannot be tailed.
+func tailFiles(ctx context.Context, paths []string, quiet bool, newSender func(file string) *ingestSender, checkpoint *ingestCheckpoint) error {
+	for _, path := range paths {
+		if _, err := filepath.Match(path, ""); isGlobPattern(path) && err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", path, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed error
+	tailing := map[string]bool{}
+
+	start := func(file string, mustSucceed bool) {
+		file = filepath.Clean(file)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if tailing[file] {
+			return
+		}
+		tailing[file] = true
+
+		log.Printf("Tailing %s", file)
+		sender := newSender(file)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := tailFile(ctx, sender, file, quiet, checkpoint)
+			sender.stop()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if mustSucceed {
+					if failed == nil {
+						failed = fmt.Errorf("error tailing %s: %v", file, err)
+					}
+					cancel()
+					return
+				}
+				log.Printf("Stopped tailing %s: %v", file, err)
+			}
+
+			// Tail the file again if it is recreated.
+			if _, err := os.Stat(file); os.IsNotExist(err) {
+				delete(tailing, file)
+			}
+		}()
+	}
+
+	var patterns []string
+	for _, path := range paths {
+		if isGlobPattern(path) {
+			patterns = append(patterns, path)
+		} else {
+			// Like tail -f, wait for files that do not exist yet.
+			start(path, true)
+		}
+	}
+
+	for {
+		for _, pattern := range patterns {
+			// The patterns have been checked, so they cannot be invalid.
+			matches, _ := filepath.Glob(pattern)
+			for _, file := range matches {
+				start(file, false)
+			}
+		}
+
+		if sleepUnlessDone(ctx, tailGlobInterval) != nil {
+			break
+		}
+	}
+
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return failed
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timestampExtractor finds the timestamp in a line, either using a
+// strptime-style layout or by looking for timestamps in common formats.
+type timestampExtractor struct {
+	patterns []timestampPattern
+	location *time.Location
+	now      func() time.Time
+
+	warnOnce sync.Once
+}
+
+type timestampPattern struct {
+	re    *regexp.Regexp
+	parse func(s string, location *time.Location) (time.Time, error)
+}
+
+// autoTimestampPatterns are the formats recognized without a layout. When
+// a line contains more than one timestamp, the first one is used.
+var autoTimestampPatterns = []timestampPattern{
+	// 2020-01-02T15:04:05.123Z, 2020-01-02 15:04:05,123 +01:00 etc.
+	{
+		re:    regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d{1,9})?(?: ?(?:Z|[+-]\d{2}:?\d{2}))?`),
+		parse: parseISO8601Timestamp,
+	},
+	// 02/Jan/2006:15:04:05 -0700 as used in access logs.
+	{
+		re:    regexp.MustCompile(`\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`),
+		parse: layoutParser("02/Jan/2006:15:04:05 -0700"),
+	},
+	// Mon, 02 Jan 2006 15:04:05 MST or -0700.
+	{
+		re:    regexp.MustCompile(`[A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} [A-Z]{3}`),
+		parse: layoutParser(time.RFC1123),
+	},
+	{
+		re:    regexp.MustCompile(`[A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} [+-]\d{4}`),
+		parse: layoutParser(time.RFC1123Z),
+	},
+	// Jan  2 15:04:05 as used in syslog, without a year.
+	{
+		re:    regexp.MustCompile(`[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}(?:\.\d{1,9})?`),
+		parse: layoutParser(time.Stamp),
+	},
+	// Seconds or milliseconds since the epoch, only at the start of a line.
+	{
+		re:    regexp.MustCompile(`^\d{10}(?:\.\d{1,9})?\b|^\d{13}\b`),
+		parse: parseEpochTimestamp,
+	},
+}
+
+// strptimeDirectives maps strptime directives to Go layouts and the
+// patterns they match.
+var strptimeDirectives = map[byte][2]string{
+	'Y': {"2006", `\d{4}`},
+	'y': {"06", `\d{2}`},
+	'm': {"01", `\d{2}`},
+	'd': {"02", `\d{2}`},
+	'e': {"_2", `[ \d]?\d`},
+	'H': {"15", `\d{2}`},
+	'I': {"03", `\d{2}`},
+	'M': {"04", `\d{2}`},
+	'S': {"05", `\d{2}`},
+	'f': {"999999999", `\d{1,9}`},
+	'p': {"PM", `[AP]M`},
+	'b': {"Jan", `[A-Z][a-z]{2}`},
+	'h': {"Jan", `[A-Z][a-z]{2}`},
+	'B': {"January", `[A-Z][a-z]+`},
+	'a': {"Mon", `[A-Z][a-z]{2}`},
+	'A': {"Monday", `[A-Z][a-z]+`},
+	'z': {"Z0700", `(?:Z|[+-]\d{4})`},
+	'Z': {"MST", `[A-Z]{2,5}`},
+	'T': {"15:04:05", `\d{2}:\d{2}:\d{2}`},
+	'F': {"2006-01-02", `\d{4}-\d{2}-\d{2}`},
+	'%': {"%", `%`},
+}
+
+// newTimestampExtractor returns an extractor for the strptime-style layout,
+// e.g. "%Y-%m-%d %H:%M:%S", or one that recognizes common formats if layout
+// is empty. Timestamps without a time zone are in location.
+func newTimestampExtractor(layout string, location *time.Location) (*timestampExtractor, error) {
+	e := &timestampExtractor{
+		patterns: autoTimestampPatterns,
+		location: location,
+		now:      time.Now,
+	}
+
+	if layout == "%s" {
+		e.patterns = []timestampPattern{{re: regexp.MustCompile(`\d{10}(?:\.\d{1,9})?`), parse: parseEpochTimestamp}}
+	} else if layout != "" {
+		pattern, err := strptimePattern(layout)
+		if err != nil {
+			return nil, err
+		}
+		e.patterns = []timestampPattern{pattern}
+	}
+
+	return e, nil
+}
+
+func strptimePattern(layout string) (timestampPattern, error) {
+	var goLayout, pattern strings.Builder
+
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' {
+			goLayout.WriteByte(layout[i])
+			pattern.WriteString(regexp.QuoteMeta(layout[i : i+1]))
+			continue
+		}
+
+		i++
+		if i == len(layout) {
+			return timestampPattern{}, fmt.Errorf("timestamp layout %q ends with a single %%", layout)
+		}
+		directive, ok := strptimeDirectives[layout[i]]
+		if !ok {
+			return timestampPattern{}, fmt.Errorf("unsupported directive %%%c in timestamp layout %q", layout[i], layout)
+		}
+		goLayout.WriteString(directive[0])
+		pattern.WriteString(directive[1])
+	}
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return timestampPattern{}, err
+	}
+
+	return timestampPattern{re: re, parse: layoutParser(goLayout.String())}, nil
+}
+
+// extract returns the first timestamp found in the line.
+func (e *timestampExtractor) extract(line string) (time.Time, bool) {
+	var found string
+	var parse func(string, *time.Location) (time.Time, error)
+	start := -1
+
+	for _, p := range e.patterns {
+		loc := p.re.FindStringIndex(line)
+		if loc != nil && (start == -1 || loc[0] < start) {
+			start = loc[0]
+			found = line[loc[0]:loc[1]]
+			parse = p.parse
+		}
+	}
+	if start == -1 {
+		return time.Time{}, false
+	}
+
+	t, err := parse(found, e.location)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// Formats without a year, e.g. syslog, are assumed to be within the last year.
+	if t.Year() == 0 {
+		now := e.now().In(e.location)
+		t = t.AddDate(now.Year(), 0, 0)
+		if t.After(now.Add(24 * time.Hour)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+	}
+
+	return t, true
+}
+
+// timestamp returns the timestamp of the line to send as @timestamp, or nil
+// if none was found, in which case Humio uses the time the event arrives.
+func (e *timestampExtractor) timestamp(line string) interface{} {
+	t, ok := e.extract(line)
+	if !ok {
+		e.warnOnce.Do(func() {
+			log.Printf("No timestamp found in a line, using the current time for lines without a timestamp: %s", line)
+		})
+		return nil
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func layoutParser(layout string) func(string, *time.Location) (time.Time, error) {
+	return func(s string, location *time.Location) (time.Time, error) {
+		return time.ParseInLocation(layout, s, location)
+	}
+}
+
+func parseISO8601Timestamp(s string, location *time.Location) (time.Time, error) {
+	s = strings.Replace(s[:10]+"T"+s[11:], ",", ".", 1)
+	s = strings.Replace(s, " ", "", 1)
+
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999Z07:00", "2006-01-02T15:04:05.999999999Z0700", "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.ParseInLocation(layout, s, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+func parseEpochTimestamp(s string, _ *time.Location) (time.Time, error) {
+	if len(s) == 13 && !strings.Contains(s, ".") {
+		ms, err := strconv.ParseInt(s, 10, 64)
+		return time.Unix(0, ms*int64(time.Millisecond)), err
+	}
+
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), nil
+}
@@ -33,6 +33,7 @@ without having to change anything on sender/client.`,
 	cmd.AddCommand(newIngestTokensRemoveCmd())
 	cmd.AddCommand(newIngestTokensListCmd())
 	cmd.AddCommand(newIngestTokensShowCmd())
+	cmd.AddCommand(newIngestTokensRotateCmd())
 
 	return cmd
 }
@@ -16,7 +16,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
@@ -35,10 +34,7 @@ func newIngestTokensListCmd() *cobra.Command {
 
 			tokens, err := client.IngestTokens().List(repo)
 
-			if err != nil {
-				cmd.Println(fmt.Errorf("Error fetching token list: %s", err))
-				os.Exit(1)
-			}
+			exitOnError(cmd, err, "Error fetching token list")
 
 			var output []string
 			output = append(output, "Name | Token | Assigned Parser")
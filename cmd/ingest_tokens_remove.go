@@ -15,36 +15,38 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 )
 
 func newIngestTokensRemoveCmd() *cobra.Command {
+	var confirm confirmFlags
+
 	cmd := &cobra.Command{
-		Use:       "remove [flags] <repo> <token-name>",
-		Short:     "Removes an ingest token.",
-		Long:      `Removes the ingest token with name '<token-name>' from the repository with name '<repo>'.`,
-		ValidArgs: []string{"repo", "token-name"},
-		Args:      cobra.ExactArgs(2),
+		Use:   "remove [flags] <repo> <token-name>",
+		Short: "Removes an ingest token.",
+		Long: `Removes the ingest token with name '<token-name>' from the repository with name '<repo>'.
+
+You are asked to type the name of the token to confirm. When not running
+interactively, use --yes or --confirm-name=<token-name> instead.`,
+		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
 			repo := args[0]
 			name := args[1]
 
+			exitOnError(cmd, confirm.confirm(cmd, "ingest token", name), "")
+
 			// Get the HTTP client
 			client := NewApiClient(cmd)
 
 			err := client.IngestTokens().Remove(repo, name)
 
-			if err != nil {
-				cmd.Println(fmt.Errorf("Error removing ingest token: %s", err))
-				os.Exit(1)
-			}
+			exitOnError(cmd, err, "Error removing ingest token")
 
 			cmd.Println("User Removed")
 		},
 	}
 
+	confirm.register(cmd, "ingest token")
+
 	return cmd
 }
@@ -0,0 +1,103 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newIngestTokensRotateCmd() *cobra.Command {
+	var outputFile, newName string
+
+	cmd := &cobra.Command{
+		Use:   "rotate [flags] <repo> <token-name>",
+		Short: "Replace an ingest token with a new one.",
+		Long: `Replaces the ingest token with name <token name> in repository <repo> with a
+new token named --new-name, and prints the new token. The assigned parser of
+the token is kept.
+
+Humio cannot regenerate or rename a token, so the new token is added before
+the old one is removed. If the new token cannot be added, the old one is
+kept. Data sent with the old token is rejected once it has been removed.
+
+Use --output-file to write the new token to a file readable only by you,
+instead of printing it.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo := args[0]
+			name := args[1]
+
+			// Get the HTTP client
+			client := NewApiClient(cmd)
+
+			token, rotateErr := client.IngestTokens().Rotate(repo, name, newName)
+
+			if token == nil {
+				return fmt.Errorf("Error rotating ingest token: %s", rotateErr)
+			}
+
+			// The new token is output even if the old one could not be
+			// removed, as its secret cannot be fetched by name later.
+			if outputFile != "" {
+				if err := writeTokenFile(outputFile, token.Token); err != nil {
+					return fmt.Errorf("Error writing token file: %s", err)
+				}
+				cmd.Println(fmt.Sprintf("New token for %s written to %s", token.Name, outputFile))
+			} else {
+				var output []string
+				output = append(output, "Name | Token | Assigned Parser")
+				output = append(output, fmt.Sprintf("%v | %v | %v", token.Name, token.Token, valueOrEmpty(token.AssignedParser)))
+
+				printTable(cmd, output)
+			}
+
+			if rotateErr != nil {
+				return fmt.Errorf("Error rotating ingest token: %s", rotateErr)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&newName, "new-name", "", "The name of the new token. It must differ from the name of the old token.")
+	cmd.Flags().StringVarP(&outputFile, "output-file", "o", "", "Write the new token to this file with permissions 0600 instead of printing it.")
+	cmd.MarkFlagRequired("new-name")
+
+	return cmd
+}
+
+// writeTokenFile writes a token to a file readable only by the owner. The
+// permissions of an existing file are tightened before the token is written.
+func writeTokenFile(path string, token string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+
+	if _, err := f.WriteString(token); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,45 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newIPFiltersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ip-filters",
+		Short: "Manage IP filters restricting where tokens can be used from [Root Only]",
+		Long: `IP filters restrict the addresses API tokens can be used from, see
+'humioctl tokens create --ip-filter'. A filter is a list of rules, which are
+checked in order, e.g.
+
+  $ humioctl ip-filters create office --rule "allow 192.168.1.0/24" --rule "deny all"`,
+	}
+
+	cmd.AddCommand(newIPFiltersListCmd())
+	cmd.AddCommand(newIPFiltersCreateCmd())
+	cmd.AddCommand(newIPFiltersUpdateCmd())
+	cmd.AddCommand(newIPFiltersDeleteCmd())
+
+	return cmd
+}
+
+// ipFilterRules joins the rules given with --rule, one per line.
+func ipFilterRules(rules []string) string {
+	return strings.Join(rules, "\n")
+}
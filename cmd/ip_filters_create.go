@@ -0,0 +1,50 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newIPFiltersCreateCmd() *cobra.Command {
+	var rules []string
+
+	cmd := &cobra.Command{
+		Use:   "create [flags] <name>",
+		Short: "Create an IP filter. [Root Only]",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(rules) == 0 {
+				return fmt.Errorf("at least one --rule is required")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+
+			client := NewApiClient(cmd)
+
+			filter, err := client.IPFilters().Create(name, ipFilterRules(rules))
+			exitOnError(cmd, err, "error creating IP filter")
+
+			cmd.Println(fmt.Sprintf("Created IP filter %s with ID %s", filter.Name, filter.ID))
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&rules, "rule", nil, "A rule of the filter, e.g. \"allow 10.0.0.0/8\" or \"deny all\". Specify multiple times for multiple rules, which are checked in order.")
+
+	return cmd
+}
@@ -0,0 +1,42 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newIPFiltersDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete [flags] <name-or-id>",
+		Short: "Delete an IP filter. [Root Only]",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			filter, err := client.IPFilters().Get(args[0])
+			exitOnError(cmd, err, "error fetching IP filter")
+
+			err = client.IPFilters().Delete(filter.ID)
+			exitOnError(cmd, err, "error deleting IP filter")
+
+			cmd.Println(fmt.Sprintf("Deleted IP filter %s", filter.Name))
+		},
+	}
+
+	return cmd
+}
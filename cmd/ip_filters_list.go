@@ -0,0 +1,45 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newIPFiltersListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list [flags]",
+		Short: "List IP filters. [Root Only]",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			filters, err := client.IPFilters().List()
+			exitOnError(cmd, err, "error fetching IP filters")
+
+			rows := []string{"Name | ID | Rules"}
+			for _, f := range filters {
+				rules := strings.Join(strings.Fields(strings.Replace(f.Filter, "\n", "; ", -1)), " ")
+				rows = append(rows, fmt.Sprintf("%s | %s | %s", f.Name, f.ID, rules))
+			}
+			printTable(cmd, rows)
+		},
+	}
+
+	return cmd
+}
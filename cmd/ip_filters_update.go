@@ -0,0 +1,62 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newIPFiltersUpdateCmd() *cobra.Command {
+	var name string
+	var rules []string
+
+	cmd := &cobra.Command{
+		Use:   "update [flags] <name-or-id>",
+		Short: "Rename an IP filter or replace its rules. [Root Only]",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if name == "" && len(rules) == 0 {
+				return fmt.Errorf("at least one of --name and --rule must be given")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			filter, err := client.IPFilters().Get(args[0])
+			exitOnError(cmd, err, "error fetching IP filter")
+
+			var newName, newRules *string
+			if name != "" {
+				newName = &name
+			}
+			if len(rules) > 0 {
+				r := ipFilterRules(rules)
+				newRules = &r
+			}
+
+			filter, err = client.IPFilters().Update(filter.ID, newName, newRules)
+			exitOnError(cmd, err, "error updating IP filter")
+
+			cmd.Println(fmt.Sprintf("Updated IP filter %s", filter.Name))
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "The new name of the filter.")
+	cmd.Flags().StringArrayVar(&rules, "rule", nil, "A rule replacing the rules of the filter. Specify multiple times for multiple rules.")
+
+	return cmd
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	keychainService = "humioctl"
+	// Tokens stored in the keychain are referenced in the config file by this prefix and the profile name.
+	keychainTokenPrefix = "keychain:"
+)
+
+var errKeychainUnavailable = errors.New("no keychain is available on this system")
+
+// noKeychain disables storing tokens in the OS keychain.
+var noKeychain bool
+
+// storeToken stores the token of a profile in the OS keychain and returns
+// the reference to save in the config file instead of the token. If the
+// keychain is disabled or not available, the token itself is returned.
+func storeToken(profileName string, token string) string {
+	if noKeychain || token == "" || strings.HasPrefix(token, keychainTokenPrefix) {
+		return token
+	}
+
+	if err := keychainSet(profileName, token); err != nil {
+		if err != errKeychainUnavailable {
+			fmt.Fprintf(os.Stderr, "Could not store the token in the keychain, saving it in the config file instead: %s\n", err)
+		}
+		return token
+	}
+
+	return keychainTokenPrefix + profileName
+}
+
+// resolveToken returns the token referenced by a value from the config file.
+// Values that are not keychain references are returned unchanged.
+func resolveToken(value string) (string, error) {
+	if !strings.HasPrefix(value, keychainTokenPrefix) {
+		return value, nil
+	}
+
+	token, err := keychainGet(strings.TrimPrefix(value, keychainTokenPrefix))
+	if err != nil {
+		return "", fmt.Errorf("could not read the token from the keychain: %s", err)
+	}
+
+	return token, nil
+}
+
+// removeStoredToken deletes the token from the keychain if value is a
+// keychain reference.
+func removeStoredToken(value string) error {
+	if !strings.HasPrefix(value, keychainTokenPrefix) {
+		return nil
+	}
+
+	return keychainDelete(strings.TrimPrefix(value, keychainTokenPrefix))
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The macOS Keychain is accessed with the security command line tool.
+
+// keychainSet stores the secret with security in interactive mode, which
+// reads the command from stdin, so the secret is not passed as an argument
+// where any user could see it, e.g. with ps.
+func keychainSet(account string, secret string) error {
+	command := strings.Join([]string{
+		"add-generic-password", "-U",
+		"-s", securityQuote(keychainService),
+		"-a", securityQuote(account),
+		"-w", securityQuote(secret),
+	}, " ")
+
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command + "\n")
+	out, err := cmd.CombinedOutput()
+	// In interactive mode security exits successfully when a command fails,
+	// and prints the error after its prompt.
+	msg := strings.TrimSpace(strings.Replace(string(out), "security>", "", -1))
+	if err != nil || msg != "" {
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("security add-generic-password: %s", msg)
+	}
+	return nil
+}
+
+// securityQuote quotes an argument of a command read by security in
+// interactive mode.
+func securityQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
+
+func keychainGet(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w").Output()
+	if err != nil {
+		return "", fmt.Errorf("security find-generic-password: %s", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func keychainDelete(account string) error {
+	return runSecurity("delete-generic-password", "-s", keychainService, "-a", account)
+}
+
+func runSecurity(args ...string) error {
+	if out, err := exec.Command("security", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("security %s: %s", args[0], strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The Secret Service (GNOME Keyring, KWallet) is accessed with the
+// secret-tool command line tool from libsecret.
+
+func keychainSet(account string, secret string) error {
+	cmd, err := secretTool("store", "--label", keychainService+": "+account, "service", keychainService, "account", account)
+	if err != nil {
+		return err
+	}
+
+	cmd.Stdin = strings.NewReader(secret)
+	return runSecretTool(cmd)
+}
+
+func keychainGet(account string) (string, error) {
+	cmd, err := secretTool("lookup", "service", keychainService, "account", account)
+	if err != nil {
+		return "", err
+	}
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := runSecretTool(cmd); err != nil {
+		return "", err
+	}
+
+	if stdout.Len() == 0 {
+		return "", fmt.Errorf("no token found for %s", account)
+	}
+
+	return strings.TrimSuffix(stdout.String(), "\n"), nil
+}
+
+func keychainDelete(account string) error {
+	cmd, err := secretTool("clear", "service", keychainService, "account", account)
+	if err != nil {
+		return err
+	}
+	return runSecretTool(cmd)
+}
+
+func secretTool(args ...string) (*exec.Cmd, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, errKeychainUnavailable
+	}
+	return exec.Command(path, args...), nil
+}
+
+func runSecretTool(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("secret-tool %s: %s", cmd.Args[1], msg)
+		}
+		return fmt.Errorf("secret-tool %s: %s", cmd.Args[1], err)
+	}
+	return nil
+}
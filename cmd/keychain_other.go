@@ -0,0 +1,16 @@
+//go:build !darwin && !linux && !windows
+// +build !darwin,!linux,!windows
+
+package cmd
+
+func keychainSet(account string, secret string) error {
+	return errKeychainUnavailable
+}
+
+func keychainGet(account string) (string, error) {
+	return "", errKeychainUnavailable
+}
+
+func keychainDelete(account string) error {
+	return errKeychainUnavailable
+}
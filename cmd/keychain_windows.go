@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// Tokens are stored as generic credentials in the Windows Credential Manager.
+
+var (
+	advapi32         = syscall.NewLazyDLL("advapi32.dll")
+	procCredWriteW   = advapi32.NewProc("CredWriteW")
+	procCredReadW    = advapi32.NewProc("CredReadW")
+	procCredDeleteW  = advapi32.NewProc("CredDeleteW")
+	procCredFree     = advapi32.NewProc("CredFree")
+	errorNotFound    = syscall.Errno(1168)
+	credTypeGeneric  = uint32(1)
+	credPersistLocal = uint32(2)
+)
+
+type winCredential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func credentialTarget(account string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(keychainService + ":" + account)
+}
+
+func keychainSet(account string, secret string) error {
+	target, err := credentialTarget(account)
+	if err != nil {
+		return err
+	}
+
+	userName, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(secret)
+	cred := winCredential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocal,
+		UserName:           userName,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	if ret, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0); ret == 0 {
+		return fmt.Errorf("CredWrite: %s", err)
+	}
+
+	return nil
+}
+
+func keychainGet(account string) (string, error) {
+	target, err := credentialTarget(account)
+	if err != nil {
+		return "", err
+	}
+
+	var cred *winCredential
+	ret, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), uintptr(credTypeGeneric), 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		if err == errorNotFound {
+			return "", fmt.Errorf("no token found for %s", account)
+		}
+		return "", fmt.Errorf("CredRead: %s", err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+
+	blob := (*[1 << 20]byte)(unsafe.Pointer(cred.CredentialBlob))[:cred.CredentialBlobSize:cred.CredentialBlobSize]
+	return string(blob), nil
+}
+
+func keychainDelete(account string) error {
+	target, err := credentialTarget(account)
+	if err != nil {
+		return err
+	}
+
+	if ret, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(target)), uintptr(credTypeGeneric), 0); ret == 0 && err != errorNotFound {
+		return fmt.Errorf("CredDelete: %s", err)
+	}
+
+	return nil
+}
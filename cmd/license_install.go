@@ -15,9 +15,7 @@
 package cmd
 
 import (
-	"fmt"
 	"io/ioutil"
-	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -33,18 +31,14 @@ func newLicenseInstallCmd() *cobra.Command {
 				filepath := args[0]
 
 				licenseBytes, readErr := ioutil.ReadFile(filepath)
-				if readErr != nil {
-					cmd.Println(fmt.Errorf("error reading license file: %s", readErr))
-					os.Exit(1)
-				}
+				exitOnError(cmd, readErr, "error reading license file")
 
 				license = string(licenseBytes)
 			} else if license != "" {
 				// License set from flag
 			} else {
-				cmd.Println("Expected either an argument <filename> or flag --license=<license>.")
 				cmd.Help()
-				os.Exit(1)
+				exitOnError(cmd, usageError("Expected either an argument <filename> or flag --license=<license>."), "")
 			}
 
 			client := NewApiClient(cmd)
@@ -56,6 +50,7 @@ func newLicenseInstallCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&license, "license", "l", "", "A string with the content license license file.")
+	markSecretFlag(cmd, "license")
 
 	return cmd
 }
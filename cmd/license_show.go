@@ -15,15 +15,36 @@
 package cmd
 
 import (
+	"fmt"
+
+	"github.com/humio/cli/api"
 	"github.com/spf13/cobra"
 )
 
 func newLicenseShowCmd() *cobra.Command {
+	var allProfiles bool
+
 	cmd := &cobra.Command{
 		Use:   "show",
 		Short: "Show the current Humio license installed",
 		Args:  cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
+			if allProfiles {
+				printForAllProfiles(cmd, []string{"License Type", "Issued To", "Number Of Seats", "Issued At", "Expires At"}, func(client *api.Client) ([][]string, error) {
+					license, err := client.Licenses().Get()
+					if err != nil {
+						return nil, err
+					}
+					var issuedTo, seats string
+					if onprem, ok := license.(api.OnPremLicense); ok {
+						issuedTo = onprem.IssuedTo
+						seats = fmt.Sprintf("%d", onprem.NumberOfSeats)
+					}
+					return [][]string{{license.LicenseType(), valueOrEmpty(issuedTo), valueOrEmpty(seats), license.IssuedAt(), license.ExpiresAt()}}, nil
+				})
+				return
+			}
+
 			client := NewApiClient(cmd)
 			license, apiErr := client.Licenses().Get()
 			exitOnError(cmd, apiErr, "error fetching the license")
@@ -32,5 +53,7 @@ func newLicenseShowCmd() *cobra.Command {
 		},
 	}
 
+	registerAllProfilesFlag(cmd, &allProfiles)
+
 	return cmd
 }
@@ -0,0 +1,138 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/humio/cli/prompt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newLoginCmd() *cobra.Command {
+	var setDefault bool
+
+	cmd := cobra.Command{
+		Use:   "login [flags] [<profile>]",
+		Short: "Log in to a Humio cluster and save the token in a profile.",
+		Long: `Validates an API token against a Humio cluster, prints the user it belongs
+to and the version of the cluster, and saves the address and token in the
+profile <profile>, which defaults to 'default'.
+
+You are asked for the address and token unless they are given by --address
+and --token or --token-file, or the equivalent environment variables, e.g.
+
+  $ humioctl login ci --address=https://humio.example.com/ --token-file=/run/secrets/humio
+
+Nothing is saved if the cluster cannot be reached or the token is invalid.
+If Humio does not respond at the address, e.g. because the server redirects
+to another address, common variations of it are probed, e.g. with the other
+scheme, and suggested if Humio responds there.
+The profile 'default' is used when no profile is selected. Use --default to
+also make another profile the default.`,
+		Args: cobra.RangeArgs(0, 1),
+		Run: func(cmd *cobra.Command, args []string) {
+			profileName := "default"
+			if len(args) == 1 {
+				profileName = args[0]
+			}
+
+			out := prompt.NewPrompt(cmd.OutOrStdout())
+
+			var profile *login
+			if explicitlySet(address != "", "HUMIO_ADDRESS") {
+				profile = &login{address: configString("address")}
+				if !strings.HasSuffix(profile.address, "/") {
+					profile.address += "/"
+				}
+
+				if explicitlySet(token != "" || tokenFile != "", "HUMIO_TOKEN") {
+					profile.token = configString("token")
+				} else {
+					var err error
+					profile.token, err = out.AskSecret("API Token")
+					exitOnError(cmd, err, "error reading token")
+				}
+			} else {
+				var err error
+				profile, err = collectProfileInfo(cmd)
+				exitOnError(cmd, err, "failed to collect profile info")
+			}
+
+			config := api.DefaultConfig()
+			config.Address = profile.address
+			config.Token = profile.token
+			client, err := api.NewClient(config)
+			exitOnError(cmd, err, "error initializing the API client")
+
+			probe, suggestion := discoverAddress(profile.address)
+			switch {
+			case probe.version == "" && suggestion != nil:
+				exitOnError(cmd, fmt.Errorf("Humio did not respond at %s: %s, but Humio %s responds at %s, use --address=%s", profile.address, probe.problem, suggestion.version, suggestion.address, suggestion.address), "could not connect to the Humio server")
+			case suggestion != nil:
+				fmt.Fprintf(os.Stderr, "Humio also responds at %s, use --address=%s to keep the token encrypted.\n", suggestion.address, suggestion.address)
+			}
+
+			status, err := client.Status()
+			exitOnError(cmd, err, "could not connect to the Humio server")
+
+			profile.username, err = client.Viewer().Username()
+			exitOnError(cmd, err, "authentication failed, the token is invalid")
+
+			user := profile.username
+			if user == "" {
+				user = "(no authentication)"
+			}
+			cmd.Println(prompt.Colorize(fmt.Sprintf("==> Logged in to %s as [purple]%s[reset] (Humio %s)", profile.address, user, status.Version)))
+
+			saveErr := updateConfig(func() error {
+				storedToken := addAccount(out, profileName, profile)
+
+				if profileName == "default" || setDefault {
+					viper.Set("address", profile.address)
+					viper.Set("token", storedToken)
+				} else {
+					// The values of --address and --token would otherwise be saved
+					// as the default, so keep the ones from the config file.
+					fileConfig := viper.New()
+					fileConfig.SetConfigFile(viper.ConfigFileUsed())
+					fileConfig.SetConfigType("yaml")
+					_ = fileConfig.ReadInConfig()
+
+					viper.Set("address", fileConfig.GetString("address"))
+					viper.Set("token", fileConfig.GetString("token"))
+				}
+				return nil
+			})
+			exitOnError(cmd, saveErr, "error saving config")
+
+			cmd.Println(fmt.Sprintf("Saved to profile '%s'", profileName))
+		},
+	}
+
+	cmd.Flags().BoolVar(&setDefault, "default", false, "Make the profile the default profile.")
+
+	return &cmd
+}
+
+// explicitlySet returns true if a value is given by a flag, or by the
+// environment variable or its _FILE variant, rather than by the config file.
+func explicitlySet(flagSet bool, envVar string) bool {
+	return flagSet || os.Getenv(envVar) != "" || os.Getenv(envVar+"_FILE") != ""
+}
@@ -0,0 +1,319 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+const metricsBatchSize = 500
+
+func newMetricsSendCmd() *cobra.Command {
+	var format string
+	var tags keyValueFlag
+
+	cmd := &cobra.Command{
+		Use:   "send [flags] [<repo>]",
+		Short: "Send metrics read from stdin.",
+		Long: `Reads metrics from stdin, one per line, and sends them to the repository
+<repo>, or your 'sandbox' repository if <repo> is not specified.
+
+Each metric becomes an event with the fields 'metric' and 'value', plus the
+tags of the metric as fields of their own. The following formats are
+supported, and detected automatically unless --format is given:
+
+  statsd    api.requests:1|c|@0.5|#region:eu,host:web1
+  graphite  api.requests 1 1577836800
+            api.requests;region=eu;host=web1 1 1577836800
+  json      {"metric": "api.requests", "value": 1, "timestamp": 1577836800, "tags": {"region": "eu"}}
+
+For example:
+
+  $ echo "backup.duration:$SECONDS|ms" | humioctl metrics send ops --tag host=$(hostname)`,
+		Args: cobra.RangeArgs(0, 1),
+		Run: func(cmd *cobra.Command, args []string) {
+			repo := "sandbox"
+			if len(args) == 1 {
+				repo = args[0]
+			}
+
+			parse, ok := metricParsers[format]
+			if !ok {
+				exitOnError(cmd, usageError(fmt.Sprintf("invalid value %q for --format, must be one of: auto, statsd, graphite, json", format)), "")
+			}
+
+			client := NewApiClient(cmd)
+
+			sent, failed, err := sendMetrics(client, repo, os.Stdin, parse, tags.values, func(line int, err error) {
+				cmd.PrintErrln(fmt.Sprintf("line %d: %s", line, err))
+			})
+			exitOnError(cmd, err, "error sending metrics")
+
+			cmd.Println(fmt.Sprintf("Sent %d metrics", sent))
+
+			if failed > 0 {
+				exitOnError(cmd, fmt.Errorf("%d lines could not be parsed", failed), "error reading metrics")
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "auto", "The format of the input, one of: auto, statsd, graphite, json.")
+	cmd.Flags().Var(&tags, "tag", "Add a tag to every metric, e.g. --tag host=web1. Specify multiple times for multiple tags.")
+
+	return cmd
+}
+
+// metric is a single measurement parsed from a line of input.
+type metric struct {
+	name       string
+	value      float64
+	metricType string
+	sampleRate float64
+	timestamp  time.Time
+	tags       map[string]string
+}
+
+var metricParsers = map[string]func(line string) (metric, error){
+	"auto":     parseMetric,
+	"statsd":   parseStatsdMetric,
+	"graphite": parseGraphiteMetric,
+	"json":     parseJSONMetric,
+}
+
+// sendMetrics reads metrics from r and sends them in batches. Lines that cannot
+// be parsed are reported using onError and skipped.
+func sendMetrics(client *api.Client, repo string, r io.Reader, parse func(string) (metric, error), tags map[string]string, onError func(line int, err error)) (sent, failed int, err error) {
+	var events []structuredEvent
+
+	flush := func() error {
+		if len(events) == 0 {
+			return nil
+		}
+		body, err := json.Marshal([]structuredEventList{{Events: events}})
+		if err != nil {
+			return err
+		}
+		if err := postIngestRequest(client, "api/v1/repositories/"+repo+"/ingest", body); err != nil {
+			return err
+		}
+		sent += len(events)
+		events = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		m, err := parse(text)
+		if err != nil {
+			onError(line, err)
+			failed++
+			continue
+		}
+
+		events = append(events, m.toStructuredEvent(tags))
+		if len(events) >= metricsBatchSize {
+			if err := flush(); err != nil {
+				return sent, failed, err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return sent, failed, err
+	}
+
+	return sent, failed, flush()
+}
+
+func (m metric) toStructuredEvent(tags map[string]string) structuredEvent {
+	attributes := map[string]interface{}{}
+	for k, v := range tags {
+		attributes[k] = v
+	}
+	for k, v := range m.tags {
+		attributes[k] = v
+	}
+
+	attributes["metric"] = m.name
+	attributes["value"] = m.value
+	if m.metricType != "" {
+		attributes["type"] = m.metricType
+	}
+	if m.sampleRate != 0 {
+		attributes["sampleRate"] = m.sampleRate
+	}
+
+	timestamp := m.timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	return structuredEvent{
+		Timestamp:  timestamp.Format(time.RFC3339Nano),
+		Attributes: attributes,
+	}
+}
+
+func parseMetric(line string) (metric, error) {
+	switch {
+	case strings.HasPrefix(line, "{"):
+		return parseJSONMetric(line)
+	case strings.Contains(line, "|"):
+		return parseStatsdMetric(line)
+	default:
+		return parseGraphiteMetric(line)
+	}
+}
+
+var statsdTypes = map[string]string{
+	"c":  "counter",
+	"g":  "gauge",
+	"ms": "timer",
+	"h":  "histogram",
+	"d":  "distribution",
+	"s":  "set",
+}
+
+// parseStatsdMetric parses a line of the form name:value|type[|@rate][|#tag:value,...].
+func parseStatsdMetric(line string) (metric, error) {
+	parts := strings.Split(line, "|")
+	if len(parts) < 2 {
+		return metric{}, fmt.Errorf("expected a statsd metric of the form name:value|type, got %q", line)
+	}
+
+	i := strings.LastIndex(parts[0], ":")
+	if i <= 0 {
+		return metric{}, fmt.Errorf("expected a statsd metric of the form name:value|type, got %q", line)
+	}
+
+	value, err := strconv.ParseFloat(parts[0][i+1:], 64)
+	if err != nil {
+		return metric{}, fmt.Errorf("invalid value %q", parts[0][i+1:])
+	}
+
+	metricType, ok := statsdTypes[parts[1]]
+	if !ok {
+		return metric{}, fmt.Errorf("unknown statsd metric type %q", parts[1])
+	}
+
+	m := metric{name: parts[0][:i], value: value, metricType: metricType}
+
+	for _, p := range parts[2:] {
+		switch {
+		case strings.HasPrefix(p, "@"):
+			if m.sampleRate, err = strconv.ParseFloat(p[1:], 64); err != nil {
+				return metric{}, fmt.Errorf("invalid sample rate %q", p[1:])
+			}
+		case strings.HasPrefix(p, "#"):
+			m.tags = map[string]string{}
+			for _, tag := range strings.Split(p[1:], ",") {
+				kv := strings.SplitN(tag, ":", 2)
+				if len(kv) == 2 {
+					m.tags[kv[0]] = kv[1]
+				} else {
+					m.tags[kv[0]] = ""
+				}
+			}
+		default:
+			return metric{}, fmt.Errorf("unexpected statsd field %q", p)
+		}
+	}
+
+	return m, nil
+}
+
+// parseGraphiteMetric parses a line of the form name[;tag=value...] value [timestamp].
+func parseGraphiteMetric(line string) (metric, error) {
+	parts := strings.Fields(line)
+	if len(parts) < 2 || len(parts) > 3 {
+		return metric{}, fmt.Errorf("expected a graphite metric of the form name value [timestamp], got %q", line)
+	}
+
+	value, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return metric{}, fmt.Errorf("invalid value %q", parts[1])
+	}
+
+	nameAndTags := strings.Split(parts[0], ";")
+	m := metric{name: nameAndTags[0], value: value}
+
+	if len(nameAndTags) > 1 {
+		m.tags = map[string]string{}
+		for _, tag := range nameAndTags[1:] {
+			kv := strings.SplitN(tag, "=", 2)
+			if len(kv) != 2 {
+				return metric{}, fmt.Errorf("expected a graphite tag of the form key=value, got %q", tag)
+			}
+			m.tags[kv[0]] = kv[1]
+		}
+	}
+
+	if len(parts) == 3 {
+		seconds, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			return metric{}, fmt.Errorf("invalid timestamp %q", parts[2])
+		}
+		m.timestamp = time.Unix(0, int64(seconds*float64(time.Second)))
+	}
+
+	return m, nil
+}
+
+// parseJSONMetric parses a JSON object with the attributes metric (or name),
+// value, and optionally timestamp in seconds and tags.
+func parseJSONMetric(line string) (metric, error) {
+	var v struct {
+		Metric    string            `json:"metric"`
+		Name      string            `json:"name"`
+		Value     *float64          `json:"value"`
+		Type      string            `json:"type"`
+		Timestamp float64           `json:"timestamp"`
+		Tags      map[string]string `json:"tags"`
+	}
+
+	if err := json.Unmarshal([]byte(line), &v); err != nil {
+		return metric{}, fmt.Errorf("invalid JSON metric: %v", err)
+	}
+
+	m := metric{name: v.Metric, metricType: v.Type, tags: v.Tags}
+	if m.name == "" {
+		m.name = v.Name
+	}
+	if m.name == "" || v.Value == nil {
+		return metric{}, fmt.Errorf("a JSON metric must have the attributes metric and value")
+	}
+	m.value = *v.Value
+
+	if v.Timestamp != 0 {
+		m.timestamp = time.Unix(0, int64(v.Timestamp*float64(time.Second)))
+	}
+
+	return m, nil
+}
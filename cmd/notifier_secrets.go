@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+// notifierSecretProperties are the properties of each type of notifier that
+// always hold secrets, e.g. webhook URLs with a token in them. Other
+// properties are secret if their name matches secretNamePattern.
+var notifierSecretProperties = map[string][]string{
+	api.NotifierTypeOpsGenie:  {"genieKey"},
+	api.NotifierTypePagerDuty: {"routingKey"},
+	api.NotifierTypeSlack:     {"url"},
+	api.NotifierTypeVictorOps: {"notifyUrl"},
+	api.NotifierTypeWebHook:   {"url"},
+}
+
+var secretNamePattern = regexp.MustCompile(`(?i)(key|token|secret|password|authorization)`)
+
+var secretPlaceholderPattern = regexp.MustCompile(`^\$\{secret:([^}]+)\}$`)
+
+var secretEnvVarReplacer = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+func secretPlaceholder(name string) string {
+	return "${secret:" + name + "}"
+}
+
+// notifierSecretName returns the name of a secret of a notifier, e.g.
+// ops-slack.url, so the secrets of several notifiers of the same type, e.g.
+// in a bundle, can be given different values.
+func notifierSecretName(notifier, property string) string {
+	return notifier + "." + property
+}
+
+// secretEnvVar returns the environment variable a secret can be given by,
+// e.g. HUMIO_SECRET_OPS_WEBHOOK_HEADERS_AUTHORIZATION for
+// ops-webhook.headers.Authorization.
+func secretEnvVar(name string) string {
+	return "HUMIO_SECRET_" + strings.ToUpper(secretEnvVarReplacer.ReplaceAllString(name, "_"))
+}
+
+// redactNotifierSecrets replaces the secrets in the properties of the
+// notifier with placeholders, and returns their names. The names start with
+// the name of the notifier, and secrets in maps, e.g. the headers of a
+// webhook, are named by their path, e.g. ops-webhook.headers.Authorization.
+func redactNotifierSecrets(notifier *api.Notifier) []string {
+	always := map[string]bool{}
+	for _, p := range notifierSecretProperties[notifier.Entity] {
+		always[p] = true
+	}
+
+	var names []string
+	for key, value := range notifier.Properties {
+		switch v := value.(type) {
+		case string:
+			if v != "" && (always[key] || secretNamePattern.MatchString(key)) {
+				name := notifierSecretName(notifier.Name, key)
+				notifier.Properties[key] = secretPlaceholder(name)
+				names = append(names, name)
+			}
+		case map[string]interface{}:
+			for k, e := range v {
+				if s, ok := e.(string); ok && s != "" && secretNamePattern.MatchString(k) {
+					name := notifierSecretName(notifier.Name, key+"."+k)
+					v[k] = secretPlaceholder(name)
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// notifierSecretFlags are the --secret flags of commands that install
+// notifiers, giving the values of the secrets that were replaced by
+// placeholders when the notifiers were exported.
+type notifierSecretFlags struct {
+	secrets keyValueFlag
+}
+
+func (s *notifierSecretFlags) register(cmd *cobra.Command) {
+	cmd.Flags().Var(&s.secrets, "secret", "The value of a secret of a notifier, e.g. slack.url=https://hooks.slack.com/... Specify multiple times for multiple secrets. Defaults to $HUMIO_SECRET_<NOTIFIER>_<NAME>.")
+	markSecretFlag(cmd, "secret")
+}
+
+// resolve replaces the placeholders in the properties of the notifier with
+// the values of the secrets. It returns an error naming all the secrets
+// that are not given. Placeholders of files exported before the names of
+// secrets started with the name of the notifier, e.g. ${secret:url}, are
+// still resolved by their name.
+func (s *notifierSecretFlags) resolve(notifier *api.Notifier) error {
+	var missing []string
+	lookup := func(placeholder string) (string, bool) {
+		m := secretPlaceholderPattern.FindStringSubmatch(placeholder)
+		if m == nil {
+			return placeholder, true
+		}
+		name := m[1]
+		if v, ok := s.secrets.values[name]; ok {
+			return v, true
+		}
+		if v, ok := os.LookupEnv(secretEnvVar(name)); ok {
+			return v, true
+		}
+		missing = append(missing, fmt.Sprintf("%s (--secret %s=... or $%s)", name, name, secretEnvVar(name)))
+		return placeholder, false
+	}
+
+	for key, value := range notifier.Properties {
+		switch v := value.(type) {
+		case string:
+			notifier.Properties[key], _ = lookup(v)
+		case map[string]interface{}:
+			for k, e := range v {
+				if str, ok := e.(string); ok {
+					v[k], _ = lookup(str)
+				}
+			}
+		case map[interface{}]interface{}:
+			// Maps in YAML files are read with keys of any type.
+			m := make(map[string]interface{}, len(v))
+			for k, e := range v {
+				if str, ok := e.(string); ok {
+					e, _ = lookup(str)
+				}
+				m[fmt.Sprint(k)] = e
+			}
+			notifier.Properties[key] = m
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("the notifier %s needs the secrets: %s", notifier.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
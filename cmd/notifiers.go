@@ -20,8 +20,9 @@ import (
 
 func newNotifiersCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "notifiers",
-		Short: "Manage notifiers",
+		Use:     "notifiers",
+		Aliases: []string{"actions"},
+		Short:   "Manage notifiers",
 	}
 
 	cmd.AddCommand(newNotifiersListCmd())
@@ -29,6 +30,7 @@ func newNotifiersCmd() *cobra.Command {
 	cmd.AddCommand(newNotifiersRemoveCmd())
 	cmd.AddCommand(newNotifiersInstallCmd())
 	cmd.AddCommand(newNotifiersExportCmd())
+	cmd.AddCommand(newNotifiersTestCmd())
 
 	return cmd
 }
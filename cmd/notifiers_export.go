@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	yaml "gopkg.in/yaml.v2"
@@ -25,11 +26,24 @@ import (
 
 func newNotifiersExportCmd() *cobra.Command {
 	var outputName string
+	var includeSecrets bool
 
 	cmd := cobra.Command{
 		Use:   "export [flags] <view> <notifier>",
 		Short: "Export a notifier <notifier> in <view> to a file.",
-		Args:  cobra.ExactArgs(2),
+		Long: `Writes the notifier <notifier> in <view> to a YAML file, which can be
+installed with 'humioctl notifiers install'.
+
+Secrets in the properties of the notifier, such as API keys, webhook URLs
+and authorization headers, are replaced by placeholders, e.g.
+
+  url: ${secret:slack.url}
+
+so the file can be shared safely. The secrets are named after the notifier
+and given when installing it, with --secret slack.url=... or the environment
+variable HUMIO_SECRET_SLACK_URL. Use --include-secrets to export the actual
+values.`,
+		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
 			view := args[0]
 			notifierName := args[1]
@@ -42,26 +56,24 @@ func newNotifiersExportCmd() *cobra.Command {
 			client := NewApiClient(cmd)
 
 			notifier, apiErr := client.Notifiers().Get(view, notifierName)
-			if apiErr != nil {
-				cmd.Println(fmt.Errorf("Error fetching notifier: %s", apiErr))
-				os.Exit(1)
+			exitOnError(cmd, apiErr, "Error fetching notifier")
+
+			if !includeSecrets {
+				if secrets := redactNotifierSecrets(notifier); len(secrets) > 0 {
+					fmt.Fprintf(os.Stderr, "The secrets %s were replaced by placeholders, give them with --secret when installing the notifier.\n", strings.Join(secrets, ", "))
+				}
 			}
 
 			yamlData, yamlErr := yaml.Marshal(&notifier)
-			if yamlErr != nil {
-				cmd.Println(fmt.Errorf("Failed to serialize the notifier: %s", yamlErr))
-				os.Exit(1)
-			}
+			exitOnError(cmd, yamlErr, "Failed to serialize the notifier")
 			outFilePath := outputName + ".yaml"
 
 			writeErr := ioutil.WriteFile(outFilePath, yamlData, 0644)
-			if writeErr != nil {
-				cmd.Println(fmt.Errorf("Error saving the notifier file: %s", writeErr))
-				os.Exit(1)
-			}
+			exitOnError(cmd, writeErr, "Error saving the notifier file")
 		},
 	}
 
+	cmd.Flags().BoolVar(&includeSecrets, "include-secrets", false, "Export the secrets of the notifier instead of placeholders.")
 	cmd.Flags().StringVarP(&outputName, "output", "o", "", "The file path where the notifier should be written. Defaults to ./<notifier-name>.yaml")
 
 	return &cmd
@@ -15,10 +15,8 @@
 package cmd
 
 import (
-	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
 
 	"github.com/humio/cli/api"
 	"github.com/spf13/cobra"
@@ -29,11 +27,14 @@ func newNotifiersInstallCmd() *cobra.Command {
 	var content []byte
 	var readErr error
 	var force bool
+	var vars templateVarFlags
+	var secrets notifierSecretFlags
 	var filePath, url, name string
 
 	cmd := cobra.Command{
-		Use:   "install [flags] <view>",
-		Short: "Installs a notifier in a view",
+		Use:     "install [flags] <view>",
+		Aliases: []string{"import"},
+		Short:   "Installs a notifier in a view",
 		Long: `Install a notifier from a URL or from a local file.
 
 The install command allows you to install notifiers from a URL or from a local file, e.g.
@@ -46,6 +47,18 @@ The install command allows you to install notifiers from a URL or from a local f
 
 By default 'install' will not override existing parsers with the same name.
 Use the --force flag to update existing parsers with conflicting names.
+
+Use --var and --var-file to install the same notifier in different environments.
+The file is then rendered as a Go template, e.g. with {{ .repo }} replaced
+by the value of --var repo=web-prod:
+
+  $ humioctl notifiers install web --file=./notifier.yaml --var-file=prod.yaml --var threshold=100
+
+Secrets replaced by placeholders such as ${secret:slack.url} by 'notifiers
+export' are given with --secret, or the environment variable
+HUMIO_SECRET_<NOTIFIER>_<NAME>, e.g. HUMIO_SECRET_SLACK_URL:
+
+  $ humioctl notifiers install web --file=./slack.yaml --secret slack.url=https://hooks.slack.com/services/...
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Check that we got the right number of argument
@@ -56,21 +69,25 @@ Use the --force flag to update existing parsers with conflicting names.
 				} else if url != "" {
 					content, readErr = getURLNotifier(url)
 				} else {
-					cmd.Println(fmt.Errorf("you must specify a path using --file or --url"))
-					os.Exit(1)
+					exitOnError(cmd, usageError("you must specify a path using --file or --url"), "")
 				}
 			} else if l := len(args); l != 2 {
-				cmd.Println(fmt.Errorf("This command takes one argument: <view>"))
-				os.Exit(1)
+				exitOnError(cmd, usageError("This command takes one argument: <view>"), "")
 			}
 			exitOnError(cmd, readErr, "Failed to load the notifier")
 
+			content, readErr = vars.render("notifier", content)
+			exitOnError(cmd, readErr, "Failed to render the notifier")
+
 			viewName := args[0]
 			notifier := api.Notifier{}
 			notifier.Name = name
 			yamlErr := yaml.Unmarshal(content, &notifier)
 			exitOnError(cmd, yamlErr, "The notifier's format was invalid")
 
+			secretErr := secrets.resolve(&notifier)
+			exitOnError(cmd, secretErr, "Missing secrets")
+
 			// Get the HTTP client
 			client := NewApiClient(cmd)
 
@@ -82,6 +99,8 @@ Use the --force flag to update existing parsers with conflicting names.
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overrides any notifier with the same name. This can be used for updating notifier that are already installed. (See --name)")
 	cmd.Flags().StringVar(&filePath, "file", "", "The local file path to the notifier to install.")
 	cmd.Flags().StringVar(&url, "url", "", "A URL to fetch the notifier file from.")
+	vars.register(&cmd)
+	secrets.register(&cmd)
 	cmd.Flags().StringVarP(&name, "name", "n", "", "Install the notifer under a specific name, ignoreing the `name` attribute in the notifier file.")
 
 	return &cmd
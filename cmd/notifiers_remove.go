@@ -15,9 +15,6 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 )
 
@@ -35,10 +32,7 @@ func newNotifiersRemoveCmd() *cobra.Command {
 			client := NewApiClient(cmd)
 
 			err := client.Notifiers().Delete(view, name)
-			if err != nil {
-				cmd.Println(fmt.Errorf("error removing ingest token: %s", err))
-				os.Exit(1)
-			}
+			exitOnError(cmd, err, "error removing notifier")
 
 			cmd.Println("Notifier removed")
 		},
@@ -35,7 +35,7 @@ func newNotifiersShowCmd() *cobra.Command {
 			notifier, err := client.Notifiers().Get(view, name)
 
 			if err != nil {
-				return fmt.Errorf("Error fetching notifier: %s", err)
+				return fmt.Errorf("Error fetching notifier: %w", err)
 			}
 
 			var output []string
@@ -0,0 +1,58 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/humio/cli/prompt"
+	"github.com/spf13/cobra"
+)
+
+func newNotifiersTestCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "test [flags] <view> <name>",
+		Short: "Send a test notification through a notifier.",
+		Long: `Makes Humio send a test notification through the notifier <name> in
+<view> and reports whether it was delivered, e.g. to check that a Slack or
+PagerDuty integration is set up correctly without waiting for an alert.
+
+The command exits with status 1 if the notification could not be delivered.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			view := args[0]
+			name := args[1]
+
+			client := NewApiClient(cmd)
+
+			result, err := client.Notifiers().Test(view, name)
+			exitOnError(cmd, err, "error testing notifier")
+
+			if result.Success {
+				cmd.Println(prompt.Colorize(fmt.Sprintf("[green]Test notification sent through %s[reset]", name)))
+				return
+			}
+
+			cmd.Println(prompt.Colorize(fmt.Sprintf("[red]Test notification through %s failed (status code %d)[reset]", name, result.StatusCode)))
+			if result.Message != "" {
+				cmd.Println(result.Message)
+			}
+			os.Exit(1)
+		},
+	}
+
+	return &cmd
+}
@@ -0,0 +1,13 @@
+package cmd
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// Desktop notifications are shown with AppleScript.
+
+func desktopNotify(title, message string) error {
+	script := "display notification " + strconv.Quote(message) + " with title " + strconv.Quote(title)
+	return exec.Command("osascript", "-e", script).Run()
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Desktop notifications are shown with notify-send from libnotify.
+
+func desktopNotify(title, message string) error {
+	path, err := exec.LookPath("notify-send")
+	if err != nil {
+		return fmt.Errorf("desktop notifications require notify-send")
+	}
+	return exec.Command(path, "--app-name=humioctl", title, message).Run()
+}
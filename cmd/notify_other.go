@@ -0,0 +1,10 @@
+//go:build !darwin && !linux && !windows
+// +build !darwin,!linux,!windows
+
+package cmd
+
+import "errors"
+
+func desktopNotify(title, message string) error {
+	return errors.New("desktop notifications are not supported on this platform")
+}
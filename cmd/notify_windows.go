@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// Desktop notifications are shown as a balloon tip using PowerShell.
+
+func desktopNotify(title, message string) error {
+	quote := func(s string) string { return "'" + strings.Replace(s, "'", "''", -1) + "'" }
+
+	script := `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, ` + quote(title) + `, ` + quote(message) + `, 'Info')
+Start-Sleep -Seconds 10
+$n.Dispose()`
+
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Start()
+}
@@ -0,0 +1,87 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/api"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func newOrgCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "org",
+		Aliases: []string{"organization"},
+		Short:   "Manage the organization [Org Admin Only]",
+	}
+
+	cmd.AddCommand(newOrgSettingsCmd())
+
+	return cmd
+}
+
+func newOrgSettingsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "settings",
+		Short: "Show and change the organization-wide defaults and limits",
+		Long: `The settings of the organization are the defaults used for new
+repositories and users, e.g. the retention of new repositories and the role
+new users are given, and the limits of the organization, such as how much it
+can ingest per day.`,
+	}
+
+	cmd.AddCommand(newOrgSettingsShowCmd())
+	cmd.AddCommand(newOrgSettingsUpdateCmd())
+
+	return cmd
+}
+
+func printOrgSettingsTable(cmd *cobra.Command, settings api.OrganizationSettings) {
+	limit := func(v float64, unit string) string {
+		if v <= 0 {
+			return "-"
+		}
+		return formatRetentionDays(v) + unit
+	}
+	count := func(v int) string {
+		if v <= 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d", v)
+	}
+
+	data := [][]string{
+		{"Organization", settings.Name},
+		{"Default Retention (Days)", limit(settings.DefaultTimeBasedRetentionDays, "")},
+		{"Default Ingest Retention", limit(settings.DefaultIngestSizeBasedRetentionGB, " GB")},
+		{"Default Storage Retention", limit(settings.DefaultStorageSizeBasedRetentionGB, " GB")},
+		{"Default User Role", valueOrEmpty(settings.DefaultUserRole)},
+		{"Max Auto Shard Count", count(settings.MaxAutoShardCount)},
+		{"Max Repositories", count(settings.MaxRepositories)},
+		{"Ingest Limit (Per Day)", limit(settings.IngestLimitGBPerDay, " GB")},
+	}
+
+	w := tablewriter.NewWriter(cmd.OutOrStdout())
+	w.AppendBulk(data)
+	w.SetBorder(false)
+	w.SetColumnSeparator(":")
+	w.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT})
+
+	cmd.Println()
+	w.Render()
+	cmd.Println()
+}
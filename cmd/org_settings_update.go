@@ -0,0 +1,82 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newOrgSettingsUpdateCmd() *cobra.Command {
+	var defaultRetentionTimeFlag, defaultIngestSizeBasedRetentionFlag, defaultStorageSizeBasedRetentionFlag, ingestLimitFlag float64PtrFlag
+	var maxAutoShardCountFlag, maxRepositoriesFlag intPtrFlag
+	var defaultUserRoleFlag stringPtrFlag
+
+	cmd := &cobra.Command{
+		Use:   "update [flags]",
+		Short: "Change the settings of the organization",
+		Long: `Changes the settings of the organization. Only the settings given as flags
+are changed. Give 0 to remove a default or a limit, e.g.
+
+  $ humioctl org settings update --default-retention-time 30 --ingest-limit 0
+
+The default retention only applies to repositories created after the change.`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			changeset := api.OrganizationSettingsChangeSet{
+				DefaultTimeBasedRetentionDays:      defaultRetentionTimeFlag.value,
+				DefaultIngestSizeBasedRetentionGB:  defaultIngestSizeBasedRetentionFlag.value,
+				DefaultStorageSizeBasedRetentionGB: defaultStorageSizeBasedRetentionFlag.value,
+				MaxAutoShardCount:                  maxAutoShardCountFlag.value,
+				DefaultUserRole:                    defaultUserRoleFlag.value,
+				IngestLimitGBPerDay:                ingestLimitFlag.value,
+				MaxRepositories:                    maxRepositoriesFlag.value,
+			}
+
+			if changeset == (api.OrganizationSettingsChangeSet{}) {
+				exitOnError(cmd, usageError("you must specify at least one setting to update"), "")
+			}
+			for _, v := range []*float64{changeset.DefaultTimeBasedRetentionDays, changeset.DefaultIngestSizeBasedRetentionGB, changeset.DefaultStorageSizeBasedRetentionGB, changeset.IngestLimitGBPerDay} {
+				if v != nil && *v < 0 {
+					exitOnError(cmd, usageError(fmt.Sprintf("expected 0 or a positive number, got %v", *v)), "")
+				}
+			}
+			for _, v := range []*int{changeset.MaxAutoShardCount, changeset.MaxRepositories} {
+				if v != nil && *v < 0 {
+					exitOnError(cmd, usageError(fmt.Sprintf("expected 0 or a positive number, got %d", *v)), "")
+				}
+			}
+
+			client := NewApiClient(cmd)
+
+			settings, err := client.Organizations().UpdateSettings(changeset)
+			exitOnError(cmd, err, "error updating organization settings")
+
+			printOrgSettingsTable(cmd, settings)
+		},
+	}
+
+	cmd.Flags().Var(&defaultRetentionTimeFlag, "default-retention-time", "The retention time in days of new repositories.")
+	cmd.Flags().Var(&defaultIngestSizeBasedRetentionFlag, "default-ingest-size-based-retention", "The ingest size based retention in GB of new repositories.")
+	cmd.Flags().Var(&defaultStorageSizeBasedRetentionFlag, "default-storage-size-based-retention", "The storage size based retention in GB of new repositories.")
+	cmd.Flags().Var(&defaultUserRoleFlag, "default-user-role", "The name of the role new users are given.")
+	cmd.Flags().Var(&maxAutoShardCountFlag, "max-auto-shard-count", "The maximum number of shards Humio automatically splits a datasource into.")
+	cmd.Flags().Var(&maxRepositoriesFlag, "max-repositories", "The maximum number of repositories in the organization.")
+	cmd.Flags().Var(&ingestLimitFlag, "ingest-limit", "The maximum amount of data in GB the organization can ingest per day.")
+
+	return cmd
+}
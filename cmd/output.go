@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
+	"strings"
 
+	"github.com/humio/cli/prompt"
 	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 func printTable(cmd *cobra.Command, rows []string) {
@@ -50,3 +55,126 @@ func ByteCountDecimal(b int64) string {
 	}
 	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "kMGTPE"[exp])
 }
+
+// pagedCommands lists the commands whose output can be long enough to be
+// shown through a pager with --pager. Commands that prompt for input, or
+// redraw or stream their output, must not be paged.
+var pagedCommands = map[string]bool{
+	"alerts export":            true,
+	"alerts list":              true,
+	"alerts silences list":     true,
+	"alerts status":            true,
+	"api get":                  true,
+	"api graphql":              true,
+	"api introspect":           true,
+	"api schema":               true,
+	"auth group-mappings list": true,
+	"cluster missing-segments": true,
+	"cluster nodes list":       true,
+	"cluster nodes show":       true,
+	"cluster segments":         true,
+	"cluster upgrade-status":   true,
+	"fdr-feeds list":           true,
+	"history":                  true,
+	"ingest-tokens list":       true,
+	"ip-filters list":          true,
+	"notifiers export":         true,
+	"notifiers list":           true,
+	"notifiers show":           true,
+	"packages list":            true,
+	"parsers export":           true,
+	"parsers list":             true,
+	"parsers test":             true,
+	"query-jobs list":          true,
+	"repos list":               true,
+	"repos show":               true,
+	"repos stats":              true,
+	"repos tag-groupings show": true,
+	"stats self":               true,
+	"tokens list":              true,
+	"usage report":             true,
+	"users list":               true,
+	"users permissions":        true,
+	"users show":               true,
+	"views export":             true,
+	"views list":               true,
+	"views show":               true,
+}
+
+// pager is the pager the output of the command is written to, if any.
+var pager *exec.Cmd
+
+// terminalStdout is stdout before it was replaced by the input of the pager.
+var terminalStdout *os.File
+
+// setupOutput enables colors if stdout is a terminal, unless they are
+// disabled with --no-color or the NO_COLOR environment variable, and starts
+// the pager if --pager is given and the command can be paged.
+func setupOutput(cmd *cobra.Command) {
+	isTerminal := terminal.IsTerminal(int(os.Stdout.Fd()))
+
+	color := isTerminal && !boolFlagOrConfig("no-color") && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	prompt.SetColor(color)
+
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if !boolFlagOrConfig("pager") || !isTerminal || !pagedCommands[path] {
+		return
+	}
+
+	if err := startPager(); err != nil {
+		fmt.Fprintf(os.Stderr, "could not start the pager: %v\n", err)
+	}
+}
+
+// startPager starts $PAGER, or less if it is not set, and replaces stdout
+// with the input of the pager.
+func startPager() error {
+	command := strings.Fields(os.Getenv("PAGER"))
+	if len(command) == 0 {
+		command = []string{"less"}
+	}
+
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	p := exec.Command(path, command[1:]...)
+	p.Stdin = r
+	p.Stdout = os.Stdout
+	p.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		// Quit if the output fits on the screen, keep colors and don't clear
+		// the screen on exit, like git.
+		p.Env = append(os.Environ(), "LESS=FRX")
+	}
+
+	if err := p.Start(); err != nil {
+		w.Close()
+		return err
+	}
+
+	pager = p
+	terminalStdout = os.Stdout
+	os.Stdout = w
+	return nil
+}
+
+// stopPager waits for the user to quit the pager, if it was started, and
+// restores stdout.
+func stopPager() {
+	if pager == nil {
+		return
+	}
+
+	os.Stdout.Close()
+	os.Stdout = terminalStdout
+	_ = pager.Wait()
+	pager = nil
+}
@@ -0,0 +1,81 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func newPackagesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "packages",
+		Short: "Manage packages",
+		Long: `Packages bundle parsers, dashboards, alerts and other assets so they can be
+installed into a view in one go.`,
+	}
+
+	cmd.AddCommand(newPackagesCreateCmd())
+	cmd.AddCommand(newPackagesInstallCmd())
+	cmd.AddCommand(newPackagesListCmd())
+	cmd.AddCommand(newPackagesUninstallCmd())
+
+	return cmd
+}
+
+// createPackageArchive zips the content of dir, with paths relative to dir.
+func createPackageArchive(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		f, err := w.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+
+		_, err = f.Write(content)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,231 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const packageManifestFile = "manifest.yaml"
+
+type packageManifest struct {
+	Schema      string `yaml:"$schema,omitempty"`
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description,omitempty"`
+	Author      string `yaml:"author,omitempty"`
+}
+
+var (
+	packageNamePattern    = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*/[a-z0-9][a-z0-9_-]*$`)
+	packageVersionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`)
+)
+
+// packageAssetDirs are the directories a package can contain, and a
+// validation function for each of the YAML files in them.
+var packageAssetDirs = map[string]func(content []byte) error{
+	"parsers": func(content []byte) error {
+		var parser api.Parser
+		if err := yaml.UnmarshalStrict(content, &parser); err != nil {
+			return err
+		}
+		if parser.Name == "" || parser.Script == "" {
+			return fmt.Errorf("a parser must have a name and a script")
+		}
+		return nil
+	},
+	"alerts": func(content []byte) error {
+		var alert api.Alert
+		if err := yaml.UnmarshalStrict(content, &alert); err != nil {
+			return err
+		}
+		if alert.Name == "" || alert.Query.QueryString == "" {
+			return fmt.Errorf("an alert must have a name and a query")
+		}
+		return nil
+	},
+	"dashboards": func(content []byte) error {
+		var dashboard map[string]interface{}
+		if err := yaml.Unmarshal(content, &dashboard); err != nil {
+			return err
+		}
+		if name, ok := dashboard["name"].(string); !ok || name == "" {
+			return fmt.Errorf("a dashboard must have a name")
+		}
+		return nil
+	},
+}
+
+func newPackagesCreateCmd() *cobra.Command {
+	var outputFile string
+	var validateOnly bool
+	var initPackage bool
+
+	cmd := &cobra.Command{
+		Use:   "create [flags] <directory>",
+		Short: "Validates a package directory and creates an installable zip file",
+		Long: `Validates the package in <directory> and zips it into a file that can be
+installed with 'humioctl packages install'.
+
+A package directory must contain a manifest.yaml file, e.g.
+
+  name: acme/webserver
+  version: 1.0.0
+  description: Parsers and dashboards for the Acme web server
+  author: Acme Inc.
+
+and can contain assets in the following sub-directories, one YAML file each:
+
+  parsers/       in the format used by 'humioctl parsers install'
+  alerts/        in the format used by 'humioctl alerts install'
+  dashboards/
+
+Use --init to create a manifest and the asset directories in a new package
+directory.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := args[0]
+
+			if initPackage {
+				err := initPackageDir(dir)
+				exitOnError(cmd, err, "error creating package")
+				cmd.Printf("Created package skeleton in %s\n", dir)
+				return
+			}
+
+			manifest, err := validatePackageDir(dir)
+			exitOnError(cmd, err, "invalid package")
+
+			if validateOnly {
+				cmd.Printf("Package %s@%s is valid\n", manifest.Name, manifest.Version)
+				return
+			}
+
+			if outputFile == "" {
+				outputFile = fmt.Sprintf("%s-%s.zip", strings.Replace(manifest.Name, "/", "-", -1), manifest.Version)
+			}
+
+			archive, err := createPackageArchive(dir)
+			exitOnError(cmd, err, "error creating package archive")
+
+			err = ioutil.WriteFile(outputFile, archive, 0644)
+			exitOnError(cmd, err, "error writing package archive")
+
+			cmd.Printf("Created package %s@%s in %s\n", manifest.Name, manifest.Version, outputFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "The path of the zip file to create. Defaults to ./<scope>-<name>-<version>.zip")
+	cmd.Flags().BoolVar(&validateOnly, "validate-only", false, "Only validate the package, do not create the zip file.")
+	cmd.Flags().BoolVar(&initPackage, "init", false, "Create a manifest and empty asset directories in <directory>.")
+
+	return cmd
+}
+
+func initPackageDir(dir string) error {
+	manifestPath := filepath.Join(dir, packageManifestFile)
+	if _, err := os.Stat(manifestPath); err == nil {
+		return fmt.Errorf("%s already exists", manifestPath)
+	}
+
+	for name := range packageAssetDirs {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			return err
+		}
+	}
+
+	name := strings.ToLower(filepath.Base(filepath.Clean(dir)))
+	manifest, err := yaml.Marshal(packageManifest{
+		Name:    "my-scope/" + name,
+		Version: "0.1.0",
+	})
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(manifestPath, manifest, 0644)
+}
+
+// validatePackageDir checks the manifest and all assets of the package in dir
+// and returns the manifest.
+func validatePackageDir(dir string) (*packageManifest, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dir, packageManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest packageManifest
+	if err := yaml.UnmarshalStrict(content, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %s", packageManifestFile, err)
+	}
+
+	if !packageNamePattern.MatchString(manifest.Name) {
+		return nil, fmt.Errorf("%s: name must be of the form <scope>/<name> in lowercase, got %q", packageManifestFile, manifest.Name)
+	}
+
+	if !packageVersionPattern.MatchString(manifest.Version) {
+		return nil, fmt.Errorf("%s: version must be a semantic version, e.g. 1.0.0, got %q", packageManifestFile, manifest.Version)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		validate, ok := packageAssetDirs[entry.Name()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported directory %q", entry.Name())
+		}
+
+		files, err := ioutil.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			path := filepath.Join(entry.Name(), file.Name())
+
+			ext := filepath.Ext(file.Name())
+			if file.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				return nil, fmt.Errorf("%s: only YAML files are allowed in %s/", path, entry.Name())
+			}
+
+			content, err := ioutil.ReadFile(filepath.Join(dir, path))
+			if err != nil {
+				return nil, err
+			}
+
+			if err := validate(content); err != nil {
+				return nil, fmt.Errorf("%s: %s", path, err)
+			}
+		}
+	}
+
+	return &manifest, nil
+}
@@ -0,0 +1,80 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// marketplaceFeature is needed to install packages from the marketplace. Zip
+// files are uploaded using the REST API, which does not depend on it.
+var marketplaceFeature = serverFeature{"installing packages from the marketplace", []string{"installPackageFromRegistryV2", "InstallPackageFromRegistryInput.viewName", "InstallPackageFromRegistryInput.packageId"}}
+
+func newPackagesInstallCmd() *cobra.Command {
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "install [flags] <view> (<directory> | <zip-file> | <scope/name[@version]>)",
+		Short: "Installs a package in a view",
+		Long: `Installs a package from a local directory, a zip file or the Humio package
+marketplace into a view, e.g.
+
+  $ humioctl packages install myview ./my-package/
+
+  $ humioctl packages install myview my-package.zip
+
+  $ humioctl packages install myview humio/nginx@1.0.0
+
+By default 'install' will not override assets already in the view.
+Use the --overwrite flag to update them.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+			source := args[1]
+
+			client := NewApiClient(cmd)
+
+			info, statErr := os.Stat(source)
+			switch {
+			case statErr == nil && info.IsDir():
+				archive, err := createPackageArchive(source)
+				exitOnError(cmd, err, "error creating package archive")
+
+				err = client.Packages().InstallArchive(viewName, archive, overwrite)
+				exitOnError(cmd, err, "error installing package")
+			case statErr == nil:
+				archive, err := ioutil.ReadFile(source)
+				exitOnError(cmd, err, "error reading package archive")
+
+				err = client.Packages().InstallArchive(viewName, archive, overwrite)
+				exitOnError(cmd, err, "error installing package")
+			default:
+				exitOnError(cmd, requireServerFeature(client, marketplaceFeature), "")
+
+				err := client.Packages().InstallFromMarketplace(viewName, source)
+				exitOnError(cmd, err, "error installing package")
+			}
+
+			cmd.Println("Package installed")
+		},
+	}
+
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite existing assets in the view with the ones from the package.")
+
+	return cmd
+}
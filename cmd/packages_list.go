@@ -0,0 +1,52 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newPackagesListCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "list [flags] <view>",
+		Short: "List the packages installed in a view.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			view := args[0]
+
+			// Get the HTTP client
+			client := NewApiClient(cmd)
+			packages, err := client.Packages().List(view)
+
+			if err != nil {
+				return fmt.Errorf("Error fetching packages: %w", err)
+			}
+
+			var output []string
+			output = append(output, "Package | Source")
+			for _, p := range packages {
+				output = append(output, fmt.Sprintf("%v | %v", p.ID, p.Source))
+			}
+
+			printTable(cmd, output)
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
@@ -0,0 +1,45 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newPackagesUninstallCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "uninstall [flags] <view> <scope/name>",
+		Short: "Uninstalls a package from a view.",
+		Long: `Uninstalls the package <scope/name> from the view <view>, removing
+all the assets that were installed with it.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			view := args[0]
+			// Uninstalling is done by name, so allow a version to be passed as shown by 'packages list'.
+			packageID := strings.SplitN(args[1], "@", 2)[0]
+
+			client := NewApiClient(cmd)
+
+			err := client.Packages().Uninstall(view, packageID)
+			exitOnError(cmd, err, "error uninstalling package")
+
+			cmd.Println("Package uninstalled")
+		},
+	}
+
+	return &cmd
+}
@@ -28,6 +28,9 @@ func newParsersCmd() *cobra.Command {
 	cmd.AddCommand(newParsersListCmd())
 	cmd.AddCommand(newParsersRemoveCmd())
 	cmd.AddCommand(newParsersExportCmd())
+	cmd.AddCommand(newParsersExportAllCmd())
+	cmd.AddCommand(newParsersTestCmd())
+	cmd.AddCommand(newParsersSyncCmd())
 
 	return cmd
 }
@@ -15,9 +15,7 @@
 package cmd
 
 import (
-	"fmt"
 	"io/ioutil"
-	"os"
 
 	"github.com/spf13/cobra"
 	yaml "gopkg.in/yaml.v2"
@@ -42,23 +40,14 @@ func newParsersExportCmd() *cobra.Command {
 			client := NewApiClient(cmd)
 
 			parser, apiErr := client.Parsers().Get(repo, parserName)
-			if apiErr != nil {
-				cmd.Println(fmt.Errorf("Error fetching parsers: %s", apiErr))
-				os.Exit(1)
-			}
+			exitOnError(cmd, apiErr, "Error fetching parsers")
 
 			yamlData, yamlErr := yaml.Marshal(&parser)
-			if yamlErr != nil {
-				cmd.Println(fmt.Errorf("Failed to serialize the parser: %s", yamlErr))
-				os.Exit(1)
-			}
+			exitOnError(cmd, yamlErr, "Failed to serialize the parser")
 			outFilePath := outputName + ".yaml"
 
 			writeErr := ioutil.WriteFile(outFilePath, yamlData, 0644)
-			if writeErr != nil {
-				cmd.Println(fmt.Errorf("Error saving the parser file: %s", writeErr))
-				os.Exit(1)
-			}
+			exitOnError(cmd, writeErr, "Error saving the parser file")
 		},
 	}
 
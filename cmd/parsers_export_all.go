@@ -0,0 +1,87 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newParsersExportAllCmd() *cobra.Command {
+	var outputDir string
+	var includeBuiltIn bool
+	var bulk bulkFlags
+
+	cmd := cobra.Command{
+		Use:   "export-all [flags] <repo>",
+		Short: "Export all parsers in <repo> to a directory.",
+		Long: `Writes each parser in the repository to a YAML file in the output directory,
+named after the parser. Existing files are overwritten, so exporting again
+updates the files in place, e.g. for backups kept in version control. The
+directory can be installed with 'humioctl parsers sync'.
+
+  $ humioctl parsers export-all ops -o ./parsers`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			repo := args[0]
+
+			client := bulk.client(cmd)
+
+			items, err := client.Parsers().List(repo)
+			exitOnError(cmd, err, "Error fetching parsers")
+
+			var names []string
+			for _, item := range items {
+				if item.IsBuiltIn && !includeBuiltIn {
+					continue
+				}
+				names = append(names, item.Name)
+			}
+
+			parsers := make([]*api.Parser, len(names))
+			errs := bulk.run(len(names), func(i int) error {
+				var err error
+				parsers[i], err = client.Parsers().Get(repo, names[i])
+				return err
+			})
+
+			assets := map[string][]byte{}
+			for i, name := range names {
+				exitOnError(cmd, errs[i], fmt.Sprintf("Error fetching parser %s", name))
+
+				yamlData, err := yaml.Marshal(parsers[i])
+				exitOnError(cmd, err, "Failed to serialize the parser")
+				assets[name] = yamlData
+			}
+
+			files, err := writeExportFiles(outputDir, assets)
+			exitOnError(cmd, err, "Error saving the parser files")
+
+			for _, file := range files {
+				cmd.Println(file)
+			}
+			cmd.Println(fmt.Sprintf("Exported %d parsers to %s", len(files), outputDir))
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output", "o", ".", "The directory to write the parser files to.")
+	cmd.Flags().BoolVar(&includeBuiltIn, "include-built-in", false, "Also export the built-in parsers.")
+	bulk.register(&cmd)
+
+	return &cmd
+}
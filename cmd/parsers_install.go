@@ -15,10 +15,13 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
+	"regexp"
+	"strings"
 
 	"github.com/humio/cli/api"
 	"github.com/spf13/cobra"
@@ -29,7 +32,8 @@ func newParsersInstallCmd() *cobra.Command {
 	var content []byte
 	var readErr error
 	var force bool
-	var filePath, url, name string
+	var vars templateVarFlags
+	var filePath, url, name, checksum string
 
 	cmd := cobra.Command{
 		Use:   "install [flags] <repo> <parser>",
@@ -57,24 +61,46 @@ install from a local file or a URL, e.g.
 
   $ humioctl parsers install --file=./parser.yaml
 
+Files on GitHub can be given by the shorthand <org>/<repo>/<path>@<ref>,
+where <ref> is a branch, tag or commit and defaults to the default branch.
+Use --sha256 to only install the parser if the file has the expected
+checksum, so exactly the reviewed version is installed:
+
+  $ humioctl parsers install web --url=acme/humio-parsers/web.yaml@v1.2.0 --sha256=9f86d0...
+
 By default 'install' will not override existing parsers with the same name.
 Use the --force flag to update existing parsers with conflicting names.
+
+Use --var and --var-file to install the same parser in different environments.
+The file is then rendered as a Go template, e.g. with {{ .repo }} replaced
+by the value of --var repo=web-prod:
+
+  $ humioctl parsers install web --file=./parser.yaml --var-file=prod.yaml --var threshold=100
 `,
 		Run: func(cmd *cobra.Command, args []string) {
+			if checksum != "" {
+				if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != sha256.Size {
+					exitOnError(cmd, usageError("--sha256 must be a SHA-256 checksum of 64 hexadecimal characters"), "")
+				}
+			}
+
 			// Check that we got the right number of argument
 			// if we only got <repo> you must supply --file or --url.
 			if l := len(args); l == 1 {
 				if filePath != "" {
 					content, readErr = getParserFromFile(filePath)
 				} else if url != "" {
-					content, readErr = getURLParser(url)
+					var parserURL string
+					parserURL, readErr = resolveParserURL(url)
+					if readErr != nil {
+						exitOnError(cmd, usageError(readErr.Error()), "")
+					}
+					content, readErr = getURLParser(parserURL)
 				} else {
-					cmd.Println(fmt.Errorf("if you only provide repo you must specify --file or --url"))
-					os.Exit(1)
+					exitOnError(cmd, usageError("if you only provide repo you must specify --file or --url"), "")
 				}
 			} else if l := len(args); l != 2 {
-				cmd.Println(fmt.Errorf("This command takes one or two arguments: <repo> [parser]"))
-				os.Exit(1)
+				exitOnError(cmd, usageError("This command takes one or two arguments: <repo> [parser]"), "")
 			} else {
 				parserName := args[1]
 				content, readErr = getGithubParser(parserName)
@@ -82,6 +108,13 @@ Use the --force flag to update existing parsers with conflicting names.
 
 			exitOnError(cmd, readErr, "Failed to load the parser")
 
+			if checksum != "" {
+				exitOnError(cmd, verifySHA256(content, checksum), "Refusing to install the parser")
+			}
+
+			content, readErr = vars.render("parser", content)
+			exitOnError(cmd, readErr, "Failed to render the parser")
+
 			parser := api.Parser{}
 			yamlErr := yaml.Unmarshal(content, &parser)
 			exitOnError(cmd, yamlErr, "The parser's format was invalid")
@@ -102,7 +135,9 @@ Use the --force flag to update existing parsers with conflicting names.
 
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overrides any parser with the same name. This can be used for updating parser that are already installed. (See --name)")
 	cmd.Flags().StringVar(&filePath, "file", "", "The local file path to the parser to install.")
-	cmd.Flags().StringVar(&url, "url", "", "A URL to fetch the parser file from.")
+	cmd.Flags().StringVar(&url, "url", "", "A URL, or GitHub shorthand <org>/<repo>/<path>@<ref>, to fetch the parser file from.")
+	cmd.Flags().StringVar(&checksum, "sha256", "", "The expected SHA-256 checksum of the parser file, in hex. The parser is not installed if it does not match.")
+	vars.register(&cmd)
 	cmd.Flags().StringVarP(&name, "name", "n", "", "Install the parser under a specific name, ignoreing the `name` attribute in the parser file.")
 
 	return &cmd
@@ -125,5 +160,42 @@ func getURLParser(url string) ([]byte, error) {
 	}
 
 	defer response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		return nil, fmt.Errorf("could not fetch %s: %s", url, response.Status)
+	}
+
 	return ioutil.ReadAll(response.Body)
 }
+
+// githubShorthandPattern matches GitHub shorthands of files, <org>/<repo>/<path>@<ref>.
+var githubShorthandPattern = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)/([^@]+)(?:@([^@]+))?$`)
+
+// resolveParserURL returns the URL of a parser file, expanding a GitHub
+// shorthand to the URL of the raw file.
+func resolveParserURL(s string) (string, error) {
+	if strings.Contains(s, "://") {
+		return s, nil
+	}
+
+	m := githubShorthandPattern.FindStringSubmatch(s)
+	if m == nil {
+		return "", fmt.Errorf("invalid --url %q, must be a URL or a GitHub shorthand <org>/<repo>/<path>@<ref>", s)
+	}
+
+	ref := m[4]
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", m[1], m[2], ref, m[3]), nil
+}
+
+// verifySHA256 returns an error if the SHA-256 checksum of content is not
+// the expected one, given in hex.
+func verifySHA256(content []byte, expected string) error {
+	sum := sha256.Sum256(content)
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("the SHA-256 checksum of the file is %s, expected %s", actual, expected)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type parserChange struct {
+	action  string
+	name    string
+	parser  *api.Parser
+	details []string
+}
+
+func newParsersSyncCmd() *cobra.Command {
+	var repoName string
+	var prune, dryRun bool
+	var vars templateVarFlags
+	var bulk bulkFlags
+	var plan changePlanFlags
+
+	cmd := cobra.Command{
+		Use:   "sync [flags] <dir>",
+		Short: "Make the parsers of a repository match a directory of parser files.",
+		Long: `Compares the parser files (*.yaml or *.yml) in <dir> with the parsers
+installed in the repository, prints a plan of the changes and applies it:
+
+  +  parsers that are not installed are created
+  ~  parsers whose script, tag fields or test data differ are updated
+  -  parsers that have no file are removed, if --prune is given
+
+The parser name is read from the file, or taken from the file name if the file
+has none. Built-in parsers are never removed.
+
+  $ humioctl parsers sync ./parsers --repo ops --prune --dry-run
+
+Use --dry-run to only print the plan. The files can be templates, see
+'humioctl parsers install --help' for --var and --var-file.
+
+To have the plan approved before applying it, e.g. in a pipeline, write it as
+JSON with --plan-out and apply it in a later run with --apply-plan. The later
+run fails without changing anything if the changes are no longer the ones in
+the plan file:
+
+  $ humioctl parsers sync ./parsers --repo ops --plan-out plan.json
+  $ humioctl parsers sync ./parsers --repo ops --apply-plan plan.json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if repoName == "" {
+				return fmt.Errorf("the --repo flag is required")
+			}
+			if err := plan.validate(); err != nil {
+				return err
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			local, err := readParserDir(args[0], &vars)
+			exitOnError(cmd, err, "Failed to load the parsers")
+
+			client := bulk.client(cmd)
+
+			installed, err := client.Parsers().List(repoName)
+			exitOnError(cmd, err, "Error fetching parsers")
+
+			changes, err := planParserSync(client, repoName, local, installed, prune)
+			exitOnError(cmd, err, "Error fetching parsers")
+
+			if len(changes) == 0 {
+				cmd.Println("No changes. The parsers are up to date.")
+				plan.handle(cmd, parserSyncPlan(client, repoName, changes))
+				return
+			}
+
+			var created, updated, removed int
+			for _, change := range changes {
+				switch change.action {
+				case "+":
+					created++
+				case "~":
+					updated++
+				case "-":
+					removed++
+				}
+				line := fmt.Sprintf("%s %s", change.action, change.name)
+				if len(change.details) > 0 {
+					line += fmt.Sprintf(" (%s)", strings.Join(change.details, ", "))
+				}
+				cmd.Println(line)
+			}
+			cmd.Println()
+			cmd.Println(fmt.Sprintf("Plan: %d to create, %d to update, %d to remove.", created, updated, removed))
+
+			if !plan.handle(cmd, parserSyncPlan(client, repoName, changes)) || dryRun {
+				return
+			}
+
+			errs := bulk.run(len(changes), func(i int) error {
+				change := changes[i]
+				switch change.action {
+				case "+":
+					return client.Parsers().Add(repoName, change.parser, false)
+				case "~":
+					return client.Parsers().Add(repoName, change.parser, true)
+				case "-":
+					return client.Parsers().Remove(repoName, change.name)
+				}
+				return nil
+			})
+			for i, err := range errs {
+				exitOnError(cmd, err, fmt.Sprintf("Error syncing parser %s", changes[i].name))
+			}
+
+			cmd.Println("Sync complete.")
+		},
+	}
+
+	cmd.Flags().StringVarP(&repoName, "repo", "r", "", "The repository to sync the parsers to.")
+	cmd.Flags().BoolVar(&prune, "prune", false, "Remove installed parsers that have no file in the directory.")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the plan without applying it.")
+	vars.register(&cmd)
+	bulk.register(&cmd)
+	plan.register(&cmd)
+
+	return &cmd
+}
+
+// parserSyncPlan returns the plan of the changes, as written by --plan-out.
+func parserSyncPlan(client *api.Client, repoName string, changes []parserChange) changePlan {
+	plan := changePlan{Command: "parsers sync", Address: client.Address(), Target: repoName}
+	for _, change := range changes {
+		planned := plannedChange{Action: changeActions[change.action], Kind: "parser", Name: change.name, Details: change.details}
+		if change.parser != nil {
+			planned.Digest = changeDigest(change.parser)
+		}
+		plan.Changes = append(plan.Changes, planned)
+	}
+	return plan
+}
+
+// readParserDir reads the parser files in dir, keyed by parser name. The
+// files are rendered as templates with the variables, if any.
+func readParserDir(dir string, vars *templateVarFlags) (map[string]*api.Parser, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	parsers := map[string]*api.Parser{}
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if file.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		path := filepath.Join(dir, file.Name())
+		content, err := getParserFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		content, err = vars.render(file.Name(), content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		parser := api.Parser{}
+		if err := yaml.Unmarshal(content, &parser); err != nil {
+			return nil, fmt.Errorf("the format of %s was invalid: %w", path, err)
+		}
+		if parser.Name == "" {
+			parser.Name = strings.TrimSuffix(file.Name(), ext)
+		}
+
+		if _, ok := parsers[parser.Name]; ok {
+			return nil, fmt.Errorf("more than one file defines the parser %s", parser.Name)
+		}
+		parsers[parser.Name] = &parser
+	}
+
+	return parsers, nil
+}
+
+// planParserSync returns the changes needed to make the installed parsers
+// match the local ones, ordered by parser name.
+func planParserSync(client *api.Client, repoName string, local map[string]*api.Parser, installed []api.ParserListItem, prune bool) ([]parserChange, error) {
+	var changes []parserChange
+
+	isInstalled := map[string]bool{}
+	for _, item := range installed {
+		isInstalled[item.Name] = true
+
+		if _, ok := local[item.Name]; !ok && prune && !item.IsBuiltIn {
+			changes = append(changes, parserChange{action: "-", name: item.Name})
+		}
+	}
+
+	for name, parser := range local {
+		if !isInstalled[name] {
+			changes = append(changes, parserChange{action: "+", name: name, parser: parser})
+			continue
+		}
+
+		current, err := client.Parsers().Get(repoName, name)
+		if err != nil {
+			return nil, err
+		}
+		if details := parserDifferences(current, parser); len(details) > 0 {
+			changes = append(changes, parserChange{action: "~", name: name, parser: parser, details: details})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].name < changes[j].name })
+	return changes, nil
+}
+
+func parserDifferences(current, desired *api.Parser) []string {
+	var details []string
+
+	if strings.TrimSpace(current.Script) != strings.TrimSpace(desired.Script) {
+		details = append(details, "script")
+	}
+	if !sameStrings(current.TagFields, desired.TagFields) {
+		details = append(details, "tag fields")
+	}
+	if !reflect.DeepEqual(nonEmpty(current.TestData()), nonEmpty(desired.TestData())) {
+		details = append(details, "test data")
+	}
+
+	return details
+}
+
+// sameStrings reports whether a and b contain the same strings in any order.
+func sameStrings(a, b []string) bool {
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return reflect.DeepEqual(nonEmpty(a), nonEmpty(b))
+}
+
+func nonEmpty(vs []string) []string {
+	result := []string{}
+	for _, v := range vs {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newParsersTestCmd() *cobra.Command {
+	var repoName string
+
+	cmd := cobra.Command{
+		Use:   "test [flags] <parser-file>",
+		Short: "Run the test cases of a parser file.",
+		Long: `Runs the parser in a parser file on the input of each of its test cases and
+checks that the parsed events have the expected field values, e.g.
+
+  tests:
+    - input: '2020-01-01T10:00:00Z GET /index.html 200'
+      output:
+        method: GET
+        status: "200"
+
+Only the fields listed in the output of a test case are checked. A test case
+fails if the event could not be parsed, unless @error is listed as expected.
+
+  $ humioctl parsers test --repo sandbox ./parser.yaml
+
+The parser is not installed. Exits with status 1 if any test case fails.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if repoName == "" {
+				return fmt.Errorf("the --repo flag is required")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			content, err := getParserFromFile(args[0])
+			exitOnError(cmd, err, "Failed to load the parser")
+
+			parser := api.Parser{}
+			err = yaml.Unmarshal(content, &parser)
+			exitOnError(cmd, err, "The parser's format was invalid")
+
+			if len(parser.Tests) == 0 {
+				exitOnError(cmd, fmt.Errorf("the parser file has no test cases"), "")
+			}
+
+			client := NewApiClient(cmd)
+
+			events, err := client.Parsers().Test(repoName, &parser)
+			exitOnError(cmd, err, "error testing parser")
+
+			if len(events) != len(parser.Tests) {
+				exitOnError(cmd, fmt.Errorf("expected %d parsed events, got %d", len(parser.Tests), len(events)), "error testing parser")
+			}
+
+			var failed int
+			for i, test := range parser.Tests {
+				failures := checkParserTestCase(test, events[i])
+				if len(failures) == 0 {
+					cmd.Println(fmt.Sprintf("PASS  case %d", i+1))
+					continue
+				}
+
+				failed++
+				cmd.Println(fmt.Sprintf("FAIL  case %d: %s", i+1, test.Input))
+				for _, f := range failures {
+					cmd.Println("        " + f)
+				}
+			}
+
+			cmd.Println(fmt.Sprintf("Passed: %d, Failed: %d", len(parser.Tests)-failed, failed))
+
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&repoName, "repo", "r", "", "The repository to run the parser in.")
+
+	return &cmd
+}
+
+// checkParserTestCase compares the parsed event with the expected output of
+// the test case and returns a description of each difference.
+func checkParserTestCase(test api.ParserTestCase, event map[string]string) []string {
+	var failures []string
+
+	if msg, ok := event["@error_msg"]; ok {
+		if _, expected := test.Output["@error"]; !expected {
+			failures = append(failures, fmt.Sprintf("parse error: %s", msg))
+		}
+	}
+
+	fields := make([]string, 0, len(test.Output))
+	for field := range test.Output {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		expected := test.Output[field]
+		actual, ok := event[field]
+		switch {
+		case !ok:
+			failures = append(failures, fmt.Sprintf("%s: expected %q, but the field is missing", field, expected))
+		case actual != expected:
+			failures = append(failures, fmt.Sprintf("%s: expected %q, got %q", field, expected, actual))
+		}
+	}
+
+	return failures
+}
@@ -12,6 +12,11 @@ type login struct {
 	address  string
 	token    string
 	username string
+	// tokens are the named tokens of the profile, as saved in the config.
+	tokens map[string]string
+	// loginMethod is how the token is asked for when the profile is
+	// refreshed, either browser or empty to only ask for it.
+	loginMethod string
 }
 
 // usersCmd represents the users command
@@ -31,6 +36,18 @@ Adding a profile:
 You can change the default profile using:
 
   $ humioctl profiles set-default <name>
+
+Profiles can be changed with 'edit', renamed with 'rename' and removed
+with 'delete'. Use 'refresh' to replace an expired token of a profile.
+
+API tokens are stored in the OS keychain (macOS Keychain, Windows Credential
+Manager or the Secret Service through libsecret's secret-tool) when one is
+available, and only a reference is saved in the config file. Use --no-keychain
+to save the token in the config file instead.
+
+A profile can also hold named tokens, e.g. a token with only ingest
+permissions, which are selected with --token-name instead of the token of the
+profile. See 'humioctl profiles tokens --help'.
     `,
 		Args: cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
@@ -58,6 +75,10 @@ You can change the default profile using:
 	cmd.AddCommand(newProfilesAddCmd())
 	cmd.AddCommand(newProfilesRemoveCmd())
 	cmd.AddCommand(newProfilesSetDefaultCmd())
+	cmd.AddCommand(newProfilesEditCmd())
+	cmd.AddCommand(newProfilesRenameCmd())
+	cmd.AddCommand(newProfilesRefreshCmd())
+	cmd.AddCommand(newProfilesTokensCmd())
 
 	return cmd
 }
@@ -2,10 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"net/url"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/humio/cli/api"
 	"github.com/humio/cli/prompt"
@@ -28,9 +25,10 @@ func newProfilesAddCmd() *cobra.Command {
 			profile, profileErr := collectProfileInfo(cmd)
 			exitOnError(cmd, profileErr, "failed to collect profile info")
 
-			addAccount(out, profileName, profile)
-
-			saveErr := saveConfig()
+			saveErr := updateConfig(func() error {
+				addAccount(out, profileName, profile)
+				return nil
+			})
 			exitOnError(cmd, saveErr, "error saving config")
 		},
 	}
@@ -38,34 +36,35 @@ func newProfilesAddCmd() *cobra.Command {
 	return cmd
 }
 
-func saveConfig() error {
-	configFile := viper.ConfigFileUsed()
-
-	if writeErr := viper.WriteConfig(); writeErr != nil {
-		if os.IsNotExist(writeErr) {
-			dirName := filepath.Dir(configFile)
-			if dirErr := os.MkdirAll(dirName, 0700); dirErr != nil {
-				return fmt.Errorf("error creating config directory %s: %s", dirName, dirErr)
-			}
-			if configFileErr := viper.WriteConfigAs(configFile); configFileErr != nil {
-				return fmt.Errorf("error writing config file: %s", configFileErr)
-			}
-		}
-	}
+// addAccount saves the profile and returns the token as it was saved in the
+// config, which is a reference to the OS keychain if the token is stored there.
+func addAccount(out *prompt.Prompt, newName string, profile *login) string {
+	profiles := viper.GetStringMap("profiles")
 
-	return nil
-}
+	token := storeToken(newName, profile.token)
 
-func addAccount(out *prompt.Prompt, newName string, profile *login) {
-	profiles := viper.GetStringMap("profiles")
+	// Keep the named tokens when the profile is replaced, e.g. by login.
+	tokens := profile.tokens
+	if tokens == nil {
+		tokens = getMapTokens(profiles[newName])
+	}
 
-	profiles[newName] = map[string]string{
+	data := map[string]interface{}{
 		"address":  profile.address,
-		"token":    profile.token,
+		"token":    token,
 		"username": profile.username,
 	}
+	if len(tokens) > 0 {
+		data["tokens"] = tokens
+	}
+	if profile.loginMethod != "" {
+		data["login-method"] = profile.loginMethod
+	}
+	profiles[newName] = data
 
 	viper.Set("profiles", profiles)
+
+	return token
 }
 
 func mapToLogin(data interface{}) *login {
@@ -73,7 +72,35 @@ func mapToLogin(data interface{}) *login {
 		address:  getMapKey(data, "address"),
 		username: getMapKey(data, "username"),
 		token:    getMapKey(data, "token"),
+		tokens:   getMapTokens(data),
+
+		loginMethod: getMapKey(data, "login-method"),
+	}
+}
+
+// getMapTokens returns the named tokens of a profile from the config.
+func getMapTokens(data interface{}) map[string]string {
+	tokens := map[string]string{}
+
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return tokens
+	}
+
+	switch t := m["tokens"].(type) {
+	case map[string]string:
+		for name, token := range t {
+			tokens[name] = token
+		}
+	case map[string]interface{}:
+		for name, token := range t {
+			if s, ok := token.(string); ok {
+				tokens[name] = s
+			}
+		}
 	}
+
+	return tokens
 }
 
 func getMapKey(data interface{}, key string) string {
@@ -111,15 +138,43 @@ func collectProfileInfo(cmd *cobra.Command) (*login, error) {
 
 		// Make sure it is a valid URL and that
 		// we always end in a slash.
-		_, urlErr := url.ParseRequestURI(addr)
+		var urlErr error
+		addr, urlErr = normalizeAddress(addr)
 
 		if urlErr != nil {
-			out.Error("The valus must be a valid URL.")
+			out.Error("The value must be a valid URL, e.g. https://humio.example.com/")
 			continue
 		}
 
-		if !strings.HasSuffix(addr, "/") {
-			addr = addr + "/"
+		out.Output("")
+		cmd.Print("==> Looking for Humio...")
+
+		probe, suggestion := discoverAddress(addr)
+
+		switch {
+		case probe.version != "" && suggestion == nil:
+			cmd.Println(prompt.Colorize("[[green]Ok[reset]]"))
+		case probe.version != "":
+			cmd.Println(prompt.Colorize("[[green]Ok[reset]]"))
+			out.Output()
+			if out.Confirm(fmt.Sprintf("Humio also responds at %s, which keeps your API token encrypted. Use that address instead?", suggestion.address)) {
+				addr = suggestion.address
+			}
+		case suggestion != nil:
+			cmd.Println(prompt.Colorize("[[yellow]Not found[reset]]"))
+			out.Output()
+			out.Description(fmt.Sprintf("Humio did not respond at %s: %s.", addr, probe.problem))
+			out.Description(fmt.Sprintf("Humio %s responds at %s.", suggestion.version, suggestion.address))
+			out.Output()
+			if !out.Confirm("Use that address instead?") {
+				continue
+			}
+			addr = suggestion.address
+		default:
+			cmd.Println(prompt.Colorize("[[red]Failed[reset]]"))
+			out.Output()
+			out.Error(fmt.Sprintf("Humio did not respond at %s: %s.\nIs the address correct and reachable?", addr, probe.problem))
+			continue
 		}
 
 		clientConfig := api.DefaultConfig()
@@ -0,0 +1,100 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/humio/cli/prompt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newProfilesEditCmd() *cobra.Command {
+	var newAddress, newToken stringPtrFlag
+
+	cmd := &cobra.Command{
+		Use:   "edit <profile-name> [flags]",
+		Short: "Change the address or token of a configuration profile",
+		Long: `Changes the address or token of a configuration profile. If neither
+--address nor --token is given you will be asked for both, as when adding
+a profile.
+
+If the profile is the default profile, the default is updated as well.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			profileName := args[0]
+
+			out := prompt.NewPrompt(cmd.OutOrStdout())
+
+			profiles := viper.GetStringMap("profiles")
+			if profiles[profileName] == nil {
+				exitOnError(cmd, fmt.Errorf("unknown profile %s", profileName), "error editing profile")
+			}
+
+			profile := mapToLogin(profiles[profileName])
+			isDefault := viper.GetString("address") == profile.address && viper.GetString("token") == profile.token
+
+			var err error
+			profile.token, err = resolveToken(profile.token)
+			exitOnError(cmd, err, "error loading profile")
+
+			if newAddress.value == nil && newToken.value == nil {
+				profile, err = collectProfileInfo(cmd)
+				exitOnError(cmd, err, "failed to collect profile info")
+			} else {
+				if newAddress.value != nil {
+					profile.address = *newAddress.value
+					if !strings.HasSuffix(profile.address, "/") {
+						profile.address += "/"
+					}
+				}
+
+				if newToken.value != nil {
+					profile.token = *newToken.value
+				}
+
+				config := api.DefaultConfig()
+				config.Address = profile.address
+				config.Token = profile.token
+				client, err := api.NewClient(config)
+				exitOnError(cmd, err, "error initializing the http client")
+
+				profile.username, err = client.Viewer().Username()
+				exitOnError(cmd, err, "error verifying the profile")
+			}
+
+			saveErr := updateConfig(func() error {
+				storedToken := addAccount(out, profileName, profile)
+
+				if isDefault {
+					viper.Set("address", profile.address)
+					viper.Set("token", storedToken)
+				}
+				return nil
+			})
+			exitOnError(cmd, saveErr, "error saving config")
+
+			out.Output("Profile updated: ", profileName)
+		},
+	}
+
+	cmd.Flags().Var(&newAddress, "address", "The new address of the Humio server.")
+	cmd.Flags().Var(&newToken, "token", "The new API token.")
+
+	return cmd
+}
@@ -0,0 +1,128 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/api"
+	"github.com/humio/cli/prompt"
+	"github.com/skratchdot/open-golang/open"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newProfilesRefreshCmd() *cobra.Command {
+	var newToken stringPtrFlag
+	var browser, noBrowser bool
+
+	cmd := &cobra.Command{
+		Use:     "refresh [flags] <profile-name>",
+		Aliases: []string{"login"},
+		Short:   "Log in to the cluster of a profile again and replace its token",
+		Long: `Asks for a new API token for the profile, e.g. when the token has expired
+or been revoked, and replaces the token of the profile with it. The address,
+named tokens and other profiles are left unchanged.
+
+The new token is verified against the cluster of the profile, and nothing is
+saved if it is invalid. If the profile is the default profile, the default
+is updated as well.
+
+With --browser the account page of the cluster, where API tokens are
+found, is opened in the browser before asking for the token. The choice is
+saved in the profile, so later refreshes open the browser too, until
+--no-browser is given:
+
+  $ humioctl profiles refresh prod --browser
+
+In scripts, give the token with --token instead.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return err
+			}
+			if browser && noBrowser {
+				return fmt.Errorf("--browser cannot be used together with --no-browser")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			profileName := args[0]
+
+			out := prompt.NewPrompt(cmd.OutOrStdout())
+
+			profiles := viper.GetStringMap("profiles")
+			if profiles[profileName] == nil {
+				exitOnError(cmd, fmt.Errorf("unknown profile %s", profileName), "error refreshing profile")
+			}
+
+			profile := mapToLogin(profiles[profileName])
+			isDefault := viper.GetString("address") == profile.address && viper.GetString("token") == profile.token
+
+			switch {
+			case browser:
+				profile.loginMethod = "browser"
+			case noBrowser:
+				profile.loginMethod = ""
+			}
+
+			if newToken.value != nil {
+				profile.token = *newToken.value
+			} else {
+				out.Info(fmt.Sprintf("Paste in a new API Token for %s", profile.address))
+				if profile.loginMethod == "browser" {
+					accountPage := profile.address + "settings"
+					if err := open.Start(accountPage); err != nil {
+						out.Description(fmt.Sprintf("Could not open the browser, visit %s to find your API token.", accountPage))
+					} else {
+						out.Description(fmt.Sprintf("The account page was opened in your browser. If not, visit %s", accountPage))
+					}
+				}
+				out.Output()
+
+				var err error
+				profile.token, err = out.AskSecret("API Token")
+				exitOnError(cmd, err, "error reading token")
+			}
+
+			config := api.DefaultConfig()
+			config.Address = profile.address
+			config.Token = profile.token
+			client, err := api.NewClient(config)
+			exitOnError(cmd, err, "error initializing the http client")
+
+			profile.username, err = client.Viewer().Username()
+			exitOnError(cmd, err, "authentication failed, the token is invalid")
+
+			saveErr := updateConfig(func() error {
+				storedToken := addAccount(out, profileName, profile)
+
+				if isDefault {
+					viper.Set("address", profile.address)
+					viper.Set("token", storedToken)
+				}
+				return nil
+			})
+			exitOnError(cmd, saveErr, "error saving config")
+
+			cmd.Println(prompt.Colorize(fmt.Sprintf("==> Refreshed profile '%s', logged in as [purple]%s[reset]", profileName, profile.username)))
+		},
+	}
+
+	cmd.Flags().Var(&newToken, "token", "The new API token. Defaults to asking for it.")
+	cmd.Flags().BoolVar(&browser, "browser", false, "Open the account page in the browser before asking for the token, now and in later refreshes of the profile.")
+	cmd.Flags().BoolVar(&noBrowser, "no-browser", false, "Stop opening the browser when refreshing the profile.")
+
+	return cmd
+}
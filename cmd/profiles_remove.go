@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/humio/cli/prompt"
@@ -11,26 +12,45 @@ import (
 // usersCmd represents the users command
 func newProfilesRemoveCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "remove <profile-name> [flags]",
-		Short: "Remove a configuration profile",
-		Args:  cobra.ExactArgs(1),
+		Use:     "remove <profile-name> [flags]",
+		Aliases: []string{"delete"},
+		Short:   "Remove a configuration profile",
+		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			profileName := args[0]
 
 			out := prompt.NewPrompt(cmd.OutOrStdout())
 
-			profiles := viper.GetStringMap("profiles")
+			var found bool
+			saveErr := updateConfig(func() error {
+				profiles := viper.GetStringMap("profiles")
+
+				if profiles[profileName] == nil {
+					return nil
+				}
+				found = true
+
+				profile := mapToLogin(profiles[profileName])
+				if err := removeStoredToken(profile.token); err != nil {
+					return fmt.Errorf("error removing token from the keychain: %s", err)
+				}
+				for _, namedToken := range profile.tokens {
+					if err := removeStoredToken(namedToken); err != nil {
+						return fmt.Errorf("error removing token from the keychain: %s", err)
+					}
+				}
+
+				delete(profiles, profileName)
+				viper.Set("profiles", profiles)
+				return nil
+			})
+			exitOnError(cmd, saveErr, "error saving config")
 
-			if profiles[profileName] == nil {
+			if !found {
 				cmd.Println("profile not found")
 				os.Exit(0)
 			}
 
-			delete(profiles, profileName)
-
-			saveErr := saveConfig()
-			exitOnError(cmd, saveErr, "error saving config")
-
 			out.Output("Profile removed: ", profileName)
 		},
 	}
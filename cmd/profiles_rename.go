@@ -0,0 +1,103 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/prompt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newProfilesRenameCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename <profile-name> <new-name>",
+		Short: "Rename a configuration profile",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			profileName := args[0]
+			newName := args[1]
+
+			out := prompt.NewPrompt(cmd.OutOrStdout())
+
+			err := updateConfig(func() error {
+				return renameProfile(out, profileName, newName)
+			})
+			exitOnError(cmd, err, "error renaming profile")
+
+			out.Output(fmt.Sprintf("Profile renamed: %s -> %s", profileName, newName))
+		},
+	}
+
+	return cmd
+}
+
+// renameProfile renames a profile in the settings, moving its tokens in the
+// keychain to the new name.
+func renameProfile(out *prompt.Prompt, profileName, newName string) error {
+	profiles := viper.GetStringMap("profiles")
+
+	if profiles[profileName] == nil {
+		return fmt.Errorf("unknown profile %s", profileName)
+	}
+
+	if profiles[newName] != nil {
+		return fmt.Errorf("profile %s already exists", newName)
+	}
+
+	profile := mapToLogin(profiles[profileName])
+	oldToken := profile.token
+
+	// Tokens in the keychain are stored by profile name, so move it to the new name.
+	var err error
+	profile.token, err = resolveToken(oldToken)
+	if err != nil {
+		return fmt.Errorf("error loading profile: %s", err)
+	}
+
+	oldTokens := profile.tokens
+	profile.tokens = map[string]string{}
+	for name, oldNamedToken := range oldTokens {
+		namedToken, err := resolveToken(oldNamedToken)
+		if err != nil {
+			return fmt.Errorf("error loading profile: %s", err)
+		}
+		profile.tokens[name] = storeToken(namedTokenAccount(newName, name), namedToken)
+	}
+
+	delete(profiles, profileName)
+	viper.Set("profiles", profiles)
+	storedToken := addAccount(out, newName, profile)
+
+	if viper.GetString("token") == oldToken {
+		viper.Set("token", storedToken)
+	}
+
+	if storedToken != oldToken {
+		if err := removeStoredToken(oldToken); err != nil {
+			return fmt.Errorf("error removing token from the keychain: %s", err)
+		}
+	}
+	for name, oldNamedToken := range oldTokens {
+		if profile.tokens[name] != oldNamedToken {
+			if err := removeStoredToken(oldNamedToken); err != nil {
+				return fmt.Errorf("error removing token from the keychain: %s", err)
+			}
+		}
+	}
+
+	return nil
+}
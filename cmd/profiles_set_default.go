@@ -17,12 +17,15 @@ func newProfilesSetDefaultCmd() *cobra.Command {
 			profileName := args[0]
 			out := prompt.NewPrompt(cmd.OutOrStdout())
 
-			profile, loadErr := loadProfile(profileName)
-			exitOnError(cmd, loadErr, "profile not found")
-			viper.Set("address", profile.address)
-			viper.Set("token", profile.token)
-
-			saveErr := saveConfig()
+			saveErr := updateConfig(func() error {
+				profile, loadErr := loadProfile(profileName)
+				if loadErr != nil {
+					return loadErr
+				}
+				viper.Set("address", profile.address)
+				viper.Set("token", profile.token)
+				return nil
+			})
 			exitOnError(cmd, saveErr, "error saving config")
 
 			out.Info(fmt.Sprintf("Default profile set to '%s'", profileName))
@@ -0,0 +1,103 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newProfilesTokensCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Manage the named tokens of configuration profiles",
+		Long: `Besides its own token, a profile can hold named tokens, e.g. 'ingest' or
+'readonly' for tokens of users or views with fewer permissions. Use
+--token-name, or the HUMIO_TOKEN_NAME environment variable, to run a command
+with one of them instead of the token of the profile, so e.g. ingest jobs
+don't have to run with admin credentials:
+
+  $ humioctl profiles tokens set prod ingest
+  $ humioctl ingest web --profile=prod --token-name=ingest --tail=/var/log/access.log
+
+Without --profile the named token is taken from the default profile. Named
+tokens are stored in the OS keychain like the token of the profile.`,
+	}
+
+	cmd.AddCommand(newProfilesTokensListCmd())
+	cmd.AddCommand(newProfilesTokensSetCmd())
+	cmd.AddCommand(newProfilesTokensRemoveCmd())
+
+	return cmd
+}
+
+// namedTokenAccount returns the name the named token of a profile is stored
+// by in the keychain.
+func namedTokenAccount(profileName, tokenName string) string {
+	return profileName + "/" + tokenName
+}
+
+// loadNamedToken returns the named token of the profile, as saved in the
+// config, or of the default profile if profileName is empty.
+func loadNamedToken(profileName, tokenName string) (string, error) {
+	profiles := viper.GetStringMap("profiles")
+
+	if profileName == "" {
+		profileName = defaultProfileName(profiles)
+		if profileName == "" {
+			return "", fmt.Errorf("there is no default profile to take the token %q from, use --profile", tokenName)
+		}
+	}
+
+	data := profiles[profileName]
+	if data == nil {
+		return "", fmt.Errorf("unknown profile %s", profileName)
+	}
+
+	tokens := getMapTokens(data)
+	token, ok := tokens[tokenName]
+	if !ok {
+		return "", fmt.Errorf("the profile %s has no token named %q, add it with 'humioctl profiles tokens set %s %s'", profileName, tokenName, profileName, tokenName)
+	}
+
+	return token, nil
+}
+
+// defaultProfileName returns the name of the profile that is the default,
+// i.e. has the default address and token, or "default" if no profile does.
+func defaultProfileName(profiles map[string]interface{}) string {
+	for name, data := range profiles {
+		profile := mapToLogin(data)
+		if isCurrentAccount(profile.address, profile.token) {
+			return name
+		}
+	}
+
+	if profiles["default"] != nil {
+		return "default"
+	}
+	return ""
+}
+
+// validateTokenName returns an error if the name cannot be used for a token.
+func validateTokenName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/ ") {
+		return fmt.Errorf("invalid token name %q, must be non-empty and not contain slashes or spaces", name)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newProfilesTokensListCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "list [flags] <profile-name>",
+		Short: "List the named tokens of a configuration profile.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			profileName := args[0]
+
+			profiles := viper.GetStringMap("profiles")
+			if profiles[profileName] == nil {
+				exitOnError(cmd, fmt.Errorf("unknown profile %s", profileName), "error listing tokens")
+			}
+
+			tokens := getMapTokens(profiles[profileName])
+			if len(tokens) == 0 {
+				cmd.Println("The profile " + profileName + " has no named tokens")
+				return
+			}
+
+			var names []string
+			for name := range tokens {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			rows := []string{"Name | Stored In"}
+			for _, name := range names {
+				storedIn := "config file"
+				if strings.HasPrefix(tokens[name], keychainTokenPrefix) {
+					storedIn = "keychain"
+				}
+				rows = append(rows, fmt.Sprintf("%s | %s", name, storedIn))
+			}
+
+			printTable(cmd, rows)
+		},
+	}
+
+	return &cmd
+}
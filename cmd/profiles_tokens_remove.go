@@ -0,0 +1,63 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/prompt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newProfilesTokensRemoveCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "remove [flags] <profile-name> <token-name>",
+		Aliases: []string{"delete"},
+		Short:   "Remove a named token from a configuration profile.",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			profileName, tokenName := args[0], args[1]
+
+			out := prompt.NewPrompt(cmd.OutOrStdout())
+
+			saveErr := updateConfig(func() error {
+				profiles := viper.GetStringMap("profiles")
+				if profiles[profileName] == nil {
+					return fmt.Errorf("unknown profile %s", profileName)
+				}
+
+				profile := mapToLogin(profiles[profileName])
+				oldToken, ok := profile.tokens[tokenName]
+				if !ok {
+					return fmt.Errorf("the profile %s has no token named %q", profileName, tokenName)
+				}
+
+				if err := removeStoredToken(oldToken); err != nil {
+					return fmt.Errorf("error removing token from the keychain: %s", err)
+				}
+
+				delete(profile.tokens, tokenName)
+				addAccount(out, profileName, profile)
+				return nil
+			})
+			exitOnError(cmd, saveErr, "error removing token")
+
+			out.Output(fmt.Sprintf("Token %s removed from profile %s", tokenName, profileName))
+		},
+	}
+
+	return &cmd
+}
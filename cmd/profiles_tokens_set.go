@@ -0,0 +1,90 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/prompt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newProfilesTokensSetCmd() *cobra.Command {
+	var newToken stringPtrFlag
+
+	cmd := cobra.Command{
+		Use:   "set [flags] <profile-name> <token-name>",
+		Short: "Add or replace a named token of a configuration profile.",
+		Long: `Saves a named token in the profile. You are asked for the token unless it is
+given with --token. The token is not validated, since e.g. an ingest token
+cannot be used to look up its user.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+				return err
+			}
+			return validateTokenName(args[1])
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			profileName, tokenName := args[0], args[1]
+
+			out := prompt.NewPrompt(cmd.OutOrStdout())
+
+			profiles := viper.GetStringMap("profiles")
+			if profiles[profileName] == nil {
+				exitOnError(cmd, fmt.Errorf("unknown profile %s", profileName), "error saving token")
+			}
+
+			var token string
+			if newToken.value != nil {
+				token = *newToken.value
+			} else {
+				var err error
+				token, err = out.AskSecret("API Token")
+				exitOnError(cmd, err, "error reading token")
+			}
+			if token == "" {
+				exitOnError(cmd, usageError("the token must not be empty"), "")
+			}
+
+			saveErr := updateConfig(func() error {
+				profiles := viper.GetStringMap("profiles")
+				if profiles[profileName] == nil {
+					return fmt.Errorf("unknown profile %s", profileName)
+				}
+
+				profile := mapToLogin(profiles[profileName])
+				oldToken, replaced := profile.tokens[tokenName]
+
+				profile.tokens[tokenName] = storeToken(namedTokenAccount(profileName, tokenName), token)
+				addAccount(out, profileName, profile)
+
+				if replaced && oldToken != profile.tokens[tokenName] {
+					if err := removeStoredToken(oldToken); err != nil {
+						return fmt.Errorf("error removing token from the keychain: %s", err)
+					}
+				}
+				return nil
+			})
+			exitOnError(cmd, saveErr, "error saving token")
+
+			out.Output(fmt.Sprintf("Token %s saved in profile %s", tokenName, profileName))
+		},
+	}
+
+	cmd.Flags().Var(&newToken, "token", "The token. Defaults to asking for it.")
+
+	return &cmd
+}
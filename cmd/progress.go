@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/humio/cli/prompt"
+)
+
+// progressInterval is how often progress lines are redrawn.
+const progressInterval = 500 * time.Millisecond
+
+// progressReporter redraws a line of progress in the background, e.g. the
+// progress bar of an ingest or the number of events exported so far. The
+// counters shown by line are updated by the caller, using atomic operations
+// as line is called from another goroutine.
+type progressReporter struct {
+	out  io.Writer
+	line func(now time.Time) string
+
+	stopped chan struct{}
+	done    chan struct{}
+}
+
+// startProgressReporter draws line on out every progressInterval until stop
+// is called.
+func startProgressReporter(out io.Writer, line func(now time.Time) string) *progressReporter {
+	r := &progressReporter{
+		out:     out,
+		line:    line,
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+
+		for {
+			r.draw()
+			select {
+			case <-ticker.C:
+			case <-r.stopped:
+				return
+			}
+		}
+	}()
+
+	return r
+}
+
+func (r *progressReporter) draw() {
+	fmt.Fprint(r.out, prompt.ClearLine+r.line(time.Now()))
+}
+
+// stop stops redrawing the line. If keep is true the line is drawn a last
+// time and left on the screen, otherwise it is removed, e.g. to be replaced
+// by a summary.
+func (r *progressReporter) stop(keep bool) {
+	close(r.stopped)
+	<-r.done
+
+	if keep {
+		r.draw()
+		fmt.Fprintln(r.out)
+	} else {
+		fmt.Fprint(r.out, prompt.ClearLine)
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// maxQueryHistoryEntries is the number of searches kept in the history, the
+// oldest are removed first.
+const maxQueryHistoryEntries = 1000
+
+// queryHistoryEntry is a search recorded in the query history.
+type queryHistoryEntry struct {
+	Time       time.Time         `json:"time"`
+	Address    string            `json:"address"`
+	Repository string            `json:"repository"`
+	Query      string            `json:"query"`
+	Start      string            `json:"start,omitempty"`
+	End        string            `json:"end,omitempty"`
+	Live       bool              `json:"live,omitempty"`
+	Params     map[string]string `json:"params,omitempty"`
+	DurationMs int64             `json:"durationMs"`
+	Results    *int              `json:"results,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// queryHistoryFile returns the path of the history file, which is next to
+// the config file.
+func queryHistoryFile() string {
+	return filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "history.jsonl")
+}
+
+// readQueryHistory returns the recorded searches, oldest first.
+func readQueryHistory() ([]queryHistoryEntry, error) {
+	content, err := ioutil.ReadFile(queryHistoryFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []queryHistoryEntry
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry queryHistoryEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// Skip lines that were only partially written.
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// appendQueryHistory records a search, removing the oldest searches if the
+// history has grown too long.
+func appendQueryHistory(entry queryHistoryEntry) error {
+	entries, err := readQueryHistory()
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, entry)
+	if len(entries) > maxQueryHistoryEntries {
+		entries = entries[len(entries)-maxQueryHistoryEntries:]
+	}
+
+	return writeQueryHistory(entries)
+}
+
+// writeQueryHistory replaces the history file. The file is only readable by
+// the user, since queries can contain sensitive values.
+func writeQueryHistory(entries []queryHistoryEntry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	file := queryHistoryFile()
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so concurrent searches do not leave a
+	// corrupt history behind.
+	tmp := fmt.Sprintf("%s.%d.tmp", file, os.Getpid())
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
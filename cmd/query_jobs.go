@@ -0,0 +1,77 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newQueryJobsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query-jobs",
+		Short: "Inspect and cancel the query jobs running on the cluster",
+		Long: `Lists the query jobs running on the cluster with the user who started them,
+their view, cost and age, like the query monitor of the UI, and cancels
+runaway ones:
+
+  $ humioctl query-jobs list --sort=cost --limit=10
+  $ humioctl query-jobs cancel P1-XgjUXJpQbVcvNwmUuhzvb9cs
+
+Listing the query jobs of all users requires the permission to monitor
+queries.`,
+	}
+
+	cmd.AddCommand(newQueryJobsListCmd())
+	cmd.AddCommand(newQueryJobsShowCmd())
+	cmd.AddCommand(newQueryJobsCancelCmd())
+
+	return cmd
+}
+
+// findRunningQuery returns the running query job with the ID.
+func findRunningQuery(client *api.Client, id string) (api.RunningQuery, error) {
+	queries, err := client.QueryJobs().ListRunning()
+	if err != nil {
+		return api.RunningQuery{}, err
+	}
+
+	for _, q := range queries {
+		if q.ID == id {
+			return q, nil
+		}
+	}
+
+	return api.RunningQuery{}, fmt.Errorf("no query job with the ID %s is running", id)
+}
+
+// queryJobProgress returns how far the query job is, e.g. "42%".
+func queryJobProgress(q api.RunningQuery) string {
+	if q.TotalWork <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", float64(q.WorkDone)/float64(q.TotalWork)*100)
+}
+
+// queryJobAge returns how long the query job has been running.
+func queryJobAge(q api.RunningQuery, now time.Time) time.Duration {
+	if q.StartedAt <= 0 {
+		return 0
+	}
+	return now.Sub(msToTime(int64(q.StartedAt))).Round(time.Second)
+}
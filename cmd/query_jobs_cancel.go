@@ -0,0 +1,65 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newQueryJobsCancelCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "cancel [flags] <id>...",
+		Short: "Cancel query jobs running on the cluster.",
+		Long: `Cancels the query jobs with the IDs, as listed by 'humioctl query-jobs list'.
+The user who started a query job sees it as cancelled.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			queries, err := client.QueryJobs().ListRunning()
+			exitOnError(cmd, err, "error fetching query jobs")
+
+			views := map[string]string{}
+			for _, q := range queries {
+				views[q.ID] = q.View
+			}
+
+			var failed int
+			for _, id := range args {
+				view, ok := views[id]
+				if !ok {
+					cmd.Println(fmt.Sprintf("No query job with the ID %s is running", id))
+					failed++
+					continue
+				}
+
+				if err := client.QueryJobs().Cancel(view, id); err != nil {
+					cmd.Println(fmt.Sprintf("Error cancelling query job %s: %s", id, err))
+					failed++
+					continue
+				}
+				cmd.Println(fmt.Sprintf("Cancelled query job %s in %s", id, view))
+			}
+
+			if failed > 0 {
+				exitOnError(cmd, fmt.Errorf("%d of %d query jobs could not be cancelled", failed, len(args)), "")
+			}
+		},
+	}
+
+	return &cmd
+}
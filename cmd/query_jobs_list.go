@@ -0,0 +1,107 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newQueryJobsListCmd() *cobra.Command {
+	var view, user, sortBy string
+	var limit listLimitFlags
+
+	cmd := cobra.Command{
+		Use:   "list [flags]",
+		Short: "List the query jobs running on the cluster.",
+		Long: `Lists the running query jobs, sorted by --sort in descending order: cost
+(the default), age or progress. Use --view and --user to only list the
+query jobs of a view or user.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if sortBy != "cost" && sortBy != "age" && sortBy != "progress" {
+				return fmt.Errorf("invalid value %q for --sort, must be one of: cost, age, progress", sortBy)
+			}
+			return cobra.NoArgs(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			queries, err := client.QueryJobs().ListRunning()
+			exitOnError(cmd, err, "error fetching query jobs")
+
+			var matching []api.RunningQuery
+			for _, q := range queries {
+				if view != "" && !strings.EqualFold(q.View, view) {
+					continue
+				}
+				if user != "" && (q.InitiatingUser == nil || !strings.EqualFold(*q.InitiatingUser, user)) {
+					continue
+				}
+				matching = append(matching, q)
+			}
+
+			now := time.Now()
+			sort.SliceStable(matching, func(i, j int) bool {
+				a, b := matching[i], matching[j]
+				switch sortBy {
+				case "age":
+					return queryJobAge(a, now) > queryJobAge(b, now)
+				case "progress":
+					return float64(a.WorkDone)/float64(a.TotalWork+1) > float64(b.WorkDone)/float64(b.TotalWork+1)
+				default:
+					return a.Cost > b.Cost
+				}
+			})
+
+			shown := matching
+			if n := limit.value(); n > 0 && len(shown) > n {
+				shown = shown[:n]
+			}
+
+			rows := []string{"ID | View | User | Live | Progress | Cost | Age | Query"}
+			for _, q := range shown {
+				var initiator string
+				if q.InitiatingUser != nil {
+					initiator = *q.InitiatingUser
+				}
+
+				rows = append(rows, fmt.Sprintf("%s | %s | %s | %s | %s | %.0f | %s | %s",
+					q.ID,
+					q.View,
+					valueOrEmpty(initiator),
+					yesNo(q.IsLive),
+					queryJobProgress(q),
+					q.Cost,
+					queryJobAge(q, now),
+					strings.Replace(truncateString(strings.Join(strings.Fields(q.QueryString), " "), 60), "|", "/", -1)))
+			}
+
+			printTable(cmd, rows)
+			limit.printTruncated(len(shown), len(matching), "query jobs")
+		},
+	}
+
+	cmd.Flags().StringVar(&view, "view", "", "Only list the query jobs of the view or repository.")
+	cmd.Flags().StringVar(&user, "user", "", "Only list the query jobs started by the user.")
+	cmd.Flags().StringVar(&sortBy, "sort", "cost", "Sort the query jobs by cost, age or progress.")
+	limit.register(&cmd, "query jobs")
+
+	return &cmd
+}
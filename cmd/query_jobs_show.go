@@ -0,0 +1,57 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newQueryJobsShowCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "show [flags] <id>",
+		Short: "Show a query job running on the cluster, including its query.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			q, err := findRunningQuery(client, args[0])
+			exitOnError(cmd, err, "error fetching query job")
+
+			var initiator string
+			if q.InitiatingUser != nil {
+				initiator = *q.InitiatingUser
+			}
+
+			now := time.Now()
+			printTable(cmd, []string{
+				"ID | " + q.ID,
+				"View | " + q.View,
+				"User | " + valueOrEmpty(initiator),
+				"Live | " + yesNo(q.IsLive),
+				"Progress | " + queryJobProgress(q),
+				fmt.Sprintf("Cost | %.0f", q.Cost),
+				fmt.Sprintf("Age | %s", queryJobAge(q, now)),
+			})
+
+			cmd.Println(q.QueryString)
+			cmd.Println()
+		},
+	}
+
+	return &cmd
+}
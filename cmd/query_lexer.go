@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"strings"
+)
+
+// queryLexeme is a piece of a query as split by lexQuery.
+type queryLexeme struct {
+	// kind is one of "string", "regex", "comment", "space" or "char", the
+	// latter being any other single character, e.g. a bracket or a pipe.
+	kind string
+	text string
+	// line and column are the position of the first character, starting
+	// at 1.
+	line, column int
+	// unterminated is set for strings, comments and regular expressions
+	// that are not closed, regular expressions by the end of the line.
+	unterminated bool
+}
+
+// lexQuery splits a query into strings, regular expressions, comments,
+// whitespace and single characters. It only knows how these are delimited,
+// not the functions of the query language, and is shared by the lexical
+// checks of 'query validate' and the static analysis of 'search explain'.
+func lexQuery(query string) []queryLexeme {
+	var lexemes []queryLexeme
+	runes := []rune(query)
+	line, column := 1, 1
+
+	// prev is the last significant character, used to tell a regular
+	// expression apart from a division, e.g. "/foo/" vs "eval(x=a/b)".
+	prev := '|'
+
+	for i := 0; i < len(runes); {
+		start := i
+		l := queryLexeme{line: line, column: column}
+		r := runes[i]
+
+		switch {
+		case r == '"':
+			l.kind = "string"
+			l.unterminated = true
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					continue
+				}
+				if runes[i] == '"' {
+					i++
+					l.unterminated = false
+					break
+				}
+			}
+			prev = '"'
+
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			l.kind = "comment"
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			l.kind = "comment"
+			l.unterminated = true
+			for i += 2; i < len(runes); i++ {
+				if runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/' {
+					i += 2
+					l.unterminated = false
+					break
+				}
+			}
+
+		case r == '/' && strings.ContainsRune("|=(,!:[", prev):
+			l.kind = "regex"
+			l.unterminated = true
+			for i++; i < len(runes) && runes[i] != '\n'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] != '\n' {
+					i++
+					continue
+				}
+				if runes[i] == '/' {
+					i++
+					l.unterminated = false
+					break
+				}
+			}
+			prev = '/'
+
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			l.kind = "space"
+			i++
+
+		default:
+			l.kind = "char"
+			i++
+			prev = r
+		}
+
+		l.text = string(runes[start:i])
+		for _, c := range l.text {
+			if c == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+		lexemes = append(lexemes, l)
+	}
+
+	return lexemes
+}
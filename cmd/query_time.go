@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// relativeTimePattern matches relative times like "10m", "2 hours" and "3d ago".
+var relativeTimePattern = regexp.MustCompile(`^(\d+)\s*([a-z]+)(?:\s+ago)?$`)
+
+// relativeTimeUnits maps the units of relative times to the units Humio uses.
+var relativeTimeUnits = map[string]string{
+	"ms": "ms", "millis": "ms", "millisecond": "ms", "milliseconds": "ms",
+	"s": "s", "sec": "s", "secs": "s", "second": "s", "seconds": "s",
+	"m": "m", "min": "m", "mins": "m", "minute": "m", "minutes": "m",
+	"h": "h", "hr": "h", "hrs": "h", "hour": "h", "hours": "h",
+	"d": "d", "day": "d", "days": "d",
+	"w": "w", "week": "w", "weeks": "w",
+	"y": "y", "year": "y", "years": "y",
+}
+
+// absoluteTimeLayouts are the layouts of absolute times. Times without a
+// time zone are in the local time zone.
+var absoluteTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseQueryTime converts a time given on the command line to the start or
+// end of a query, either a relative time like "2h", or an absolute time in
+// milliseconds since the epoch. Supported are:
+//
+//   - relative times, e.g. 10m, 2h, 1d, "3 days", "2h ago" or 1h30m
+//   - now, today and yesterday, the latter two meaning local midnight
+//   - RFC 3339 and ISO 8601 times, e.g. 2020-01-01T00:00:00Z or 2020-01-01
+//   - seconds or milliseconds since the epoch
+func parseQueryTime(s string, now time.Time) (string, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+
+	switch v {
+	case "", "now":
+		return v, nil
+	case "today":
+		return epochMillis(midnight(now)), nil
+	case "yesterday":
+		return epochMillis(midnight(now).AddDate(0, 0, -1)), nil
+	}
+
+	if m := relativeTimePattern.FindStringSubmatch(v); m != nil {
+		if unit, ok := relativeTimeUnits[m[2]]; ok {
+			return m[1] + unit, nil
+		}
+	}
+
+	// Durations combining units, e.g. 1h30m, are sent in seconds.
+	if d, err := time.ParseDuration(strings.TrimSuffix(v, " ago")); err == nil && d > 0 {
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s", nil
+	}
+
+	if isDigits(v) {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid time %q", s)
+		}
+		// Up to 10 digits are seconds, which covers times until the year 2286.
+		if len(v) <= 10 {
+			n *= 1000
+		}
+		return strconv.FormatInt(n, 10), nil
+	}
+
+	for _, layout := range absoluteTimeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(v), now.Location()); err == nil {
+			return epochMillis(t), nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid time %q, expected a relative time like 2h, an absolute time like 2020-01-01T00:00:00Z, now, today, yesterday or milliseconds since the epoch", s)
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func epochMillis(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// parseQueryTimeRange parses the start and end of a query, and checks that
+// the start is before the end if both are absolute.
+func parseQueryTimeRange(start, end string, now time.Time) (string, string, error) {
+	queryStart, err := parseQueryTime(start, now)
+	if err != nil {
+		return "", "", err
+	}
+	queryEnd, err := parseQueryTime(end, now)
+	if err != nil {
+		return "", "", err
+	}
+
+	if isDigits(queryStart) && isDigits(queryEnd) {
+		s, _ := strconv.ParseInt(queryStart, 10, 64)
+		e, _ := strconv.ParseInt(queryEnd, 10, 64)
+		if s >= e {
+			return "", "", fmt.Errorf("the start time %s must be before the end time %s", start, end)
+		}
+	}
+
+	return queryStart, queryEnd, nil
+}
+
+// relativeTimeUnitDurations are the durations of the units of relative times
+// returned by parseQueryTime.
+var relativeTimeUnitDurations = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+	"y":  365 * 24 * time.Hour,
+}
+
+// relativeTimeDuration returns the duration of a relative time returned by
+// parseQueryTime, e.g. 2h, and false if the time is not relative.
+func relativeTimeDuration(s string) (time.Duration, bool) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return 0, false
+	}
+
+	unit, ok := relativeTimeUnitDurations[s[i:]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
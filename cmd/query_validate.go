@@ -0,0 +1,142 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newQueryValidateCmd() *cobra.Command {
+	var repo string
+
+	cmd := &cobra.Command{
+		Use:   "validate [flags] [<query>]",
+		Short: "Check a query for syntax errors",
+		Long: `Checks a query for syntax errors, e.g. before it is put into an alert
+or dashboard. If <query> is not given or is "-" the query is read from stdin.
+
+The query is checked locally for unterminated strings, comments and regular
+expressions and unbalanced parentheses, brackets and braces. Errors are reported
+as <line>:<column>: <message>.
+
+If --repo is given the query is also validated by the Humio cluster, which
+catches errors like unknown functions or invalid arguments.
+
+  $ humioctl query validate --repo=web < query.txt`,
+		Args: cobra.RangeArgs(0, 1),
+		Run: func(cmd *cobra.Command, args []string) {
+			queryString, err := readArgOrStdin(args, 0)
+			exitOnError(cmd, err, "error reading query")
+
+			problems := lintQuery(queryString)
+			for _, p := range problems {
+				cmd.Println(p)
+			}
+			if len(problems) > 0 {
+				os.Exit(1)
+			}
+
+			if repo != "" {
+				client := NewApiClient(cmd)
+				err := validateQueryOnServer(client, repo, queryString)
+				if queryError, ok := err.(api.QueryError); ok {
+					cmd.Println(queryError.Error())
+					os.Exit(1)
+				}
+				exitOnError(cmd, err, "error validating query")
+			}
+
+			cmd.Println("Query is valid")
+		},
+	}
+
+	cmd.Flags().StringVarP(&repo, "repo", "r", "", "Also validate the query using the Humio cluster, in the context of this repository or view.")
+
+	return cmd
+}
+
+// validateQueryOnServer starts a query job for the query and stops it right away.
+// Humio rejects query jobs for invalid queries with an api.QueryError.
+func validateQueryOnServer(client *api.Client, repo string, queryString string) error {
+	id, err := client.QueryJobs().Create(repo, api.Query{
+		QueryString: queryString,
+		Start:       "1s",
+	})
+	if err != nil {
+		return err
+	}
+
+	return client.QueryJobs().Delete(repo, id)
+}
+
+type queryProblem struct {
+	line, column int
+	message      string
+}
+
+func (p queryProblem) String() string {
+	return fmt.Sprintf("%d:%d: %s", p.line, p.column, p.message)
+}
+
+// lintQuery does a lexical check of a query. It does not know the functions of
+// the query language, only how strings, comments, regular expressions and
+// brackets are delimited.
+func lintQuery(query string) []queryProblem {
+	var problems []queryProblem
+
+	type opening struct {
+		r            rune
+		line, column int
+	}
+	var stack []opening
+
+	closing := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	unterminated := map[string]string{
+		"string":  "unterminated string",
+		"comment": "unterminated comment",
+		"regex":   "unterminated regular expression",
+	}
+
+	for _, l := range lexQuery(query) {
+		if l.unterminated {
+			problems = append(problems, queryProblem{l.line, l.column, unterminated[l.kind]})
+			continue
+		}
+		if l.kind != "char" {
+			continue
+		}
+
+		switch r := []rune(l.text)[0]; r {
+		case '(', '[', '{':
+			stack = append(stack, opening{r, l.line, l.column})
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1].r != closing[r] {
+				problems = append(problems, queryProblem{l.line, l.column, fmt.Sprintf("unexpected '%c'", r)})
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	for _, o := range stack {
+		problems = append(problems, queryProblem{o.line, o.column, fmt.Sprintf("'%c' is never closed", o.r)})
+	}
+
+	return problems
+}
@@ -31,8 +31,14 @@ func newReposCmd() *cobra.Command {
 	cmd.AddCommand(newReposShowCmd())
 	cmd.AddCommand(newReposListCmd())
 	cmd.AddCommand(newReposCreateCmd())
+	cmd.AddCommand(newReposCloneCmd())
 	cmd.AddCommand(newReposUpdateCmd())
 	cmd.AddCommand(newReposDeleteCmd())
+	cmd.AddCommand(newReposStatsCmd())
+	cmd.AddCommand(newReposArchivingCmd())
+	cmd.AddCommand(newReposSetDefaultParserCmd())
+	cmd.AddCommand(newReposTagGroupingsCmd())
+	cmd.AddCommand(newReposGcCmd())
 
 	return cmd
 }
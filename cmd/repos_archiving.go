@@ -0,0 +1,144 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/humio/cli/api"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// archivingProviderFeatures are the features of the server needed to
+// archive to providers other than S3, which older versions do not have.
+var archivingProviderFeatures = map[string]serverFeature{
+	"gcs":   {"archiving to Google Cloud Storage", []string{"gcsConfigureArchiving"}},
+	"azure": {"archiving to Azure Blob Storage", []string{"azureConfigureArchiving"}},
+}
+
+// archivingTarget is the archiving configuration of a repository for one
+// provider.
+type archivingTarget struct {
+	provider string
+	disabled bool
+	format   api.S3ArchivingFormat
+	// details are the rows describing where the data is archived to.
+	details [][]string
+}
+
+func newReposArchivingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "archiving",
+		Short: "Manage archiving of repositories to S3, Google Cloud Storage or Azure",
+		Long: `Configure archiving of the data in a repository to an S3 bucket, a Google Cloud
+Storage bucket or an Azure Blob Storage container. Humio must be given write
+access to the bucket or container, see the Humio documentation on archiving.
+
+  $ humioctl repos archiving enable ops --bucket humio-archive --region eu-west-1 --format ndjson
+  $ humioctl repos archiving enable ops --provider gcs --bucket humio-archive --credentials-file sa.json
+  $ humioctl repos archiving enable ops --provider azure --storage-account humio --container archive --sas-token-file sas.txt`,
+	}
+
+	cmd.AddCommand(newReposArchivingShowCmd())
+	cmd.AddCommand(newReposArchivingEnableCmd())
+	cmd.AddCommand(newReposArchivingDisableCmd())
+
+	return cmd
+}
+
+// fetchArchivingTargets returns the archiving configurations of the
+// repository. Providers the server does not support are left out.
+func fetchArchivingTargets(client *api.Client, repoName string) ([]archivingTarget, error) {
+	var targets []archivingTarget
+
+	s3, err := client.Repositories().S3ArchivingConfiguration(repoName)
+	if err != nil {
+		return nil, err
+	}
+	if s3 != nil {
+		targets = append(targets, archivingTarget{
+			provider: "s3",
+			disabled: s3.Disabled,
+			format:   s3.Format,
+			details:  [][]string{{"Bucket", s3.Bucket}, {"Region", s3.Region}},
+		})
+	}
+
+	if requireServerFeature(client, archivingProviderFeatures["gcs"]) == nil {
+		gcs, err := client.Repositories().GCSArchivingConfiguration(repoName)
+		if err != nil {
+			return nil, err
+		}
+		if gcs != nil {
+			targets = append(targets, archivingTarget{
+				provider: "gcs",
+				disabled: gcs.Disabled,
+				format:   gcs.Format,
+				details:  [][]string{{"Bucket", gcs.Bucket}},
+			})
+		}
+	}
+
+	if requireServerFeature(client, archivingProviderFeatures["azure"]) == nil {
+		azure, err := client.Repositories().AzureArchivingConfiguration(repoName)
+		if err != nil {
+			return nil, err
+		}
+		if azure != nil {
+			targets = append(targets, archivingTarget{
+				provider: "azure",
+				disabled: azure.Disabled,
+				format:   azure.Format,
+				details: [][]string{
+					{"Storage Account", azure.StorageAccountName},
+					{"Container", azure.ContainerName},
+					{"Authentication", valueOrEmpty(azure.AuthenticationType)},
+				},
+			})
+		}
+	}
+
+	return targets, nil
+}
+
+func findArchivingTarget(targets []archivingTarget, provider string) *archivingTarget {
+	for i := range targets {
+		if targets[i].provider == provider {
+			return &targets[i]
+		}
+	}
+	return nil
+}
+
+func printArchivingTable(cmd *cobra.Command, target archivingTarget) {
+	data := [][]string{
+		{"Provider", archivingProviderNames[target.provider]},
+		{"Enabled", yesNo(!target.disabled)},
+	}
+	data = append(data, target.details...)
+	data = append(data, []string{"Format", string(target.format)})
+
+	w := tablewriter.NewWriter(cmd.OutOrStdout())
+	w.AppendBulk(data)
+	w.SetBorder(false)
+	w.SetColumnSeparator(":")
+	w.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT})
+	w.Render()
+}
+
+var archivingProviderNames = map[string]string{
+	"s3":    "S3",
+	"gcs":   "Google Cloud Storage",
+	"azure": "Azure Blob Storage",
+}
@@ -0,0 +1,84 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newReposArchivingDisableCmd() *cobra.Command {
+	var provider string
+
+	cmd := cobra.Command{
+		Use:   "disable [flags] <repo>",
+		Short: "Disable archiving of a repository.",
+		Long: `Stops archiving the data of the repository. The configuration is kept, so
+archiving can be resumed with 'humioctl repos archiving enable <repo>'.
+
+If the repository is archived to more than one provider, --provider selects
+the one to disable: s3, gcs or azure.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if _, ok := archivingProviderNames[provider]; provider != "" && !ok {
+				return fmt.Errorf("invalid value %q for --provider, must be one of: s3, gcs, azure", provider)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			repoName := args[0]
+
+			client := NewApiClient(cmd)
+
+			if provider == "" {
+				targets, err := fetchArchivingTargets(client, repoName)
+				exitOnError(cmd, err, "error fetching archiving configuration")
+
+				var enabled []string
+				for _, target := range targets {
+					if !target.disabled {
+						enabled = append(enabled, target.provider)
+					}
+				}
+				switch len(enabled) {
+				case 0:
+					cmd.Println("Archiving is not enabled for repository " + repoName)
+					return
+				case 1:
+					provider = enabled[0]
+				default:
+					exitOnError(cmd, usageError(fmt.Sprintf("the repository is archived to more than one provider, use --provider to select one of: %v", enabled)), "")
+				}
+			}
+
+			var err error
+			switch provider {
+			case "s3":
+				err = client.Repositories().DisableS3Archiving(repoName)
+			case "gcs":
+				err = client.Repositories().DisableGCSArchiving(repoName)
+			case "azure":
+				err = client.Repositories().DisableAzureArchiving(repoName)
+			}
+			exitOnError(cmd, err, "error disabling archiving")
+
+			cmd.Println(fmt.Sprintf("Archiving to %s disabled for repository %s", archivingProviderNames[provider], repoName))
+		},
+	}
+
+	cmd.Flags().StringVar(&provider, "provider", "", "The provider to disable archiving to: s3, gcs or azure. Defaults to the enabled one.")
+
+	return &cmd
+}
@@ -0,0 +1,271 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newReposArchivingEnableCmd() *cobra.Command {
+	var provider, bucket, region, format string
+	var credentialsFile, storageAccount, container, accountKeyFile, sasTokenFile string
+
+	cmd := cobra.Command{
+		Use:   "enable [flags] <repo>",
+		Short: "Enable archiving of a repository to S3, Google Cloud Storage or Azure.",
+		Long: `Configures where the data of the repository is archived to and enables
+archiving. --provider is one of:
+
+  s3     An S3 bucket, given by --bucket and --region.
+  gcs    A Google Cloud Storage bucket, given by --bucket. Humio writes to it
+         with the service account key in --credentials-file, or the default
+         credentials of the cluster.
+  azure  An Azure Blob Storage container, given by --storage-account and
+         --container. Humio writes to it with the account key in
+         --account-key-file, the SAS token in --sas-token-file, or the
+         managed identity of the cluster.
+
+The provider defaults to the one the repository is archived to, or s3. The
+target is required the first time, after that archiving that was disabled
+can be enabled again without it. The server does not return the
+credentials, so they must be given again when changing the configuration,
+otherwise the default credentials of the cluster are used.
+
+The format is either 'ndjson', where each event is a JSON object with all its
+fields, or 'raw', where only the raw string of each event is archived.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if _, ok := archivingProviderNames[provider]; provider != "" && !ok {
+				return fmt.Errorf("invalid value %q for --provider, must be one of: s3, gcs, azure", provider)
+			}
+			if accountKeyFile != "" && sasTokenFile != "" {
+				return fmt.Errorf("--account-key-file cannot be used together with --sas-token-file")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			repoName := args[0]
+
+			archivingFormat := api.S3ArchivingFormat(strings.ToUpper(format))
+			if archivingFormat != api.S3ArchivingFormatNDJSON && archivingFormat != api.S3ArchivingFormatRaw {
+				exitOnError(cmd, usageError(fmt.Sprintf("unsupported format %q, must be one of: ndjson, raw", format)), "")
+			}
+			formatChanged := cmd.Flags().Changed("format")
+
+			client := NewApiClient(cmd)
+
+			targets, err := fetchArchivingTargets(client, repoName)
+			exitOnError(cmd, err, "error fetching archiving configuration")
+
+			if provider == "" {
+				provider = "s3"
+				if len(targets) == 1 {
+					provider = targets[0].provider
+				}
+			}
+
+			// Flags of other providers are most likely a mistake.
+			providerFlags := map[string][]string{
+				"s3":    {"region"},
+				"gcs":   {"credentials-file"},
+				"azure": {"storage-account", "container", "account-key-file", "sas-token-file"},
+			}
+			for p, flags := range providerFlags {
+				for _, flag := range flags {
+					if p != provider && cmd.Flags().Changed(flag) {
+						exitOnError(cmd, usageError(fmt.Sprintf("--%s cannot be used with --provider %s", flag, provider)), "")
+					}
+				}
+			}
+			if provider == "azure" && cmd.Flags().Changed("bucket") {
+				exitOnError(cmd, usageError("--bucket cannot be used with --provider azure, use --storage-account and --container"), "")
+			}
+
+			if feature, ok := archivingProviderFeatures[provider]; ok {
+				exitOnError(cmd, requireServerFeature(client, feature), "")
+			}
+
+			switch provider {
+			case "s3":
+				err = enableS3Archiving(client, repoName, bucket, region, archivingFormat, formatChanged)
+			case "gcs":
+				err = enableGCSArchiving(client, repoName, bucket, credentialsFile, archivingFormat, formatChanged)
+			case "azure":
+				err = enableAzureArchiving(client, repoName, storageAccount, container, accountKeyFile, sasTokenFile, archivingFormat, formatChanged)
+			}
+			exitOnError(cmd, err, "error configuring archiving")
+
+			targets, err = fetchArchivingTargets(client, repoName)
+			exitOnError(cmd, err, "error fetching archiving configuration")
+
+			if target := findArchivingTarget(targets, provider); target != nil {
+				printArchivingTable(cmd, *target)
+				cmd.Println()
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&provider, "provider", "", "Where to archive to: s3, gcs or azure. Defaults to the provider the repository is archived to, or s3.")
+	cmd.Flags().StringVar(&bucket, "bucket", "", "The name of the S3 or Google Cloud Storage bucket to archive to.")
+	cmd.Flags().StringVar(&region, "region", "", "The AWS region of the S3 bucket, e.g. eu-west-1.")
+	cmd.Flags().StringVar(&credentialsFile, "credentials-file", "", "A JSON key file of the Google Cloud service account to write to the bucket with.")
+	cmd.Flags().StringVar(&storageAccount, "storage-account", "", "The name of the Azure storage account to archive to.")
+	cmd.Flags().StringVar(&container, "container", "", "The name of the Azure Blob Storage container to archive to.")
+	cmd.Flags().StringVar(&accountKeyFile, "account-key-file", "", "A file containing the access key of the Azure storage account.")
+	cmd.Flags().StringVar(&sasTokenFile, "sas-token-file", "", "A file containing a SAS token with write access to the Azure container.")
+	cmd.Flags().StringVar(&format, "format", "ndjson", "The format of the archived files, either 'ndjson' or 'raw'.")
+
+	return &cmd
+}
+
+func enableS3Archiving(client *api.Client, repoName, bucket, region string, format api.S3ArchivingFormat, formatChanged bool) error {
+	config, err := client.Repositories().S3ArchivingConfiguration(repoName)
+	if err != nil {
+		return err
+	}
+
+	if bucket == "" && region == "" && !formatChanged {
+		if config == nil {
+			return usageError("archiving is not configured for the repository, --bucket and --region are required")
+		}
+		return client.Repositories().EnableS3Archiving(repoName)
+	}
+
+	// Only the changed settings need to be given when updating the configuration.
+	if config != nil {
+		if bucket == "" {
+			bucket = config.Bucket
+		}
+		if region == "" {
+			region = config.Region
+		}
+		if !formatChanged {
+			format = config.Format
+		}
+	}
+	if bucket == "" || region == "" {
+		return usageError("both --bucket and --region are required")
+	}
+
+	if err := client.Repositories().ConfigureS3Archiving(repoName, bucket, region, format); err != nil {
+		return err
+	}
+	if config != nil && config.Disabled {
+		return client.Repositories().EnableS3Archiving(repoName)
+	}
+	return nil
+}
+
+func enableGCSArchiving(client *api.Client, repoName, bucket, credentialsFile string, format api.S3ArchivingFormat, formatChanged bool) error {
+	config, err := client.Repositories().GCSArchivingConfiguration(repoName)
+	if err != nil {
+		return err
+	}
+
+	if bucket == "" && credentialsFile == "" && !formatChanged {
+		if config == nil {
+			return usageError("archiving to Google Cloud Storage is not configured for the repository, --bucket is required")
+		}
+		return client.Repositories().EnableGCSArchiving(repoName)
+	}
+
+	if config != nil {
+		if bucket == "" {
+			bucket = config.Bucket
+		}
+		if !formatChanged {
+			format = config.Format
+		}
+	}
+	if bucket == "" {
+		return usageError("--bucket is required")
+	}
+
+	key, err := readArchivingSecret(credentialsFile)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Repositories().ConfigureGCSArchiving(repoName, bucket, key, format); err != nil {
+		return err
+	}
+	if config != nil && config.Disabled {
+		return client.Repositories().EnableGCSArchiving(repoName)
+	}
+	return nil
+}
+
+func enableAzureArchiving(client *api.Client, repoName, storageAccount, container, accountKeyFile, sasTokenFile string, format api.S3ArchivingFormat, formatChanged bool) error {
+	config, err := client.Repositories().AzureArchivingConfiguration(repoName)
+	if err != nil {
+		return err
+	}
+
+	if storageAccount == "" && container == "" && accountKeyFile == "" && sasTokenFile == "" && !formatChanged {
+		if config == nil {
+			return usageError("archiving to Azure is not configured for the repository, --storage-account and --container are required")
+		}
+		return client.Repositories().EnableAzureArchiving(repoName)
+	}
+
+	if config != nil {
+		if storageAccount == "" {
+			storageAccount = config.StorageAccountName
+		}
+		if container == "" {
+			container = config.ContainerName
+		}
+		if !formatChanged {
+			format = config.Format
+		}
+	}
+	if storageAccount == "" || container == "" {
+		return usageError("both --storage-account and --container are required")
+	}
+
+	var credentials api.AzureArchivingCredentials
+	if credentials.AccountKey, err = readArchivingSecret(accountKeyFile); err != nil {
+		return err
+	}
+	if credentials.SASToken, err = readArchivingSecret(sasTokenFile); err != nil {
+		return err
+	}
+
+	if err := client.Repositories().ConfigureAzureArchiving(repoName, storageAccount, container, credentials, format); err != nil {
+		return err
+	}
+	if config != nil && config.Disabled {
+		return client.Repositories().EnableAzureArchiving(repoName)
+	}
+	return nil
+}
+
+// readArchivingSecret returns the content of a file with credentials, or
+// the empty string if no file is given. Secrets are read from files, so
+// they do not end up in the shell history.
+func readArchivingSecret(file string) (string, error) {
+	if file == "" {
+		return "", nil
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
@@ -0,0 +1,47 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newReposArchivingShowCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "show [flags] <repo>",
+		Short: "Show the archiving configuration of a repository.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			repoName := args[0]
+
+			client := NewApiClient(cmd)
+
+			targets, err := fetchArchivingTargets(client, repoName)
+			exitOnError(cmd, err, "error fetching archiving configuration")
+
+			if len(targets) == 0 {
+				cmd.Println("Archiving is not configured for repository " + repoName)
+				return
+			}
+
+			for _, target := range targets {
+				printArchivingTable(cmd, target)
+				cmd.Println()
+			}
+		},
+	}
+
+	return &cmd
+}
@@ -0,0 +1,233 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+// repoCloneKinds are the kinds of resources that can be cloned from one
+// repository to another, in the order they are cloned. Notifiers are cloned
+// before alerts, so the alerts can refer to the cloned notifiers.
+var repoCloneKinds = []string{"parsers", "notifiers", "alerts", "dashboards", "ingest-tokens"}
+
+func newReposCloneCmd() *cobra.Command {
+	var include []string
+
+	cmd := cobra.Command{
+		Use:   "clone [flags] <source-repo> <new-repo>",
+		Short: "Create a repository with the parsers, alerts and dashboards of another.",
+		Long: fmt.Sprintf(`Creates the repository <new-repo> and copies resources from <source-repo>
+to it. Use --include to select what to copy, by default everything is copied:
+
+  %s
+
+Ingest tokens are created with the same names and parsers, but new secrets.
+Data is not copied.
+
+  $ humioctl repos clone web-template team-web --include parsers,alerts`, strings.Join(repoCloneKinds, ", ")),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := validateRepoCloneKinds(include); err != nil {
+				return err
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			source := args[0]
+			target := args[1]
+
+			client := NewApiClient(cmd)
+
+			// Fail before creating the new repository if the source does not exist.
+			_, err := client.Repositories().Get(source)
+			exitOnError(cmd, err, "error fetching source repository")
+
+			err = client.Repositories().Create(target)
+			exitOnError(cmd, err, "error creating repository")
+			cmd.Println(fmt.Sprintf("Created repository %s", target))
+
+			err = cloneRepoResources(cmd, client, source, target, include)
+			exitOnError(cmd, err, "error cloning repository")
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&include, "include", repoCloneKinds, "The kinds of resources to copy.")
+
+	return &cmd
+}
+
+func validateRepoCloneKinds(kinds []string) error {
+	for _, kind := range kinds {
+		valid := false
+		for _, k := range repoCloneKinds {
+			valid = valid || k == kind
+		}
+		if !valid {
+			return fmt.Errorf("invalid value %q for --include, must be one of: %s", kind, strings.Join(repoCloneKinds, ", "))
+		}
+	}
+	return nil
+}
+
+// cloneRepoResources copies the given kinds of resources from the source to
+// the target repository, printing a line for each copied resource.
+func cloneRepoResources(cmd *cobra.Command, client *api.Client, source, target string, include []string) error {
+	included := map[string]bool{}
+	for _, kind := range include {
+		included[kind] = true
+	}
+
+	// Maps the IDs of notifiers in the source to the IDs of their copies.
+	notifierIDs := map[string]string{}
+
+	for _, kind := range repoCloneKinds {
+		if !included[kind] {
+			continue
+		}
+
+		var err error
+		switch kind {
+		case "parsers":
+			err = cloneParsers(cmd, client, source, target)
+		case "notifiers":
+			err = cloneNotifiers(cmd, client, source, target, notifierIDs)
+		case "alerts":
+			err = cloneAlerts(cmd, client, source, target, notifierIDs, included["notifiers"])
+		case "dashboards":
+			err = cloneDashboards(cmd, client, source, target)
+		case "ingest-tokens":
+			err = cloneIngestTokens(cmd, client, source, target)
+		}
+
+		if err != nil {
+			return fmt.Errorf("error copying %s: %w", kind, err)
+		}
+	}
+
+	return nil
+}
+
+func cloneParsers(cmd *cobra.Command, client *api.Client, source, target string) error {
+	parsers, err := client.Parsers().List(source)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range parsers {
+		if p.IsBuiltIn {
+			continue
+		}
+
+		parser, err := client.Parsers().Get(source, p.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := client.Parsers().Add(target, parser, false); err != nil {
+			return fmt.Errorf("parser %s: %w", p.Name, err)
+		}
+		cmd.Println(fmt.Sprintf("Copied parser %s", p.Name))
+	}
+
+	return nil
+}
+
+func cloneNotifiers(cmd *cobra.Command, client *api.Client, source, target string, ids map[string]string) error {
+	notifiers, err := client.Notifiers().List(source)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range notifiers {
+		sourceID := n.ID
+		n.ID = ""
+
+		created, err := client.Notifiers().Add(target, &n, false)
+		if err != nil {
+			return fmt.Errorf("notifier %s: %w", n.Name, err)
+		}
+		ids[sourceID] = created.ID
+		cmd.Println(fmt.Sprintf("Copied notifier %s", n.Name))
+	}
+
+	return nil
+}
+
+func cloneAlerts(cmd *cobra.Command, client *api.Client, source, target string, notifierIDs map[string]string, notifiersCloned bool) error {
+	alerts, err := client.Alerts().List(source)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range alerts {
+		// Alerts refer to notifiers by ID, which differ between repositories.
+		// Without copies of the notifiers the alert is created without them.
+		var notifiers []string
+		for _, id := range a.Notifiers {
+			if newID, ok := notifierIDs[id]; ok {
+				notifiers = append(notifiers, newID)
+			}
+		}
+		if len(notifiers) < len(a.Notifiers) && !notifiersCloned {
+			cmd.PrintErrln(fmt.Sprintf("Alert %s is copied without its notifiers, include notifiers to copy them too", a.Name))
+		}
+
+		a.ID = ""
+		a.Notifiers = notifiers
+
+		if _, err := client.Alerts().Add(target, &a, false); err != nil {
+			return fmt.Errorf("alert %s: %w", a.Name, err)
+		}
+		cmd.Println(fmt.Sprintf("Copied alert %s", a.Name))
+	}
+
+	return nil
+}
+
+func cloneDashboards(cmd *cobra.Command, client *api.Client, source, target string) error {
+	dashboards, err := client.Dashboards().List(source)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range dashboards {
+		if err := client.Dashboards().CreateFromTemplate(target, d.TemplateYaml); err != nil {
+			return fmt.Errorf("dashboard %s: %w", d.Name, err)
+		}
+		cmd.Println(fmt.Sprintf("Copied dashboard %s", d.Name))
+	}
+
+	return nil
+}
+
+func cloneIngestTokens(cmd *cobra.Command, client *api.Client, source, target string) error {
+	tokens, err := client.IngestTokens().List(source)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tokens {
+		if _, err := client.IngestTokens().Add(target, t.Name, t.AssignedParser); err != nil {
+			return fmt.Errorf("ingest token %s: %w", t.Name, err)
+		}
+		cmd.Println(fmt.Sprintf("Created ingest token %s", t.Name))
+	}
+
+	return nil
+}
@@ -21,19 +21,35 @@ import (
 )
 
 func newReposCreateCmd() *cobra.Command {
+	var template string
+
 	cmd := cobra.Command{
 		Use:   "create [flags] <repo>",
 		Short: "Create a repository.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Creates the repository <repo>.
+
+Use --template to copy the parsers, notifiers, alerts, dashboards and ingest
+tokens of an existing repository to the new one. See also 'repos clone'.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			repoName := args[0]
 
 			client := NewApiClient(cmd)
 
+			if template != "" {
+				_, apiErr := client.Repositories().Get(template)
+				exitOnError(cmd, apiErr, "error fetching template repository")
+			}
+
 			apiErr := client.Repositories().Create(repoName)
 			exitOnError(cmd, apiErr, "error creating repository")
 			fmt.Println(fmt.Sprintf("Sucessfully created repo %s", repoName))
 
+			if template != "" {
+				apiErr = cloneRepoResources(cmd, client, template, repoName, repoCloneKinds)
+				exitOnError(cmd, apiErr, "error copying from template repository")
+			}
+
 			repo, apiErr := client.Repositories().Get(repoName)
 			exitOnError(cmd, apiErr, "error fetching repository")
 
@@ -43,5 +59,7 @@ func newReposCreateCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVar(&template, "template", "", "An existing repository to copy parsers, alerts, dashboards and other resources from.")
+
 	return &cmd
 }
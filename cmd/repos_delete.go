@@ -20,15 +20,28 @@ import (
 
 func newReposDeleteCmd() *cobra.Command {
 	var allowDataDeletionFlag bool
+	var confirm confirmFlags
 
 	cmd := cobra.Command{
 		Use:   "delete [flags] <repo> \"descriptive reason for why it is being deleted\"",
 		Short: "Delete a repository.",
-		Args:  cobra.ExactArgs(2),
+		Long: `Deletes a repository and all its data. You are asked to type the name of
+the repository to confirm. When not running interactively, use --yes or
+--confirm-name=<repo> instead:
+
+  $ humioctl repos delete old-logs "moved to archive" --confirm-name=old-logs
+
+Deleting a repository that contains data also requires --allow-data-deletion,
+even with --yes or --force. --force skips the confirmation and is only
+accepted when the environment variable ` + allowForceEnvVar + `=true is set, e.g. in CI
+pipelines.`,
+		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
 			repo := args[0]
 			reason := args[1]
 
+			exitOnError(cmd, confirm.confirm(cmd, "repository", repo), "")
+
 			client := NewApiClient(cmd)
 
 			apiError := client.Repositories().Delete(repo, reason, allowDataDeletionFlag)
@@ -36,7 +49,9 @@ func newReposDeleteCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().BoolVar(&allowDataDeletionFlag, "allow-data-deletion", false, "Allow changing retention settings for a non-empty repository")
+	cmd.Flags().BoolVar(&allowDataDeletionFlag, "allow-data-deletion", false, "Allow deleting a repository that contains data.")
+
+	confirm.register(&cmd, "repository")
 
 	return &cmd
 }
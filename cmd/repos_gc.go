@@ -0,0 +1,245 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+// gcCandidate is an asset of a repository found to be unused by 'repos gc'.
+type gcCandidate struct {
+	kind   string
+	name   string
+	reason string
+	remove func() error
+}
+
+func newReposGcCmd() *cobra.Command {
+	var repoName string
+	var dryRun bool
+	var disabledDays int
+	var confirm confirmFlags
+
+	cmd := cobra.Command{
+		Use:   "gc [flags]",
+		Short: "Delete unused parsers, notifiers and alerts of a repository.",
+		Long: `Finds the assets of a repository that are most likely unused, prints them
+and deletes them after confirmation:
+
+  alerts     disabled alerts that have not triggered for --disabled-days days,
+             except alerts silenced by 'alerts silence' until a later time
+  notifiers  notifiers not used by any alert that is kept
+  parsers    parsers not assigned to any ingest token, except the default
+             parser of the repository, parsers used by FDR feeds and
+             built-in parsers
+
+Deleting must be confirmed by typing the name of the repository, or with
+--yes or --confirm-name when not running interactively. Use --dry-run to only
+print the assets.
+
+  $ humioctl repos gc --repo ops --dry-run
+  $ humioctl repos gc --repo ops --disabled-days 30 --confirm-name ops`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if repoName == "" {
+				return fmt.Errorf("the --repo flag is required")
+			}
+			if disabledDays < 0 {
+				return fmt.Errorf("--disabled-days cannot be negative")
+			}
+			return cobra.NoArgs(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			candidates, err := findUnusedAssets(client, repoName, time.Duration(disabledDays)*24*time.Hour)
+			exitOnError(cmd, err, "error finding unused assets")
+
+			if len(candidates) == 0 {
+				cmd.Println("No unused assets found.")
+				return
+			}
+
+			rows := []string{"Kind | Name | Reason"}
+			for _, c := range candidates {
+				rows = append(rows, fmt.Sprintf("%s | %s | %s", c.kind, c.name, c.reason))
+			}
+			printTable(cmd, rows)
+
+			if dryRun {
+				return
+			}
+
+			what := fmt.Sprintf("the %d assets above from the repository %q", len(candidates), repoName)
+			exitOnError(cmd, confirm.confirmDeleting(cmd, what, "repository", repoName), "")
+
+			var failed int
+			for _, c := range candidates {
+				if err := c.remove(); err != nil {
+					cmd.PrintErrln(fmt.Sprintf("error deleting %s %s: %s", c.kind, c.name, err))
+					failed++
+					continue
+				}
+				cmd.Println(fmt.Sprintf("Deleted %s %s", c.kind, c.name))
+			}
+
+			cmd.Println(fmt.Sprintf("Deleted: %d, Failed: %d", len(candidates)-failed, failed))
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&repoName, "repo", "r", "", "The repository to delete unused assets from.")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the unused assets without deleting them.")
+	cmd.Flags().IntVar(&disabledDays, "disabled-days", 90, "Delete disabled alerts that have not triggered for this many days.")
+	cmd.Flags().BoolVarP(&confirm.yes, "yes", "y", false, "Delete the unused assets without asking for confirmation.")
+	cmd.Flags().StringVar(&confirm.confirmName, "confirm-name", "", "Confirm deleting the unused assets by giving the name of the repository, for use in scripts.")
+
+	return &cmd
+}
+
+// findUnusedAssets returns the unused assets of the repository, in the
+// order they can be deleted: alerts first, so the notifiers only they use
+// are unused too.
+func findUnusedAssets(client *api.Client, repoName string, disabledFor time.Duration) ([]gcCandidate, error) {
+	var candidates []gcCandidate
+
+	alerts, err := client.Alerts().List(repoName)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := client.Alerts().ListStatus(repoName)
+	if err != nil {
+		return nil, err
+	}
+	lastTriggered := map[string]*int64{}
+	for _, status := range statuses {
+		lastTriggered[status.Name] = status.LastTriggered
+	}
+
+	now := time.Now()
+	usedNotifiers := map[string]bool{}
+	usedByDeleted := map[string]bool{}
+	sort.Slice(alerts, func(i, j int) bool { return strings.ToLower(alerts[i].Name) < strings.ToLower(alerts[j].Name) })
+	for _, alert := range alerts {
+		alert := alert
+		if until, ok := alertSilenceExpiry(alert); alert.Silenced && !(ok && until.After(now)) {
+			t := lastTriggered[alert.Name]
+			if t == nil || now.Sub(msToTime(*t)) >= disabledFor {
+				reason := "disabled, never triggered"
+				if t != nil {
+					reason = "disabled, last triggered " + formatTimeAgo(now, msToTime(*t))
+				}
+				candidates = append(candidates, gcCandidate{kind: "alert", name: alert.Name, reason: reason, remove: func() error {
+					return client.Alerts().Delete(repoName, alert.Name)
+				}})
+				for _, id := range alert.Notifiers {
+					usedByDeleted[id] = true
+				}
+				continue
+			}
+		}
+		for _, id := range alert.Notifiers {
+			usedNotifiers[id] = true
+		}
+	}
+
+	notifiers, err := client.Notifiers().List(repoName)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(notifiers, func(i, j int) bool { return strings.ToLower(notifiers[i].Name) < strings.ToLower(notifiers[j].Name) })
+	for _, notifier := range notifiers {
+		notifier := notifier
+		if usedNotifiers[notifier.ID] {
+			continue
+		}
+		reason := "not used by any alert"
+		if usedByDeleted[notifier.ID] {
+			reason = "only used by alerts deleted here"
+		}
+		candidates = append(candidates, gcCandidate{kind: "notifier", name: notifier.Name, reason: reason, remove: func() error {
+			return client.Notifiers().Delete(repoName, notifier.Name)
+		}})
+	}
+
+	used, err := usedParsers(client, repoName)
+	if err != nil {
+		return nil, err
+	}
+	parsers, err := client.Parsers().List(repoName)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(parsers, func(i, j int) bool { return strings.ToLower(parsers[i].Name) < strings.ToLower(parsers[j].Name) })
+	for _, parser := range parsers {
+		parser := parser
+		if parser.IsBuiltIn || used[parser.Name] || used[parser.ID] {
+			continue
+		}
+		candidates = append(candidates, gcCandidate{kind: "parser", name: parser.Name, reason: "not assigned to any ingest token", remove: func() error {
+			return client.Parsers().Remove(repoName, parser.Name)
+		}})
+	}
+
+	return candidates, nil
+}
+
+// usedParsers returns the names of the parsers of the repository that are
+// assigned to ingest tokens or are the default parser, and the IDs of the
+// parsers used by FDR feeds. The default parser and FDR feeds are only
+// checked if the server supports them.
+func usedParsers(client *api.Client, repoName string) (map[string]bool, error) {
+	used := map[string]bool{}
+
+	tokens, err := client.IngestTokens().List(repoName)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		if token.AssignedParser != "" {
+			used[token.AssignedParser] = true
+		}
+	}
+
+	if requireServerFeature(client, serverFeatures["repos set-default-parser"]) == nil {
+		name, err := client.Repositories().DefaultParser(repoName)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			used[name] = true
+		}
+	}
+
+	if requireServerFeature(client, serverFeatures["fdr-feeds create"]) == nil {
+		feeds, err := client.FdrFeeds().List(repoName)
+		if err != nil {
+			return nil, err
+		}
+		for _, feed := range feeds {
+			used[feed.ParserID] = true
+		}
+	}
+
+	return used, nil
+}
@@ -15,59 +15,213 @@
 package cmd
 
 import (
+	"fmt"
+	"math"
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/humio/cli/api"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
+// repoColumns are the columns 'repos list' can show, by name.
+var repoColumns = map[string]struct {
+	header string
+	value  func(api.RepoListItem) string
+}{
+	"name":        {"Name", func(r api.RepoListItem) string { return r.Name }},
+	"size":        {"Space Used", func(r api.RepoListItem) string { return ByteCountDecimal(r.SpaceUsed) }},
+	"ingest":      {"Ingested", func(r api.RepoListItem) string { return ByteCountDecimal(r.IngestedBytes) }},
+	"retention":   {"Retention (Days)", func(r api.RepoListItem) string { return formatRetentionDays(r.RetentionDays) }},
+	"description": {"Description", func(r api.RepoListItem) string { return truncateString(r.Description, 60) }},
+}
+
+type repoListOptions struct {
+	sortBy  string
+	reverse bool
+	filter  *regexp.Regexp
+	limit   int
+}
+
 func newReposListCmd() *cobra.Command {
-	var orderBySize, reverse bool
+	var orderBySize, reverse, allProfiles, isRegex bool
+	var sortBy, filter string
+	var columns []string
+	var filterRegexp *regexp.Regexp
+	var limit listLimitFlags
 
 	cmd := cobra.Command{
 		Use:   "list [flags]",
 		Short: "List repositories.",
-		Args:  cobra.ExactArgs(0),
-		Run: func(cmd *cobra.Command, args []string) {
-			client := NewApiClient(cmd)
+		Long: `Lists the repositories, sorted by --sort: name, size (space used), ingest
+(bytes ingested before compression) or retention (days). Names are sorted in
+ascending order, the others with the largest first. --reverse=false reverses
+the order.
 
-			repos, apiErr := client.Repositories().List()
-			exitOnError(cmd, apiErr, "error fetching repository")
+Use --filter to only list repositories whose name matches a glob pattern,
+or a regular expression with --regex, and --columns to choose the columns:
+name, size, ingest, retention and description.
 
-			sort.Slice(repos, func(i, j int) bool {
-				var a, b api.RepoListItem
-				if reverse {
-					a = repos[i]
-					b = repos[j]
-				} else {
-					a = repos[j]
-					b = repos[i]
+  $ humioctl repos list --filter 'web-*' --sort ingest --columns name,ingest,retention
+  $ humioctl repos list --filter '^(web|api)-prod$' --regex`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if orderBySize {
+				sortBy = "size"
+			}
+			switch sortBy {
+			case "name", "size", "ingest", "retention":
+			default:
+				return fmt.Errorf("invalid value %q for --sort, must be one of: name, size, ingest, retention", sortBy)
+			}
+			for _, c := range columns {
+				if _, ok := repoColumns[c]; !ok {
+					return fmt.Errorf("unknown column %q, must be one of: name, size, ingest, retention, description", c)
 				}
-
-				if orderBySize {
-					return a.SpaceUsed > b.SpaceUsed
+			}
+			if filter != "" {
+				pattern := filter
+				if !isRegex {
+					pattern = globToRegexp(filter)
 				}
-				return a.Name < b.Name
-			})
+				var err error
+				if filterRegexp, err = regexp.Compile(pattern); err != nil {
+					return fmt.Errorf("invalid --filter: %v", err)
+				}
+			}
+			return cobra.ExactArgs(0)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			options := repoListOptions{sortBy: sortBy, reverse: reverse, filter: filterRegexp, limit: limit.value()}
+
+			header := make([]string, len(columns))
+			for i, c := range columns {
+				header[i] = repoColumns[c].header
+			}
 
-			rows := make([][]string, len(repos))
-			for i, view := range repos {
-				rows[i] = []string{view.Name, ByteCountDecimal(view.SpaceUsed)}
+			if allProfiles {
+				printForAllProfiles(cmd, header, func(client *api.Client) ([][]string, error) {
+					repos, _, err := listRepos(client, options)
+					return repoRows(repos, columns), err
+				})
+				return
 			}
 
+			client := NewApiClient(cmd)
+
+			repos, total, apiErr := listRepos(client, options)
+			exitOnError(cmd, apiErr, "error fetching repository")
+
 			w := tablewriter.NewWriter(cmd.OutOrStdout())
-			w.SetHeader([]string{"Name", "Space Used"})
-			w.AppendBulk(rows)
+			w.SetHeader(header)
+			w.AppendBulk(repoRows(repos, columns))
 			w.SetBorder(false)
 
 			w.Render()
 			cmd.Println()
+
+			limit.printTruncated(len(repos), total, "repositories")
 		},
 	}
 
-	cmd.Flags().BoolVarP(&orderBySize, "size", "s", false, "Order by size instead of name")
+	cmd.Flags().StringVar(&sortBy, "sort", "name", "Sort by name, size, ingest or retention.")
+	cmd.Flags().BoolVarP(&orderBySize, "size", "s", false, "Order by size instead of name, the same as --sort size.")
 	cmd.Flags().BoolVarP(&reverse, "reverse", "r", true, "Reverse sorting order")
+	cmd.Flags().StringVar(&filter, "filter", "", "Only list repositories whose name matches this glob pattern, e.g. 'web-*'.")
+	cmd.Flags().BoolVar(&isRegex, "regex", false, "Treat --filter as a regular expression instead of a glob pattern.")
+	cmd.Flags().StringSliceVar(&columns, "columns", []string{"name", "size"}, "The columns to show, separated by commas: name, size, ingest, retention, description.")
+	limit.register(&cmd, "repositories")
+	registerAllProfilesFlag(&cmd, &allProfiles)
 
 	return &cmd
 }
+
+// listRepos returns the repositories matching the filter, sorted, and the
+// total number of repositories, or the number of matching repositories if
+// filtered.
+func listRepos(client *api.Client, options repoListOptions) ([]api.RepoListItem, int, error) {
+	// Sorting and filtering is done locally, and the server does not list
+	// the repositories in any particular order, so all of them are fetched
+	// and sorted before the limit is applied.
+	repos, total, err := client.Repositories().ListLimit(0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if options.filter != nil {
+		var matching []api.RepoListItem
+		for _, r := range repos {
+			if options.filter.MatchString(r.Name) {
+				matching = append(matching, r)
+			}
+		}
+		repos = matching
+		total = len(matching)
+	}
+
+	sort.Slice(repos, func(i, j int) bool {
+		var a, b api.RepoListItem
+		if options.reverse {
+			a = repos[i]
+			b = repos[j]
+		} else {
+			a = repos[j]
+			b = repos[i]
+		}
+
+		switch options.sortBy {
+		case "size":
+			return a.SpaceUsed > b.SpaceUsed
+		case "ingest":
+			return a.IngestedBytes > b.IngestedBytes
+		case "retention":
+			return retentionOrder(a.RetentionDays) > retentionOrder(b.RetentionDays)
+		}
+		return a.Name < b.Name
+	})
+
+	if options.limit > 0 && len(repos) > options.limit {
+		repos = repos[:options.limit]
+	}
+
+	return repos, total, nil
+}
+
+func repoRows(repos []api.RepoListItem, columns []string) [][]string {
+	rows := make([][]string, len(repos))
+	for i, repo := range repos {
+		rows[i] = make([]string, len(columns))
+		for j, c := range columns {
+			rows[i][j] = repoColumns[c].value(repo)
+		}
+	}
+	return rows
+}
+
+// formatRetentionDays returns the time based retention of a repository, or
+// "-" if it has none.
+func formatRetentionDays(days float64) string {
+	if days <= 0 {
+		return "-"
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", days), ".0")
+}
+
+// retentionOrder returns the time based retention for sorting, where no
+// retention, keeping the data forever, is the longest.
+func retentionOrder(days float64) float64 {
+	if days <= 0 {
+		return math.Inf(1)
+	}
+	return days
+}
+
+// globToRegexp returns a regular expression matching the whole string
+// against a glob pattern, where * matches any characters and ? matches one.
+func globToRegexp(glob string) string {
+	pattern := regexp.QuoteMeta(glob)
+	pattern = strings.Replace(pattern, `\*`, ".*", -1)
+	pattern = strings.Replace(pattern, `\?`, ".", -1)
+	return "^" + pattern + "$"
+}
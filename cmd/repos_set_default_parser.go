@@ -0,0 +1,44 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newReposSetDefaultParserCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "set-default-parser [flags] <repo> <parser>",
+		Short: "Set the default parser of a repository.",
+		Long: `Sets the parser used for data ingested into the repository without a
+parser, e.g. with an ingest token that has no parser assigned.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			repoName := args[0]
+			parserName := args[1]
+
+			client := NewApiClient(cmd)
+
+			err := client.Repositories().SetDefaultParser(repoName, parserName)
+			exitOnError(cmd, err, "error setting default parser")
+
+			cmd.Println(fmt.Sprintf("Default parser of repository %s set to %s", repoName, parserName))
+		},
+	}
+
+	return &cmd
+}
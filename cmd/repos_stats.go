@@ -0,0 +1,196 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+type repoUsageBucket struct {
+	Start  time.Time `json:"start"`
+	Bytes  int64     `json:"bytes"`
+	Events int64     `json:"events"`
+}
+
+type repoStats struct {
+	Repository           string            `json:"repository"`
+	CompressedByteSize   int64             `json:"compressedByteSize"`
+	UncompressedByteSize int64             `json:"uncompressedByteSize"`
+	SegmentCount         int               `json:"segmentCount"`
+	Ingest               []repoUsageBucket `json:"ingest"`
+}
+
+func newReposStatsCmd() *cobra.Command {
+	var since, span string
+	var printAsJSON bool
+
+	cmd := cobra.Command{
+		Use:   "stats [flags] <repo>",
+		Short: "Show ingest and storage usage of a repository.",
+		Long: `Shows the current storage usage of a repository and how much data was
+ingested into it in each interval of --span during the last --since, e.g.
+
+  $ humioctl repos stats myrepo --since 7d
+
+The ingest volume is taken from the daily ingest statistics of the server,
+so --since and --span are whole days, and data that has since been deleted
+by retention is included.
+
+Use --json to get the numbers in a format suitable for scripts.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			repoName := args[0]
+
+			client := NewApiClient(cmd)
+
+			usage, err := client.Repositories().Stats(repoName)
+			exitOnError(cmd, err, "error fetching repository")
+
+			buckets, err := fetchRepoIngestUsage(client, repoName, since, span)
+			exitOnError(cmd, err, "error fetching ingest usage")
+
+			stats := repoStats{
+				Repository:           repoName,
+				CompressedByteSize:   usage.CompressedByteSize,
+				UncompressedByteSize: usage.UncompressedByteSize,
+				SegmentCount:         usage.SegmentCount,
+				Ingest:               buckets,
+			}
+
+			if printAsJSON {
+				data, err := json.Marshal(stats)
+				exitOnError(cmd, err, "error encoding stats")
+				printJSON(cmd.OutOrStdout(), data)
+				return
+			}
+
+			printRepoStats(cmd, stats)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "7d", "How far back to report ingest usage, as a relative time in days, e.g. 7d or 4w.")
+	cmd.Flags().StringVar(&span, "span", "1d", "The size of each ingest interval in days, e.g. 1d or 1w.")
+	cmd.Flags().BoolVar(&printAsJSON, "json", false, "Print the stats as JSON.")
+
+	return &cmd
+}
+
+// fetchRepoIngestUsage returns the ingest of a repository during the last
+// since in buckets of span, both relative times in whole days.
+func fetchRepoIngestUsage(client *api.Client, repoName, since, span string) ([]repoUsageBucket, error) {
+	days, err := relativeTimeDays("--since", since)
+	if err != nil {
+		return nil, err
+	}
+	spanDays, err := relativeTimeDays("--span", span)
+	if err != nil {
+		return nil, err
+	}
+
+	statistics, err := client.Repositories().IngestStatistics(repoName, days)
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []repoUsageBucket
+	for i, s := range statistics {
+		if i%spanDays == 0 {
+			buckets = append(buckets, repoUsageBucket{
+				Start: time.Unix(0, s.Start*int64(time.Millisecond)).UTC(),
+			})
+		}
+		b := &buckets[len(buckets)-1]
+		b.Bytes += s.IngestBytes
+		b.Events += s.Events
+	}
+
+	return buckets, nil
+}
+
+// relativeTimeDays returns the number of days of a relative time given with
+// flag, e.g. 7d or 2w.
+func relativeTimeDays(flag, s string) (int, error) {
+	v, err := parseQueryTime(s, time.Now())
+	if err != nil {
+		return 0, usageError(fmt.Sprintf("invalid %s: %v", flag, err))
+	}
+	d, ok := relativeTimeDuration(v)
+	if !ok || d <= 0 || d%(24*time.Hour) != 0 {
+		return 0, usageError(fmt.Sprintf("invalid %s %q, must be a whole number of days, e.g. 7d", flag, s))
+	}
+	return int(d / (24 * time.Hour)), nil
+}
+
+func printRepoStats(cmd *cobra.Command, stats repoStats) {
+	var total int64
+	values := make([]int64, len(stats.Ingest))
+	for i, b := range stats.Ingest {
+		values[i] = b.Bytes
+		total += b.Bytes
+	}
+
+	data := [][]string{
+		{"Repository", stats.Repository},
+		{"Compressed Size", ByteCountDecimal(stats.CompressedByteSize)},
+		{"Uncompressed Size", ByteCountDecimal(stats.UncompressedByteSize)},
+		{"Segments", strconv.Itoa(stats.SegmentCount)},
+		{"Ingest", ByteCountDecimal(total)},
+		{"Ingest Trend", sparkline(values)},
+	}
+
+	w := tablewriter.NewWriter(cmd.OutOrStdout())
+	w.AppendBulk(data)
+	w.SetBorder(false)
+	w.SetColumnSeparator(":")
+	w.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT})
+	w.Render()
+
+	rows := []string{"Start | Ingest | Events"}
+	for _, b := range stats.Ingest {
+		rows = append(rows, fmt.Sprintf("%s | %s | %d", b.Start.Format("2006-01-02 15:04"), ByteCountDecimal(b.Bytes), b.Events))
+	}
+	printTable(cmd, rows)
+}
+
+// sparkline renders values as a line of block characters scaled to the largest value.
+func sparkline(values []int64) string {
+	const ticks = "▁▂▃▄▅▆▇█"
+	blocks := []rune(ticks)
+
+	var max int64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if max > 0 {
+			i = int(v * int64(len(blocks)-1) / max)
+		}
+		b.WriteRune(blocks[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newReposTagGroupingsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tag-groupings",
+		Short: "Manage tag groupings of repositories",
+		Long: `Tag groupings limit the number of datasources created for tags with many
+distinct values, by hashing the values of a tag into a fixed number of
+groups. Each grouping is given as <tag>=<number of groups>:
+
+  $ humioctl repos tag-groupings set web host=16 source=8`,
+	}
+
+	cmd.AddCommand(newReposTagGroupingsShowCmd())
+	cmd.AddCommand(newReposTagGroupingsSetCmd())
+	cmd.AddCommand(newReposTagGroupingsClearCmd())
+
+	return cmd
+}
+
+// parseTagGroupings parses tag groupings of the form <tag>=<modulus>, e.g. "host=16".
+func parseTagGroupings(values []string) ([]api.TagGrouping, error) {
+	groupings := make([]api.TagGrouping, len(values))
+	for i, v := range values {
+		pieces := strings.SplitN(v, "=", 2)
+		if len(pieces) != 2 || pieces[0] == "" {
+			return nil, fmt.Errorf("invalid tag grouping %q, expected <tag>=<number of groups>", v)
+		}
+
+		modulus, err := strconv.Atoi(pieces[1])
+		if err != nil || modulus < 1 {
+			return nil, fmt.Errorf("invalid number of groups in %q, must be a positive integer", v)
+		}
+
+		groupings[i] = api.TagGrouping{TagName: strings.TrimPrefix(pieces[0], "#"), Modulus: modulus}
+	}
+	return groupings, nil
+}
@@ -0,0 +1,40 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newReposTagGroupingsClearCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "clear [flags] <repo>",
+		Short: "Remove all tag groupings of a repository.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			repoName := args[0]
+
+			client := NewApiClient(cmd)
+
+			err := client.Repositories().SetTagGroupings(repoName, []api.TagGrouping{})
+			exitOnError(cmd, err, "error removing tag groupings")
+
+			cmd.Println("Tag groupings of repository " + repoName + " removed")
+		},
+	}
+
+	return &cmd
+}
@@ -0,0 +1,48 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newReposTagGroupingsSetCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "set [flags] <repo> <tag>=<groups>...",
+		Short: "Replace the tag groupings of a repository.",
+		Long: `Replaces the tag groupings of the repository with the given groupings.
+Tags that are not given are no longer grouped.
+
+  $ humioctl repos tag-groupings set web host=16 source=8`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			repoName := args[0]
+
+			groupings, err := parseTagGroupings(args[1:])
+			if err != nil {
+				exitOnError(cmd, usageError(err.Error()), "")
+			}
+
+			client := NewApiClient(cmd)
+
+			err = client.Repositories().SetTagGroupings(repoName, groupings)
+			exitOnError(cmd, err, "error setting tag groupings")
+
+			cmd.Println("Tag groupings of repository " + repoName + " updated")
+		},
+	}
+
+	return &cmd
+}
@@ -0,0 +1,50 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newReposTagGroupingsShowCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "show [flags] <repo>",
+		Short: "Show the tag groupings of a repository.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			repoName := args[0]
+
+			client := NewApiClient(cmd)
+
+			groupings, err := client.Repositories().TagGroupings(repoName)
+			exitOnError(cmd, err, "error fetching tag groupings")
+
+			if len(groupings) == 0 {
+				cmd.Println("Repository " + repoName + " has no tag groupings")
+				return
+			}
+
+			rows := []string{"Tag | Groups"}
+			for _, g := range groupings {
+				rows = append(rows, fmt.Sprintf("#%s | %d", g.TagName, g.Modulus))
+			}
+			printTable(cmd, rows)
+		},
+	}
+
+	return &cmd
+}
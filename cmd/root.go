@@ -15,10 +15,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/humio/cli/api"
 	homedir "github.com/mitchellh/go-homedir"
@@ -26,20 +30,50 @@ import (
 	"github.com/spf13/viper"
 )
 
-var cfgFile, tokenFile, token, address, profileFlag string
+var cfgFile, tokenFile, token, tokenName, address, profileFlag string
 
 var printVersion bool
 
+// endpointMaxRPS holds the --endpoint-max-rps flags, which override the
+// endpoint-max-rps config key.
+var endpointMaxRPS keyValueFlag
+
+// argsValidated is set once cobra has accepted the arguments and flags of
+// the command, so errors before that point are reported as usage errors.
+var argsValidated bool
+
+// commandContext is the context of the command being run. It times out
+// after --timeout, which aborts the requests of the command, and commands
+// that can be interrupted derive their contexts from it.
+var commandContext = context.Background()
+
+// commandTimeout is the value of --timeout, and cancelCommandContext
+// releases the timer of commandContext.
+var commandTimeout time.Duration
+var cancelCommandContext = func() {}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd *cobra.Command
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	err := rootCmd.Execute()
+	cancelCommandContext()
+	if err != nil {
+		if !argsValidated {
+			err = usageError(err.Error())
+		}
+		exitOnError(rootCmd, err, "")
+	}
+	// Commands that stop when their context is done, e.g. ingest, return
+	// no error when they time out.
+	if commandTimedOut() {
+		exitOnError(rootCmd, commandTimeoutError(commandTimeout), "")
 	}
+	stopPager()
+	recordCommandStats(0)
+	recordCommandLog(0, nil, "")
 }
 
 func init() {
@@ -66,17 +100,76 @@ Common Management Commands:
   parsers <subcommand>
   views <subcommand>
 	status
+
+Exit Codes:
+  1  General error        5  Not found (404)
+  2  Invalid arguments    6  GraphQL error
+  3  Unauthorized (401)   7  Could not connect to the server
+  4  Forbidden (403)      8  Server error (5xx)
+                          9  Timed out
+
+  Use --error-format=json to print errors as JSON objects.
+
+Environment Variables:
+  HUMIO_ADDRESS and HUMIO_TOKEN override the values in your config file.
+  HUMIO_ADDRESS_FILE and HUMIO_TOKEN_FILE read the values from files
+  instead, e.g. Kubernetes secrets mounted as files. HUMIO_TOKEN_NAME selects
+  a named token of the profile like --token-name. Flags take precedence
+  over environment variables.
+
+Timeouts:
+  Use --timeout to abort a command that runs for too long, e.g. in CI
+  pipelines. The requests of the command are aborted, and cleanup such as
+  stopping query jobs and sending buffered events still happens. Timeouts
+  of individual HTTP requests can be set in the config file using the keys
+  http-timeout (default 30s), query-timeout (default 2m) and ingest-timeout
+  (default 1m). A value of 0 disables the timeout. Uploads, e.g. of package
+  archives, are not limited by http-timeout while they are sent, only the
+  time until the server responds is.
+
+Rate Limiting:
+  Requests rejected by the server with 429 Too Many Requests are retried
+  after the time given by the server, and other requests, e.g. ingest, wait
+  as well. Use --max-rps, or the config key max-rps, to limit the number of
+  requests sent per second, and --max-concurrent-requests, or the config key
+  max-concurrent-requests, to limit the number of requests sent at once.
+  Commands making many requests, e.g. syncs and exports, queue their
+  requests behind other requests and take --concurrency.
+
+  Use --endpoint-max-rps to limit the requests per second to an endpoint,
+  by path prefix, or set them in the config file, e.g.
+
+    endpoint-max-rps:
+      graphql: 5
+      api/v1/repositories/: 10
+
+Output:
+  Colors are used when stdout is a terminal, unless --no-color is given or
+  the NO_COLOR environment variable is set. Use --pager, or the config key
+  pager, to show long output, e.g. of list commands, through $PAGER.
+
+Audit Log:
+  Use --log-file, or the config key log-file, to append a JSON line to a
+  file when a command starts, for each API request it makes and when it
+  finishes, e.g. to keep a record of changes made by admins. Tokens and
+  other secrets given as flags, and the values of key=value flags such as
+  --var, are redacted. The command is not run if the file cannot be
+  written.
+
+Usage Statistics:
+  Set 'stats: true' in the config file to record which commands are run
+  locally, see 'humioctl stats'.
 		`,
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if printVersion {
-				fmt.Println("humioctl ", version)
-				os.Exit(0)
+				printVersionInfo(cmd, clientVersionInfo(), false)
+				return
 			}
 
 			// If no token or address flags are passed
 			// and no configuration file exists, run login.
-			if viper.GetString("token") == "" && viper.GetString("address") == "" {
+			if configString("token") == "" && configString("address") == "" {
 				if err := newWelcomeCmd().Execute(); err != nil {
 					fmt.Println(fmt.Errorf("error printing welcome message: %v", err))
 				}
@@ -88,8 +181,26 @@ Common Management Commands:
 			}
 		},
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			setupOutput(cmd)
 			cmd.SetOutput(os.Stdout)
+
+			// Errors from here on are not caused by the arguments, so don't print the usage.
+			argsValidated = true
+			cmd.SilenceUsage = true
+
+			exitOnError(cmd, validateErrorFormat(), "")
+
+			startCommandStats(cmd)
+			exitOnError(cmd, startCommandLog(cmd, args), "")
+
+			exitOnError(cmd, checkServerFeatures(cmd), "")
+
+			if commandTimeout = durationFlagOrConfig("timeout"); commandTimeout > 0 {
+				commandContext, cancelCommandContext = context.WithTimeout(context.Background(), commandTimeout)
+			}
 		},
+		// Errors are printed by Execute.
+		SilenceErrors: true,
 	}
 
 	cobra.OnInitialize(initConfig)
@@ -100,23 +211,40 @@ Common Management Commands:
 	rootCmd.PersistentFlags().StringVarP(&profileFlag, "profile", "u", "", "Name of the config profile to use")
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "Config file (default is $HOME/.humio/config.yaml)")
 	rootCmd.PersistentFlags().StringVarP(&token, "token", "t", "", "The API token to user when talking to Humio. Overrides the value in your config file.")
+	rootCmd.PersistentFlags().StringVar(&tokenName, "token-name", "", "Use the named token of the profile instead of its own token, see 'humioctl profiles tokens'.")
 	rootCmd.PersistentFlags().StringVar(&tokenFile, "token-file", "", "File path to a file containing the API token. Overrides the value in your config file and the value of --token.")
 	rootCmd.PersistentFlags().StringVarP(&address, "address", "a", "", "The HTTP address of the Humio cluster. Overrides the value in your config file.")
+	rootCmd.PersistentFlags().StringVar(&errorFormat, "error-format", "text", "The format of error messages, either text or json.")
+	rootCmd.PersistentFlags().BoolVar(&noKeychain, "no-keychain", false, "Save API tokens of new profiles in the config file instead of the OS keychain.")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Abort the command if it has not completed within this duration, e.g. 5m. Defaults to no timeout.")
+	rootCmd.PersistentFlags().Float64("max-rps", 0, "The maximum number of requests per second to send to the server. Defaults to no limit.")
+	rootCmd.PersistentFlags().Int("max-concurrent-requests", 0, "The maximum number of requests to send to the server at once. Defaults to no limit.")
+	rootCmd.PersistentFlags().Var(&endpointMaxRPS, "endpoint-max-rps", "The maximum number of requests per second to send to an endpoint, by path prefix, e.g. graphql=5. Specify multiple times for multiple endpoints.")
+
+	rootCmd.PersistentFlags().Bool("no-color", false, "Don't use colors in the output.")
+	rootCmd.PersistentFlags().Bool("pager", false, "Show long output through $PAGER, or less if it is not set, when stdout is a terminal.")
+	rootCmd.PersistentFlags().String("log-file", "", "Append a JSON line for the command, each API request it makes and its outcome to this file.")
 
 	viper.BindPFlag("address", rootCmd.PersistentFlags().Lookup("address"))
 	viper.BindPFlag("token", rootCmd.PersistentFlags().Lookup("token"))
 	viper.BindPFlag("token-file", rootCmd.PersistentFlags().Lookup("token-file"))
 
 	rootCmd.Flags().BoolVarP(&printVersion, "version", "v", false, "Print the client version")
+	rootCmd.Flags().MarkDeprecated("version", "use 'humioctl version' instead")
 
 	rootCmd.AddCommand(newUsersCmd())
+	rootCmd.AddCommand(newAuthCmd())
 	rootCmd.AddCommand(newParsersCmd())
 	rootCmd.AddCommand(newIngestCmd())
+	rootCmd.AddCommand(newAgentCmd())
 	rootCmd.AddCommand(newProfilesCmd())
 	rootCmd.AddCommand(newIngestTokensCmd())
+	rootCmd.AddCommand(newTokensCmd())
+	rootCmd.AddCommand(newIPFiltersCmd())
 	rootCmd.AddCommand(newViewsCmd())
 	rootCmd.AddCommand(newCompletionCmd())
 	rootCmd.AddCommand(newLicenseCmd())
+	rootCmd.AddCommand(newOrgCmd())
 	rootCmd.AddCommand(newReposCmd())
 	rootCmd.AddCommand(newSearchCmd())
 	rootCmd.AddCommand(newStatusCmd())
@@ -124,9 +252,27 @@ Common Management Commands:
 	rootCmd.AddCommand(newClusterCmd())
 	rootCmd.AddCommand(newNotifiersCmd())
 	rootCmd.AddCommand(newAlertsCmd())
+	rootCmd.AddCommand(newApiCmd())
+	rootCmd.AddCommand(newQueryCmd())
+	rootCmd.AddCommand(newQueryJobsCmd())
+	rootCmd.AddCommand(newPackagesCmd())
+	rootCmd.AddCommand(newBundleCmd())
+	rootCmd.AddCommand(newFdrFeedsCmd())
+	rootCmd.AddCommand(newAuditCmd())
+	rootCmd.AddCommand(newMetricsCmd())
+	rootCmd.AddCommand(newUsageCmd())
+	rootCmd.AddCommand(newLoginCmd())
+	rootCmd.AddCommand(newHistoryCmd())
+	rootCmd.AddCommand(newVersionCmd())
+	rootCmd.AddCommand(newDoctorCmd())
+	rootCmd.AddCommand(newValidateCmd())
+	rootCmd.AddCommand(newStatsCmd())
 
 	// Hidden Commands
 	rootCmd.AddCommand(newWelcomeCmd())
+	rootCmd.AddCommand(newResourceCompletionCmd())
+
+	rootCmd.BashCompletionFunction = bashCompletionFunction
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -137,10 +283,7 @@ func initConfig() {
 	} else {
 		// Find home directory.
 		home, err := homedir.Dir()
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
+		exitOnError(rootCmd, err, "error finding home directory")
 
 		cfgFile = path.Join(home, ".humio", "config.yaml")
 		viper.SetConfigFile(cfgFile)
@@ -156,10 +299,7 @@ func initConfig() {
 	// If the user has specified a profile flag, load it.
 	if profileFlag != "" {
 		profile, loadErr := loadProfile(profileFlag)
-		if loadErr != nil {
-			fmt.Println(fmt.Errorf("failed to load profile: %s", loadErr))
-			os.Exit(1)
-		}
+		exitOnError(rootCmd, loadErr, "failed to load profile")
 
 		// Explicitly bound address or token have precedence
 		if address == "" {
@@ -170,31 +310,171 @@ func initConfig() {
 		}
 	}
 
-	if tokenFile != "" {
-		tokenFileContent, tokenFileErr := ioutil.ReadFile(tokenFile)
-		if tokenFileErr != nil {
-			fmt.Println(fmt.Sprintf("error loading token file: %s", tokenFileErr))
-			os.Exit(1)
-		}
-		viper.Set("token", string(tokenFileContent))
+	if tokenName == "" {
+		tokenName = os.Getenv("HUMIO_TOKEN_NAME")
+	}
+	// An explicitly given token has precedence over the named token.
+	if tokenName != "" && token == "" && tokenFile == "" {
+		namedToken, err := loadNamedToken(profileFlag, tokenName)
+		exitOnError(rootCmd, err, "failed to load token")
+		resolvedSettings["token"] = namedToken
 	}
+
+	setFromFile("token", tokenFile, token)
+	setFromFile("address", "", address)
 }
 
-func NewApiClient(cmd *cobra.Command) *api.Client {
-	client, err := newApiClientE(cmd)
+// durationFlagOrConfig returns the value of the persistent flag name if it
+// was given, and otherwise the value of the key of the same name in the config
+// file or environment. Flags that only apply to one command, like --timeout,
+// are read this way instead of being bound to viper, as bound flags would be
+// saved to the config file with its next change.
+func durationFlagOrConfig(name string) time.Duration {
+	if flags := rootCmd.PersistentFlags(); flags.Changed(name) {
+		d, _ := flags.GetDuration(name)
+		return d
+	}
+	return viper.GetDuration(name)
+}
 
-	if err != nil {
-		fmt.Println(fmt.Errorf("Error creating HTTP client: %s", err))
-		os.Exit(1)
+// float64FlagOrConfig is like durationFlagOrConfig for a number.
+func float64FlagOrConfig(name string) float64 {
+	if flags := rootCmd.PersistentFlags(); flags.Changed(name) {
+		f, _ := flags.GetFloat64(name)
+		return f
+	}
+	return viper.GetFloat64(name)
+}
+
+// intFlagOrConfig is like durationFlagOrConfig for an integer.
+func intFlagOrConfig(name string) int {
+	if flags := rootCmd.PersistentFlags(); flags.Changed(name) {
+		n, _ := flags.GetInt(name)
+		return n
+	}
+	return viper.GetInt(name)
+}
+
+// boolFlagOrConfig is like durationFlagOrConfig for a boolean.
+func boolFlagOrConfig(name string) bool {
+	if flags := rootCmd.PersistentFlags(); flags.Changed(name) {
+		b, _ := flags.GetBool(name)
+		return b
+	}
+	return viper.GetBool(name)
+}
+
+// stringFlagOrConfig is like durationFlagOrConfig for a string.
+func stringFlagOrConfig(name string) string {
+	if flags := rootCmd.PersistentFlags(); flags.Changed(name) {
+		s, _ := flags.GetString(name)
+		return s
+	}
+	return viper.GetString(name)
+}
+
+// setFromFile sets a config value from the content of a file, e.g. a
+// Kubernetes secret mounted as a file. A file passed by flag overrides all
+// other values, while a file from the HUMIO_<KEY>_FILE environment variable
+// only overrides the environment and config file, like other variables.
+func setFromFile(key, fileFlag, valueFlag string) {
+	file := fileFlag
+	if file == "" && valueFlag == "" && profileFlag == "" {
+		file = os.Getenv("HUMIO_" + strings.ToUpper(key) + "_FILE")
+	}
+	if file == "" {
+		return
 	}
 
+	content, err := ioutil.ReadFile(file)
+	exitOnError(rootCmd, err, fmt.Sprintf("error loading %s file", key))
+	resolvedSettings[key] = strings.TrimSpace(string(content))
+}
+
+// resolvedSettings holds the address and token read from files or from the
+// named tokens of a profile. They take precedence over the values in viper,
+// but are kept out of it, as the settings in viper are saved to the config
+// file with its next change.
+var resolvedSettings = map[string]string{}
+
+// configString returns the setting key, e.g. the address of the cluster to
+// use, including the values read from files and named tokens.
+func configString(key string) string {
+	if value, ok := resolvedSettings[key]; ok {
+		return value
+	}
+	return viper.GetString(key)
+}
+
+func NewApiClient(cmd *cobra.Command) *api.Client {
+	client, err := newApiClientE(cmd)
+	exitOnError(cmd, err, "Error creating HTTP client")
+
 	return client
 }
 
 func newApiClientE(cmd *cobra.Command) (*api.Client, error) {
+	return newApiClientForLogin(configString("address"), configString("token"))
+}
+
+// newApiClientForLogin creates a client for the given address and token,
+// using the other client settings from the config.
+func newApiClientForLogin(address, token string) (*api.Client, error) {
 	config := api.DefaultConfig()
-	config.Address = viper.GetString("address")
-	config.Token = viper.GetString("token")
+	config.Address = address
+
+	var err error
+	config.Token, err = resolveToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, timeout := range map[string]*time.Duration{
+		"http-timeout":   &config.Timeout,
+		"query-timeout":  &config.QueryTimeout,
+		"ingest-timeout": &config.IngestTimeout,
+	} {
+		if viper.IsSet(key) {
+			*timeout = viper.GetDuration(key)
+		}
+	}
+
+	config.MaxRequestsPerSecond = float64FlagOrConfig("max-rps")
+	config.MaxConcurrentRequests = intFlagOrConfig("max-concurrent-requests")
+	config.EndpointRateLimits, err = endpointRateLimits()
+	if err != nil {
+		return nil, err
+	}
+	if commandLogEnabled() {
+		config.RequestLogger = logAPIRequest
+	}
+	config.Context = commandContext
 
 	return api.NewClient(config)
 }
+
+// endpointRateLimits returns the limits of the endpoint-max-rps config key,
+// overridden by the --endpoint-max-rps flags.
+func endpointRateLimits() (map[string]float64, error) {
+	values := map[string]string{}
+	for endpoint, v := range viper.GetStringMap("endpoint-max-rps") {
+		values[endpoint] = fmt.Sprint(v)
+	}
+	for endpoint, v := range endpointMaxRPS.values {
+		values[endpoint] = v
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	limits := make(map[string]float64, len(values))
+	for endpoint, v := range values {
+		limit, err := strconv.ParseFloat(v, 64)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid rate limit %q for endpoint %s, expected a number of requests per second", v, endpoint)
+		}
+		limits[endpoint] = limit
+	}
+	return limits, nil
+}
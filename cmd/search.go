@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/humio/cli/api"
 	"github.com/humio/cli/prompt"
 	"github.com/olekukonko/tablewriter"
+	"github.com/skratchdot/open-golang/open"
 	"github.com/spf13/cobra"
 	"io"
 	"math"
@@ -20,31 +22,216 @@ import (
 
 func newSearchCmd() *cobra.Command {
 	var (
-		start      string
-		end        string
-		live       bool
-		fmtStr     string
-		noProgress bool
+		start             string
+		end               string
+		since             string
+		until             string
+		live              bool
+		fmtStr            string
+		noProgress        bool
+		aggregateProgress bool
+		export            searchExportOptions
+		params            keyValueFlag
+		noHistory         bool
+		notify            []string
+		repos             []string
+		view              string
+		explain           bool
+		openUI            bool
+		printURL          bool
 	)
 
 	cmd := &cobra.Command{
-		Use:   "search <repo> <query>",
+		Use:   "search [<repo>] <query>",
 		Short: "Search",
-		Args:  cobra.ExactArgs(2),
+		Long: `Runs a query against <repo> and prints the result.
+
+To search several repositories at once, give them with --repo instead of
+<repo>. The query is run against each of them concurrently and the results
+are combined, with a #repo field telling which repository each event or row
+is from. Event lists are sorted by @timestamp and include #repo in the
+default --fmt:
+
+  $ humioctl search --repo web,api,auth 'status=500' --since 1h
+
+A view can be given with --view, which is the same as giving it as <repo>.
+Live searches and --export are not supported with multiple repositories;
+create a view for those instead.
+
+Queries can refer to parameters using the ?param syntax. Values for the
+parameters are given using --param, e.g.
+
+  $ humioctl search web 'status=?status | count()' --param status=500
+
+The time range is given by --start and --end, or their aliases --since and
+--until, as either a relative time, e.g. 10m, 2h, 7d or "3 days ago", an
+absolute time, e.g. 2020-01-01T00:00:00Z or 2020-01-01 (in the local time
+zone), now, today, yesterday, or seconds or milliseconds since the epoch:
+
+  $ humioctl search web 'status=500' --since yesterday --until today
+
+Searches are recorded in the query history, see 'humioctl history'. Use
+--no-history to leave a search out.
+
+Use --notify to be notified when a long-running search completes, either
+with a desktop notification or by posting a summary to a webhook:
+
+  $ humioctl search web 'count()' --start 30d --notify desktop
+  $ humioctl search web 'count()' --start 30d --notify https://hooks.example.com/humio
+
+Use --explain to check a query before running it, e.g. a search over a long
+time range. It prints how the query is going to be run, such as the tag
+filters Humio can use to skip datasources and whether the query searches free
+text, and estimates the amount of data scanned from the segments in the time
+range. The query is not run:
+
+  $ humioctl search web '#type=accesslog status=500 | count()' --start 90d --explain
+
+Use --open to open the search in the Humio UI instead of running it, with
+the same repository, query and time range, e.g. to share it with teammates,
+or --url to print the link:
+
+  $ humioctl search web 'status=500 | top(url)' --since 1h --url`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(repos) > 0 || view != "" {
+				if len(repos) > 0 && view != "" {
+					return fmt.Errorf("--repo cannot be used together with --view")
+				}
+				if len(args) != 1 {
+					return fmt.Errorf("only <query> can be given when using --repo or --view")
+				}
+				return nil
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
-			repository := args[0]
-			queryString := args[1]
+			var repository, queryString string
+			switch {
+			case len(repos) == 1:
+				repository, queryString = repos[0], args[0]
+			case len(repos) > 1:
+				repository, queryString = strings.Join(repos, ","), args[0]
+				if live {
+					exitOnError(cmd, usageError("--live cannot be used with multiple repositories"), "")
+				}
+				if export.file != "" {
+					exitOnError(cmd, usageError("--export cannot be used with multiple repositories"), "")
+				}
+				if !cmd.Flags().Changed("fmt") {
+					fmtStr = "{@timestamp} {#repo} {@rawstring}"
+				}
+			case view != "":
+				repository, queryString = view, args[0]
+			default:
+				repository, queryString = args[0], args[1]
+			}
+
+			if len(notify) > 0 {
+				if live {
+					exitOnError(cmd, usageError("--notify cannot be used with --live"), "")
+				}
+				if err := validateNotifyTargets(notify); err != nil {
+					exitOnError(cmd, usageError(err.Error()), "")
+				}
+			}
+
+			if cmd.Flags().Changed("since") {
+				if cmd.Flags().Changed("start") {
+					exitOnError(cmd, usageError("--since cannot be used together with --start"), "")
+				}
+				start = since
+			}
+			if cmd.Flags().Changed("until") {
+				if cmd.Flags().Changed("end") {
+					exitOnError(cmd, usageError("--until cannot be used together with --end"), "")
+				}
+				end = until
+			}
+
+			queryStart, queryEnd, err := parseQueryTimeRange(start, end, time.Now())
+			if err != nil {
+				exitOnError(cmd, usageError(err.Error()), "")
+			}
+
 			client := NewApiClient(cmd)
 
-			ctx := contextCancelledOnInterrupt(context.Background())
+			if openUI || printURL {
+				if len(repos) > 1 {
+					exitOnError(cmd, usageError("--open and --url cannot be used with multiple repositories"), "")
+				}
+				if len(params.values) > 0 {
+					fmt.Fprintln(os.Stderr, "The values of --param are not included in the link, enter them in the UI.")
+				}
+
+				link := searchURL(client.Address(), repository, queryString, queryStart, queryEnd, live)
+				if printURL {
+					cmd.Println(link)
+					return
+				}
+				if err := open.Start(link); err != nil {
+					fmt.Fprintf(os.Stderr, "Could not open the browser: %v\n", err)
+					cmd.Println(link)
+				}
+				return
+			}
+
+			if explain {
+				repositories := repos
+				if len(repositories) == 0 {
+					repositories = []string{repository}
+				}
+				if problems := explainSearch(cmd.OutOrStdout(), client, repositories, queryString, queryStart, queryEnd, live); len(problems) > 0 {
+					os.Exit(1)
+				}
+				return
+			}
+
+			ctx := contextCancelledOnInterrupt(commandContext)
+
+			startedAt := time.Now()
+			resultCount := -1
 
 			// run in lambda func to be able to defer and delete the query job
-			err := func() error {
-				id, err := client.QueryJobs().Create(repository, api.Query{
+			err = func() error {
+				if len(repos) > 1 {
+					result, err := searchRepositories(ctx, client, repos, api.Query{
+						QueryString: queryString,
+						Start:       queryStart,
+						End:         queryEnd,
+						Arguments:   params.values,
+					}, noProgress)
+					if err != nil {
+						return err
+					}
+
+					if result.Metadata.IsAggregate {
+						newAggregatePrinter(cmd.OutOrStdout()).print(result)
+					} else {
+						newEventListPrinter(cmd.OutOrStdout(), fmtStr).print(result)
+					}
+					resultCount = len(result.Events)
+					return nil
+				}
+
+				if export.file != "" {
+					if live {
+						return fmt.Errorf("--export cannot be used with --live")
+					}
+					export.noProgress = noProgress
+					return exportSearchResult(ctx, client, repository, api.Query{
+						QueryString: queryString,
+						Start:       queryStart,
+						End:         queryEnd,
+						Arguments:   params.values,
+					}, export)
+				}
+
+				id, err := client.QueryJobs().CreateContext(ctx, repository, api.Query{
 					QueryString: queryString,
-					Start:       start,
-					End:         end,
+					Start:       queryStart,
+					End:         queryEnd,
 					Live:        live,
+					Arguments:   params.values,
 					ShowQueryEventDistribution: true,
 				})
 
@@ -52,12 +239,8 @@ func newSearchCmd() *cobra.Command {
 					return err
 				}
 
-				var progress *queryResultProgressBar
-				if !noProgress {
-					progress = newQueryResultProgressBar()
-				}
-
 				defer func(id string) {
+					// Also stops the query on the server if it was interrupted.
 					// Humio will eventually delete the query when we stop polling and we can't do much about errors here.
 					_ = client.QueryJobs().Delete(repository, id)
 				}(id)
@@ -84,12 +267,29 @@ func newSearchCmd() *cobra.Command {
 					printer = newEventListPrinter(cmd.OutOrStdout(), fmtStr)
 				}
 
+				// The progress is written to stderr, so it does not end up in the output if it is redirected.
+				var progress *queryResultProgressBar
+				var partial *aggregateProgressPrinter
+				switch {
+				case noProgress:
+				case aggregateProgress && result.Metadata.IsAggregate:
+					partial = newAggregateProgressPrinter(os.Stderr)
+				default:
+					progress = newQueryResultProgressBar()
+				}
+
 				for !result.Done {
 					if progress != nil {
 						progress.Update(result)
 					}
+					if partial != nil {
+						partial.update(result)
+					}
 					result, err = poller.WaitAndPollContext(ctx)
 					if err != nil {
+						if partial != nil {
+							partial.clear()
+						}
 						return err
 					}
 				}
@@ -98,8 +298,12 @@ func newSearchCmd() *cobra.Command {
 					progress.Update(result)
 					progress.Finish()
 				}
+				if partial != nil {
+					partial.clear()
+				}
 
 				printer.print(result)
+				resultCount = len(result.Events)
 
 				if live {
 					for {
@@ -109,16 +313,64 @@ func newSearchCmd() *cobra.Command {
 						}
 
 						printer.print(result)
+						resultCount = len(result.Events)
 					}
 				}
 
 				return nil
 			}()
 
-			if err == context.Canceled {
+			// Interrupting a search is not an error.
+			interrupted := errors.Is(err, context.Canceled)
+			if interrupted {
 				err = nil
 			}
 
+			// There is no need to notify about searches interrupted by the user.
+			if len(notify) > 0 && !interrupted {
+				n := searchNotification{
+					Repository: repository,
+					Query:      queryString,
+					Start:      start,
+					End:        end,
+					DurationMs: time.Since(startedAt).Milliseconds(),
+				}
+				if resultCount >= 0 {
+					n.Results = &resultCount
+				}
+				if err != nil {
+					n.Error = err.Error()
+				}
+				for _, notifyErr := range sendSearchNotifications(notify, n) {
+					fmt.Fprintf(os.Stderr, "%s\n", notifyErr)
+				}
+			}
+
+			if !noHistory {
+				entry := queryHistoryEntry{
+					Time:       startedAt,
+					Address:    client.Address(),
+					Repository: repository,
+					Query:      queryString,
+					Start:      start,
+					End:        end,
+					Live:       live,
+					Params:     params.values,
+					DurationMs: time.Since(startedAt).Milliseconds(),
+				}
+				if resultCount >= 0 {
+					entry.Results = &resultCount
+				}
+				if err != nil {
+					entry.Error = err.Error()
+				}
+
+				// Failing to record the search is not a reason to fail the command.
+				if historyErr := appendQueryHistory(entry); historyErr != nil {
+					fmt.Fprintf(os.Stderr, "Could not record the search in the query history: %s\n", historyErr)
+				}
+			}
+
 			if queryError, ok := err.(api.QueryError); ok {
 				fmt.Printf("There was an error in your query string:\n\n%s\n", queryError.Error())
 				os.Exit(1)
@@ -128,18 +380,35 @@ func newSearchCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&start, "start", "s", "10m", "Query start time")
-	cmd.Flags().StringVarP(&end, "end", "e", "", "Query end time")
+	cmd.Flags().StringVarP(&start, "start", "s", "10m", "Query start time, e.g. 2h, 2020-01-01T00:00:00Z, yesterday or milliseconds since the epoch.")
+	cmd.Flags().StringVarP(&end, "end", "e", "", "Query end time, in the same formats as --start. Defaults to now.")
+	cmd.Flags().StringVar(&since, "since", "", "An alias of --start.")
+	cmd.Flags().StringVar(&until, "until", "", "An alias of --end.")
 	cmd.Flags().BoolVarP(&live, "live", "l", false, "Run a live search and keep outputting until interrupted.")
 	cmd.Flags().StringVarP(&fmtStr, "fmt", "f", "{@timestamp} {@rawstring}", "Format string if the result is an event list\n"+
 		"Insert fields by wrapping field names in brackets, e.g. {@timestamp}\n"+
 		"Limited format modifiers are supported such as {@timestamp:40} which will right align and left pad @timestamp to 40 characters.\n"+
 		"{@timestamp:-40} left aligns and right pads to 40 characters.")
 	cmd.Flags().BoolVar(&noProgress, "no-progress", false, "Do not should progress information.")
+	cmd.Flags().BoolVar(&aggregateProgress, "aggregate-progress", false, "For aggregate queries, show the intermediate result while the query is running, updating it in place.")
+	cmd.Flags().Var(&params, "param", "Set the value of a query parameter, e.g. --param status=500. Specify multiple times for multiple parameters.")
+	cmd.Flags().BoolVar(&noHistory, "no-history", false, "Do not record the search in the query history.")
+	cmd.Flags().StringSliceVarP(&repos, "repo", "r", nil, "Search these repositories instead of <repo>, e.g. --repo web,api. Specify multiple times or comma separated for multiple repositories.")
+	cmd.Flags().StringVar(&view, "view", "", "Search this view instead of <repo>.")
+	cmd.Flags().BoolVarP(&openUI, "open", "o", false, "Open the search in the Humio UI in the browser instead of running it.")
+	cmd.Flags().BoolVar(&printURL, "url", false, "Print the link to the search in the Humio UI instead of running it.")
+	cmd.Flags().BoolVar(&explain, "explain", false, "Print how the query would be run and an estimate of the data it scans, without running it.")
+	cmd.Flags().StringSliceVar(&notify, "notify", nil, "Notify when the search completes, either 'desktop' for a desktop notification or the URL of a webhook to post a summary to. Can be given multiple times.")
+	markSecretFlag(cmd, "notify")
+	cmd.Flags().StringVar(&export.file, "export", "", "Write the full result to a file instead of printing it, fetching it in pages of query jobs. Suitable for very large result sets.")
+	cmd.Flags().StringVar(&export.format, "export-format", "", "When used with --export: The file format, either 'ndjson' or 'csv'. Defaults to 'csv' if the file name ends in .csv, otherwise 'ndjson'.")
+	cmd.Flags().StringSliceVar(&export.fields, "export-fields", nil, "When used with --export-format=csv: The fields to use as columns. Defaults to the fields of the first event.")
 
 	return cmd
 }
 
+// contextCancelledOnInterrupt returns a context which is cancelled when the
+// process receives SIGINT or SIGTERM, e.g. when Ctrl-C is pressed.
 func contextCancelledOnInterrupt(ctx context.Context) context.Context {
 	ctx, cancel := context.WithCancel(ctx)
 
@@ -149,6 +418,8 @@ func contextCancelledOnInterrupt(ctx context.Context) context.Context {
 	go func() {
 		<-sigC
 		cancel()
+		// A second interrupt terminates the process immediately.
+		signal.Stop(sigC)
 	}()
 
 	return ctx
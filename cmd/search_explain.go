@@ -0,0 +1,479 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/olekukonko/tablewriter"
+)
+
+// largeScanBytes is the estimated amount of data scanned above which
+// explainSearch suggests narrowing the query.
+const largeScanBytes = 1000 * 1000 * 1000 * 1000
+
+// aggregateFunctions are the functions of the query language that turn an
+// event list into an aggregate result, by lowercase name.
+var aggregateFunctions = map[string]bool{
+	"avg": true, "bucket": true, "collect": true, "count": true,
+	"counterasrate": true, "fieldstats": true, "groupby": true, "head": true,
+	"max": true, "min": true, "percentile": true, "range": true,
+	"sankey": true, "selectlast": true, "series": true, "session": true,
+	"sort": true, "stats": true, "stddev": true, "sum": true, "table": true,
+	"tail": true, "timechart": true, "top": true, "worldmap": true,
+}
+
+var queryFunctionCallPattern = regexp.MustCompile(`^(?:[\w.]+\s*:=\s*)?([A-Za-z_][\w:.]*)\s*\(`)
+
+// tagFilter is a filter on a tag field, e.g. #type=accesslog. The value can
+// contain * wildcards.
+type tagFilter struct {
+	tag   string
+	value string
+}
+
+func (f tagFilter) String() string {
+	return "#" + f.tag + "=" + f.value
+}
+
+func (f tagFilter) matches(value string) bool {
+	pattern := "^" + strings.Replace(regexp.QuoteMeta(f.value), `\*`, ".*", -1) + "$"
+	matched, _ := regexp.MatchString(pattern, value)
+	return matched
+}
+
+// queryPlan is the result of a static analysis of a query. Like lintQuery it
+// does not know the full query language, so it is a best effort.
+type queryPlan struct {
+	stages int
+	// tagFilters are the tag filters before the first function of the
+	// query, which Humio uses to skip the datasources that do not match.
+	tagFilters []tagFilter
+	// ignoredTagFilters is set when some of the tag filters are combined with
+	// 'or' or negated, so they cannot be used to skip datasources.
+	ignoredTagFilters bool
+	freeText          bool
+	rawStringRegex    bool
+	functions         []string
+	aggregate         string
+}
+
+// analyzeQuery splits the query into the stages of its pipeline and finds
+// what is searched for in each of them.
+func analyzeQuery(query string) queryPlan {
+	var plan queryPlan
+	beforeFunctions := true
+
+	for _, stage := range splitQueryPipeline(query) {
+		stage = strings.TrimSpace(stage)
+		if stage == "" {
+			continue
+		}
+		plan.stages++
+
+		if m := queryFunctionCallPattern.FindStringSubmatch(stage); m != nil {
+			name := m[1]
+			plan.functions = append(plan.functions, name)
+			if aggregateFunctions[strings.ToLower(name)] && plan.aggregate == "" {
+				plan.aggregate = name
+			}
+			if strings.EqualFold(name, "regex") && !strings.Contains(stage, "field") && beforeFunctions {
+				plan.rawStringRegex = true
+			}
+			beforeFunctions = false
+			continue
+		}
+
+		tokens := tokenizeQueryFilter(stage)
+		combined := false
+		for _, t := range tokens {
+			if t.kind == "word" && (strings.EqualFold(t.text, "or") || strings.EqualFold(t.text, "not")) {
+				combined = true
+			}
+		}
+
+		for i, t := range tokens {
+			var prev, next queryToken
+			if i > 0 {
+				prev = tokens[i-1]
+			}
+			if i+1 < len(tokens) {
+				next = tokens[i+1]
+			}
+
+			switch {
+			case t.kind == "word" && strings.HasPrefix(t.text, "#") && next.text == "=" && i+2 < len(tokens):
+				if !beforeFunctions {
+					continue
+				}
+				if combined || prev.text == "!" {
+					plan.ignoredTagFilters = true
+					continue
+				}
+				value := tokens[i+2].text
+				if tokens[i+2].kind == "string" {
+					if unquoted, err := strconv.Unquote(value); err == nil {
+						value = unquoted
+					}
+				}
+				plan.tagFilters = append(plan.tagFilters, tagFilter{tag: strings.TrimPrefix(t.text, "#"), value: value})
+			case t.kind == "regex" && prev.kind != "op":
+				if beforeFunctions {
+					plan.rawStringRegex = true
+				}
+			case (t.kind == "word" || t.kind == "string") && prev.kind != "op" && next.kind != "op":
+				switch strings.ToLower(t.text) {
+				case "and", "or", "not", "*":
+				default:
+					plan.freeText = true
+				}
+			}
+		}
+	}
+
+	return plan
+}
+
+// splitQueryPipeline splits the query on the pipes outside strings, regular
+// expressions and brackets, leaving out comments.
+func splitQueryPipeline(query string) []string {
+	var stages []string
+	var stage strings.Builder
+	depth := 0
+
+	for _, l := range lexQuery(query) {
+		switch {
+		case l.kind == "comment":
+			stage.WriteRune(' ')
+		case l.kind == "char" && l.text == "|" && depth == 0:
+			stages = append(stages, stage.String())
+			stage.Reset()
+		default:
+			if l.kind == "char" {
+				switch l.text {
+				case "(", "[", "{":
+					depth++
+				case ")", "]", "}":
+					if depth > 0 {
+						depth--
+					}
+				}
+			}
+			stage.WriteString(l.text)
+		}
+	}
+
+	return append(stages, stage.String())
+}
+
+type queryToken struct {
+	kind string
+	text string
+}
+
+// tokenizeQueryFilter splits a filter, e.g. `#type=web "error" status!=200`,
+// into words, strings, regular expressions and operators.
+func tokenizeQueryFilter(filter string) []queryToken {
+	var tokens []queryToken
+	runes := []rune(filter)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+		case r == '"' || r == '/':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				j = len(runes) - 1
+			}
+			kind := "string"
+			if r == '/' {
+				kind = "regex"
+				// Flags, e.g. /error/i.
+				for j+1 < len(runes) && strings.ContainsRune("igmdF", runes[j+1]) {
+					j++
+				}
+			}
+			tokens = append(tokens, queryToken{kind, string(runes[i : j+1])})
+			i = j
+		case strings.ContainsRune("=!<>", r):
+			j := i
+			for j+1 < len(runes) && strings.ContainsRune("=~<>", runes[j+1]) {
+				j++
+			}
+			tokens = append(tokens, queryToken{"op", string(runes[i : j+1])})
+			i = j
+		case strings.ContainsRune("(),", r):
+			tokens = append(tokens, queryToken{"paren", string(r)})
+		default:
+			j := i
+			for j+1 < len(runes) && !strings.ContainsRune(" \t\r\n\"=!<>(),", runes[j+1]) {
+				j++
+			}
+			tokens = append(tokens, queryToken{"word", string(runes[i : j+1])})
+			i = j
+		}
+	}
+
+	// A lone ! negates what follows, it is not a comparison.
+	for i := range tokens {
+		if tokens[i].text == "!" {
+			tokens[i].kind = "not"
+		}
+	}
+
+	return tokens
+}
+
+// queryTimeMillis returns the time in milliseconds since the epoch of a query
+// start or end returned by parseQueryTimeRange.
+func queryTimeMillis(t string, now time.Time) (int64, bool) {
+	nowMillis := now.UnixNano() / int64(time.Millisecond)
+	switch {
+	case t == "" || t == "now":
+		return nowMillis, true
+	case isDigits(t):
+		n, err := strconv.ParseInt(t, 10, 64)
+		return n, err == nil
+	}
+	if d, ok := relativeTimeDuration(t); ok {
+		return nowMillis - int64(d/time.Millisecond), true
+	}
+	return 0, false
+}
+
+// scanEstimate is an estimate of the data a query scans in a repository,
+// from the segments overlapping the time range in the datasources matching
+// the tag filters of the query.
+type scanEstimate struct {
+	repository       string
+	segments         int
+	totalSegments    int
+	datasources      int
+	totalDatasources int
+	compressedSize   int64
+	// size is the estimated uncompressed size, using the compression ratio
+	// of the repository, or 0 if it is not known.
+	size int64
+	err  error
+}
+
+func estimateQueryScan(client *api.Client, repository string, plan queryPlan, startMillis, endMillis int64) scanEstimate {
+	estimate := scanEstimate{repository: repository}
+
+	segments, err := client.Repositories().Segments(repository)
+	if err != nil {
+		estimate.err = err
+		return estimate
+	}
+
+	// Without the datasources, all of them are assumed to match.
+	matching := map[string]bool{}
+	all := map[string]bool{}
+	datasources, err := client.Repositories().Datasources(repository)
+	if err == nil {
+		for _, ds := range datasources {
+			all[ds.ID] = true
+			matching[ds.ID] = matchesTagFilters(plan.tagFilters, ds.Tags)
+		}
+	}
+
+	scanned := map[string]bool{}
+	for _, s := range segments {
+		all[s.Datasource] = true
+		estimate.totalSegments++
+		if m, ok := matching[s.Datasource]; ok && !m {
+			continue
+		}
+		if s.End < startMillis || s.Start > endMillis {
+			continue
+		}
+		estimate.segments++
+		estimate.compressedSize += s.CurrentSize
+		scanned[s.Datasource] = true
+	}
+	estimate.datasources = len(scanned)
+	estimate.totalDatasources = len(all)
+
+	if stats, err := client.Repositories().Stats(repository); err == nil && stats.CompressedByteSize > 0 {
+		ratio := float64(stats.UncompressedByteSize) / float64(stats.CompressedByteSize)
+		estimate.size = int64(float64(estimate.compressedSize) * ratio)
+	}
+
+	return estimate
+}
+
+// matchesTagFilters returns whether a datasource with the tags matches the
+// filters. Filters on the same tag match if any of them do.
+func matchesTagFilters(filters []tagFilter, tags map[string]string) bool {
+	byTag := map[string][]tagFilter{}
+	for _, f := range filters {
+		byTag[f.tag] = append(byTag[f.tag], f)
+	}
+
+	for tag, fs := range byTag {
+		value, ok := tags[tag]
+		if !ok {
+			// The tag may have been given by the parser rather than the
+			// datasource, so it cannot be ruled out.
+			continue
+		}
+		matched := false
+		for _, f := range fs {
+			if f.matches(value) {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (e scanEstimate) String() string {
+	if e.err != nil {
+		return fmt.Sprintf("unknown (%v)", e.err)
+	}
+
+	size := ByteCountDecimal(e.compressedSize) + " compressed"
+	if e.size > 0 {
+		size = fmt.Sprintf("~%s (%s compressed)", ByteCountDecimal(e.size), ByteCountDecimal(e.compressedSize))
+	}
+	return fmt.Sprintf("%s in %d of %d segments, %d of %d datasources", size, e.segments, e.totalSegments, e.datasources, e.totalDatasources)
+}
+
+// explainSearch prints the plan of the query and the estimated amount of data
+// it scans in each of the repositories, without running it. It returns the
+// problems found in the query.
+func explainSearch(out io.Writer, client *api.Client, repositories []string, queryString, queryStart, queryEnd string, live bool) []string {
+	plan := analyzeQuery(queryString)
+
+	var problems []string
+	for _, p := range lintQuery(queryString) {
+		problems = append(problems, p.String())
+	}
+	if len(problems) == 0 {
+		err := validateQueryOnServer(client, repositories[0], queryString)
+		if queryError, ok := err.(api.QueryError); ok {
+			problems = append(problems, queryError.Error())
+		} else if err != nil {
+			problems = append(problems, fmt.Sprintf("could not validate the query: %v", err))
+		}
+	}
+
+	now := time.Now()
+	startMillis, startOK := queryTimeMillis(queryStart, now)
+	endMillis, endOK := queryTimeMillis(queryEnd, now)
+
+	timeRange := fmt.Sprintf("%s to %s", formatSegmentTime(startMillis), formatSegmentTime(endMillis))
+	if live {
+		timeRange = "live, " + timeRange
+	}
+
+	tagFilters := "none"
+	if len(plan.tagFilters) > 0 {
+		var filters []string
+		for _, f := range plan.tagFilters {
+			filters = append(filters, f.String())
+		}
+		tagFilters = strings.Join(filters, ", ")
+	}
+
+	aggregate := "no, the result is an event list"
+	if plan.aggregate != "" {
+		aggregate = "yes, " + plan.aggregate + "()"
+	}
+
+	functions := "none"
+	if len(plan.functions) > 0 {
+		functions = strings.Join(plan.functions, ", ")
+	}
+
+	data := [][]string{
+		{"Repository", strings.Join(repositories, ", ")},
+		{"Time range", timeRange},
+		{"Pipeline stages", strconv.Itoa(plan.stages)},
+		{"Functions", functions},
+		{"Tag filters", tagFilters},
+		{"Free-text search", yesNo(plan.freeText)},
+		{"Regex on @rawstring", yesNo(plan.rawStringRegex)},
+		{"Aggregate", aggregate},
+	}
+
+	var total int64
+	if startOK && endOK {
+		for _, repository := range repositories {
+			estimate := estimateQueryScan(client, repository, plan, startMillis, endMillis)
+			label := "Data to scan"
+			if len(repositories) > 1 {
+				label = "Data to scan in " + repository
+			}
+			data = append(data, []string{label, estimate.String()})
+
+			if estimate.size > 0 {
+				total += estimate.size
+			} else {
+				total += estimate.compressedSize
+			}
+		}
+		if len(repositories) > 1 {
+			data = append(data, []string{"Data to scan in total", "~" + ByteCountDecimal(total)})
+		}
+	} else {
+		data = append(data, []string{"Data to scan", "unknown, the time range is not supported"})
+	}
+
+	w := tablewriter.NewWriter(out)
+	w.SetAutoWrapText(false)
+	w.AppendBulk(data)
+	w.SetBorder(false)
+	w.SetColumnSeparator(":")
+	w.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT})
+	w.Render()
+
+	var hints []string
+	if len(plan.tagFilters) == 0 {
+		hints = append(hints, "The query does not start with a tag filter, e.g. #type=accesslog, so all datasources in the time range are searched.")
+	}
+	if plan.ignoredTagFilters {
+		hints = append(hints, "Tag filters that are negated or combined with 'or' cannot be used to skip datasources.")
+	}
+	if plan.freeText {
+		hints = append(hints, "Free-text search looks through the @rawstring of every event. Filtering on a field, e.g. loglevel=ERROR, is usually faster.")
+	}
+	if plan.rawStringRegex {
+		hints = append(hints, "Regular expressions on @rawstring are evaluated for every event. Put a plain text or field filter before them when possible.")
+	}
+	if total > largeScanBytes {
+		hints = append(hints, fmt.Sprintf("The query scans more than %s. Consider narrowing the time range with --start and --end.", ByteCountDecimal(largeScanBytes)))
+	}
+
+	if len(hints) > 0 {
+		fmt.Fprintln(out)
+		for _, h := range hints {
+			fmt.Fprintf(out, "  - %s\n", h)
+		}
+	}
+
+	if len(problems) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "There are errors in the query:")
+		fmt.Fprintln(out)
+		for _, p := range problems {
+			fmt.Fprintln(out, p)
+		}
+	}
+
+	return problems
+}
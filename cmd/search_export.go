@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/humio/cli/prompt"
+)
+
+type searchExportOptions struct {
+	file       string
+	format     string
+	fields     []string
+	noProgress bool
+}
+
+type eventWriter interface {
+	write(line []byte) error
+	flush() error
+}
+
+// exportSearchResult runs the query as query jobs and writes the events to a
+// file. A query job only returns a limited number of events, so when the
+// result is cut off the time range is split in two and each half is exported
+// in turn, oldest first, until every page fits in one result. Only one page
+// is held in memory at a time. The events are written to a temporary file
+// that replaces opts.file once the export has succeeded.
+func exportSearchResult(ctx context.Context, client *api.Client, repository string, query api.Query, opts searchExportOptions) error {
+	format := opts.format
+	if format == "" {
+		if strings.ToLower(filepath.Ext(opts.file)) == ".csv" {
+			format = "csv"
+		} else {
+			format = "ndjson"
+		}
+	}
+	if format != "ndjson" && format != "csv" {
+		return fmt.Errorf("unsupported export format %q, must be one of: ndjson, csv", format)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(opts.file), "."+filepath.Base(opts.file)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Fails once the file has been renamed.
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	// Temporary files are only readable by the owner.
+	if err := f.Chmod(0644); err != nil {
+		return err
+	}
+
+	e := &searchExporter{ctx: ctx, client: client, repository: repository}
+	if format == "csv" {
+		e.w = &csvEventWriter{w: csv.NewWriter(f), columns: opts.fields}
+	} else {
+		e.w = &ndjsonEventWriter{w: bufio.NewWriter(f)}
+	}
+
+	if !opts.noProgress {
+		e.progress = newExportProgress(opts.file)
+		defer e.progress.finish()
+	}
+
+	if err := e.export(query); err != nil {
+		return err
+	}
+	if err := e.w.flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), opts.file)
+}
+
+type searchExporter struct {
+	ctx        context.Context
+	client     *api.Client
+	repository string
+	w          eventWriter
+	progress   *exportProgress
+}
+
+// export writes the events of query, splitting its time range into pages
+// if the result of a single query job is incomplete.
+func (e *searchExporter) export(query api.Query) error {
+	result, err := e.run(query)
+	if err != nil {
+		return err
+	}
+
+	if !result.Metadata.IsAggregate && (result.Metadata.ExtraData["hasMoreEvents"] == "true" || result.Metadata.EventCount > uint64(len(result.Events))) {
+		start, end := result.Metadata.QueryStart, result.Metadata.QueryEnd
+		if end < start+2 {
+			return fmt.Errorf("more than %d events at %s, which is too many to export in one query job", len(result.Events), time.Unix(0, int64(start)*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano))
+		}
+
+		// The end of the time range is exclusive, so the halves do not
+		// overlap.
+		middle := start + (end-start)/2
+		for _, r := range [][2]uint64{{start, middle}, {middle, end}} {
+			page := query
+			page.Start = strconv.FormatUint(r[0], 10)
+			page.End = strconv.FormatUint(r[1], 10)
+			if err := e.export(page); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	events := result.Events
+	sort.SliceStable(events, func(i, j int) bool {
+		ti, _ := events[i]["@timestamp"].(float64)
+		tj, _ := events[j]["@timestamp"].(float64)
+		return ti < tj
+	})
+
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if err := e.w.write(line); err != nil {
+			return err
+		}
+		if e.progress != nil {
+			e.progress.add(len(line))
+		}
+	}
+
+	return nil
+}
+
+// run runs query as a query job and returns its final result.
+func (e *searchExporter) run(query api.Query) (api.QueryResult, error) {
+	id, err := e.client.QueryJobs().CreateContext(e.ctx, e.repository, query)
+	if err != nil {
+		return api.QueryResult{}, err
+	}
+
+	defer func() {
+		// Also stops the query on the server if it was interrupted.
+		_ = e.client.QueryJobs().Delete(e.repository, id)
+	}()
+
+	poller := queryJobPoller{
+		queryJobs:  e.client.QueryJobs(),
+		repository: e.repository,
+		id:         id,
+	}
+
+	for {
+		result, err := poller.WaitAndPollContext(e.ctx)
+		if err != nil || result.Done {
+			return result, err
+		}
+	}
+}
+
+type ndjsonEventWriter struct {
+	w *bufio.Writer
+}
+
+func (e *ndjsonEventWriter) write(line []byte) error {
+	if _, err := e.w.Write(line); err != nil {
+		return err
+	}
+	return e.w.WriteByte('\n')
+}
+
+func (e *ndjsonEventWriter) flush() error {
+	return e.w.Flush()
+}
+
+type csvEventWriter struct {
+	w             *csv.Writer
+	columns       []string
+	headerWritten bool
+}
+
+func (e *csvEventWriter) write(line []byte) error {
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.UseNumber()
+
+	var event map[string]interface{}
+	if err := d.Decode(&event); err != nil {
+		return fmt.Errorf("could not parse event: %v", err)
+	}
+
+	if !e.headerWritten {
+		// Without an explicit list of fields, use the fields of the first event.
+		if len(e.columns) == 0 {
+			for k := range event {
+				e.columns = append(e.columns, k)
+			}
+			sort.Strings(e.columns)
+		}
+
+		if err := e.w.Write(e.columns); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+
+	record := make([]string, len(e.columns))
+	for i, c := range e.columns {
+		if v, ok := event[c]; ok && v != nil {
+			record[i] = fmt.Sprint(v)
+		}
+	}
+
+	return e.w.Write(record)
+}
+
+func (e *csvEventWriter) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type exportProgress struct {
+	file     string
+	events   uint64
+	bytes    uint64
+	reporter *progressReporter
+}
+
+func newExportProgress(file string) *exportProgress {
+	p := &exportProgress{file: file}
+	p.reporter = startProgressReporter(os.Stderr, p.line)
+	return p
+}
+
+func (p *exportProgress) add(n int) {
+	atomic.AddUint64(&p.events, 1)
+	atomic.AddUint64(&p.bytes, uint64(n))
+}
+
+func (p *exportProgress) line(time.Time) string {
+	events := atomic.LoadUint64(&p.events)
+	v, suffix := prompt.AddSISuffix(float64(atomic.LoadUint64(&p.bytes)), true)
+	return fmt.Sprintf("  Exported %d events (%.1f %sB) to %s", events, v, suffix, p.file)
+}
+
+func (p *exportProgress) finish() {
+	p.reporter.stop(true)
+}
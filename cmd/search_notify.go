@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// searchNotification is the summary of a completed search, posted as JSON
+// to the webhooks given with --notify.
+type searchNotification struct {
+	Repository string `json:"repository"`
+	Query      string `json:"query"`
+	Start      string `json:"start,omitempty"`
+	End        string `json:"end,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Results    *int   `json:"results,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (n searchNotification) title() string {
+	if n.Error != "" {
+		return "Humio search failed"
+	}
+	return "Humio search completed"
+}
+
+func (n searchNotification) message() string {
+	duration := (time.Duration(n.DurationMs) * time.Millisecond).Truncate(time.Second)
+	switch {
+	case n.Error != "":
+		return fmt.Sprintf("%s: %s (after %s)", n.Repository, n.Error, duration)
+	case n.Results != nil:
+		return fmt.Sprintf("%s: %d results in %s", n.Repository, *n.Results, duration)
+	default:
+		return fmt.Sprintf("%s: completed in %s", n.Repository, duration)
+	}
+}
+
+// validateNotifyTargets checks that each target is either "desktop" or the
+// URL of a webhook.
+func validateNotifyTargets(targets []string) error {
+	for _, target := range targets {
+		if target == "desktop" {
+			continue
+		}
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid --notify target %q, must be 'desktop' or an http(s) URL", target)
+		}
+	}
+	return nil
+}
+
+// sendSearchNotifications notifies each target about the search. Failing to
+// notify a target is reported, but does not fail the search.
+func sendSearchNotifications(targets []string, n searchNotification) []error {
+	var errs []error
+	for _, target := range targets {
+		var err error
+		if target == "desktop" {
+			err = desktopNotify(n.title(), n.message())
+		} else {
+			err = postSearchNotification(target, n)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not notify %s: %w", target, err))
+		}
+	}
+	return errs
+}
+
+func postSearchNotification(target string, n searchNotification) error {
+	return postWebhook(target, struct {
+		searchNotification
+		Text string `json:"text"`
+	}{n, n.title() + ": " + n.message()})
+}
+
+// postWebhook posts the payload as JSON to the webhook. Including a "text"
+// field in the payload makes it show up in e.g. Slack and Mattermost.
+func postWebhook(target string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("got status code %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"net/url"
+)
+
+// searchURL returns the link to the search page of the Humio UI with the
+// query, repository or view and time range of a search, as given by
+// parseQueryTimeRange.
+func searchURL(address, repository, queryString, start, end string, live bool) string {
+	values := url.Values{}
+	values.Set("query", queryString)
+	if start != "" {
+		values.Set("start", start)
+	}
+	if end != "" && end != "now" {
+		values.Set("end", end)
+	}
+	if live {
+		values.Set("live", "true")
+	}
+	return address + url.PathEscape(repository) + "/search?" + values.Encode()
+}
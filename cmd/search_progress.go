@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/humio/cli/api"
+	"github.com/humio/cli/prompt"
+)
+
+// aggregateProgressMaxRows limits the number of rows shown while the query is
+// running, as lines that have scrolled off the screen cannot be redrawn.
+const aggregateProgressMaxRows = 20
+
+// aggregateProgressPrinter shows the progress and the intermediate result of
+// an aggregate query, redrawing it in place on every update like the UI does.
+type aggregateProgressPrinter struct {
+	w     io.Writer
+	table *aggregatePrinter
+	lines int
+}
+
+func newAggregateProgressPrinter(w io.Writer) *aggregateProgressPrinter {
+	return &aggregateProgressPrinter{
+		w:     w,
+		table: newAggregatePrinter(nil),
+	}
+}
+
+func (p *aggregateProgressPrinter) update(result api.QueryResult) {
+	var buf bytes.Buffer
+
+	var percentage float64
+	if result.Metadata.TotalWork > 0 {
+		percentage = float64(result.Metadata.WorkDone) / float64(result.Metadata.TotalWork) * 100
+	}
+	v, suffix := prompt.AddSISuffix(float64(result.Metadata.ProcessedEvents), false)
+	fmt.Fprintf(&buf, "  Searching...  %.1f %%  %.1f %s events processed\n\n", percentage, v, suffix)
+
+	rows := len(result.Events)
+	if rows > aggregateProgressMaxRows {
+		result.Events = result.Events[:aggregateProgressMaxRows]
+	}
+
+	p.table.w = &buf
+	p.table.print(result)
+
+	if rows > aggregateProgressMaxRows {
+		fmt.Fprintf(&buf, "  ... and %d more rows\n", rows-aggregateProgressMaxRows)
+	}
+
+	p.clear()
+	p.w.Write(buf.Bytes())
+	p.lines = bytes.Count(buf.Bytes(), []byte("\n"))
+}
+
+// clear removes the last update from the terminal.
+func (p *aggregateProgressPrinter) clear() {
+	if p.lines > 0 {
+		fmt.Fprint(p.w, prompt.ClearLinesAbove(p.lines))
+	}
+	p.lines = 0
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/humio/cli/api"
+)
+
+// searchRepositories runs the query against each of the repositories
+// concurrently, and returns the combined result when all of them are done.
+// Each event of the result has a #repo field with the repository it is
+// from. If the query fails in any repository, the others are stopped.
+func searchRepositories(ctx context.Context, client *api.Client, repositories []string, query api.Query, noProgress bool) (api.QueryResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type update struct {
+		index  int
+		result api.QueryResult
+		err    error
+	}
+	updates := make(chan update)
+
+	for i, repository := range repositories {
+		go func(i int, repository string) {
+			send := func(u update) bool {
+				select {
+				case updates <- u:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			}
+
+			id, err := client.QueryJobs().CreateContext(ctx, repository, query)
+			if err != nil {
+				send(update{index: i, err: err})
+				return
+			}
+
+			defer func() {
+				// Also stops the query on the server if it was interrupted.
+				_ = client.QueryJobs().Delete(repository, id)
+			}()
+
+			poller := queryJobPoller{
+				queryJobs:  client.QueryJobs(),
+				repository: repository,
+				id:         id,
+			}
+
+			for {
+				result, err := poller.WaitAndPollContext(ctx)
+				if !send(update{index: i, result: result, err: err}) || err != nil || result.Done {
+					return
+				}
+			}
+		}(i, repository)
+	}
+
+	var progress *queryResultProgressBar
+	if !noProgress {
+		progress = newQueryResultProgressBar()
+	}
+
+	results := make([]api.QueryResult, len(repositories))
+	remaining := len(repositories)
+	for remaining > 0 {
+		u := <-updates
+		if u.err != nil {
+			if progress != nil {
+				progress.Finish()
+			}
+			if _, ok := u.err.(api.QueryError); ok {
+				return api.QueryResult{}, u.err
+			}
+			return api.QueryResult{}, fmt.Errorf("error searching %s: %w", repositories[u.index], u.err)
+		}
+
+		results[u.index] = u.result
+		if u.result.Done {
+			remaining--
+		}
+
+		if progress != nil {
+			progress.Update(combineRepositoryResults(repositories, results))
+		}
+	}
+
+	if progress != nil {
+		progress.Finish()
+	}
+
+	return combineRepositoryResults(repositories, results), nil
+}
+
+// combineRepositoryResults combines the results of the same query in each of
+// the repositories, adding a #repo field to the events.
+func combineRepositoryResults(repositories []string, results []api.QueryResult) api.QueryResult {
+	combined := api.QueryResult{Done: true}
+
+	for i, result := range results {
+		combined.Done = combined.Done && result.Done
+
+		metadata := result.Metadata
+		combined.Metadata.EventCount += metadata.EventCount
+		combined.Metadata.ProcessedBytes += metadata.ProcessedBytes
+		combined.Metadata.ProcessedEvents += metadata.ProcessedEvents
+		combined.Metadata.TotalWork += metadata.TotalWork
+		combined.Metadata.WorkDone += metadata.WorkDone
+		if metadata.TimeMillis > combined.Metadata.TimeMillis {
+			combined.Metadata.TimeMillis = metadata.TimeMillis
+		}
+		if metadata.IsAggregate {
+			combined.Metadata.IsAggregate = true
+		}
+		if len(metadata.FieldOrder) > 0 && len(combined.Metadata.FieldOrder) == 0 {
+			combined.Metadata.FieldOrder = []string{"#repo"}
+			for _, field := range metadata.FieldOrder {
+				if field != "#repo" {
+					combined.Metadata.FieldOrder = append(combined.Metadata.FieldOrder, field)
+				}
+			}
+		}
+
+		for _, event := range result.Events {
+			e := make(map[string]interface{}, len(event)+1)
+			for k, v := range event {
+				e[k] = v
+			}
+			e["#repo"] = repositories[i]
+			if id, ok := e["@id"].(string); ok {
+				// Event IDs are only unique within a repository.
+				e["@id"] = repositories[i] + "/" + id
+			}
+			combined.Events = append(combined.Events, e)
+		}
+	}
+
+	return combined
+}
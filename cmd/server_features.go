@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+// serverFeature is a feature of Humio that older versions do not have,
+// detected by what it adds to the GraphQL schema: the names of mutations,
+// or fields of input types written as "<input type>.<field>", for commands
+// that send fields added after the mutation itself.
+type serverFeature struct {
+	name   string
+	schema []string
+}
+
+// serverFeatures lists, for each command changing the cluster, the feature
+// it depends on, so the command refuses to run with a clear message when the
+// server does not support it, instead of failing with a GraphQL error.
+var serverFeatures = map[string]serverFeature{
+	"auth group-mappings add":    {"group mappings", []string{"addGroup", "updateGroup"}},
+	"parsers install":            {"installing parsers", []string{"createParser", "CreateParserInput.testData", "CreateParserInput.tagFields", "CreateParserInput.force"}},
+	"fdr-feeds create":           {"FDR feeds", []string{"createFdrFeed"}},
+	"fdr-feeds disable":          {"FDR feeds", []string{"updateFdrFeed"}},
+	"fdr-feeds enable":           {"FDR feeds", []string{"updateFdrFeed"}},
+	"fdr-feeds remove":           {"FDR feeds", []string{"deleteFdrFeed"}},
+	"fdr-feeds update":           {"FDR feeds", []string{"updateFdrFeed"}},
+	"ip-filters create":          {"IP filters", []string{"createIPFilter"}},
+	"ip-filters delete":          {"IP filters", []string{"deleteIPFilter"}},
+	"ip-filters update":          {"IP filters", []string{"updateIPFilter"}},
+	"packages uninstall":         {"packages", []string{"uninstallPackage"}},
+	"repos set-default-parser":   {"default parsers of repositories", []string{"setDefaultParserForRepository"}},
+	"repos tag-groupings clear":  {"tag groupings", []string{"setTagGroupings"}},
+	"repos tag-groupings set":    {"tag groupings", []string{"setTagGroupings"}},
+	"tokens create":              {"view permission tokens", []string{"createViewPermissionsToken"}},
+	"tokens delete":              {"view permission tokens", []string{"deleteToken"}},
+	"views federated add-local":  {"federated views", []string{"createLocalClusterConnection"}},
+	"views federated add-remote": {"federated views", []string{"createRemoteClusterConnection"}},
+	"views federated create":     {"federated views", []string{"createMultiClusterSearchView"}},
+	"views federated remove":     {"federated views", []string{"deleteClusterConnection"}},
+	"views permissions grant":    {"group permissions", []string{"assignRoleToGroup"}},
+	"views permissions revoke":   {"group permissions", []string{"unassignRoleFromGroup"}},
+	"views update":               {"updating views", []string{"updateViewConnections", "updateDescriptionForSearchDomain", "ViewConnectionInput.repositoryName", "ViewConnectionInput.filter"}},
+	"views federated update":     {"federated views", []string{"updateRemoteClusterConnection", "updateLocalClusterConnection"}},
+}
+
+// checkServerFeatures returns an error if the server does not support the
+// feature the command depends on. If the schema of the server cannot be
+// fetched, e.g. because introspection is disabled, the command is allowed
+// to try.
+func checkServerFeatures(cmd *cobra.Command) error {
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	feature, ok := serverFeatures[path]
+	if !ok {
+		return nil
+	}
+
+	client, err := newApiClientE(cmd)
+	if err != nil {
+		return nil
+	}
+
+	return requireServerFeature(client, feature)
+}
+
+// requireServerFeature returns an error if the server does not support the
+// feature, e.g. for commands that only depend on it with some flags.
+func requireServerFeature(client *api.Client, feature serverFeature) error {
+	missing, err := missingFromSchema(client, feature.schema, false)
+	if err == nil && len(missing) > 0 {
+		// The cached schema may be from before the server was upgraded.
+		missing, err = missingFromSchema(client, feature.schema, true)
+	}
+	if err != nil || len(missing) == 0 {
+		return nil
+	}
+
+	version := "a version of Humio"
+	if status, err := client.Status(); err == nil && status.Version != "" {
+		version = "Humio " + status.Version
+	}
+
+	descriptions := make([]string, len(missing))
+	for i, m := range missing {
+		if strings.Contains(m, ".") {
+			descriptions[i] = "input field " + m
+		} else {
+			descriptions[i] = "mutation " + m
+		}
+	}
+
+	verb := "is"
+	if len(missing) > 1 {
+		verb = "are"
+	}
+
+	return fmt.Errorf("the server runs %s, which does not support %s (the GraphQL %s %s missing), upgrade Humio to use this command", version, feature.name, strings.Join(descriptions, ", "), verb)
+}
+
+// missingFromSchema returns the mutations and input fields that are not in
+// the GraphQL schema of the server.
+func missingFromSchema(client *api.Client, items []string, refresh bool) ([]string, error) {
+	schema, err := loadGraphQLSchema(client, refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Schema struct {
+				MutationType struct {
+					Name string `json:"name"`
+				} `json:"mutationType"`
+				Types []struct {
+					Name   string `json:"name"`
+					Fields []struct {
+						Name string `json:"name"`
+					} `json:"fields"`
+					InputFields []struct {
+						Name string `json:"name"`
+					} `json:"inputFields"`
+				} `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(schema, &result); err != nil {
+		return nil, err
+	}
+
+	supported := map[string]bool{}
+	for _, t := range result.Data.Schema.Types {
+		if t.Name == result.Data.Schema.MutationType.Name {
+			for _, f := range t.Fields {
+				supported[f.Name] = true
+			}
+		}
+		for _, f := range t.InputFields {
+			supported[t.Name+"."+f.Name] = true
+		}
+	}
+	if len(supported) == 0 {
+		return nil, fmt.Errorf("the schema has no mutations")
+	}
+
+	var missing []string
+	for _, item := range items {
+		if !supported[item] {
+			missing = append(missing, item)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,412 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// sigmaLevels are the levels of Sigma rules, from least to most severe.
+var sigmaLevels = []string{"informational", "low", "medium", "high", "critical"}
+
+// sigmaRule is the subset of a Sigma rule needed to create an alert.
+// See https://github.com/SigmaHQ/sigma-specification.
+type sigmaRule struct {
+	Title       string        `yaml:"title"`
+	ID          string        `yaml:"id"`
+	Description string        `yaml:"description"`
+	Level       string        `yaml:"level"`
+	Tags        []string      `yaml:"tags"`
+	Action      string        `yaml:"action"`
+	Detection   yaml.MapSlice `yaml:"detection"`
+}
+
+// sigmaConverter converts the detection of Sigma rules into Humio queries.
+// Field names are renamed using fields, e.g. to match the names of fields
+// extracted by a parser.
+type sigmaConverter struct {
+	fields map[string]string
+}
+
+// sigmaQuery is a converted part of a rule. Compound parts are wrapped in
+// parentheses when combined with other parts.
+type sigmaQuery struct {
+	query    string
+	compound bool
+}
+
+func (q sigmaQuery) wrapped() string {
+	if q.compound {
+		return "(" + q.query + ")"
+	}
+	return q.query
+}
+
+func joinSigmaQueries(queries []sigmaQuery, op string) sigmaQuery {
+	if len(queries) == 1 {
+		return queries[0]
+	}
+
+	parts := make([]string, len(queries))
+	for i, q := range queries {
+		parts[i] = q.wrapped()
+	}
+	return sigmaQuery{query: strings.Join(parts, " "+op+" "), compound: true}
+}
+
+func sigmaLevelIndex(level string) int {
+	for i, l := range sigmaLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// convert returns the Humio query for the detection of the rule.
+func (c sigmaConverter) convert(rule sigmaRule) (string, error) {
+	if rule.Action != "" {
+		return "", fmt.Errorf("rule collections using action %q are not supported", rule.Action)
+	}
+
+	searches := map[string]sigmaQuery{}
+	var names []string
+	var conditions []string
+
+	for _, item := range rule.Detection {
+		name := fmt.Sprint(item.Key)
+		switch name {
+		case "condition":
+			switch v := item.Value.(type) {
+			case string:
+				conditions = append(conditions, v)
+			case []interface{}:
+				for _, c := range v {
+					conditions = append(conditions, fmt.Sprint(c))
+				}
+			default:
+				return "", fmt.Errorf("invalid condition %v", item.Value)
+			}
+		case "timeframe":
+			// Only used by aggregations, which are rejected when parsing the condition.
+		default:
+			q, err := c.convertSearch(item.Value)
+			if err != nil {
+				return "", fmt.Errorf("%s: %v", name, err)
+			}
+			searches[name] = q
+			names = append(names, name)
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "", fmt.Errorf("the detection has no condition")
+	}
+
+	var queries []sigmaQuery
+	for _, condition := range conditions {
+		p := &sigmaConditionParser{tokens: tokenizeSigmaCondition(condition), searches: searches, names: names}
+		q, err := p.parse()
+		if err != nil {
+			return "", fmt.Errorf("condition %q: %v", condition, err)
+		}
+		queries = append(queries, q)
+	}
+
+	return joinSigmaQueries(queries, "or").query, nil
+}
+
+// convertSearch converts a search identifier, which is either a map of
+// fields that must all match, a list of such maps of which one must match,
+// or a list of keywords of which one must occur in the event.
+func (c sigmaConverter) convertSearch(v interface{}) (sigmaQuery, error) {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		var queries []sigmaQuery
+		for _, item := range v {
+			q, err := c.convertField(fmt.Sprint(item.Key), item.Value)
+			if err != nil {
+				return sigmaQuery{}, err
+			}
+			queries = append(queries, q)
+		}
+		if len(queries) == 0 {
+			return sigmaQuery{}, fmt.Errorf("empty search")
+		}
+		return joinSigmaQueries(queries, "and"), nil
+	case []interface{}:
+		var queries []sigmaQuery
+		for _, item := range v {
+			var q sigmaQuery
+			var err error
+			if m, ok := item.(yaml.MapSlice); ok {
+				q, err = c.convertSearch(m)
+			} else {
+				q, err = sigmaKeyword(item)
+			}
+			if err != nil {
+				return sigmaQuery{}, err
+			}
+			queries = append(queries, q)
+		}
+		if len(queries) == 0 {
+			return sigmaQuery{}, fmt.Errorf("empty search")
+		}
+		return joinSigmaQueries(queries, "or"), nil
+	default:
+		return sigmaKeyword(v)
+	}
+}
+
+func sigmaKeyword(v interface{}) (sigmaQuery, error) {
+	s, ok := v.(string)
+	if !ok {
+		s = fmt.Sprint(v)
+	}
+	return sigmaQuery{query: "/" + sigmaValueRegex(s) + "/i"}, nil
+}
+
+// convertField converts a field with modifiers, e.g. "CommandLine|contains|all",
+// and the value or list of values to match.
+func (c sigmaConverter) convertField(key string, value interface{}) (sigmaQuery, error) {
+	pieces := strings.Split(key, "|")
+	field := pieces[0]
+	if mapped, ok := c.fields[field]; ok {
+		field = mapped
+	}
+
+	var match string
+	op := "or"
+	for _, modifier := range pieces[1:] {
+		switch modifier {
+		case "all":
+			op = "and"
+		case "contains", "startswith", "endswith", "re", "cidr":
+			if match != "" {
+				return sigmaQuery{}, fmt.Errorf("field %s: only one of contains, startswith, endswith, re and cidr can be used", pieces[0])
+			}
+			match = modifier
+		default:
+			return sigmaQuery{}, fmt.Errorf("field %s: the modifier %q is not supported", pieces[0], modifier)
+		}
+	}
+
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	if len(values) == 0 {
+		return sigmaQuery{}, fmt.Errorf("field %s: no values", pieces[0])
+	}
+
+	queries := make([]sigmaQuery, len(values))
+	for i, v := range values {
+		q, err := sigmaFieldMatch(field, match, v)
+		if err != nil {
+			return sigmaQuery{}, fmt.Errorf("field %s: %v", pieces[0], err)
+		}
+		queries[i] = q
+	}
+
+	return joinSigmaQueries(queries, op), nil
+}
+
+func sigmaFieldMatch(field, match string, v interface{}) (sigmaQuery, error) {
+	switch v := v.(type) {
+	case nil:
+		return sigmaQuery{query: fmt.Sprintf("not %s=*", field)}, nil
+	case string:
+		switch match {
+		case "re":
+			return sigmaQuery{query: fmt.Sprintf("%s=/%s/", field, strings.Replace(v, "/", `\/`, -1))}, nil
+		case "cidr":
+			return sigmaQuery{query: fmt.Sprintf("cidr(%s, subnet=%q)", field, v)}, nil
+		}
+
+		pattern := sigmaValueRegex(v)
+		if match != "contains" && match != "endswith" {
+			pattern = "^" + pattern
+		}
+		if match != "contains" && match != "startswith" {
+			pattern = pattern + "$"
+		}
+		return sigmaQuery{query: fmt.Sprintf("%s=/%s/i", field, pattern)}, nil
+	case []interface{}, yaml.MapSlice:
+		return sigmaQuery{}, fmt.Errorf("nested values are not supported")
+	default:
+		if match != "" {
+			return sigmaQuery{}, fmt.Errorf("the %s modifier can only be used with strings", match)
+		}
+		return sigmaQuery{query: fmt.Sprintf("%s=%v", field, v)}, nil
+	}
+}
+
+// sigmaValueRegex returns a regular expression matching a Sigma value, in
+// which * and ? are wildcards unless escaped with a backslash.
+func sigmaValueRegex(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '\\' && i+1 < len(s) && (s[i+1] == '*' || s[i+1] == '?' || s[i+1] == '\\'):
+			b.WriteString(regexp.QuoteMeta(s[i+1 : i+2]))
+			i++
+		case ch == '*':
+			b.WriteString(".*")
+		case ch == '?':
+			b.WriteString(".")
+		case ch == '/':
+			b.WriteString(`\/`)
+		default:
+			b.WriteString(regexp.QuoteMeta(s[i : i+1]))
+		}
+	}
+	return b.String()
+}
+
+func tokenizeSigmaCondition(condition string) []string {
+	condition = strings.Replace(condition, "(", " ( ", -1)
+	condition = strings.Replace(condition, ")", " ) ", -1)
+	condition = strings.Replace(condition, "|", " | ", -1)
+	return strings.Fields(condition)
+}
+
+// sigmaConditionParser parses the condition of a rule, e.g.
+// "selection and not 1 of filter*", into a query of the converted searches.
+type sigmaConditionParser struct {
+	tokens   []string
+	pos      int
+	searches map[string]sigmaQuery
+	names    []string
+}
+
+func (p *sigmaConditionParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *sigmaConditionParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *sigmaConditionParser) parse() (sigmaQuery, error) {
+	q, err := p.parseOr()
+	if err != nil {
+		return sigmaQuery{}, err
+	}
+	switch t := p.peek(); t {
+	case "":
+		return q, nil
+	case "|":
+		return sigmaQuery{}, fmt.Errorf("aggregations are not supported")
+	default:
+		return sigmaQuery{}, fmt.Errorf("unexpected %q", t)
+	}
+}
+
+func (p *sigmaConditionParser) parseOr() (sigmaQuery, error) {
+	return p.parseBinary("or", p.parseAnd)
+}
+
+func (p *sigmaConditionParser) parseAnd() (sigmaQuery, error) {
+	return p.parseBinary("and", p.parseNot)
+}
+
+func (p *sigmaConditionParser) parseBinary(op string, operand func() (sigmaQuery, error)) (sigmaQuery, error) {
+	q, err := operand()
+	if err != nil {
+		return sigmaQuery{}, err
+	}
+
+	queries := []sigmaQuery{q}
+	for strings.ToLower(p.peek()) == op {
+		p.next()
+		q, err := operand()
+		if err != nil {
+			return sigmaQuery{}, err
+		}
+		queries = append(queries, q)
+	}
+
+	return joinSigmaQueries(queries, op), nil
+}
+
+func (p *sigmaConditionParser) parseNot() (sigmaQuery, error) {
+	if strings.ToLower(p.peek()) != "not" {
+		return p.parsePrimary()
+	}
+	p.next()
+
+	q, err := p.parseNot()
+	if err != nil {
+		return sigmaQuery{}, err
+	}
+	return sigmaQuery{query: "not " + q.wrapped()}, nil
+}
+
+func (p *sigmaConditionParser) parsePrimary() (sigmaQuery, error) {
+	t := p.next()
+	switch {
+	case t == "":
+		return sigmaQuery{}, fmt.Errorf("unexpected end of condition")
+	case t == "(":
+		q, err := p.parseOr()
+		if err != nil {
+			return sigmaQuery{}, err
+		}
+		if p.next() != ")" {
+			return sigmaQuery{}, fmt.Errorf("missing )")
+		}
+		return q, nil
+	case strings.ToLower(p.peek()) == "of":
+		p.next()
+		return p.parseOf(t, p.next())
+	}
+
+	q, ok := p.searches[t]
+	if !ok {
+		return sigmaQuery{}, fmt.Errorf("unknown search identifier %q", t)
+	}
+	return q, nil
+}
+
+// parseOf handles "1 of selection*", "all of them" and the like.
+func (p *sigmaConditionParser) parseOf(quantifier, pattern string) (sigmaQuery, error) {
+	var op string
+	switch strings.ToLower(quantifier) {
+	case "1", "any":
+		op = "or"
+	case "all":
+		op = "and"
+	default:
+		return sigmaQuery{}, fmt.Errorf("unsupported quantifier %q", quantifier)
+	}
+
+	var matched []string
+	for _, name := range p.names {
+		if pattern == "them" {
+			if !strings.HasPrefix(name, "_") {
+				matched = append(matched, name)
+			}
+		} else if ok, _ := path.Match(pattern, name); ok {
+			matched = append(matched, name)
+		}
+	}
+	if len(matched) == 0 {
+		return sigmaQuery{}, fmt.Errorf("no search identifiers match %q", pattern)
+	}
+	sort.Strings(matched)
+
+	queries := make([]sigmaQuery, len(matched))
+	for i, name := range matched {
+		queries[i] = p.searches[name]
+	}
+	return joinSigmaQueries(queries, op), nil
+}
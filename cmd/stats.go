@@ -0,0 +1,41 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show local usage statistics of humioctl",
+		Long: `Records which commands are run, how long they take and how often they fail,
+so teams distributing humioctl can see which features are used. Recording is
+off by default. Turn it on with 'humioctl stats enable', or by setting
+'stats: true' in the config file or HUMIO_STATS=true in the environment.
+
+Only command and flag names are recorded, never arguments, flag values,
+queries or tokens. The statistics are kept in stats.json next to the config
+file and are never sent anywhere.`,
+	}
+
+	cmd.AddCommand(newStatsSelfCmd())
+	cmd.AddCommand(newStatsToggleCmd(true))
+	cmd.AddCommand(newStatsToggleCmd(false))
+	cmd.AddCommand(newStatsResetCmd())
+
+	return cmd
+}
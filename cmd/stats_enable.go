@@ -0,0 +1,47 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// newStatsToggleCmd creates the enable or disable command.
+func newStatsToggleCmd(enable bool) *cobra.Command {
+	use, short, done := "disable", "Stop recording usage statistics", "Recording of usage statistics disabled"
+	if enable {
+		use, short, done = "enable", "Start recording usage statistics", "Recording of usage statistics enabled"
+	}
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long: short + `. The setting is saved as 'stats' in the config file.
+Already recorded statistics are kept, use 'humioctl stats reset' to remove
+them.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exitOnError(cmd, updateConfig(func() error {
+				viper.Set("stats", enable)
+				return nil
+			}), "error saving config")
+
+			cmd.Println(done)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,43 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newStatsResetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reset",
+		Short: "Remove the recorded usage statistics",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// The reset command itself should not be the first thing recorded.
+			statsCommand = nil
+
+			err := os.Remove(commandStatsFile())
+			if os.IsNotExist(err) {
+				err = nil
+			}
+			exitOnError(cmd, err, "error removing stats")
+
+			cmd.Println("Usage statistics removed")
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,118 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func newStatsSelfCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "self [flags]",
+		Short: "Print the recorded usage of humioctl commands",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			stats, err := readCommandStats()
+			exitOnError(cmd, err, "error reading stats")
+
+			if jsonOutput {
+				data, err := json.MarshalIndent(stats, "", "  ")
+				exitOnError(cmd, err, "error encoding stats")
+				cmd.Println(string(data))
+				return
+			}
+
+			if !commandStatsEnabled() {
+				fmt.Fprintln(cmd.OutOrStderr(), "Recording is disabled, enable it with 'humioctl stats enable'.")
+			}
+
+			if len(stats.Commands) == 0 {
+				cmd.Println("No commands have been recorded")
+				return
+			}
+
+			names := make([]string, 0, len(stats.Commands))
+			for name := range stats.Commands {
+				names = append(names, name)
+			}
+			sort.Slice(names, func(i, j int) bool {
+				a, b := stats.Commands[names[i]], stats.Commands[names[j]]
+				if a.Runs != b.Runs {
+					return a.Runs > b.Runs
+				}
+				return names[i] < names[j]
+			})
+
+			rows := make([][]string, len(names))
+			for i, name := range names {
+				usage := stats.Commands[name]
+				avg := time.Duration(usage.TotalDurationMs/int64(usage.Runs)) * time.Millisecond
+				rows[i] = []string{
+					name,
+					strconv.Itoa(usage.Runs),
+					strconv.Itoa(usage.Failures),
+					avg.String(),
+					(time.Duration(usage.MaxDurationMs) * time.Millisecond).String(),
+					usage.LastRun.Local().Format("2006-01-02 15:04"),
+					truncateString(formatFlagUsage(usage.Flags), 50),
+				}
+			}
+
+			cmd.Println(fmt.Sprintf("Recorded since %s", stats.Since.Local().Format("2006-01-02 15:04")))
+			cmd.Println()
+
+			w := tablewriter.NewWriter(cmd.OutOrStdout())
+			w.SetHeader([]string{"Command", "Runs", "Failures", "Avg", "Max", "Last Run", "Flags"})
+			w.SetAutoWrapText(false)
+			w.AppendBulk(rows)
+			w.SetBorder(false)
+			w.Render()
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the stats as JSON.")
+
+	return cmd
+}
+
+// formatFlagUsage lists flags by how often they were used, e.g. "--repo (12), --json (3)".
+func formatFlagUsage(flags map[string]int) string {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if flags[names[i]] != flags[names[j]] {
+			return flags[names[i]] > flags[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("--%s (%d)", name, flags[name])
+	}
+	return strings.Join(parts, ", ")
+}
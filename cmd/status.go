@@ -16,19 +16,60 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/humio/cli/api"
 	"github.com/humio/cli/prompt"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 func newStatusCmd() *cobra.Command {
+	var allProfiles, watch bool
+	var interval time.Duration
+
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Shows general status information",
-		Args:  cobra.ExactArgs(0),
+		Long: `Shows the status and version of the Humio cluster and the current user.
+
+Use --watch to show an overview of the cluster nodes, ingest rates and segment
+replication that is refreshed every --interval, like top:
+
+  $ humioctl status --watch --interval 10s
+
+The ingest rates are read from the metrics in the humio repository, and are
+only shown if you have access to it.`,
+		Args: cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
+			if watch {
+				if allProfiles {
+					exitOnError(cmd, usageError("--watch cannot be combined with --all-profiles"), "")
+				}
+				if interval <= 0 {
+					exitOnError(cmd, usageError("--interval must be positive"), "")
+				}
+
+				client := NewApiClient(cmd)
+				watchCluster(contextCancelledOnInterrupt(commandContext), cmd.OutOrStdout(), client, interval)
+				return
+			}
+
+			if allProfiles {
+				printForAllProfiles(cmd, []string{"Status", "Address", "Version", "Username"}, func(client *api.Client) ([][]string, error) {
+					serverStatus, err := client.Status()
+					if err != nil {
+						return nil, err
+					}
+					username, err := client.Viewer().Username()
+					if err != nil {
+						return nil, err
+					}
+					return [][]string{{serverStatus.Status, client.Address(), serverStatus.Version, username}}, nil
+				})
+				return
+			}
+
 			client := NewApiClient(cmd)
 			serverStatus, serverErr := client.Status()
 			exitOnError(cmd, serverErr, "error getting server status")
@@ -38,7 +79,7 @@ func newStatusCmd() *cobra.Command {
 
 			data := [][]string{
 				[]string{"Status", formatStatusText(serverStatus.Status)},
-				[]string{"Address", viper.GetString("address")},
+				[]string{"Address", configString("address")},
 				[]string{"Version", serverStatus.Version},
 				[]string{"Username", username},
 			}
@@ -54,6 +95,10 @@ func newStatusCmd() *cobra.Command {
 		},
 	}
 
+	registerAllProfilesFlag(cmd, &allProfiles)
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Continuously show an overview of the cluster.")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Second, "When used with --watch: How often to refresh the overview.")
+
 	cmd.AddCommand(newLicenseInstallCmd())
 	cmd.AddCommand(newLicenseShowCmd())
 
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/humio/cli/api"
+	"github.com/humio/cli/prompt"
+	"github.com/olekukonko/tablewriter"
+)
+
+// nodeIngestRateQuery sums the latest one minute ingest rate of all
+// repositories on each node, from the metrics logged to the humio repository.
+const nodeIngestRateQuery = `#kind=metrics name=/^ingest-bytes\// | groupby([#vhost, name], function=selectLast(m1)) | groupby(#vhost, function=sum(m1, as=bytesPerSecond))`
+
+// clusterOverview is a snapshot of the cluster shown by the watch mode of
+// status and cluster show.
+type clusterOverview struct {
+	status      *api.StatusResponse
+	cluster     api.Cluster
+	ingestRates map[int]float64
+	ingestErr   error
+	fetchedAt   time.Time
+}
+
+// watchCluster redraws an overview of the cluster every interval until ctx
+// is cancelled, like top. Errors are shown in place of the overview, so a
+// cluster that is temporarily unavailable does not end the watch.
+func watchCluster(ctx context.Context, w io.Writer, client *api.Client, interval time.Duration) {
+	for {
+		var buf bytes.Buffer
+		overview, err := fetchClusterOverview(ctx, client)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(&buf, "%s  Error fetching cluster status: %s\n", time.Now().Format("15:04:05"), err)
+		} else {
+			printClusterOverview(&buf, client.Address(), interval, overview)
+		}
+
+		// Move to the top left corner and clear the screen before drawing.
+		fmt.Fprint(w, prompt.ClearScreen)
+		w.Write(buf.Bytes())
+
+		if sleepUnlessDone(ctx, interval) != nil {
+			return
+		}
+	}
+}
+
+func fetchClusterOverview(ctx context.Context, client *api.Client) (clusterOverview, error) {
+	overview := clusterOverview{fetchedAt: time.Now()}
+
+	var err error
+	overview.status, err = client.Status()
+	if err != nil {
+		return overview, err
+	}
+
+	overview.cluster, err = client.Clusters().Get()
+	if err != nil {
+		return overview, err
+	}
+
+	// Reading the metrics requires access to the humio repository, so the
+	// overview is still shown without ingest rates.
+	overview.ingestRates, overview.ingestErr = fetchNodeIngestRates(ctx, client)
+
+	return overview, nil
+}
+
+func fetchNodeIngestRates(ctx context.Context, client *api.Client) (map[int]float64, error) {
+	body, err := client.QueryJobs().Stream(ctx, "humio", api.Query{
+		QueryString: nodeIngestRateQuery,
+		Start:       "2m",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	rates := map[int]float64{}
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var row map[string]interface{}
+		d := json.NewDecoder(strings.NewReader(line))
+		d.UseNumber()
+		if err := d.Decode(&row); err != nil {
+			return nil, fmt.Errorf("could not parse result: %v", err)
+		}
+
+		vhost, err := strconv.Atoi(fmt.Sprint(row["#vhost"]))
+		if err != nil {
+			continue
+		}
+		rate, _ := strconv.ParseFloat(fmt.Sprint(row["bytesPerSecond"]), 64)
+		rates[vhost] = rate
+	}
+
+	return rates, scanner.Err()
+}
+
+func printClusterOverview(w io.Writer, address string, interval time.Duration, overview clusterOverview) {
+	cluster := overview.cluster
+
+	var available int
+	var ingestTotal float64
+	for _, node := range cluster.Nodes {
+		if node.IsAvailable {
+			available++
+		}
+		ingestTotal += overview.ingestRates[node.Id]
+	}
+
+	fmt.Fprintf(w, "%s  Humio %s  Status %s  (every %s, Ctrl-C to quit)\n", overview.fetchedAt.Format("15:04:05"), overview.status.Version, overview.status.Status, interval)
+	fmt.Fprintf(w, "Address:    %s\n", address)
+	fmt.Fprintf(w, "Nodes:      %d of %d available\n", available, len(cluster.Nodes))
+	if overview.ingestErr == nil {
+		fmt.Fprintf(w, "Ingest:     %s/s\n", ByteCountDecimal(int64(ingestTotal)))
+	}
+	fmt.Fprintf(w, "Segments:   %s missing, %s under-replicated, %s over-replicated\n",
+		ByteCountDecimal(int64(cluster.MissingSegmentSize)),
+		ByteCountDecimal(int64(cluster.UnderReplicatedSegmentSize)),
+		ByteCountDecimal(int64(cluster.OverReplicatedSegmentSize)))
+	fmt.Fprintln(w)
+
+	nodes := append([]api.ClusterNode{}, cluster.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Id < nodes[j].Id })
+
+	rows := make([][]string, len(nodes))
+	for i, node := range nodes {
+		state := "up"
+		if !node.IsAvailable {
+			state = "DOWN"
+		}
+
+		ingest := "-"
+		if rate, ok := overview.ingestRates[node.Id]; ok {
+			ingest = ByteCountDecimal(int64(rate)) + "/s"
+		}
+
+		rows[i] = []string{
+			strconv.Itoa(node.Id),
+			node.Name,
+			state,
+			ingest,
+			ByteCountDecimal(int64(node.PrimarySize + node.SecondarySize)),
+			ByteCountDecimal(int64(node.FreeOnPrimary)),
+			ByteCountDecimal(int64(node.InboundSegmentSize)),
+			ByteCountDecimal(int64(node.OutboundSegmentSize)),
+			node.LastHeartbeat,
+		}
+	}
+
+	t := tablewriter.NewWriter(w)
+	t.SetHeader([]string{"ID", "Name", "State", "Ingest", "Stored", "Free", "Inbound", "Outbound", "Last Heartbeat"})
+	t.AppendBulk(rows)
+	t.SetBorder(false)
+	t.SetAutoWrapText(false)
+	t.Render()
+
+	if overview.ingestErr != nil {
+		fmt.Fprintf(w, "\nIngest rates unavailable: %s\n", overview.ingestErr)
+	}
+}
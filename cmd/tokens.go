@@ -0,0 +1,38 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newTokensCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Manage API tokens with permissions on views and repositories [Root Only]",
+		Long: `Tokens with permissions on a set of views and repositories are meant for
+integrations between systems, instead of using the personal API token of a
+user. Restrict where they can be used from with an IP filter, see
+'humioctl ip-filters'.
+
+  $ humioctl tokens create grafana --view web --view ops --permission ReadAccess --ip-filter office --expires 90d`,
+	}
+
+	cmd.AddCommand(newTokensListCmd())
+	cmd.AddCommand(newTokensCreateCmd())
+	cmd.AddCommand(newTokensDeleteCmd())
+
+	return cmd
+}
@@ -0,0 +1,117 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newTokensCreateCmd() *cobra.Command {
+	var views, permissions []string
+	var ipFilter, expires string
+
+	cmd := &cobra.Command{
+		Use:   "create [flags] <name>",
+		Short: "Create a token with permissions on views and repositories. [Root Only]",
+		Long: `Creates a token with the given permissions on the views and repositories,
+and prints it. The token cannot be shown again, so store it safely.
+
+Permissions are given by their names in the Humio GraphQL schema, e.g.
+ReadAccess, ChangeParsers or ChangeAlertsAndNotifiers.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(views) == 0 {
+				return fmt.Errorf("at least one --view is required")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+
+			var expireAt time.Time
+			if expires != "" {
+				var err error
+				expireAt, err = parseExpiry(expires, time.Now())
+				if err != nil {
+					exitOnError(cmd, usageError(err.Error()), "")
+				}
+			}
+
+			client := NewApiClient(cmd)
+
+			viewIDs := make([]string, len(views))
+			for i, view := range views {
+				id, err := client.Views().ID(view)
+				exitOnError(cmd, err, fmt.Sprintf("error fetching view %s", view))
+				viewIDs[i] = id
+			}
+
+			var ipFilterID string
+			if ipFilter != "" {
+				filter, err := client.IPFilters().Get(ipFilter)
+				exitOnError(cmd, err, "error fetching IP filter")
+				ipFilterID = filter.ID
+			}
+
+			token, err := client.Tokens().CreateViewPermissionsToken(name, viewIDs, permissions, ipFilterID, expireAt)
+			exitOnError(cmd, err, "error creating token")
+
+			cmd.Println(token)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&views, "view", nil, "A view or repository the token has permissions on. Specify multiple times for multiple views.")
+	cmd.Flags().StringSliceVar(&permissions, "permission", []string{"ReadAccess"}, "A permission of the token. Specify multiple times for multiple permissions.")
+	cmd.Flags().StringVar(&ipFilter, "ip-filter", "", "The name or ID of an IP filter restricting where the token can be used from.")
+	cmd.Flags().StringVar(&expires, "expires", "", "When the token expires, either after a duration, e.g. 90d, or at a time, e.g. 2021-01-01. Defaults to never.")
+
+	return cmd
+}
+
+// relativeTimeDurations are the durations of the units of relative times.
+var relativeTimeDurations = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+	"y":  365 * 24 * time.Hour,
+}
+
+// parseExpiry parses a time in the future, given either as a duration from
+// now, e.g. 90d, or as an absolute time.
+func parseExpiry(s string, now time.Time) (time.Time, error) {
+	if m := relativeTimePattern.FindStringSubmatch(s); m != nil {
+		if unit, ok := relativeTimeUnits[m[2]]; ok {
+			n, _ := strconv.Atoi(m[1])
+			return now.Add(time.Duration(n) * relativeTimeDurations[unit]), nil
+		}
+	}
+
+	for _, layout := range absoluteTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			if !t.After(now) {
+				return time.Time{}, fmt.Errorf("the expiry time %s is in the past", s)
+			}
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid expiry %q, expected a duration like 90d or a time like 2021-01-01", s)
+}
@@ -0,0 +1,37 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newTokensDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete [flags] <id>",
+		Short: "Delete a token, see 'tokens list' for the IDs. [Root Only]",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			err := client.Tokens().Delete(args[0])
+			exitOnError(cmd, err, "error deleting token")
+
+			cmd.Println("Deleted token " + args[0])
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,60 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newTokensListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list [flags]",
+		Short: "List tokens with permissions on views and repositories. [Root Only]",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			client := NewApiClient(cmd)
+
+			tokens, err := client.Tokens().ListViewPermissionsTokens()
+			exitOnError(cmd, err, "error fetching tokens")
+
+			rows := []string{"Name | ID | Views | Permissions | IP Filter | Expires"}
+			for _, t := range tokens {
+				views := make([]string, len(t.Views))
+				for i, v := range t.Views {
+					views[i] = v.Name
+				}
+
+				ipFilter := ""
+				if t.IPFilter != nil {
+					ipFilter = t.IPFilter.Name
+				}
+
+				expires := "never"
+				if t.ExpireAt != nil {
+					expires = time.Unix(0, *t.ExpireAt*int64(time.Millisecond)).Local().Format("2006-01-02 15:04")
+				}
+
+				rows = append(rows, fmt.Sprintf("%s | %s | %s | %s | %s | %s", t.Name, t.ID, strings.Join(views, ", "), strings.Join(t.Permissions, ", "), valueOrEmpty(ipFilter), expires))
+			}
+			printTable(cmd, rows)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,139 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+type repoUsage struct {
+	Organization         string
+	Repository           string
+	IngestBytes          int64
+	Events               int64
+	CompressedByteSize   int64
+	UncompressedByteSize int64
+}
+
+func newUsageReportCmd() *cobra.Command {
+	var since, format string
+
+	cmd := cobra.Command{
+		Use:   "report [flags]",
+		Short: "Report ingest and storage usage of all repositories.",
+		Long: `Reports how much data was ingested into each repository during the last
+--since and how much storage each repository uses, e.g. for chargeback:
+
+  $ humioctl usage report --since 30d --format=csv > usage.csv
+
+The ingest volume is taken from the daily ingest statistics of the server,
+so --since is a whole number of days, and data that has since been deleted
+by retention is included.
+
+Use --format=csv to get one row per repository followed by a total row for
+the organization.`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			if format != "table" && format != "csv" {
+				exitOnError(cmd, usageError(fmt.Sprintf("unsupported format %q, must be one of: table, csv", format)), "")
+			}
+
+			_, err := relativeTimeDays("--since", since)
+			exitOnError(cmd, err, "")
+
+			client := NewApiClient(cmd)
+
+			organization, err := client.Viewer().OrganizationName()
+			exitOnError(cmd, err, "error fetching organization")
+
+			repos, err := client.Repositories().List()
+			exitOnError(cmd, err, "error fetching repositories")
+
+			usages := make([]repoUsage, len(repos))
+			for i, repo := range repos {
+				fmt.Fprintf(os.Stderr, "\r  Fetching usage of repository %d of %d  ", i+1, len(repos))
+
+				stats, err := client.Repositories().Stats(repo.Name)
+				exitOnError(cmd, err, fmt.Sprintf("error fetching repository %s", repo.Name))
+
+				buckets, err := fetchRepoIngestUsage(client, repo.Name, since, since)
+				exitOnError(cmd, err, fmt.Sprintf("error fetching ingest usage of repository %s", repo.Name))
+
+				usages[i] = repoUsage{
+					Organization:         organization,
+					Repository:           repo.Name,
+					CompressedByteSize:   stats.CompressedByteSize,
+					UncompressedByteSize: stats.UncompressedByteSize,
+				}
+				for _, b := range buckets {
+					usages[i].IngestBytes += b.Bytes
+					usages[i].Events += b.Events
+				}
+			}
+			if len(repos) > 0 {
+				fmt.Fprintln(os.Stderr)
+			}
+
+			total := repoUsage{Organization: organization, Repository: "(total)"}
+			for _, u := range usages {
+				total.IngestBytes += u.IngestBytes
+				total.Events += u.Events
+				total.CompressedByteSize += u.CompressedByteSize
+				total.UncompressedByteSize += u.UncompressedByteSize
+			}
+
+			if format == "csv" {
+				err := writeUsageCSV(cmd, append(usages, total))
+				exitOnError(cmd, err, "error writing report")
+				return
+			}
+
+			rows := []string{"Repository | Ingest | Events | Compressed Size | Uncompressed Size"}
+			for _, u := range append(usages, total) {
+				rows = append(rows, fmt.Sprintf("%s | %s | %d | %s | %s", u.Repository, ByteCountDecimal(u.IngestBytes), u.Events, ByteCountDecimal(u.CompressedByteSize), ByteCountDecimal(u.UncompressedByteSize)))
+			}
+			printTable(cmd, rows)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "30d", "How far back to report ingest usage, as a relative time in days, e.g. 30d.")
+	cmd.Flags().StringVar(&format, "format", "table", "The format of the report, either table or csv.")
+
+	return &cmd
+}
+
+func writeUsageCSV(cmd *cobra.Command, usages []repoUsage) error {
+	w := csv.NewWriter(cmd.OutOrStdout())
+	w.Write([]string{"organization", "repository", "ingest_bytes", "events", "compressed_bytes", "uncompressed_bytes"})
+
+	for _, u := range usages {
+		w.Write([]string{
+			u.Organization,
+			u.Repository,
+			strconv.FormatInt(u.IngestBytes, 10),
+			strconv.FormatInt(u.Events, 10),
+			strconv.FormatInt(u.CompressedByteSize, 10),
+			strconv.FormatInt(u.UncompressedByteSize, 10),
+		})
+	}
+
+	w.Flush()
+	return w.Error()
+}
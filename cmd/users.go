@@ -34,6 +34,9 @@ func newUsersCmd() *cobra.Command {
 	cmd.AddCommand(newUsersUpdateCmd())
 	cmd.AddCommand(newUsersListCmd())
 	cmd.AddCommand(newUsersShowCmd())
+	cmd.AddCommand(newUsersImportCmd())
+	cmd.AddCommand(newUsersSyncCmd())
+	cmd.AddCommand(newUsersPermissionsCmd())
 
 	return cmd
 }
@@ -15,9 +15,6 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/humio/cli/api"
 	"github.com/spf13/cobra"
 )
@@ -46,10 +43,7 @@ func newUsersAddCmd() *cobra.Command {
 				Picture:     pictureFlag.value,
 			})
 
-			if err != nil {
-				cmd.Println(fmt.Errorf("Error creating the user: %s", err))
-				os.Exit(1)
-			}
+			exitOnError(cmd, err, "Error creating the user")
 
 			printUserTable(cmd, user)
 		},
@@ -0,0 +1,222 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newUsersImportCmd() *cobra.Command {
+	var bulk bulkFlags
+
+	cmd := cobra.Command{
+		Use:   "import [flags] <csv-file>",
+		Short: "Creates or updates users from a CSV file. [Root Only]",
+		Long: `Creates or updates many users in one run from a CSV file.
+
+The first line of the file must be a header naming the columns. The
+'username' column is required, the other supported columns are:
+
+  name, email, company, country-code, picture, root
+
+Users that do not exist are created. Existing users are updated if any
+of the given values differ, otherwise they are skipped. Empty cells leave
+the value unchanged, e.g.
+
+  username,name,email,root
+  jane,Jane Doe,jane@example.com,false
+  john,,john@example.com,true`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			file, err := os.Open(args[0])
+			exitOnError(cmd, err, "error opening user file")
+			defer file.Close()
+
+			changesets, err := readUserChangesets(file)
+			exitOnError(cmd, err, "error reading user file")
+
+			client := bulk.client(cmd)
+
+			users, err := client.Users().List()
+			exitOnError(cmd, err, "error fetching user list")
+
+			existing := map[string]api.User{}
+			for _, user := range users {
+				existing[user.Username] = user
+			}
+
+			actions := make([]string, len(changesets))
+			errs := bulk.run(len(changesets), func(i int) error {
+				c := changesets[i]
+				user, exists := existing[c.username]
+				switch {
+				case !exists:
+					actions[i] = "created"
+					_, err := client.Users().Add(c.username, c.changeset)
+					return err
+				case userChangesetDiffers(user, c.changeset):
+					actions[i] = "updated"
+					_, err := client.Users().Update(c.username, c.changeset)
+					return err
+				default:
+					actions[i] = "skipped"
+					return nil
+				}
+			})
+
+			var created, updated, skipped, failed int
+			for i, c := range changesets {
+				if errs[i] != nil {
+					cmd.Println(fmt.Errorf("error importing user %s: %s", c.username, errs[i]))
+					failed++
+					continue
+				}
+				switch actions[i] {
+				case "created":
+					created++
+				case "updated":
+					updated++
+				default:
+					skipped++
+				}
+			}
+
+			cmd.Println(fmt.Sprintf("Created: %d, Updated: %d, Skipped: %d, Failed: %d", created, updated, skipped, failed))
+
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	bulk.register(&cmd)
+
+	return &cmd
+}
+
+type userImport struct {
+	username  string
+	changeset api.UserChangeSet
+}
+
+func readUserChangesets(r io.Reader) ([]userImport, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return userChangesetsFromRecords(records)
+}
+
+// userChangesetsFromRecords returns the users of the CSV records, the first
+// of which is the header.
+func userChangesetsFromRecords(records [][]string) ([]userImport, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the file is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns["username"]; !ok {
+		return nil, fmt.Errorf("the header must contain a 'username' column")
+	}
+
+	for name := range columns {
+		switch name {
+		case "username", "name", "email", "company", "country-code", "picture", "root":
+		default:
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+
+	var result []userImport
+	for line, record := range records[1:] {
+		value := func(column string) *string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return nil
+			}
+			v := strings.TrimSpace(record[i])
+			if v == "" {
+				return nil
+			}
+			return &v
+		}
+
+		username := value("username")
+		if username == nil {
+			return nil, fmt.Errorf("line %d: missing username", line+2)
+		}
+
+		var isRoot *bool
+		if root := value("root"); root != nil {
+			var f boolPtrFlag
+			if err := f.Set(strings.ToLower(*root)); err != nil {
+				return nil, fmt.Errorf("line %d: %s", line+2, err)
+			}
+			isRoot = f.value
+		}
+
+		result = append(result, userImport{
+			username: *username,
+			changeset: api.UserChangeSet{
+				IsRoot:      isRoot,
+				FullName:    value("name"),
+				Company:     value("company"),
+				CountryCode: value("country-code"),
+				Picture:     value("picture"),
+				Email:       value("email"),
+			},
+		})
+	}
+
+	return result, nil
+}
+
+func userChangesetDiffers(user api.User, c api.UserChangeSet) bool {
+	return len(userChangesetDifferences(user, c)) > 0
+}
+
+// userChangesetDifferences returns the names of the values the changeset
+// would change.
+func userChangesetDifferences(user api.User, c api.UserChangeSet) []string {
+	var details []string
+	differs := func(name string, current string, desired *string) {
+		if desired != nil && *desired != current {
+			details = append(details, name)
+		}
+	}
+
+	if c.IsRoot != nil && *c.IsRoot != user.IsRoot {
+		details = append(details, "root")
+	}
+	differs("name", user.FullName, c.FullName)
+	differs("company", user.Company, c.Company)
+	differs("country code", user.CountryCode, c.CountryCode)
+	differs("picture", user.Picture, c.Picture)
+	differs("email", user.Email, c.Email)
+
+	return details
+}
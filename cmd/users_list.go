@@ -19,13 +19,15 @@ import (
 )
 
 func newUsersListCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "list",
+	var limit listLimitFlags
+
+	cmd := &cobra.Command{
+		Use:   "list [flags]",
 		Short: "Lists all users. [Root Only]",
 		Run: func(cmd *cobra.Command, args []string) {
 			client := NewApiClient(cmd)
 
-			users, err := client.Users().List()
+			users, total, err := client.Users().ListLimit(limit.value())
 			exitOnError(cmd, err, "error fetching user list")
 
 			rows := make([]string, len(users))
@@ -37,6 +39,12 @@ func newUsersListCmd() *cobra.Command {
 				"Username | Name | Root | Created"},
 				rows...,
 			))
+
+			limit.printTruncated(len(users), total, "users")
 		},
 	}
+
+	limit.register(cmd, "users")
+
+	return cmd
 }
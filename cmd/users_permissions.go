@@ -0,0 +1,146 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func newUsersPermissionsCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "permissions [flags] <username> <repo-or-view>",
+		Short: "Show the effective permissions of a user on a repository or view [Root Only]",
+		Long: `Shows the permissions a user has on a repository or view as a matrix of
+permissions and the roles that give them, either because the user is a
+member of the role or of one of its groups. The last column is the
+effective permission, which is the union of the permissions of the roles.
+
+Members of several roles can see events matching any of their query
+prefixes.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			username, viewName := args[0], args[1]
+
+			client := NewApiClient(cmd)
+
+			user, err := client.Users().Get(username)
+			exitOnError(cmd, err, "Error fetching user")
+
+			roles, err := client.Views().RoleMembers(viewName)
+			exitOnError(cmd, err, "Error fetching roles")
+
+			if user.IsRoot {
+				cmd.Printf("%s is a root user and has all permissions on every repository and view.\n", username)
+			}
+
+			grants := userRoleGrants(username, roles)
+			if len(grants) == 0 {
+				cmd.Printf("%s has no permissions on %s, since they are not a member of any of its roles or their groups.\n", username, viewName)
+				return
+			}
+
+			printPermissionMatrix(cmd, grants)
+		},
+	}
+
+	return &cmd
+}
+
+// roleGrant is a role on a view that a user is a member of.
+type roleGrant struct {
+	role api.ViewRoleMembers
+	via  []string
+}
+
+// userRoleGrants returns the roles the user is a member of, directly or
+// through groups.
+func userRoleGrants(username string, roles []api.ViewRoleMembers) []roleGrant {
+	var grants []roleGrant
+	for _, role := range roles {
+		var via []string
+		for _, u := range role.Role.Users {
+			if u.Username == username {
+				via = append(via, "direct")
+				break
+			}
+		}
+		for _, g := range role.Role.Groups {
+			for _, u := range g.Users {
+				if u.Username == username {
+					via = append(via, "group "+g.DisplayName)
+					break
+				}
+			}
+		}
+
+		if len(via) > 0 {
+			grants = append(grants, roleGrant{role: role, via: via})
+		}
+	}
+	return grants
+}
+
+func printPermissionMatrix(cmd *cobra.Command, grants []roleGrant) {
+	granted := map[string]map[int]bool{}
+	for i, g := range grants {
+		for _, p := range g.role.Role.Permissions {
+			if granted[p] == nil {
+				granted[p] = map[int]bool{}
+			}
+			granted[p][i] = true
+		}
+	}
+
+	permissions := make([]string, 0, len(granted))
+	for p := range granted {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+
+	header := []string{"Permission"}
+	for _, g := range grants {
+		header = append(header, fmt.Sprintf("%s (%s)", g.role.Role.Name, strings.Join(g.via, ", ")))
+	}
+	header = append(header, "Effective")
+
+	var rows [][]string
+	for _, p := range permissions {
+		row := []string{p}
+		for i := range grants {
+			row = append(row, yesNo(granted[p][i]))
+		}
+		rows = append(rows, append(row, "yes"))
+	}
+
+	prefixes := []string{"Query Prefix"}
+	for _, g := range grants {
+		prefixes = append(prefixes, valueOrEmpty(g.role.QueryPrefix))
+	}
+	rows = append(rows, append(prefixes, ""))
+
+	w := tablewriter.NewWriter(cmd.OutOrStdout())
+	w.SetHeader(header)
+	w.AppendBulk(rows)
+	w.SetBorder(false)
+	w.SetAutoWrapText(false)
+	w.SetAutoFormatHeaders(false)
+	w.Render()
+}
@@ -19,14 +19,19 @@ import (
 )
 
 func newUsersRemoveCmd() *cobra.Command {
+	var confirm confirmFlags
 
 	cmd := cobra.Command{
-		Use:   "remove",
+		Use:   "remove [flags] <username>",
 		Short: "Remove a user [Root Only]",
-		Args:  cobra.ExactArgs(1),
+		Long: `Removes a user. You are asked to type the username to confirm. When not
+running interactively, use --yes or --confirm-name=<username> instead.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			username := args[0]
 
+			exitOnError(cmd, confirm.confirm(cmd, "user", username), "")
+
 			client := NewApiClient(cmd)
 
 			removedUser, err := client.Users().Remove(username)
@@ -36,5 +41,7 @@ func newUsersRemoveCmd() *cobra.Command {
 		},
 	}
 
+	confirm.register(&cmd, "user")
+
 	return &cmd
 }
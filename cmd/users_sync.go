@@ -0,0 +1,491 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+// desiredUser is a user as it should be after 'users sync'.
+type desiredUser struct {
+	userImport
+	groups []string
+	// delete is set for users marked to be deleted, as Humio has no
+	// inactive users.
+	delete bool
+}
+
+type userSyncChange struct {
+	action string
+	line   string
+	// phase orders the changes, see the userSyncPhase constants. Changes
+	// in the same phase do not depend on each other.
+	phase int
+	apply func() error
+	// planned is the change as written to plan files by --plan-out.
+	planned plannedChange
+}
+
+const (
+	userSyncPhaseUsers = iota
+	userSyncPhaseGroups
+	userSyncPhaseMembers
+	userSyncPhaseRemovals
+)
+
+func newUsersSyncCmd() *cobra.Command {
+	var prune, dryRun bool
+	var bulk bulkFlags
+	var plan changePlanFlags
+	var confirm confirmFlags
+
+	cmd := cobra.Command{
+		Use:   "sync [flags] <file>",
+		Short: "Make the users and group memberships match a file. [Root Only]",
+		Long: `Compares the users in <file> with the users of Humio, e.g. exported from the
+directory of the organization, prints a plan of the changes and applies it:
+
+  +  users that do not exist are created
+  ~  users whose values differ are updated
+  -  users marked to be deleted in the file are deleted, and with --prune,
+     users that are not in the file, except root users
+
+Humio has no inactive users, so users are deleted rather than deactivated:
+they lose their group memberships and settings, and are created anew if they
+are listed again later. Deleting users must be confirmed by typing the name
+of the organization, or with --yes or --confirm-name when not running
+interactively.
+
+The groups of the users in the file are the source of truth for the groups
+they name: users are added to the groups they are listed in, groups that do
+not exist are created, and users listed in the file are removed from the
+groups they are not listed in. With --prune, users that are not in the file
+are also removed from those groups. Groups not named in the file are not
+changed.
+
+The file is either a CSV file with the columns of 'humioctl users import',
+a 'groups' column separated by semicolons and a 'delete' column:
+
+  username,name,email,groups,delete
+  jane,Jane Doe,jane@example.com,ops;devs,false
+  john,,john@example.com,devs,true
+
+or a JSON file with SCIM users, either a list or a SCIM list response, where
+users that are not active are deleted:
+
+  {"Resources": [{"userName": "jane", "displayName": "Jane Doe",
+    "emails": [{"value": "jane@example.com", "primary": true}],
+    "active": true, "groups": [{"display": "ops"}]}]}
+
+  $ humioctl users sync users.csv --prune --dry-run
+  $ humioctl users sync users.csv --prune --confirm-name myorg
+
+Use --dry-run to only print the plan. To have the plan approved before
+applying it, write it as JSON with --plan-out and apply it in a later run with
+--apply-plan, which fails without changing anything if the changes are no
+longer the ones in the plan file:
+
+  $ humioctl users sync users.csv --plan-out plan.json
+  $ humioctl users sync users.csv --apply-plan plan.json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := plan.validate(); err != nil {
+				return err
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			desired, err := readDesiredUsers(args[0])
+			exitOnError(cmd, err, "error reading user file")
+
+			client := bulk.client(cmd)
+
+			users, err := client.Users().List()
+			exitOnError(cmd, err, "error fetching user list")
+
+			groups, err := client.Groups().List()
+			exitOnError(cmd, err, "error fetching groups")
+
+			changes, err := planUserSync(client, desired, users, groups, prune)
+			exitOnError(cmd, err, "error fetching group members")
+
+			if len(changes) == 0 {
+				cmd.Println("No changes. The users are up to date.")
+				plan.handle(cmd, userSyncPlan(client, changes))
+				return
+			}
+
+			counts := map[string]int{}
+			for _, change := range changes {
+				counts[change.action]++
+				cmd.Println(fmt.Sprintf("%s %s", change.action, change.line))
+			}
+			cmd.Println()
+			cmd.Println(fmt.Sprintf("Plan: %d to create, %d to update, %d to remove.", counts["+"], counts["~"], counts["-"]))
+
+			if !plan.handle(cmd, userSyncPlan(client, changes)) || dryRun {
+				return
+			}
+
+			if deletions := countUserDeletions(changes); deletions > 0 {
+				organization, err := client.Viewer().OrganizationName()
+				exitOnError(cmd, err, "error fetching organization")
+
+				what := fmt.Sprintf("the %d users marked - above", deletions)
+				exitOnError(cmd, confirm.confirmDeleting(cmd, what, "organization", organization), "")
+			}
+
+			for phase := userSyncPhaseUsers; phase <= userSyncPhaseRemovals; phase++ {
+				var inPhase []userSyncChange
+				for _, change := range changes {
+					if change.phase == phase {
+						inPhase = append(inPhase, change)
+					}
+				}
+
+				errs := bulk.run(len(inPhase), func(i int) error {
+					return inPhase[i].apply()
+				})
+				for i, err := range errs {
+					exitOnError(cmd, err, fmt.Sprintf("error syncing %s", inPhase[i].line))
+				}
+			}
+
+			cmd.Println("Sync complete.")
+		},
+	}
+
+	cmd.Flags().BoolVar(&prune, "prune", false, "Delete users that are not in the file, except root users.")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the plan without applying it.")
+	cmd.Flags().BoolVarP(&confirm.yes, "yes", "y", false, "Delete users without asking for confirmation.")
+	cmd.Flags().StringVar(&confirm.confirmName, "confirm-name", "", "Confirm deleting users by giving the name of the organization, for use in scripts.")
+	bulk.register(&cmd)
+	plan.register(&cmd)
+
+	return &cmd
+}
+
+// userSyncPlan returns the plan of the changes, as written by --plan-out.
+func userSyncPlan(client *api.Client, changes []userSyncChange) changePlan {
+	plan := changePlan{Command: "users sync", Address: client.Address()}
+	for _, change := range changes {
+		plan.Changes = append(plan.Changes, change.planned)
+	}
+	return plan
+}
+
+// countUserDeletions returns the number of users deleted by changes.
+func countUserDeletions(changes []userSyncChange) int {
+	var n int
+	for _, change := range changes {
+		if change.planned.Action == "delete" && change.planned.Kind == "user" {
+			n++
+		}
+	}
+	return n
+}
+
+// planUserSync returns the changes needed to make the users and the members
+// of the groups named in the file match the desired users. Users are created
+// and updated first, then the groups are changed, then users are removed.
+func planUserSync(client *api.Client, desired []desiredUser, users []api.User, groups []api.Group, prune bool) ([]userSyncChange, error) {
+	var changes, removals []userSyncChange
+
+	existing := map[string]api.User{}
+	for _, user := range users {
+		existing[user.Username] = user
+	}
+
+	listed := map[string]bool{}
+	removed := map[string]bool{}
+	members := map[string][]string{}
+	for _, d := range desired {
+		d := d
+		listed[d.username] = true
+		user, exists := existing[d.username]
+
+		switch {
+		case d.delete:
+			if exists {
+				removed[d.username] = true
+				removals = append(removals, userSyncChange{action: "-", line: fmt.Sprintf("user %s (delete)", d.username), phase: userSyncPhaseRemovals, apply: func() error {
+					_, err := client.Users().Remove(d.username)
+					return err
+				}, planned: plannedChange{Action: "delete", Kind: "user", Name: d.username}})
+			}
+			continue
+		case !exists:
+			changes = append(changes, userSyncChange{action: "+", line: "user " + d.username, phase: userSyncPhaseUsers, apply: func() error {
+				_, err := client.Users().Add(d.username, d.changeset)
+				return err
+			}, planned: plannedChange{Action: "create", Kind: "user", Name: d.username, Digest: changeDigest(d.changeset)}})
+		default:
+			if details := userChangesetDifferences(user, d.changeset); len(details) > 0 {
+				changes = append(changes, userSyncChange{action: "~", line: fmt.Sprintf("user %s (%s)", d.username, strings.Join(details, ", ")), phase: userSyncPhaseUsers, apply: func() error {
+					_, err := client.Users().Update(d.username, d.changeset)
+					return err
+				}, planned: plannedChange{Action: "update", Kind: "user", Name: d.username, Details: details, Digest: changeDigest(d.changeset)}})
+			}
+		}
+
+		for _, group := range d.groups {
+			members[group] = append(members[group], d.username)
+		}
+	}
+
+	if prune {
+		for _, user := range users {
+			if listed[user.Username] || user.IsRoot {
+				continue
+			}
+			username := user.Username
+			removed[username] = true
+			removals = append(removals, userSyncChange{action: "-", line: fmt.Sprintf("user %s (delete, not in the file)", username), phase: userSyncPhaseRemovals, apply: func() error {
+				_, err := client.Users().Remove(username)
+				return err
+			}, planned: plannedChange{Action: "delete", Kind: "user", Name: username}})
+		}
+	}
+
+	groupNames := make([]string, 0, len(members))
+	for name := range members {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
+	for _, name := range groupNames {
+		name := name
+		group, exists := findGroup(groups, func(g api.Group) bool { return g.DisplayName == name })
+		groupID := &group.ID
+
+		var current []string
+		if exists {
+			var err error
+			current, err = client.Groups().Members(group.ID)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			changes = append(changes, userSyncChange{action: "+", line: "group " + name, phase: userSyncPhaseGroups, apply: func() error {
+				created, err := client.Groups().Add(name, "")
+				*groupID = created.ID
+				return err
+			}, planned: plannedChange{Action: "create", Kind: "group", Name: name}})
+		}
+
+		isMember := map[string]bool{}
+		for _, username := range current {
+			isMember[username] = true
+		}
+		isWanted := map[string]bool{}
+		var add []string
+		for _, username := range members[name] {
+			isWanted[username] = true
+			if !isMember[username] {
+				add = append(add, username)
+			}
+		}
+		var remove []string
+		for _, username := range current {
+			if !isWanted[username] && !removed[username] && (listed[username] || prune) {
+				remove = append(remove, username)
+			}
+		}
+
+		if len(add) > 0 {
+			changes = append(changes, userSyncChange{action: "+", line: fmt.Sprintf("members of %s: %s", name, strings.Join(add, ", ")), phase: userSyncPhaseMembers, apply: func() error {
+				return client.Groups().AddMembers(*groupID, add)
+			}, planned: plannedChange{Action: "update", Kind: "group", Name: name, Details: []string{"add members: " + strings.Join(add, ", ")}}})
+		}
+		if len(remove) > 0 {
+			changes = append(changes, userSyncChange{action: "-", line: fmt.Sprintf("members of %s: %s", name, strings.Join(remove, ", ")), phase: userSyncPhaseMembers, apply: func() error {
+				return client.Groups().RemoveMembers(*groupID, remove)
+			}, planned: plannedChange{Action: "update", Kind: "group", Name: name, Details: []string{"remove members: " + strings.Join(remove, ", ")}}})
+		}
+	}
+
+	return append(changes, removals...), nil
+}
+
+// readDesiredUsers reads the users of a CSV file, or of a JSON file with
+// SCIM users.
+func readDesiredUsers(path string) ([]desiredUser, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []desiredUser
+	trimmed := bytes.TrimSpace(content)
+	if strings.ToLower(filepath.Ext(path)) == ".json" || bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")) {
+		users, err = desiredUsersFromSCIM(content)
+	} else {
+		users, err = desiredUsersFromCSV(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, user := range users {
+		if seen[user.username] {
+			return nil, fmt.Errorf("the user %s is listed more than once", user.username)
+		}
+		seen[user.username] = true
+	}
+
+	return users, nil
+}
+
+// desiredUsersFromCSV reads the users of a CSV file with the columns of
+// 'users import' and the groups and delete columns.
+func desiredUsersFromCSV(content []byte) ([]desiredUser, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the file is empty")
+	}
+
+	groupsColumn, deleteColumn := -1, -1
+	var keep []int
+	for i, name := range records[0] {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "groups":
+			groupsColumn = i
+		case "delete":
+			deleteColumn = i
+		default:
+			keep = append(keep, i)
+		}
+	}
+
+	// The other columns are read like by 'users import'.
+	importRecords := make([][]string, len(records))
+	for i, record := range records {
+		for _, column := range keep {
+			if column < len(record) {
+				importRecords[i] = append(importRecords[i], record[column])
+			}
+		}
+	}
+
+	imports, err := userChangesetsFromRecords(importRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]desiredUser, len(imports))
+	for i, u := range imports {
+		record := records[i+1]
+		users[i] = desiredUser{userImport: u}
+
+		if groupsColumn >= 0 && groupsColumn < len(record) {
+			for _, group := range strings.Split(record[groupsColumn], ";") {
+				if group = strings.TrimSpace(group); group != "" {
+					users[i].groups = append(users[i].groups, group)
+				}
+			}
+		}
+
+		if deleteColumn >= 0 && deleteColumn < len(record) && strings.TrimSpace(record[deleteColumn]) != "" {
+			var f boolPtrFlag
+			if err := f.Set(strings.ToLower(strings.TrimSpace(record[deleteColumn]))); err != nil {
+				return nil, fmt.Errorf("line %d: %s", i+2, err)
+			}
+			users[i].delete = *f.value
+		}
+	}
+
+	return users, nil
+}
+
+// scimUser is the part of a SCIM user resource that is synced.
+type scimUser struct {
+	UserName    string `json:"userName"`
+	DisplayName string `json:"displayName"`
+	Name        struct {
+		Formatted string `json:"formatted"`
+	} `json:"name"`
+	Emails []struct {
+		Value   string `json:"value"`
+		Primary bool   `json:"primary"`
+	} `json:"emails"`
+	Active *bool `json:"active"`
+	Groups []struct {
+		Display string `json:"display"`
+	} `json:"groups"`
+}
+
+// desiredUsersFromSCIM reads the users of a list of SCIM users, or of a SCIM
+// list response.
+func desiredUsersFromSCIM(content []byte) ([]desiredUser, error) {
+	var resources []scimUser
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		if err := json.Unmarshal(content, &resources); err != nil {
+			return nil, err
+		}
+	} else {
+		var list struct {
+			Resources []scimUser `json:"Resources"`
+		}
+		if err := json.Unmarshal(content, &list); err != nil {
+			return nil, err
+		}
+		resources = list.Resources
+	}
+
+	users := make([]desiredUser, len(resources))
+	for i, r := range resources {
+		if r.UserName == "" {
+			return nil, fmt.Errorf("user %d: missing userName", i+1)
+		}
+
+		var changeset api.UserChangeSet
+		if name := r.DisplayName; name != "" {
+			changeset.FullName = &name
+		} else if name := r.Name.Formatted; name != "" {
+			changeset.FullName = &name
+		}
+		for _, email := range r.Emails {
+			if email.Value != "" && (changeset.Email == nil || email.Primary) {
+				value := email.Value
+				changeset.Email = &value
+			}
+		}
+
+		users[i] = desiredUser{
+			userImport: userImport{username: r.UserName, changeset: changeset},
+			delete:     r.Active != nil && !*r.Active,
+		}
+		for _, g := range r.Groups {
+			if g.Display != "" {
+				users[i].groups = append(users[i].groups, g.Display)
+			}
+		}
+	}
+
+	return users, nil
+}
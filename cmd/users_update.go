@@ -25,7 +25,7 @@ func newUsersUpdateCmd() *cobra.Command {
 	var pictureFlag urlPtrFlag
 
 	cmd := cobra.Command{
-		Use:   "update",
+		Use:   "update [flags] <username>",
 		Short: "Updates a user's settings [Root Only]",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
@@ -6,7 +6,9 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -17,13 +19,6 @@ func check(err error) {
 	}
 }
 
-func exitOnError(cmd *cobra.Command, err error, message string) {
-	if err != nil {
-		cmd.Println(fmt.Errorf(message+": %s", err))
-		os.Exit(1)
-	}
-}
-
 var (
 	commit  = "none"
 	date    = "unknown"
@@ -134,3 +129,82 @@ func (sf *float64PtrFlag) String() string {
 func (sf *float64PtrFlag) Type() string {
 	return "float64"
 }
+
+type intPtrFlag struct {
+	value *int
+}
+
+func (sf *intPtrFlag) Set(v string) error {
+	val, err := strconv.Atoi(v)
+	if err != nil {
+		return err
+	}
+	sf.value = &val
+	return nil
+}
+
+func (sf *intPtrFlag) String() string {
+	if sf.value == nil {
+		return ""
+	}
+	return strconv.Itoa(*sf.value)
+}
+
+func (sf *intPtrFlag) Type() string {
+	return "int"
+}
+
+type keyValueFlag struct {
+	values map[string]string
+}
+
+func (kv *keyValueFlag) Set(v string) error {
+	pieces := strings.SplitN(v, "=", 2)
+	if len(pieces) != 2 || pieces[0] == "" {
+		return fmt.Errorf("expected a value of the form key=value, got %q", v)
+	}
+	if kv.values == nil {
+		kv.values = map[string]string{}
+	}
+	kv.values[pieces[0]] = pieces[1]
+	return nil
+}
+
+func (kv *keyValueFlag) String() string {
+	pairs := make([]string, 0, len(kv.values))
+	for k, v := range kv.values {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (kv *keyValueFlag) Type() string {
+	return "key=value"
+}
+
+// listLimitFlags are the --limit and --all flags of list commands.
+type listLimitFlags struct {
+	limit int
+	all   bool
+}
+
+func (l *listLimitFlags) register(cmd *cobra.Command, noun string) {
+	cmd.Flags().IntVar(&l.limit, "limit", 0, fmt.Sprintf("The maximum number of %s to list. Defaults to all.", noun))
+	cmd.Flags().BoolVar(&l.all, "all", false, fmt.Sprintf("List all %s, ignoring --limit.", noun))
+}
+
+// value returns the limit to use, where 0 means no limit.
+func (l *listLimitFlags) value() int {
+	if l.all || l.limit < 0 {
+		return 0
+	}
+	return l.limit
+}
+
+// printTruncated prints a hint to stderr if not all items were listed.
+func (l *listLimitFlags) printTruncated(shown, total int, noun string) {
+	if shown < total {
+		fmt.Fprintf(os.Stderr, "Showing %d of %d %s. Use --limit or --all to list more.\n", shown, total, noun)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newValidateCmd() *cobra.Command {
+	var kind, printSchema string
+	var vars templateVarFlags
+
+	cmd := &cobra.Command{
+		Use:   "validate [flags] <file>...",
+		Short: "Validate YAML files of parsers, alerts, actions, dashboards and packages locally",
+		Long: `Checks YAML asset files against the schemas of the files installed by
+humioctl, without contacting Humio, and reports all violations found in
+each file. The exit code is 1 if any file is invalid, so it can be used as
+a pre-commit hook:
+
+  $ humioctl validate parsers/*.yaml alerts/*.yaml
+
+The kind of asset is, in order:
+
+  1. given by --kind
+  2. the name of the schema referenced by $schema in the file, e.g.
+     $schema: https://humio.com/schemas/humioctl/alert.json
+  3. package, for files named manifest.yaml
+  4. from the directory the file is in: parsers, alerts, actions or
+     notifiers, or dashboards
+  5. guessed from the properties in the file
+
+Files using template variables can be rendered with --var and --var-file
+before they are validated, as when they are installed.
+
+Use --print-schema to print the JSON Schema of a kind, e.g. to configure an
+editor to validate and complete asset files:
+
+  $ humioctl validate --print-schema=alert > alert.schema.json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if printSchema != "" {
+				if len(args) > 0 {
+					return fmt.Errorf("--print-schema does not take any files")
+				}
+				return nil
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("at least one file must be given")
+			}
+			if kind != "" {
+				if _, ok := assetSchemas[kind]; !ok {
+					return fmt.Errorf("unknown kind %q, must be one of: %s", kind, strings.Join(assetKinds(), ", "))
+				}
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if printSchema != "" {
+				schema, ok := assetSchemas[printSchema]
+				if !ok {
+					exitOnError(cmd, usageError(fmt.Sprintf("unknown kind %q, must be one of: %s", printSchema, strings.Join(assetKinds(), ", "))), "")
+				}
+				cmd.Println(schema)
+				return
+			}
+
+			invalid := 0
+			for _, file := range args {
+				fileKind, violations := validateAssetFile(file, kind, &vars)
+				if len(violations) == 0 {
+					cmd.Printf("%s: valid %s\n", file, fileKind)
+					continue
+				}
+
+				invalid++
+				for _, v := range violations {
+					cmd.Printf("%s: %s\n", file, v)
+				}
+			}
+
+			if invalid > 0 {
+				fmt.Fprintf(os.Stderr, "%d of %d files are invalid\n", invalid, len(args))
+				os.Exit(exitCodeError)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&kind, "kind", "", "The kind of asset the files contain, one of: "+strings.Join(assetKinds(), ", ")+". Defaults to detecting it for each file.")
+	cmd.Flags().StringVar(&printSchema, "print-schema", "", "Print the JSON Schema of a kind of asset instead of validating files.")
+	vars.register(cmd)
+
+	return cmd
+}
+
+// validateAssetFile returns the kind of asset in the file and the violations
+// of its schema, or why the file could not be validated.
+func validateAssetFile(file string, kind string, vars *templateVarFlags) (string, []string) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return kind, []string{err.Error()}
+	}
+
+	content, err = vars.render(file, content)
+	if err != nil {
+		return kind, []string{err.Error()}
+	}
+
+	doc, err := parseYAMLDocument(content)
+	if err != nil {
+		return kind, []string{fmt.Sprintf("invalid YAML: %v", err)}
+	}
+
+	if kind == "" {
+		var ok bool
+		kind, ok = detectAssetKind(file, doc)
+		if !ok {
+			return kind, []string{"cannot tell the kind of asset, use --kind or add $schema to the file"}
+		}
+	}
+
+	schema, err := loadAssetSchema(kind)
+	if err != nil {
+		return kind, []string{err.Error()}
+	}
+
+	var violations []string
+	for _, v := range schema.validate("", doc) {
+		violations = append(violations, fmt.Sprintf("%s: %s", kind, v))
+	}
+	return kind, violations
+}
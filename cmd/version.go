@@ -15,19 +15,144 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+// newestSupportedServerVersion is the newest Humio version this version of
+// the CLI has been tested with. Newer servers may have changed or removed
+// parts of the API the CLI uses.
+const newestSupportedServerVersion = "1.30"
+
+type versionInfo struct {
+	Version     string             `json:"version"`
+	Commit      string             `json:"commit"`
+	Date        string             `json:"date"`
+	Server      *serverVersionInfo `json:"server,omitempty"`
+	ServerError string             `json:"serverError,omitempty"`
+	Warning     string             `json:"warning,omitempty"`
+}
+
+type serverVersionInfo struct {
+	Address string `json:"address"`
+	Version string `json:"version"`
+}
+
+func clientVersionInfo() versionInfo {
+	return versionInfo{Version: version, Commit: commit, Date: date}
+}
+
 func newVersionCmd() *cobra.Command {
+	var jsonFlag, clientOnly bool
+
 	cmd := &cobra.Command{
-		Use:   "version",
+		Use:   "version [flags]",
 		Short: "Show cli version information.",
+		Long: `Shows the version, commit and build date of the CLI and, if a server is
+configured, the version of the server. A warning is printed if the server
+is newer than the versions this CLI supports, in which case you should
+upgrade the CLI.
+
+Use --json for output that is easy to use in scripts.`,
+		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println(version)
+			info := clientVersionInfo()
+
+			if !clientOnly && configString("address") != "" {
+				fetchServerVersion(cmd, &info)
+			}
+
+			printVersionInfo(cmd, info, jsonFlag)
 		},
 	}
 
+	cmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output as json.")
+	cmd.Flags().BoolVar(&clientOnly, "client", false, "Only show the client version, without contacting the server.")
+
 	return cmd
 }
+
+func printVersionInfo(cmd *cobra.Command, info versionInfo, jsonFlag bool) {
+	if jsonFlag {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		exitOnError(cmd, enc.Encode(info), "Error encoding version")
+		return
+	}
+
+	cmd.Printf("Client version: %s\n", info.Version)
+	cmd.Printf("Commit:         %s\n", info.Commit)
+	cmd.Printf("Build date:     %s\n", info.Date)
+	if info.Server != nil {
+		cmd.Printf("Server version: %s (%s)\n", info.Server.Version, info.Server.Address)
+	}
+	if info.ServerError != "" {
+		fmt.Fprintf(os.Stderr, "Could not get the server version: %s\n", info.ServerError)
+	}
+	if info.Warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", info.Warning)
+	}
+}
+
+func fetchServerVersion(cmd *cobra.Command, info *versionInfo) {
+	client, err := newApiClientE(cmd)
+	if err != nil {
+		info.ServerError = err.Error()
+		return
+	}
+
+	status, err := client.Status()
+	if err != nil {
+		info.ServerError = err.Error()
+		return
+	}
+
+	info.Server = &serverVersionInfo{Address: client.Address(), Version: status.Version}
+	if compareVersions(status.Version, newestSupportedServerVersion) > 0 {
+		info.Warning = fmt.Sprintf("the server runs Humio %s, which is newer than this CLI supports (%s). Upgrade humioctl if commands fail.", status.Version, newestSupportedServerVersion)
+	}
+}
+
+// compareVersions compares the major and minor versions of Humio versions
+// such as "1.30.1--build-123", returning -1, 0 or 1. Versions that cannot be
+// parsed, e.g. of development builds, are equal to any version.
+func compareVersions(a, b string) int {
+	va, okA := majorMinorVersion(a)
+	vb, okB := majorMinorVersion(b)
+	if !okA || !okB {
+		return 0
+	}
+
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func majorMinorVersion(v string) ([2]int, bool) {
+	var result [2]int
+
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) < 2 {
+		return result, false
+	}
+
+	for i := range result {
+		n, err := strconv.Atoi(strings.SplitN(parts[i], "-", 2)[0])
+		if err != nil {
+			return result, false
+		}
+		result[i] = n
+	}
+	return result, true
+}
@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/humio/cli/api"
 	"github.com/olekukonko/tablewriter"
@@ -31,6 +32,12 @@ func newViewsCmd() *cobra.Command {
 
 	cmd.AddCommand(newViewsShowCmd())
 	cmd.AddCommand(newViewsListCmd())
+	cmd.AddCommand(newViewsCreateCmd())
+	cmd.AddCommand(newViewsUpdateCmd())
+	cmd.AddCommand(newViewsExportCmd())
+	cmd.AddCommand(newViewsImportCmd())
+	cmd.AddCommand(newViewsFederatedCmd())
+	cmd.AddCommand(newViewsPermissionsCmd())
 
 	return cmd
 }
@@ -39,6 +46,7 @@ func printViewTable(view *api.View) {
 
 	data := [][]string{
 		[]string{"Name", view.Name},
+		[]string{"Description", view.Description},
 	}
 
 	w := tablewriter.NewWriter(os.Stdout)
@@ -94,3 +102,19 @@ func printViewConnectionsTable(view *api.View) {
 	w.Render()
 	fmt.Println()
 }
+
+// parseViewConnections parses connections of the form <repo>[:<filter>], e.g. "web:#tag=nginx".
+func parseViewConnections(values []string) ([]api.ViewConnection, error) {
+	connections := make([]api.ViewConnection, len(values))
+	for i, v := range values {
+		pieces := strings.SplitN(v, ":", 2)
+		if pieces[0] == "" {
+			return nil, fmt.Errorf("invalid connection %q, expected <repo>[:<filter>]", v)
+		}
+		connections[i] = api.ViewConnection{RepoName: pieces[0]}
+		if len(pieces) == 2 {
+			connections[i].Filter = pieces[1]
+		}
+	}
+	return connections, nil
+}
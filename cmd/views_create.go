@@ -0,0 +1,58 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newViewsCreateCmd() *cobra.Command {
+	var description string
+	var connectionFlags []string
+
+	cmd := cobra.Command{
+		Use:   "create [flags] <view>",
+		Short: "Create a view.",
+		Long: `Creates a view with connections to one or more repositories.
+
+Each connection is given as <repo>[:<filter>], where the filter is a query
+limiting which events from the repository are part of the view, e.g.
+
+  $ humioctl views create myview --connection 'repo1:#tag=web' --connection repo2`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+
+			connections, err := parseViewConnections(connectionFlags)
+			exitOnError(cmd, err, "error parsing connections")
+
+			client := NewApiClient(cmd)
+
+			err = client.Views().Create(viewName, description, connections)
+			exitOnError(cmd, err, "error creating view")
+
+			view, err := client.Views().Get(viewName)
+			exitOnError(cmd, err, "error fetching view")
+
+			printViewTable(view)
+			printViewConnectionsTable(view)
+		},
+	}
+
+	cmd.Flags().StringVar(&description, "description", "", "The description of the view.")
+	cmd.Flags().StringArrayVar(&connectionFlags, "connection", nil, "A connection of the form <repo>[:<filter>]. Specify multiple times for multiple connections.")
+
+	return &cmd
+}
@@ -0,0 +1,98 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// viewDefinition is the format of exported views.
+type viewDefinition struct {
+	Name         string                     `yaml:"name"`
+	Description  string                     `yaml:"description,omitempty"`
+	Connections  []viewConnectionDefinition `yaml:"connections"`
+	DefaultQuery string                     `yaml:"defaultQuery,omitempty"`
+	Permissions  []viewPermissionDefinition `yaml:"permissions,omitempty"`
+}
+
+type viewConnectionDefinition struct {
+	Repository string `yaml:"repository"`
+	Filter     string `yaml:"filter,omitempty"`
+}
+
+type viewPermissionDefinition struct {
+	Role        string `yaml:"role"`
+	QueryPrefix string `yaml:"queryPrefix,omitempty"`
+}
+
+func newViewsExportCmd() *cobra.Command {
+	var outputName string
+
+	cmd := cobra.Command{
+		Use:   "export [flags] <view>",
+		Short: "Export a view to a file.",
+		Long: `Exports the definition of a view, including its connections, default
+query and role permissions, to a YAML file. Use 'views import' to create
+the view from the file, e.g. on another cluster.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+
+			if outputName == "" {
+				outputName = viewName + ".yaml"
+			}
+
+			client := NewApiClient(cmd)
+
+			view, err := client.Views().Get(viewName)
+			exitOnError(cmd, err, "error fetching view")
+
+			defaultQuery, err := client.Views().GetDefaultQuery(viewName)
+			exitOnError(cmd, err, "error fetching default query")
+
+			yamlData, err := yaml.Marshal(toViewDefinition(view, defaultQuery))
+			exitOnError(cmd, err, "failed to serialize the view")
+
+			err = ioutil.WriteFile(outputName, yamlData, 0644)
+			exitOnError(cmd, err, "error saving the view file")
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputName, "output", "o", "", "The file path where the view should be written. Defaults to ./<view-name>.yaml")
+
+	return &cmd
+}
+
+func toViewDefinition(view *api.View, defaultQuery string) viewDefinition {
+	def := viewDefinition{
+		Name:         view.Name,
+		Description:  view.Description,
+		DefaultQuery: defaultQuery,
+	}
+
+	for _, c := range view.Connections {
+		def.Connections = append(def.Connections, viewConnectionDefinition{Repository: c.RepoName, Filter: c.Filter})
+	}
+
+	for _, r := range view.Roles {
+		def.Permissions = append(def.Permissions, viewPermissionDefinition{Role: r.Role.Name, QueryPrefix: r.QueryPrefix})
+	}
+
+	return def
+}
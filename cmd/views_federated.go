@@ -0,0 +1,124 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func newViewsFederatedCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "federated",
+		Aliases: []string{"fed"},
+		Short:   "Manage federated views searching several clusters",
+		Long: `Federated views search views or repositories on several Humio clusters at
+once. Each connection of a federated view is either remote, to a view or
+repository on another cluster searched with an API token of that cluster, or
+local, to a view or repository on the cluster of the federated view.
+
+The tags of a connection are added to the events found through it, e.g. to
+tell the clusters apart in queries.
+
+  $ humioctl views federated create all-web
+  $ humioctl views federated add-remote all-web --url https://eu.humio.example.com/ --remote-token-file eu-token.txt --tag cluster=eu
+  $ humioctl views federated add-local all-web --target web --tag cluster=us
+  $ humioctl views federated show all-web`,
+	}
+
+	cmd.AddCommand(newViewsFederatedCreateCmd())
+	cmd.AddCommand(newViewsFederatedShowCmd())
+	cmd.AddCommand(newViewsFederatedAddRemoteCmd())
+	cmd.AddCommand(newViewsFederatedAddLocalCmd())
+	cmd.AddCommand(newViewsFederatedUpdateCmd())
+	cmd.AddCommand(newViewsFederatedRemoveCmd())
+
+	return cmd
+}
+
+func printClusterConnectionsTable(cmd *cobra.Command, connections []api.ClusterConnection) {
+	rows := []string{"ID | Kind | Target | Tags | Query Prefix"}
+	for _, c := range connections {
+		rows = append(rows, fmt.Sprintf("%s | %s | %s | %s | %s", c.ID, c.Kind, clusterConnectionTarget(c), valueOrEmpty(formatClusterConnectionTags(c.Tags)), valueOrEmpty(c.QueryPrefix)))
+	}
+	printTable(cmd, rows)
+}
+
+func printClusterConnectionTable(cmd *cobra.Command, connection *api.ClusterConnection) {
+	data := [][]string{
+		{"ID", connection.ID},
+		{"Kind", connection.Kind},
+		{"Target", clusterConnectionTarget(*connection)},
+		{"Cluster ID", valueOrEmpty(connection.ClusterID)},
+		{"Tags", valueOrEmpty(formatClusterConnectionTags(connection.Tags))},
+		{"Query Prefix", valueOrEmpty(connection.QueryPrefix)},
+	}
+
+	w := tablewriter.NewWriter(cmd.OutOrStdout())
+	w.AppendBulk(data)
+	w.SetBorder(false)
+	w.SetColumnSeparator(":")
+	w.SetColumnAlignment([]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT})
+
+	cmd.Println()
+	w.Render()
+	cmd.Println()
+}
+
+// clusterConnectionTarget returns the URL of the cluster of a remote
+// connection, or the name of the view of a local one.
+func clusterConnectionTarget(connection api.ClusterConnection) string {
+	if connection.Kind == api.ClusterConnectionLocal {
+		return connection.TargetViewName
+	}
+	return connection.PublicURL
+}
+
+func formatClusterConnectionTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// readRemoteToken reads the API token of a remote connection from a file,
+// or from stdin if the file is "-", so it is not given on the command line.
+func readRemoteToken(file string) (string, error) {
+	var content []byte
+	var err error
+	if file == "-" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("the token in %s is empty", file)
+	}
+	return token, nil
+}
@@ -0,0 +1,53 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newViewsFederatedAddLocalCmd() *cobra.Command {
+	var target, queryPrefix string
+	var tags keyValueFlag
+
+	cmd := cobra.Command{
+		Use:   "add-local [flags] <view>",
+		Short: "Connect a federated view to a view or repository on this cluster.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if target == "" {
+				return fmt.Errorf("the --target flag is required")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+
+			client := NewApiClient(cmd)
+
+			connection, err := client.ClusterConnections().AddLocal(viewName, target, tags.values, queryPrefix)
+			exitOnError(cmd, err, "error adding connection")
+
+			printClusterConnectionTable(cmd, connection)
+		},
+	}
+
+	cmd.Flags().StringVar(&target, "target", "", "The view or repository to connect to.")
+	cmd.Flags().Var(&tags, "tag", "A tag to add to the events found through the connection, e.g. cluster=us. Specify multiple times for multiple tags.")
+	cmd.Flags().StringVar(&queryPrefix, "query-prefix", "", "A query limiting which events are searched through the connection.")
+
+	return &cmd
+}
@@ -0,0 +1,62 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newViewsFederatedAddRemoteCmd() *cobra.Command {
+	var publicURL, tokenFile, queryPrefix string
+	var tags keyValueFlag
+
+	cmd := cobra.Command{
+		Use:   "add-remote [flags] <view>",
+		Short: "Connect a federated view to another cluster.",
+		Long: `Connects a federated view to a view or repository on the cluster at --url.
+The cluster is searched with the API token in --remote-token-file, which
+decides what can be searched. Use - to read the token from stdin, e.g.
+
+  $ vault read -field=token secret/humio-eu | humioctl views federated add-remote all-web --url https://eu.humio.example.com/ --remote-token-file - --tag cluster=eu`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if publicURL == "" || tokenFile == "" {
+				return fmt.Errorf("--url and --remote-token-file are required")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+
+			token, err := readRemoteToken(tokenFile)
+			exitOnError(cmd, err, "error reading the token")
+
+			client := NewApiClient(cmd)
+
+			connection, err := client.ClusterConnections().AddRemote(viewName, publicURL, token, tags.values, queryPrefix)
+			exitOnError(cmd, err, "error adding connection")
+
+			printClusterConnectionTable(cmd, connection)
+		},
+	}
+
+	cmd.Flags().StringVar(&publicURL, "url", "", "The URL of the other cluster, e.g. https://eu.humio.example.com/.")
+	cmd.Flags().StringVar(&tokenFile, "remote-token-file", "", "A file containing an API token of the other cluster, or - to read it from stdin.")
+	cmd.Flags().Var(&tags, "tag", "A tag to add to the events found through the connection, e.g. cluster=eu. Specify multiple times for multiple tags.")
+	cmd.Flags().StringVar(&queryPrefix, "query-prefix", "", "A query limiting which events are searched through the connection.")
+
+	return &cmd
+}
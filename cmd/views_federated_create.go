@@ -0,0 +1,45 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newViewsFederatedCreateCmd() *cobra.Command {
+	var description string
+
+	cmd := cobra.Command{
+		Use:   "create [flags] <view>",
+		Short: "Create a federated view.",
+		Long: `Creates a federated view without connections. Connect it to views or
+repositories with 'views federated add-remote' and 'views federated add-local'.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+
+			client := NewApiClient(cmd)
+
+			err := client.ClusterConnections().CreateView(viewName, description)
+			exitOnError(cmd, err, "error creating federated view")
+
+			cmd.Printf("Federated view %s created\n", viewName)
+		},
+	}
+
+	cmd.Flags().StringVar(&description, "description", "", "The description of the view.")
+
+	return &cmd
+}
@@ -0,0 +1,42 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newViewsFederatedRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove [flags] <view> <connection-id>",
+		Short: "Remove a connection of a federated view.",
+		Long: `Removes the connection with ID <connection-id> from the federated view
+<view>. The IDs of the connections are shown by 'views federated show'.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+			id := args[1]
+
+			client := NewApiClient(cmd)
+
+			err := client.ClusterConnections().Delete(viewName, id)
+			exitOnError(cmd, err, "error removing connection")
+
+			cmd.Println("Connection removed")
+		},
+	}
+
+	return cmd
+}
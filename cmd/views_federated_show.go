@@ -0,0 +1,58 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+)
+
+func newViewsFederatedShowCmd() *cobra.Command {
+	var jsonFlag bool
+
+	cmd := cobra.Command{
+		Use:   "show [flags] <view>",
+		Short: "List the connections of a federated view.",
+		Long: `Lists the connections of a federated view. The API tokens of remote
+connections are never shown.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+
+			client := NewApiClient(cmd)
+
+			connections, err := client.ClusterConnections().List(viewName)
+			exitOnError(cmd, err, "error fetching connections")
+
+			if jsonFlag {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				exitOnError(cmd, enc.Encode(connections), "error encoding connections")
+				return
+			}
+
+			if len(connections) == 0 {
+				cmd.Println("The view has no connections.")
+				return
+			}
+			printClusterConnectionsTable(cmd, connections)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output as json.")
+
+	return &cmd
+}
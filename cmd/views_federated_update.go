@@ -0,0 +1,76 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newViewsFederatedUpdateCmd() *cobra.Command {
+	var urlFlag, targetFlag, queryPrefixFlag stringPtrFlag
+	var tokenFile string
+	var tags keyValueFlag
+
+	cmd := cobra.Command{
+		Use:   "update [flags] <view> <connection-id>",
+		Short: "Change a connection of a federated view.",
+		Long: `Changes a connection of a federated view. Only the given settings are
+changed, except --tag, which replaces all the tags of the connection.
+
+Rotate the API token of a remote connection with --remote-token-file, which
+reads the new token from a file, or from stdin if it is -:
+
+  $ humioctl views federated update all-web 3f2a9c --remote-token-file new-eu-token.txt
+
+The IDs of the connections are shown by 'views federated show'.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+			id := args[1]
+
+			changeset := api.ClusterConnectionChangeSet{
+				PublicURL:      urlFlag.value,
+				TargetViewName: targetFlag.value,
+				Tags:           tags.values,
+				QueryPrefix:    queryPrefixFlag.value,
+			}
+			if tokenFile != "" {
+				token, err := readRemoteToken(tokenFile)
+				exitOnError(cmd, err, "error reading the token")
+				changeset.Token = &token
+			}
+
+			if changeset.PublicURL == nil && changeset.Token == nil && changeset.TargetViewName == nil && changeset.Tags == nil && changeset.QueryPrefix == nil {
+				exitOnError(cmd, usageError("nothing to change, use --url, --remote-token-file, --target, --tag or --query-prefix"), "")
+			}
+
+			client := NewApiClient(cmd)
+
+			connection, err := client.ClusterConnections().Update(viewName, id, changeset)
+			exitOnError(cmd, err, "error updating connection")
+
+			printClusterConnectionTable(cmd, connection)
+		},
+	}
+
+	cmd.Flags().Var(&urlFlag, "url", "The URL of the other cluster of a remote connection.")
+	cmd.Flags().StringVar(&tokenFile, "remote-token-file", "", "A file containing a new API token of the other cluster of a remote connection, or - to read it from stdin.")
+	cmd.Flags().Var(&targetFlag, "target", "The view or repository of a local connection.")
+	cmd.Flags().Var(&tags, "tag", "A tag to add to the events found through the connection, e.g. cluster=eu. Replaces all the tags of the connection. Specify multiple times for multiple tags.")
+	cmd.Flags().Var(&queryPrefixFlag, "query-prefix", "A query limiting which events are searched through the connection.")
+
+	return &cmd
+}
@@ -0,0 +1,106 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newViewsImportCmd() *cobra.Command {
+	var name string
+	var update bool
+	var vars templateVarFlags
+
+	cmd := cobra.Command{
+		Use:   "import [flags] <file>",
+		Short: "Create a view from an exported view file.",
+		Long: `Creates a view from a file written by 'views export', including its
+connections, default query and role permissions.
+
+The roles and the connected repositories must already exist. Use --update to
+update a view that already exists instead of failing.
+
+Use --var and --var-file to install the same view in different environments.
+The file is then rendered as a Go template, e.g. with {{ .repo }} replaced
+by the value of --var repo=web-prod:
+
+  $ humioctl views import views/web.yaml --var-file=prod.yaml --var threshold=100`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			content, err := ioutil.ReadFile(args[0])
+			exitOnError(cmd, err, "error reading the view file")
+
+			content, err = vars.render("view", content)
+			exitOnError(cmd, err, "error rendering the view file")
+
+			var def viewDefinition
+			err = yaml.UnmarshalStrict(content, &def)
+			exitOnError(cmd, err, "the view file is invalid")
+
+			if name != "" {
+				def.Name = name
+			}
+			if def.Name == "" {
+				exitOnError(cmd, usageError("the view file does not contain a name, use --name to set one"), "")
+			}
+
+			var connections []api.ViewConnection
+			for _, c := range def.Connections {
+				connections = append(connections, api.ViewConnection{RepoName: c.Repository, Filter: c.Filter})
+			}
+
+			client := NewApiClient(cmd)
+
+			_, getErr := client.Views().Get(def.Name)
+			if getErr == nil {
+				if !update {
+					exitOnError(cmd, fmt.Errorf("view %s already exists, use --update to update it", def.Name), "error importing view")
+				}
+
+				err = client.Views().UpdateDescription(def.Name, def.Description)
+				exitOnError(cmd, err, "error updating description")
+
+				err = client.Views().UpdateConnections(def.Name, connections)
+				exitOnError(cmd, err, "error updating connections")
+			} else {
+				err = client.Views().Create(def.Name, def.Description, connections)
+				exitOnError(cmd, err, "error creating view")
+			}
+
+			if def.DefaultQuery != "" {
+				err = client.Views().SetDefaultQuery(def.Name, def.DefaultQuery)
+				exitOnError(cmd, err, "error setting default query")
+			}
+
+			for _, p := range def.Permissions {
+				err = client.Views().SetRolePermission(def.Name, p.Role, p.QueryPrefix)
+				exitOnError(cmd, err, fmt.Sprintf("error setting permissions of role %s", p.Role))
+			}
+
+			cmd.Println(fmt.Sprintf("View %s imported", def.Name))
+		},
+	}
+
+	cmd.Flags().StringVarP(&name, "name", "n", "", "Import the view under a specific name, ignoring the name in the file.")
+	cmd.Flags().BoolVar(&update, "update", false, "Update the view if it already exists.")
+	vars.register(&cmd)
+
+	return &cmd
+}
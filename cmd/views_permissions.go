@@ -0,0 +1,82 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newViewsPermissionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "permissions",
+		Short: "Manage which groups have access to a view",
+		Long: `The groups with access to a view or repository, and what they may do in it,
+are given by roles. A role is given on the view with a query prefix, which
+limits the events its members can see, and groups are assigned the role on
+the view. The permissions of a role, e.g. to change alerts, are the same on
+all views it is given on.
+
+To give a new view to a team with the least privileges needed:
+
+  $ humioctl views create web-eu --connection 'web:#region=eu'
+  $ humioctl views permissions grant web-eu web-team --role reader --query-prefix '#region=eu'
+  $ humioctl views permissions list web-eu
+
+The effective permissions of a single user are shown by 'users permissions'.`,
+	}
+
+	cmd.AddCommand(newViewsPermissionsListCmd())
+	cmd.AddCommand(newViewsPermissionsGrantCmd())
+	cmd.AddCommand(newViewsPermissionsRevokeCmd())
+
+	return cmd
+}
+
+// findViewRole returns the role with the given name given on a view, if any.
+func findViewRole(roles []api.ViewRoleMembers, name string) (api.ViewRoleMembers, bool) {
+	for _, role := range roles {
+		if role.Role.Name == name {
+			return role, true
+		}
+	}
+	return api.ViewRoleMembers{}, false
+}
+
+func printViewPermissionsTable(cmd *cobra.Command, roles []api.ViewRoleMembers) {
+	rows := []string{"Role | Groups | Users | Query Prefix | Permissions"}
+	for _, role := range roles {
+		groups := make([]string, len(role.Role.Groups))
+		for i, g := range role.Role.Groups {
+			groups[i] = g.DisplayName
+		}
+		users := make([]string, len(role.Role.Users))
+		for i, u := range role.Role.Users {
+			users[i] = u.Username
+		}
+
+		rows = append(rows, fmt.Sprintf("%s | %s | %s | %s | %s",
+			role.Role.Name,
+			valueOrEmpty(strings.Join(groups, ", ")),
+			valueOrEmpty(strings.Join(users, ", ")),
+			valueOrEmpty(strings.Replace(role.QueryPrefix, "|", "/", -1)),
+			valueOrEmpty(strings.Join(role.Role.Permissions, ", "))))
+	}
+
+	printTable(cmd, rows)
+}
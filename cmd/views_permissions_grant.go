@@ -0,0 +1,88 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/humio/cli/api"
+	"github.com/spf13/cobra"
+)
+
+func newViewsPermissionsGrantCmd() *cobra.Command {
+	var roleName, queryPrefix string
+
+	cmd := &cobra.Command{
+		Use:   "grant [flags] <view> <group>",
+		Short: "Give a group a role on a view.",
+		Long: `Assigns the group <group> the role given by --role on the view or
+repository <view>, giving its members the permissions of the role.
+
+If the role is not given on the view yet, it is given with the query prefix
+of --query-prefix, which defaults to none, i.e. all events of the view. If
+the role is already given on the view, its query prefix is only changed if
+--query-prefix is set, and then for all groups with the role on the view.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+			groupName := args[1]
+
+			client := NewApiClient(cmd)
+
+			groups, err := client.Groups().List()
+			exitOnError(cmd, err, "error fetching groups")
+
+			group, ok := findGroup(groups, func(g api.Group) bool { return g.DisplayName == groupName })
+			if !ok {
+				exitOnError(cmd, fmt.Errorf("could not find a group named %s", groupName), "error granting role")
+			}
+
+			roles, err := client.Views().RoleMembers(viewName)
+			exitOnError(cmd, err, "error fetching roles")
+
+			role, ok := findViewRole(roles, roleName)
+			if !ok || (cmd.Flags().Changed("query-prefix") && role.QueryPrefix != queryPrefix) {
+				err = client.Views().SetRolePermission(viewName, roleName, queryPrefix)
+				exitOnError(cmd, err, "error giving the role on the view")
+
+				roles, err = client.Views().RoleMembers(viewName)
+				exitOnError(cmd, err, "error fetching roles")
+
+				role, ok = findViewRole(roles, roleName)
+				if !ok {
+					exitOnError(cmd, fmt.Errorf("the role %s was not given on %s, does the role exist?", roleName, viewName), "error granting role")
+				}
+			}
+
+			for _, g := range role.Role.Groups {
+				if g.ID == group.ID {
+					cmd.Println(fmt.Sprintf("%s already has the role %s on %s", groupName, roleName, viewName))
+					return
+				}
+			}
+
+			err = client.Views().AssignRoleToGroup(viewName, role.Role.ID, group.ID)
+			exitOnError(cmd, err, "error granting role")
+
+			cmd.Println(fmt.Sprintf("Granted the role %s on %s to %s", roleName, viewName, groupName))
+		},
+	}
+
+	cmd.Flags().StringVar(&roleName, "role", "", "The role to give the group on the view.")
+	cmd.Flags().StringVar(&queryPrefix, "query-prefix", "", "Only let members of the role see events matching this query, e.g. '#env=prod'.")
+	cmd.MarkFlagRequired("role")
+
+	return cmd
+}
@@ -0,0 +1,46 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newViewsPermissionsListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list [flags] <view>",
+		Short: "List the roles given on a view, with their groups and permissions.",
+		Long: `Lists the roles given on the view or repository <view>, with the groups and
+users who are members of each role, its query prefix and its permissions.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+
+			client := NewApiClient(cmd)
+
+			roles, err := client.Views().RoleMembers(viewName)
+			exitOnError(cmd, err, "error fetching roles")
+
+			if len(roles) == 0 {
+				cmd.Printf("No roles are given on %s, only root users have access to it.\n", viewName)
+				return
+			}
+
+			printViewPermissionsTable(cmd, roles)
+		},
+	}
+
+	return cmd
+}
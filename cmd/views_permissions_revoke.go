@@ -0,0 +1,75 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newViewsPermissionsRevokeCmd() *cobra.Command {
+	var roleName string
+
+	cmd := &cobra.Command{
+		Use:   "revoke [flags] <view> <group>",
+		Short: "Take a role on a view from a group.",
+		Long: `Unassigns the group <group> the role given by --role on the view or
+repository <view>, or all its roles on the view if --role is not set. The
+roles stay given on the view for their other groups and users.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+			groupName := args[1]
+
+			client := NewApiClient(cmd)
+
+			roles, err := client.Views().RoleMembers(viewName)
+			exitOnError(cmd, err, "error fetching roles")
+
+			var revoked []string
+			for _, role := range roles {
+				if roleName != "" && role.Role.Name != roleName {
+					continue
+				}
+				for _, g := range role.Role.Groups {
+					if g.DisplayName == groupName {
+						err := client.Views().UnassignRoleFromGroup(viewName, role.Role.ID, g.ID)
+						exitOnError(cmd, err, fmt.Sprintf("error revoking the role %s", role.Role.Name))
+						revoked = append(revoked, role.Role.Name)
+					}
+				}
+			}
+
+			if len(revoked) == 0 {
+				if roleName != "" {
+					exitOnError(cmd, fmt.Errorf("%s does not have the role %s on %s", groupName, roleName, viewName), "error revoking role")
+				}
+				exitOnError(cmd, fmt.Errorf("%s has no roles on %s", groupName, viewName), "error revoking role")
+			}
+
+			noun := "role"
+			if len(revoked) > 1 {
+				noun = "roles"
+			}
+			cmd.Println(fmt.Sprintf("Revoked the %s %s on %s from %s", noun, strings.Join(revoked, ", "), viewName, groupName))
+		},
+	}
+
+	cmd.Flags().StringVar(&roleName, "role", "", "The role to take from the group. Defaults to all its roles on the view.")
+
+	return cmd
+}
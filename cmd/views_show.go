@@ -15,10 +15,14 @@
 package cmd
 
 import (
+	"encoding/json"
+
 	"github.com/spf13/cobra"
 )
 
 func newViewsShowCmd() *cobra.Command {
+	var jsonFlag bool
+
 	cmd := cobra.Command{
 		Use:   "show [flags] <view>",
 		Short: "Show details about a view.",
@@ -31,6 +35,13 @@ func newViewsShowCmd() *cobra.Command {
 			view, apiErr := client.Views().Get(viewName)
 			exitOnError(cmd, apiErr, "Error fetching view")
 
+			if jsonFlag {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				exitOnError(cmd, enc.Encode(view), "Error encoding view")
+				return
+			}
+
 			printViewTable(view)
 
 			printViewConnectionsTable(view)
@@ -39,5 +50,7 @@ func newViewsShowCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output as json.")
+
 	return &cmd
 }
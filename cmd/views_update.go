@@ -0,0 +1,71 @@
+// Copyright © 2020 Humio Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newViewsUpdateCmd() *cobra.Command {
+	var descriptionFlag stringPtrFlag
+	var connectionFlags []string
+
+	cmd := cobra.Command{
+		Use:   "update [flags] <view>",
+		Short: "Updates the settings of a view.",
+		Long: `Updates the description or connections of a view.
+
+If --connection is given, the connections of the view are replaced by the given
+ones. Each connection is given as <repo>[:<filter>], e.g.
+
+  $ humioctl views update myview --connection 'repo1:#tag=web' --connection repo2`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			viewName := args[0]
+
+			if descriptionFlag.value == nil && len(connectionFlags) == 0 {
+				exitOnError(cmd, fmt.Errorf("you must specify at least one flag to update"), "nothing specified to update")
+			}
+
+			client := NewApiClient(cmd)
+
+			if len(connectionFlags) > 0 {
+				connections, err := parseViewConnections(connectionFlags)
+				exitOnError(cmd, err, "error parsing connections")
+
+				err = client.Views().UpdateConnections(viewName, connections)
+				exitOnError(cmd, err, "error updating view connections")
+			}
+
+			if descriptionFlag.value != nil {
+				err := client.Views().UpdateDescription(viewName, *descriptionFlag.value)
+				exitOnError(cmd, err, "error updating view description")
+			}
+
+			view, err := client.Views().Get(viewName)
+			exitOnError(cmd, err, "error fetching view")
+
+			printViewTable(view)
+			printViewConnectionsTable(view)
+		},
+	}
+
+	cmd.Flags().Var(&descriptionFlag, "description", "The description of the view.")
+	cmd.Flags().StringArrayVar(&connectionFlags, "connection", nil, "A connection of the form <repo>[:<filter>]. Replaces all existing connections. Specify multiple times for multiple connections.")
+
+	return &cmd
+}
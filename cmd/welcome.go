@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/humio/cli/prompt"
@@ -36,18 +35,17 @@ func newWelcomeCmd() *cobra.Command {
 			profile, err := collectProfileInfo(cmd)
 			exitOnError(cmd, err, "failed to collect profile info")
 
-			viper.Set("address", profile.address)
-			viper.Set("token", profile.token)
-
-			addAccount(out, "default", profile)
-
 			configFile := viper.ConfigFileUsed()
 			cmd.Println(prompt.Colorize("==> Writing settings to: [purple]" + configFile + "[reset]"))
 
-			if saveErr := saveConfig(); saveErr != nil {
-				cmd.Println(fmt.Errorf("error saving config: %s", saveErr))
-				os.Exit(1)
-			}
+			saveErr := updateConfig(func() error {
+				storedToken := addAccount(out, "default", profile)
+
+				viper.Set("address", profile.address)
+				viper.Set("token", storedToken)
+				return nil
+			})
+			exitOnError(cmd, saveErr, "error saving config")
 
 			cmd.Println()
 			out.Description("The authentication info has been saved to the profile 'default'.")
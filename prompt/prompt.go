@@ -97,19 +97,57 @@ func (p *Prompt) Info(text string) {
 	p.Output(Colorize(c))
 }
 
+// colorEnabled is false if Colorize should remove the color tags instead
+// of replacing them with ANSI escape codes.
+var colorEnabled = true
+
+// SetColor enables or disables colored output.
+func SetColor(enabled bool) {
+	colorEnabled = enabled
+}
+
+// ColorEnabled returns true if colored output is enabled.
+func ColorEnabled() bool {
+	return colorEnabled
+}
+
+// ANSI escape codes controlling the cursor of the terminal, for output that
+// is redrawn in place. They must only be written to a terminal.
+const (
+	// ClearLine moves the cursor to the start of the line and clears it.
+	ClearLine = "\r\x1b[K"
+	// ClearScreen moves the cursor to the top left corner and clears the screen.
+	ClearScreen = "\x1b[H\x1b[2J"
+)
+
+// ClearLinesAbove moves the cursor n lines up and clears everything below it.
+func ClearLinesAbove(n int) string {
+	return fmt.Sprintf("\x1b[%dA\x1b[J", n)
+}
+
+// colorCodes are the color tags Colorize replaces and their ANSI escape codes.
+var colorCodes = []string{
+	"[reset]", "\x1b[0m",
+	"[gray]", "\x1b[38;5;249m",
+	"[purple]", "\x1b[38;5;129m",
+	"[bold]", "\x1b[1m",
+	"[red]", "\x1b[38;5;1m",
+	"[yellow]", "\x1b[33m",
+	"[green]", "\x1b[38;5;2m",
+	"[underline]", "\x1b[4m",
+}
+
+// Colorize replaces color tags like [red] and [reset] in text with ANSI
+// escape codes, or removes them if colored output is disabled.
 func Colorize(text string) string {
-	replacer := strings.NewReplacer(
-		"[reset]", "\x1b[0m",
-		"[gray]", "\x1b[38;5;249m",
-		"[purple]", "\x1b[38;5;129m",
-		"[bold]", "\x1b[1m",
-		"[red]", "\x1b[38;5;1m",
-		"[yellow]", "\x1b[33m",
-		"[green]", "\x1b[38;5;2m",
-		"[underline]", "\x1b[4m",
-	)
-
-	return replacer.Replace(text)
+	replacements := append([]string{}, colorCodes...)
+	if !colorEnabled {
+		for i := 1; i < len(replacements); i += 2 {
+			replacements[i] = ""
+		}
+	}
+
+	return strings.NewReplacer(replacements...).Replace(text)
 }
 
 func Owl() string {